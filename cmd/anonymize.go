@@ -0,0 +1,423 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// anonymizeMappingFile names the private mapping written beside (never inside)
+// the output directory when --anonymize is set
+const anonymizeMappingFile = "anonymize-mapping.yaml"
+
+// embeddedIPv4Pattern finds IPv4 addresses and CIDRs inside longer strings
+var embeddedIPv4Pattern = regexp.MustCompile(`[0-9]{1,3}(\.[0-9]{1,3}){3}(/[0-9]{1,2})?`)
+
+// anonymizeMappingPath returns where the mapping of a collection written to
+// dir goes: --anonymize-mapping, or <dir>-anonymize-mapping.yaml next to the
+// directory, so publishing the output directory never publishes the mapping
+func anonymizeMappingPath(dir string) string {
+	if anonMapping != "" {
+		return anonMapping
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = filepath.Clean(dir)
+	}
+	return absDir + "-" + anonymizeMappingFile
+}
+
+// isWithinDir reports whether path is dir or lies below it
+func isWithinDir(path, dir string) bool {
+	absPath, err1 := filepath.Abs(path)
+	absDir, err2 := filepath.Abs(dir)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// pseudonymizer replaces identifiers with stable pseudonyms: the same input
+// always maps to the same pseudonym for the lifetime of the process, so
+// comparison mode gets matching pseudonyms for both clusters. With a seed,
+// pseudonyms are derived from a keyed hash of the input instead of numbered
+// in order of appearance, so they also match across separate runs.
+type pseudonymizer struct {
+	// mapping is category -> original -> pseudonym
+	mapping map[string]map[string]string
+	// seed keys the hash of --seed; nil numbers pseudonyms in order
+	seed []byte
+	// used is category -> pseudonyms handed out, to resolve hash collisions
+	used map[string]map[string]bool
+
+	// known caches the names replaced inside longer strings, longest first;
+	// knownSize is the mapping size it was built from
+	known     []knownName
+	knownSize int
+}
+
+// knownName is an original name and its pseudonym
+type knownName struct {
+	original  string
+	pseudonym string
+}
+
+// anonymizer is set when --anonymize is enabled
+var anonymizer *pseudonymizer
+
+func newPseudonymizer(seed string) *pseudonymizer {
+	p := &pseudonymizer{mapping: make(map[string]map[string]string), used: make(map[string]map[string]bool)}
+	if seed != "" {
+		p.seed = []byte(seed)
+	}
+	return p
+}
+
+// pseudonym returns the stable replacement for value in a category
+func (p *pseudonymizer) pseudonym(category, value string) string {
+	if value == "" {
+		return value
+	}
+
+	names, ok := p.mapping[category]
+	if !ok {
+		names = make(map[string]string)
+		p.mapping[category] = names
+	}
+	if existing, ok := names[value]; ok {
+		return existing
+	}
+
+	var replacement string
+	if p.seed != nil {
+		replacement = p.seededPseudonym(category, value)
+	} else {
+		n := len(names) + 1
+		switch category {
+		case "ips":
+			replacement = pseudonymIP(value, n)
+		case "namespaces":
+			replacement = fmt.Sprintf("namespace-%d", n)
+		case "nodes":
+			replacement = fmt.Sprintf("node-%d", n)
+		default:
+			replacement = fmt.Sprintf("host-%d.example.com", n)
+		}
+	}
+	names[value] = replacement
+	return replacement
+}
+
+// seededPseudonym derives a pseudonym from an HMAC-SHA256 of the value keyed
+// with the seed, so it depends only on the seed and the value. On the rare
+// collision with a pseudonym already handed out, the hash is retried with a
+// counter, which then depends on the order the two values were seen in.
+func (p *pseudonymizer) seededPseudonym(category, value string) string {
+	used, ok := p.used[category]
+	if !ok {
+		used = make(map[string]bool)
+		p.used[category] = used
+	}
+
+	for attempt := 0; ; attempt++ {
+		mac := hmac.New(sha256.New, p.seed)
+		fmt.Fprintf(mac, "%s\x00%s\x00%d", category, value, attempt)
+		n := binary.BigEndian.Uint32(mac.Sum(nil))
+
+		var candidate string
+		switch category {
+		case "ips":
+			candidate = pseudonymIP(value, int(n&0xffffff))
+		case "namespaces":
+			candidate = fmt.Sprintf("namespace-%08x", n)
+		case "nodes":
+			candidate = fmt.Sprintf("node-%08x", n)
+		default:
+			candidate = fmt.Sprintf("host-%08x.example.com", n)
+		}
+		if !used[candidate] {
+			used[candidate] = true
+			return candidate
+		}
+	}
+}
+
+// pseudonymIP keeps the address family so the result still parses as an IP
+func pseudonymIP(value string, n int) string {
+	if ip := net.ParseIP(value); ip != nil && ip.To4() == nil {
+		// n can exceed one 16-bit group, so build the address rather than format it
+		pseudonym := make(net.IP, net.IPv6len)
+		pseudonym[0] = 0xfd
+		binary.BigEndian.PutUint32(pseudonym[12:], uint32(n))
+		return pseudonym.String()
+	}
+	return fmt.Sprintf("10.%d.%d.%d", (n>>16)&0xff, (n>>8)&0xff, n&0xff)
+}
+
+// isWellKnownNamespace reports namespaces that exist on every cluster; they are
+// kept as is to preserve the structure of the collection
+func isWellKnownNamespace(name string) bool {
+	return name == "default" || strings.HasPrefix(name, "kube-") || strings.HasPrefix(name, "openshift")
+}
+
+// anonymizeList replaces namespace names, node names, IPs and hostnames in every object
+func anonymizeList(list *unstructured.UnstructuredList) {
+	if anonymizer == nil {
+		return
+	}
+
+	for i := range list.Items {
+		obj := list.Items[i].Object
+
+		// The names of Namespace and Node objects are identifiers themselves
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			if name, ok := metadata["name"].(string); ok {
+				switch list.Items[i].GetKind() {
+				case "Namespace":
+					metadata["name"] = anonymizer.namespace(name)
+				case "Node":
+					metadata["name"] = anonymizer.pseudonym("nodes", name)
+				}
+			}
+		}
+
+		list.Items[i].Object = anonymizer.anonymizeValue("", obj).(map[string]interface{})
+	}
+}
+
+// namespace anonymizes a namespace name unless it is well known
+func (p *pseudonymizer) namespace(name string) string {
+	if isWellKnownNamespace(name) {
+		return name
+	}
+	return p.pseudonym("namespaces", name)
+}
+
+// anonymizeValue walks a decoded object. Fields are recognized by key (e.g.
+// namespace, nodeName, host) and any string that parses as an IP is replaced.
+func (p *pseudonymizer) anonymizeValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// Node addresses carry their type next to the value; they are replaced
+		// once, from the original value, not again after the generic walk
+		address, isAddress := v["address"].(string)
+		for k, child := range v {
+			if isAddress && k == "address" {
+				continue
+			}
+			v[k] = p.anonymizeValue(k, child)
+		}
+		if isAddress {
+			switch v["type"] {
+			case "Hostname":
+				v["address"] = p.pseudonym("nodes", address)
+			case "InternalDNS", "ExternalDNS":
+				v["address"] = p.pseudonym("hostnames", address)
+			default:
+				v["address"] = p.anonymizeString("address", address)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = p.anonymizeValue(key, child)
+		}
+		return v
+	case string:
+		return p.anonymizeString(key, v)
+	default:
+		return v
+	}
+}
+
+// anonymizeString replaces a string based on the key it is stored under. Any
+// other string has the IPs and already known names it contains replaced.
+func (p *pseudonymizer) anonymizeString(key, value string) string {
+	if net.ParseIP(value) != nil {
+		return p.pseudonym("ips", value)
+	}
+	if cidr, ok := p.anonymizeCIDR(value); ok {
+		return cidr
+	}
+
+	switch key {
+	case "namespace", "kubernetes.io/metadata.name":
+		return p.namespace(value)
+	case "nodeName", "kubernetes.io/hostname":
+		return p.pseudonym("nodes", value)
+	case "host", "hosts", "hostname", "hostName", "subdomain":
+		return p.pseudonym("hostnames", value)
+	}
+
+	return p.replaceKnownNames(p.replaceEmbeddedIPs(value))
+}
+
+// anonymizeCIDR replaces the address of a CIDR and keeps its prefix length
+func (p *pseudonymizer) anonymizeCIDR(value string) (string, bool) {
+	ip, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return value, false
+	}
+	ones, _ := ipNet.Mask.Size()
+	return p.pseudonym("ips", ip.String()) + "/" + strconv.Itoa(ones), true
+}
+
+// replaceEmbeddedIPs replaces IPv4 addresses and CIDRs inside a longer string,
+// e.g. in a URL or a JSON-valued annotation. A match next to another digit or
+// dot is part of something else, such as a version number, and is kept.
+func (p *pseudonymizer) replaceEmbeddedIPs(value string) string {
+	matches := embeddedIPv4Pattern.FindAllStringIndex(value, -1)
+	if matches == nil {
+		return value
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if (start > 0 && isIPChar(value[start-1])) || (end < len(value) && isIPChar(value[end])) {
+			continue
+		}
+		match := value[start:end]
+		replacement, ok := p.anonymizeCIDR(match)
+		if !ok {
+			if net.ParseIP(match) == nil {
+				continue
+			}
+			replacement = p.pseudonym("ips", match)
+		}
+		b.WriteString(value[last:start])
+		b.WriteString(replacement)
+		last = end
+	}
+	b.WriteString(value[last:])
+	return b.String()
+}
+
+// replaceKnownNames replaces the namespace, node and host names already in the
+// mapping wherever they appear as a whole word in a longer string, e.g. in
+// etcd-<node>, a providerID or a JSON-valued annotation
+func (p *pseudonymizer) replaceKnownNames(value string) string {
+	var candidates []knownName
+	for _, name := range p.knownNames() {
+		if strings.Contains(value, name.original) {
+			candidates = append(candidates, name)
+		}
+	}
+	if candidates == nil {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		matched := false
+		if i == 0 || !isNameChar(value[i-1]) {
+			for _, name := range candidates {
+				end := i + len(name.original)
+				if strings.HasPrefix(value[i:], name.original) && (end == len(value) || !isNameChar(value[end])) {
+					b.WriteString(name.pseudonym)
+					i = end
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			b.WriteByte(value[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// knownNames returns the mapped names, longest first so a hostname wins over
+// the node name it starts with; the list is rebuilt when the mapping grows
+func (p *pseudonymizer) knownNames() []knownName {
+	size := 0
+	for _, category := range []string{"namespaces", "nodes", "hostnames"} {
+		size += len(p.mapping[category])
+	}
+	if size == p.knownSize {
+		return p.known
+	}
+
+	p.known = p.known[:0]
+	for _, category := range []string{"namespaces", "nodes", "hostnames"} {
+		for original, pseudonym := range p.mapping[category] {
+			p.known = append(p.known, knownName{original: original, pseudonym: pseudonym})
+		}
+	}
+	sort.Slice(p.known, func(i, j int) bool {
+		if len(p.known[i].original) != len(p.known[j].original) {
+			return len(p.known[i].original) > len(p.known[j].original)
+		}
+		return p.known[i].original < p.known[j].original
+	})
+	p.knownSize = size
+	return p.known
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isIPChar(c byte) bool {
+	return c >= '0' && c <= '9' || c == '.'
+}
+
+// prepareAnonymizer adds every namespace and node, with the node addresses,
+// to the mapping before collection starts, so their names are also replaced
+// inside strings of resources collected before Namespaces and Nodes
+func prepareAnonymizer(dynamicClient dynamic.Interface) {
+	if anonymizer == nil {
+		return
+	}
+
+	for _, gvr := range []schema.GroupVersionResource{
+		{Version: "v1", Resource: "namespaces"},
+		{Version: "v1", Resource: "nodes"},
+	} {
+		list, err := listResource(dynamicClient, gvr)
+		if err != nil {
+			fmt.Printf("Warning: failed to list %s for --anonymize; names embedded in other strings may not be replaced: %v\n", gvr.Resource, err)
+			continue
+		}
+		anonymizeList(list)
+	}
+}
+
+// writeAnonymizeMapping saves the pseudonym mapping so a collection can be
+// de-anonymized locally. The file must not be shared with the collection.
+func writeAnonymizeMapping(path string) error {
+	if anonymizer == nil {
+		return nil
+	}
+
+	yamlData, err := yaml.Marshal(anonymizer.mapping)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anonymize mapping: %w", err)
+	}
+
+	// Written without recording it, so it is never signed, pushed or uploaded
+	header := "# Mapping of original identifiers to pseudonyms. Keep this file private.\n"
+	if err := os.WriteFile(path, []byte(header+string(yamlData)), 0600); err != nil {
+		return fmt.Errorf("failed to write anonymize mapping %s: %w", path, err)
+	}
+
+	fmt.Printf("Anonymize mapping: %s (keep private)\n", path)
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPseudonymStability(t *testing.T) {
+	p := newPseudonymizer("")
+
+	first := p.pseudonym("nodes", "worker-a")
+	second := p.pseudonym("nodes", "worker-b")
+	if first != "node-1" || second != "node-2" {
+		t.Errorf("pseudonyms = %s, %s, want node-1, node-2", first, second)
+	}
+	if again := p.pseudonym("nodes", "worker-a"); again != first {
+		t.Errorf("pseudonym changed from %s to %s", first, again)
+	}
+	// Categories are numbered independently
+	if got := p.pseudonym("namespaces", "worker-a"); got != "namespace-1" {
+		t.Errorf("namespace pseudonym = %s, want namespace-1", got)
+	}
+	if got := p.pseudonym("nodes", ""); got != "" {
+		t.Errorf("empty value became %q", got)
+	}
+}
+
+func TestSeededPseudonyms(t *testing.T) {
+	first := newPseudonymizer("case-42")
+	second := newPseudonymizer("case-42")
+
+	// The same seed gives the same pseudonyms in any order of appearance
+	a := first.pseudonym("namespaces", "shop")
+	b := first.pseudonym("namespaces", "billing")
+	if second.pseudonym("namespaces", "billing") != b || second.pseudonym("namespaces", "shop") != a {
+		t.Errorf("pseudonyms differ between runs with the same seed")
+	}
+	if !regexp.MustCompile(`^namespace-[0-9a-f]{8}$`).MatchString(a) {
+		t.Errorf("seeded namespace pseudonym = %q, want namespace-<8 hex digits>", a)
+	}
+	if other := newPseudonymizer("case-43").pseudonym("namespaces", "shop"); other == a {
+		t.Errorf("different seeds gave the same pseudonym %s", a)
+	}
+	if ip := first.pseudonym("ips", "2001:db8::1"); net.ParseIP(ip) == nil || net.ParseIP(ip).To4() != nil {
+		t.Errorf("seeded IPv6 pseudonym = %q, want an IPv6 address", ip)
+	}
+
+	// A collision with a pseudonym already handed out is rehashed
+	colliding := newPseudonymizer("case-42")
+	colliding.used["namespaces"] = map[string]bool{a: true}
+	if got := colliding.pseudonym("namespaces", "shop"); got == a || !strings.HasPrefix(got, "namespace-") {
+		t.Errorf("pseudonym after a collision = %q, want a different namespace pseudonym", got)
+	}
+}
+
+func TestAnonymizeString(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+	}{
+		{name: "IPv4", key: "podIP", value: "192.168.1.7", want: "10.0.0.1"},
+		{name: "IPv6", key: "podIP", value: "2001:db8::1", want: "fd00::1"},
+		{name: "CIDR keeps its prefix length", key: "podCIDR", value: "192.168.0.0/16", want: "10.0.0.1/16"},
+		{name: "namespace field", key: "namespace", value: "payments", want: "namespace-1"},
+		{name: "well-known namespace", key: "namespace", value: "kube-system", want: "kube-system"},
+		{name: "node field", key: "nodeName", value: "worker-a", want: "node-1"},
+		{name: "host field", key: "host", value: "shop.example.org", want: "host-1.example.com"},
+		{name: "unrelated field", key: "image", value: "nginx:1.25.3", want: "nginx:1.25.3"},
+		{name: "embedded IP", key: "url", value: "https://192.168.1.7:6443/healthz", want: "https://10.0.0.1:6443/healthz"},
+		{name: "version numbers are kept", key: "version", value: "v1.2.3.4.5", want: "v1.2.3.4.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPseudonymizer("")
+			if got := p.anonymizeString(tt.key, tt.value); got != tt.want {
+				t.Errorf("anonymizeString(%q, %q) = %q, want %q", tt.key, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceKnownNames(t *testing.T) {
+	p := newPseudonymizer("")
+	p.pseudonym("nodes", "worker-a")
+	p.pseudonym("hostnames", "worker-a.lab.local")
+	p.namespace("shop")
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "etcd-worker-a", want: "etcd-node-1"},
+		{value: "worker-a.lab.local:10250", want: "host-1.example.com:10250"},
+		{value: `{"namespace":"shop"}`, want: `{"namespace":"namespace-1"}`},
+		{value: "workshop", want: "workshop"},
+		{value: "worker-ab", want: "worker-ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := p.replaceKnownNames(tt.value); got != tt.want {
+				t.Errorf("replaceKnownNames(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymizeList(t *testing.T) {
+	defer func(saved *pseudonymizer) { anonymizer = saved }(anonymizer)
+	anonymizer = newPseudonymizer("")
+
+	list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"kind":     "Node",
+			"metadata": map[string]interface{}{"name": "worker-a"},
+			"status": map[string]interface{}{"addresses": []interface{}{
+				map[string]interface{}{"type": "InternalIP", "address": "192.168.1.7"},
+				map[string]interface{}{"type": "Hostname", "address": "worker-a"},
+			}},
+		}},
+		{Object: map[string]interface{}{
+			"kind":     "Pod",
+			"metadata": map[string]interface{}{"name": "etcd-worker-a", "namespace": "shop"},
+			"spec":     map[string]interface{}{"nodeName": "worker-a"},
+		}},
+	}}
+	anonymizeList(list)
+
+	want := []map[string]interface{}{
+		{
+			"kind":     "Node",
+			"metadata": map[string]interface{}{"name": "node-1"},
+			"status": map[string]interface{}{"addresses": []interface{}{
+				map[string]interface{}{"type": "InternalIP", "address": "10.0.0.1"},
+				map[string]interface{}{"type": "Hostname", "address": "node-1"},
+			}},
+		},
+		{
+			"kind":     "Pod",
+			"metadata": map[string]interface{}{"name": "etcd-node-1", "namespace": "namespace-1"},
+			"spec":     map[string]interface{}{"nodeName": "node-1"},
+		},
+	}
+	for i := range want {
+		if got := list.Items[i].Object; !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("item %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestAnonymizeMappingPath(t *testing.T) {
+	defer func(saved string) { anonMapping = saved }(anonMapping)
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+
+	anonMapping = ""
+	path := anonymizeMappingPath(output)
+	if path != output+"-"+anonymizeMappingFile {
+		t.Errorf("anonymizeMappingPath() = %s", path)
+	}
+	if isWithinDir(path, output) {
+		t.Errorf("mapping %s is inside the output %s", path, output)
+	}
+
+	anonMapping = filepath.Join(dir, "private", "mapping.yaml")
+	if got := anonymizeMappingPath(output); got != anonMapping {
+		t.Errorf("anonymizeMappingPath() = %s, want --anonymize-mapping %s", got, anonMapping)
+	}
+}
+
+func TestWriteAnonymizeMappingNotPublished(t *testing.T) {
+	defer func(saved *pseudonymizer) { anonymizer = saved }(anonymizer)
+	anonymizer = newPseudonymizer("")
+	anonymizer.namespace("shop")
+
+	// In single file mode --anonymize-mapping may point next to the output file
+	dir := t.TempDir()
+	if err := writeOutputFile(filepath.Join(dir, "all-resources.yaml"), []byte("kind: List\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "mapping.yaml")
+	if err := writeAnonymizeMapping(path); err != nil {
+		t.Fatalf("writeAnonymizeMapping() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "shop") {
+		t.Fatalf("mapping = %q, %v; want the shop pseudonym", data, err)
+	}
+	if files := writtenOutputFiles(dir); !reflect.DeepEqual(files, []string{filepath.Join(dir, "all-resources.yaml")}) {
+		t.Errorf("writtenOutputFiles() = %v, want only the collection file", files)
+	}
+}
+
+func TestIsWithinDir(t *testing.T) {
+	tests := []struct {
+		path string
+		dir  string
+		want bool
+	}{
+		{path: "/out", dir: "/out", want: true},
+		{path: "/out/a/b.yaml", dir: "/out", want: true},
+		{path: "/out-anonymize-mapping.yaml", dir: "/out", want: false},
+		{path: "/other/out", dir: "/out", want: false},
+		{path: "/out/../mapping.yaml", dir: "/out", want: false},
+		{path: "/out/..mapping.yaml", dir: "/out", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isWithinDir(tt.path, tt.dir); got != tt.want {
+				t.Errorf("isWithinDir(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
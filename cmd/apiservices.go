@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// apiServicesGVR is the aggregation layer registration resource
+var apiServicesGVR = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// apiServiceHealth is the Available condition of one APIService
+type apiServiceHealth struct {
+	Name      string
+	Service   string
+	Available bool
+	Reason    string
+	Message   string
+}
+
+// runAPIServicesMode collects APIServices and reports which aggregated APIs are
+// unavailable; those are the usual cause of partial discovery failures
+func runAPIServicesMode(dynamicClient dynamic.Interface) error {
+	startTime := time.Now()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	list, err := collectFocusedList(dynamicClient, apiServicesGVR)
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(outputDir, formatFilename(apiServicesGVR.Resource, apiServicesGVR.GroupVersion().String()))
+
+	var health []apiServiceHealth
+	unavailable := 0
+	for i := range list.Items {
+		h := apiServiceHealthOf(&list.Items[i])
+		if !h.Available {
+			unavailable++
+		}
+		health = append(health, h)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Name < health[j].Name })
+
+	report := formatAPIServicesReport(health)
+	reportPath := filepath.Join(outputDir, "apiservices-health.txt")
+	if err := writeOutputFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reportPath, err)
+	}
+	fmt.Print(report)
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== APIService Summary ===\n")
+	fmt.Printf("APIServices: %d (%d unavailable)\n", len(health), unavailable)
+	fmt.Printf("Saved to: %s\n", filePath)
+	fmt.Printf("Health report: %s\n", reportPath)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("==========================\n")
+
+	return nil
+}
+
+// apiServiceHealthOf reads the Available condition of an APIService
+func apiServiceHealthOf(apiService *unstructured.Unstructured) apiServiceHealth {
+	h := apiServiceHealth{Name: apiService.GetName(), Service: "Local"}
+
+	if name, ok, _ := unstructured.NestedString(apiService.Object, "spec", "service", "name"); ok {
+		namespace, _, _ := unstructured.NestedString(apiService.Object, "spec", "service", "namespace")
+		h.Service = namespace + "/" + name
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+	for _, condition := range conditions {
+		c, ok := condition.(map[string]interface{})
+		if !ok || c["type"] != "Available" {
+			continue
+		}
+		h.Available = c["status"] == "True"
+		h.Reason, _ = c["reason"].(string)
+		h.Message, _ = c["message"].(string)
+	}
+	if h.Reason == "" && !h.Available {
+		h.Reason = "NoAvailableCondition"
+	}
+
+	return h
+}
+
+// formatAPIServicesReport lists unavailable APIServices first, with their reason
+func formatAPIServicesReport(health []apiServiceHealth) string {
+	var unavailable, available []string
+	for _, h := range health {
+		if h.Available {
+			available = append(available, fmt.Sprintf("  %s (%s)", h.Name, h.Service))
+			continue
+		}
+		line := fmt.Sprintf("  %s (%s): %s", h.Name, h.Service, h.Reason)
+		if h.Message != "" {
+			line += " - " + h.Message
+		}
+		unavailable = append(unavailable, line)
+	}
+
+	var report strings.Builder
+	report.WriteString("=== Aggregation Layer Health ===\n")
+	report.WriteString(fmt.Sprintf("\nUnavailable (%d):\n", len(unavailable)))
+	for _, line := range unavailable {
+		report.WriteString(line + "\n")
+	}
+	report.WriteString(fmt.Sprintf("\nAvailable (%d):\n", len(available)))
+	for _, line := range available {
+		report.WriteString(line + "\n")
+	}
+
+	return report.String()
+}
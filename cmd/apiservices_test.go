@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAPIServiceHealthOf(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiService map[string]interface{}
+		want       apiServiceHealth
+	}{
+		{
+			name: "local and available",
+			apiService: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "v1.apps"},
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Available", "status": "True", "reason": "Local"},
+				}},
+			},
+			want: apiServiceHealth{Name: "v1.apps", Service: "Local", Available: true, Reason: "Local"},
+		},
+		{
+			name: "aggregated and unavailable",
+			apiService: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "v1beta1.metrics.k8s.io"},
+				"spec":     map[string]interface{}{"service": map[string]interface{}{"name": "metrics-server", "namespace": "kube-system"}},
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Available", "status": "False", "reason": "FailedDiscoveryCheck", "message": "no response"},
+				}},
+			},
+			want: apiServiceHealth{Name: "v1beta1.metrics.k8s.io", Service: "kube-system/metrics-server", Reason: "FailedDiscoveryCheck", Message: "no response"},
+		},
+		{
+			name:       "no Available condition",
+			apiService: map[string]interface{}{"metadata": map[string]interface{}{"name": "v1.example.com"}},
+			want:       apiServiceHealth{Name: "v1.example.com", Service: "Local", Reason: "NoAvailableCondition"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiServiceHealthOf(&unstructured.Unstructured{Object: tt.apiService}); got != tt.want {
+				t.Errorf("apiServiceHealthOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAPIServicesReport(t *testing.T) {
+	report := formatAPIServicesReport([]apiServiceHealth{
+		{Name: "v1.apps", Service: "Local", Available: true},
+		{Name: "v1beta1.metrics.k8s.io", Service: "kube-system/metrics-server", Reason: "FailedDiscoveryCheck", Message: "no response"},
+	})
+
+	want := `=== Aggregation Layer Health ===
+
+Unavailable (1):
+  v1beta1.metrics.k8s.io (kube-system/metrics-server): FailedDiscoveryCheck - no response
+
+Available (1):
+  v1.apps (Local)
+`
+	if report != want {
+		t.Errorf("report =\n%s\nwant\n%s", report, want)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	// hpaGVR is the HorizontalPodAutoscaler version that carries every metric type
+	hpaGVR = schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+	// vpaGVR is the VerticalPodAutoscaler CRD, only present when the VPA is installed
+	vpaGVR = schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"}
+)
+
+// hpaScaling is the summary of one HorizontalPodAutoscaler
+type hpaScaling struct {
+	Key     string // namespace/name
+	Target  string // kind/name of the scaled workload
+	Min     int64
+	Max     int64
+	Current int64
+	Desired int64
+	Metrics []string // "cpu 85%/70%" (current/target)
+}
+
+// vpaRecommendation is the summary of one VerticalPodAutoscaler
+type vpaRecommendation struct {
+	Key             string
+	Target          string
+	Mode            string
+	Recommendations []string // "app: cpu=250m memory=512Mi"
+}
+
+// runAutoscalersMode collects HPAs, and VPAs when the VPA CRD is installed,
+// and summarizes current against desired replicas and metrics so autoscalers
+// pinned at their minimum or maximum stand out
+func runAutoscalersMode(dynamicClient dynamic.Interface) error {
+	startTime := time.Now()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	hpaList, err := collectFocusedList(dynamicClient, hpaGVR)
+	if err != nil {
+		return err
+	}
+
+	var hpas []hpaScaling
+	pinned := 0
+	for i := range hpaList.Items {
+		h := hpaScalingOf(&hpaList.Items[i])
+		if h.Current >= h.Max && h.Max > 0 {
+			pinned++
+		}
+		hpas = append(hpas, h)
+	}
+	sort.Slice(hpas, func(i, j int) bool { return hpas[i].Key < hpas[j].Key })
+
+	var vpas []vpaRecommendation
+	vpaInstalled := true
+	vpaList, err := collectFocusedList(dynamicClient, vpaGVR)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		vpaInstalled = false
+		if verbose {
+			fmt.Printf("VerticalPodAutoscalers not installed, skipping\n")
+		}
+	} else {
+		for i := range vpaList.Items {
+			vpas = append(vpas, vpaRecommendationOf(&vpaList.Items[i]))
+		}
+		sort.Slice(vpas, func(i, j int) bool { return vpas[i].Key < vpas[j].Key })
+	}
+
+	report := formatAutoscalersReport(hpas, vpas, vpaInstalled)
+	reportPath := filepath.Join(outputDir, "autoscalers-summary.txt")
+	if err := writeOutputFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reportPath, err)
+	}
+	fmt.Print(report)
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== Autoscaler Summary ===\n")
+	fmt.Printf("HPAs: %d (%d at max replicas)\n", len(hpas), pinned)
+	if vpaInstalled {
+		fmt.Printf("VPAs: %d\n", len(vpas))
+	}
+	fmt.Printf("Output directory: %s\n", outputDir)
+	fmt.Printf("Summary: %s\n", reportPath)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("==========================\n")
+
+	return nil
+}
+
+// hpaScalingOf reads replica bounds from .spec, replica counts from .status
+// and pairs each .spec.metrics target with its .status.currentMetrics value
+func hpaScalingOf(hpa *unstructured.Unstructured) hpaScaling {
+	h := hpaScaling{Key: hpa.GetNamespace() + "/" + hpa.GetName(), Min: 1}
+
+	kind, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "kind")
+	name, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "name")
+	h.Target = kind + "/" + name
+	if min, ok, _ := unstructured.NestedInt64(hpa.Object, "spec", "minReplicas"); ok {
+		h.Min = min
+	}
+	h.Max, _, _ = unstructured.NestedInt64(hpa.Object, "spec", "maxReplicas")
+	h.Current, _, _ = unstructured.NestedInt64(hpa.Object, "status", "currentReplicas")
+	h.Desired, _, _ = unstructured.NestedInt64(hpa.Object, "status", "desiredReplicas")
+
+	current := make(map[string]string)
+	statusMetrics, _, _ := unstructured.NestedSlice(hpa.Object, "status", "currentMetrics")
+	for _, entry := range statusMetrics {
+		if metric, ok := entry.(map[string]interface{}); ok {
+			name, value := hpaMetricValue(metric, "current")
+			current[name] = value
+		}
+	}
+
+	specMetrics, _, _ := unstructured.NestedSlice(hpa.Object, "spec", "metrics")
+	for _, entry := range specMetrics {
+		metric, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, target := hpaMetricValue(metric, "target")
+		value := current[name]
+		if value == "" {
+			value = "<unknown>"
+		}
+		h.Metrics = append(h.Metrics, fmt.Sprintf("%s %s/%s", name, value, target))
+	}
+
+	return h
+}
+
+// hpaMetricSources maps an HPA metric type to the field holding its source
+var hpaMetricSources = map[string]string{
+	"Resource":          "resource",
+	"ContainerResource": "containerResource",
+	"Pods":              "pods",
+	"Object":            "object",
+	"External":          "external",
+}
+
+// hpaMetricValue returns the name of a metric entry and its "target" or
+// "current" value; resource sources name the resource, the others name a metric
+func hpaMetricValue(metric map[string]interface{}, field string) (string, string) {
+	metricType, _ := metric["type"].(string)
+	source, _ := metric[hpaMetricSources[metricType]].(map[string]interface{})
+
+	name, ok := source["name"].(string)
+	if !ok {
+		name, _, _ = unstructured.NestedString(source, "metric", "name")
+	}
+
+	value, _ := source[field].(map[string]interface{})
+	switch {
+	case value["averageUtilization"] != nil:
+		return name, fmt.Sprintf("%v%%", value["averageUtilization"])
+	case value["averageValue"] != nil:
+		return name, fmt.Sprintf("%v", value["averageValue"])
+	case value["value"] != nil:
+		return name, fmt.Sprintf("%v", value["value"])
+	}
+	return name, "<unknown>"
+}
+
+// vpaRecommendationOf reads the update mode and the per-container target recommendation
+func vpaRecommendationOf(vpa *unstructured.Unstructured) vpaRecommendation {
+	v := vpaRecommendation{Key: vpa.GetNamespace() + "/" + vpa.GetName(), Mode: "Auto"}
+
+	kind, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "kind")
+	name, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "name")
+	v.Target = kind + "/" + name
+	if mode, ok, _ := unstructured.NestedString(vpa.Object, "spec", "updatePolicy", "updateMode"); ok && mode != "" {
+		v.Mode = mode
+	}
+
+	containers, _, _ := unstructured.NestedSlice(vpa.Object, "status", "recommendation", "containerRecommendations")
+	for _, entry := range containers {
+		container, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		containerName, _ := container["containerName"].(string)
+		target, _, _ := unstructured.NestedStringMap(container, "target")
+
+		var resources []string
+		for resource, quantity := range target {
+			resources = append(resources, resource+"="+quantity)
+		}
+		sort.Strings(resources)
+		v.Recommendations = append(v.Recommendations, containerName+": "+strings.Join(resources, " "))
+	}
+
+	return v
+}
+
+// formatAutoscalersReport renders one row per HPA with its scaling state, then
+// the VPA recommendations
+func formatAutoscalersReport(hpas []hpaScaling, vpas []vpaRecommendation, vpaInstalled bool) string {
+	var report strings.Builder
+	report.WriteString("=== HorizontalPodAutoscalers ===\n\n")
+
+	if len(hpas) == 0 {
+		report.WriteString("No HorizontalPodAutoscalers found\n")
+	} else {
+		table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "HPA\tTARGET\tMIN\tMAX\tCURRENT\tDESIRED\tMETRICS (CURRENT/TARGET)\tSTATE")
+		for _, h := range hpas {
+			metrics := strings.Join(h.Metrics, ", ")
+			if metrics == "" {
+				metrics = "<none>"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n", h.Key, h.Target, h.Min, h.Max, h.Current, h.Desired, metrics, hpaState(h))
+		}
+		table.Flush()
+	}
+
+	report.WriteString("\n=== VerticalPodAutoscalers ===\n\n")
+	switch {
+	case !vpaInstalled:
+		report.WriteString("VerticalPodAutoscaler CRD not installed\n")
+	case len(vpas) == 0:
+		report.WriteString("No VerticalPodAutoscalers found\n")
+	default:
+		table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "VPA\tTARGET\tMODE\tRECOMMENDATION")
+		for _, v := range vpas {
+			recommendation := strings.Join(v.Recommendations, "; ")
+			if recommendation == "" {
+				recommendation = "<none yet>"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", v.Key, v.Target, v.Mode, recommendation)
+		}
+		table.Flush()
+	}
+
+	return report.String()
+}
+
+// hpaState flags an HPA that cannot scale further up, is pinned at its
+// minimum, or is between a current and a different desired replica count
+func hpaState(h hpaScaling) string {
+	switch {
+	case h.Max > 0 && h.Current >= h.Max:
+		return "AT MAX"
+	case h.Desired > h.Current:
+		return "scaling up"
+	case h.Desired < h.Current:
+		return "scaling down"
+	case h.Current <= h.Min:
+		return "at min"
+	}
+	return "ok"
+}
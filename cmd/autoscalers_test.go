@@ -0,0 +1,128 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestHpaScalingOf(t *testing.T) {
+	hpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "HorizontalPodAutoscaler",
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"kind": "Deployment", "name": "web"},
+			"minReplicas":    int64(2),
+			"maxReplicas":    int64(10),
+			"metrics": []interface{}{
+				map[string]interface{}{
+					"type": "Resource",
+					"resource": map[string]interface{}{
+						"name":   "cpu",
+						"target": map[string]interface{}{"type": "Utilization", "averageUtilization": int64(70)},
+					},
+				},
+				map[string]interface{}{
+					"type": "External",
+					"external": map[string]interface{}{
+						"metric": map[string]interface{}{"name": "queue_depth"},
+						"target": map[string]interface{}{"type": "AverageValue", "averageValue": "30"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"currentReplicas": int64(4),
+			"desiredReplicas": int64(6),
+			"currentMetrics": []interface{}{
+				map[string]interface{}{
+					"type": "Resource",
+					"resource": map[string]interface{}{
+						"name":    "cpu",
+						"current": map[string]interface{}{"averageUtilization": int64(85)},
+					},
+				},
+			},
+		},
+	}}
+	hpa.SetNamespace("shop")
+	hpa.SetName("web")
+
+	want := hpaScaling{
+		Key:     "shop/web",
+		Target:  "Deployment/web",
+		Min:     2,
+		Max:     10,
+		Current: 4,
+		Desired: 6,
+		Metrics: []string{"cpu 85%/70%", "queue_depth <unknown>/30"},
+	}
+	if got := hpaScalingOf(hpa); !reflect.DeepEqual(got, want) {
+		t.Errorf("hpaScalingOf() = %+v, want %+v", got, want)
+	}
+
+	bare := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "HorizontalPodAutoscaler"}}
+	if got := hpaScalingOf(bare); got.Min != 1 {
+		t.Errorf("default minReplicas = %d, want 1", got.Min)
+	}
+}
+
+func TestHpaState(t *testing.T) {
+	tests := []struct {
+		name string
+		hpa  hpaScaling
+		want string
+	}{
+		{"at max", hpaScaling{Min: 1, Max: 5, Current: 5, Desired: 5}, "AT MAX"},
+		{"scaling up", hpaScaling{Min: 1, Max: 5, Current: 2, Desired: 4}, "scaling up"},
+		{"scaling down", hpaScaling{Min: 1, Max: 5, Current: 4, Desired: 2}, "scaling down"},
+		{"at min", hpaScaling{Min: 2, Max: 5, Current: 2, Desired: 2}, "at min"},
+		{"steady", hpaScaling{Min: 1, Max: 5, Current: 3, Desired: 3}, "ok"},
+		{"no max", hpaScaling{Min: 1, Current: 3, Desired: 3}, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hpaState(tt.hpa); got != tt.want {
+				t.Errorf("hpaState(%+v) = %q, want %q", tt.hpa, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVpaRecommendationOf(t *testing.T) {
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "VerticalPodAutoscaler",
+		"spec": map[string]interface{}{
+			"targetRef":    map[string]interface{}{"kind": "StatefulSet", "name": "db"},
+			"updatePolicy": map[string]interface{}{"updateMode": "Off"},
+		},
+		"status": map[string]interface{}{
+			"recommendation": map[string]interface{}{
+				"containerRecommendations": []interface{}{
+					map[string]interface{}{
+						"containerName": "postgres",
+						"target":        map[string]interface{}{"memory": "512Mi", "cpu": "250m"},
+					},
+				},
+			},
+		},
+	}}
+	vpa.SetNamespace("shop")
+	vpa.SetName("db")
+
+	want := vpaRecommendation{
+		Key:             "shop/db",
+		Target:          "StatefulSet/db",
+		Mode:            "Off",
+		Recommendations: []string{"postgres: cpu=250m memory=512Mi"},
+	}
+	if got := vpaRecommendationOf(vpa); !reflect.DeepEqual(got, want) {
+		t.Errorf("vpaRecommendationOf() = %+v, want %+v", got, want)
+	}
+
+	pending := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "VerticalPodAutoscaler"}}
+	if got := vpaRecommendationOf(pending); got.Mode != "Auto" || got.Recommendations != nil {
+		t.Errorf("vpaRecommendationOf(pending) = %+v, want Auto mode and no recommendations", got)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// driftReportFile is written next to the collection when --baseline is set
+const driftReportFile = "drift-report.txt"
+
+// writeBaselineDrift diffs a fresh single-file collection against the earlier
+// collection given with --baseline, the single-cluster counterpart of
+// comparison mode. --deep, --compare-labels and --only-changed-namespaces
+// apply as they do there.
+func writeBaselineDrift(outputFile string) error {
+	if baselineFile == "" {
+		return nil
+	}
+
+	path := filepath.Join(filepath.Dir(outputFile), driftReportFile)
+	baselineName := fmt.Sprintf("baseline (%s)", filepath.Base(baselineFile))
+	if err := generateDiff(baselineFile, outputFile, path, baselineName, "current"); err != nil {
+		return fmt.Errorf("failed to write drift report against %s: %w", baselineFile, err)
+	}
+
+	fmt.Printf("Drift report: %s\n", path)
+	return nil
+}
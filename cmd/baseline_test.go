@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteBaselineDrift(t *testing.T) {
+	defer func(baseline string, list, deep, namespaces bool, label string) {
+		baselineFile, listCommon, deepDiff, changedNamespaces, compareLabel = baseline, list, deep, namespaces, label
+	}(baselineFile, listCommon, deepDiff, changedNamespaces, compareLabel)
+	listCommon, deepDiff, changedNamespaces, compareLabel = false, false, false, ""
+
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "all-resources.yaml")
+	if err := os.WriteFile(outputFile, []byte("--- # Resource: pods\n--- # Resource: widgets\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without --baseline nothing is written
+	baselineFile = ""
+	if err := writeBaselineDrift(outputFile); err != nil {
+		t.Fatalf("writeBaselineDrift() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, driftReportFile)); !os.IsNotExist(err) {
+		t.Fatalf("drift report written without --baseline: %v", err)
+	}
+
+	baselineFile = filepath.Join(t.TempDir(), "last-week.yaml")
+	if err := os.WriteFile(baselineFile, []byte("--- # Resource: pods\n--- # Resource: gadgets\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBaselineDrift(outputFile); err != nil {
+		t.Fatalf("writeBaselineDrift() error = %v", err)
+	}
+
+	report, err := os.ReadFile(filepath.Join(dir, driftReportFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Cluster 1: baseline (last-week.yaml) (2 resources)",
+		"Cluster 2: current (2 resources)",
+		"=== Resources only in baseline (last-week.yaml) ===\n- gadgets",
+		"=== Resources only in current ===\n- widgets",
+	} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("drift report missing %q:\n%s", want, report)
+		}
+	}
+}
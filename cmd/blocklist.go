@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// defaultBlocklistFile is loaded when --blocklist is not given and it exists,
+// so a policy can be mounted into every container or host running the tool
+const defaultBlocklistFile = "/etc/k8s-resource-collector/blocklist"
+
+var (
+	// blockedResources are the resource names the policy file forbids collecting
+	blockedResources []string
+	// blocklistPath is the policy file in effect; empty when there is none
+	blocklistPath string
+	// blockedSeen records the blocked resources discovered in the current run
+	blockedSeen map[string]bool
+)
+
+// loadBlocklist reads the resource blocklist, one resource name per line with
+// # comments. Unlike --exclude-resources it cannot be overridden by
+// --include-resources or any other flag.
+func loadBlocklist(path string) error {
+	if path == "" {
+		if _, err := os.Stat(defaultBlocklistFile); err != nil {
+			return nil
+		}
+		path = defaultBlocklistFile
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read blocklist %s: %w", path, err)
+	}
+
+	blockedResources = names
+	blocklistPath = path
+	if verbose {
+		fmt.Printf("Loaded blocklist %s (%d resources)\n", path, len(names))
+	}
+	return nil
+}
+
+// isBlockedResource reports whether a resource (or the parent of a
+// subresource) is forbidden by the blocklist, and records it for the summary
+func isBlockedResource(name string) bool {
+	base, _, _ := strings.Cut(name, "/")
+	if !contains(blockedResources, base) {
+		return false
+	}
+	if blockedSeen != nil {
+		blockedSeen[base] = true
+	}
+	return true
+}
+
+// isBlockedKind reports whether objects of a kind read from files (must-gather
+// or import) belong to a blocked resource
+func isBlockedKind(gvk schema.GroupVersionKind) bool {
+	if len(blockedResources) == 0 {
+		return false
+	}
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+	return isBlockedResource(resource.Resource)
+}
+
+// blocklistDynamicClient refuses every read of a blocked resource, so the
+// dedicated collectors (RBAC graph, leases, webhooks and so on) cannot write
+// objects the policy forbids even though they bypass discovery. A refused read
+// fails with Forbidden, which collectors already handle like missing access.
+type blocklistDynamicClient struct {
+	dynamic.Interface
+}
+
+// newBlocklistDynamicClient wraps a dynamic client when a blocklist is loaded
+func newBlocklistDynamicClient(dynamicClient dynamic.Interface) dynamic.Interface {
+	if len(blockedResources) == 0 {
+		return dynamicClient
+	}
+	return &blocklistDynamicClient{Interface: dynamicClient}
+}
+
+// Resource returns a client that refuses reads of blocked resources
+func (c *blocklistDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	parent := c.Interface.Resource(gvr)
+	if !isBlockedResource(gvr.Resource) {
+		return parent
+	}
+	return &blockedResource{blockedNamespacedResource: blockedNamespacedResource{ResourceInterface: parent, gvr: gvr}, parent: parent}
+}
+
+// blockedResource is the cluster-wide client of a blocked resource
+type blockedResource struct {
+	blockedNamespacedResource
+	parent dynamic.NamespaceableResourceInterface
+}
+
+func (r *blockedResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &blockedNamespacedResource{ResourceInterface: r.parent.Namespace(namespace), gvr: r.gvr}
+}
+
+// blockedNamespacedResource fails every read of a blocked resource
+type blockedNamespacedResource struct {
+	dynamic.ResourceInterface
+	gvr schema.GroupVersionResource
+}
+
+func (r *blockedNamespacedResource) blocked() error {
+	return apierrors.NewForbidden(r.gvr.GroupResource(), "", fmt.Errorf("blocked by policy %s", blocklistPath))
+}
+
+func (r *blockedNamespacedResource) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return nil, r.blocked()
+}
+
+func (r *blockedNamespacedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return nil, r.blocked()
+}
+
+func (r *blockedNamespacedResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, r.blocked()
+}
+
+// discardSink swallows a blocked resource block during import
+type discardSink struct {
+	io.Writer
+}
+
+func (discardSink) Close() error {
+	return nil
+}
+
+// printBlockedSummary lists the discovered resources the blocklist kept out
+func printBlockedSummary() {
+	if len(blockedSeen) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range blockedSeen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Printf("Blocked by policy (%s): %s\n", blocklistPath, strings.Join(names, ", "))
+}
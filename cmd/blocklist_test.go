@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLoadBlocklist(t *testing.T) {
+	defer func(blocked []string, path string) {
+		blockedResources, blocklistPath = blocked, path
+	}(blockedResources, blocklistPath)
+	blockedResources, blocklistPath = nil, ""
+
+	path := filepath.Join(t.TempDir(), "blocklist")
+	content := "# Never leave the cluster\nsecrets\n  configmaps  # app settings\n\n#leases\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadBlocklist(path); err != nil {
+		t.Fatalf("loadBlocklist() error = %v", err)
+	}
+	if want := []string{"secrets", "configmaps"}; !reflect.DeepEqual(blockedResources, want) {
+		t.Errorf("blockedResources = %v, want %v", blockedResources, want)
+	}
+	if blocklistPath != path {
+		t.Errorf("blocklistPath = %q, want %q", blocklistPath, path)
+	}
+
+	if err := loadBlocklist(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loadBlocklist() of a missing file error = nil")
+	}
+}
+
+func TestIsBlockedResource(t *testing.T) {
+	defer func(blocked []string, seen map[string]bool) {
+		blockedResources, blockedSeen = blocked, seen
+	}(blockedResources, blockedSeen)
+	blockedResources = []string{"secrets"}
+	blockedSeen = make(map[string]bool)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"secrets", true},
+		{"secrets/status", true},
+		{"configmaps", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBlockedResource(tt.name); got != tt.want {
+			t.Errorf("isBlockedResource(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if want := map[string]bool{"secrets": true}; !reflect.DeepEqual(blockedSeen, want) {
+		t.Errorf("blockedSeen = %v, want %v", blockedSeen, want)
+	}
+
+	if !isBlockedKind(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}) {
+		t.Error("isBlockedKind(Secret) = false, want true")
+	}
+}
+
+func TestBlocklistDynamicClient(t *testing.T) {
+	defer func(blocked []string) { blockedResources = blocked }(blockedResources)
+
+	stub := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+		"secrets":    namespacedList("Secret", [2]string{"shop", "token"}),
+		"configmaps": namespacedList("ConfigMap", [2]string{"shop", "app"}),
+	}}
+
+	blockedResources = nil
+	if client := newBlocklistDynamicClient(stub); client != stub {
+		t.Error("client wrapped without a blocklist")
+	}
+
+	blockedResources = []string{"secrets"}
+	client := newBlocklistDynamicClient(stub)
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	if _, err := client.Resource(secrets).List(context.Background(), metav1.ListOptions{}); !apierrors.IsForbidden(err) {
+		t.Errorf("cluster-wide List of a blocked resource error = %v, want Forbidden", err)
+	}
+	if _, err := client.Resource(secrets).Namespace("shop").List(context.Background(), metav1.ListOptions{}); !apierrors.IsForbidden(err) {
+		t.Errorf("namespaced List of a blocked resource error = %v, want Forbidden", err)
+	}
+	if _, err := client.Resource(secrets).Namespace("shop").Get(context.Background(), "token", metav1.GetOptions{}); !apierrors.IsForbidden(err) {
+		t.Errorf("Get of a blocked resource error = %v, want Forbidden", err)
+	}
+	list, err := client.Resource(configMaps).List(context.Background(), metav1.ListOptions{})
+	if err != nil || len(list.Items) != 1 {
+		t.Errorf("List of an allowed resource = %v, %v, want the configmap", list, err)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// checkpointFile records must-gather processing progress next to the output
+const checkpointFile = ".must-gather-checkpoint.jsonl"
+
+// checkpointHeader is the first line of a checkpoint and names its must-gather
+type checkpointHeader struct {
+	Source string `json:"source"`
+}
+
+// checkpointEntry is one processed file, relative to the must-gather root, with
+// the objects read from it
+type checkpointEntry struct {
+	File      string                   `json:"file"`
+	Resources map[string][]interface{} `json:"resources"`
+}
+
+// mustGatherCheckpoint is the progress of a must-gather run. Every processed
+// file is appended to the checkpoint as one JSON line as soon as it is read,
+// so an interrupted run can be resumed with --resume: it skips those files
+// and still writes complete output.
+type mustGatherCheckpoint struct {
+	path      string
+	root      string
+	file      *os.File
+	processed map[string]bool
+	resources map[string][]interface{}
+}
+
+var (
+	// checkpointMustGather is --checkpoint: record progress without --resume
+	checkpointMustGather bool
+	// activeCheckpoint is the checkpoint of the current must-gather run
+	activeCheckpoint *mustGatherCheckpoint
+)
+
+// openMustGatherCheckpoint starts the checkpoint in dir for source, if
+// --checkpoint or --resume asks for one; otherwise it returns nil and no
+// progress is recorded. With --resume an existing checkpoint of the same
+// must-gather is continued; otherwise, or when it belongs to another
+// must-gather, it is replaced.
+func openMustGatherCheckpoint(dir, source, root string) (*mustGatherCheckpoint, error) {
+	if !checkpointMustGather && !resume {
+		return nil, nil
+	}
+
+	checkpoint := &mustGatherCheckpoint{
+		path:      filepath.Join(dir, checkpointFile),
+		root:      root,
+		processed: make(map[string]bool),
+		resources: make(map[string][]interface{}),
+	}
+
+	if resume {
+		continued, err := checkpoint.load(source)
+		if err != nil {
+			return nil, err
+		}
+		if continued {
+			file, err := os.OpenFile(checkpoint.path, os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open checkpoint %s: %w", checkpoint.path, err)
+			}
+			checkpoint.file = file
+			fmt.Printf("Resuming from checkpoint: %d files already processed\n", len(checkpoint.processed))
+			return checkpoint, nil
+		}
+	}
+
+	file, err := os.Create(checkpoint.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint %s: %w", checkpoint.path, err)
+	}
+	checkpoint.file = file
+	if err := checkpoint.append(checkpointHeader{Source: source}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// load reads an existing checkpoint of source and reports whether there was
+// one. A last line cut short by the interruption is ignored; its file is
+// processed again.
+func (c *mustGatherCheckpoint) load(source string) (bool, error) {
+	file, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read checkpoint %s: %w", c.path, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	line, err := reader.ReadBytes('\n')
+	var header checkpointHeader
+	if err != nil || json.Unmarshal(line, &header) != nil {
+		fmt.Printf("Warning: ignoring unreadable checkpoint %s\n", c.path)
+		return false, nil
+	}
+	if header.Source != source {
+		fmt.Printf("Warning: ignoring checkpoint %s of another must-gather (%s)\n", c.path, header.Source)
+		return false, nil
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read checkpoint %s: %w", c.path, err)
+		}
+		var entry checkpointEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			break
+		}
+		c.processed[entry.File] = true
+		for key, items := range entry.Resources {
+			c.resources[key] = append(c.resources[key], items...)
+		}
+	}
+	return true, nil
+}
+
+// seed copies the objects of the files already processed into resourceMap
+func (c *mustGatherCheckpoint) seed(resourceMap map[string][]interface{}) {
+	if c == nil {
+		return
+	}
+	for key, items := range c.resources {
+		resourceMap[key] = append(resourceMap[key], items...)
+	}
+}
+
+// done reports whether an earlier run already processed the file
+func (c *mustGatherCheckpoint) done(path string) bool {
+	if c == nil {
+		return false
+	}
+	return c.processed[c.relative(path)]
+}
+
+// record appends a processed file and the objects read from it. A checkpoint
+// that cannot be written only costs the resume, so it is a warning.
+func (c *mustGatherCheckpoint) record(path string, fileResources map[string][]interface{}) {
+	if c == nil {
+		return
+	}
+
+	relative := c.relative(path)
+	c.processed[relative] = true
+	if err := c.append(checkpointEntry{File: relative, Resources: fileResources}); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// append writes one JSON line to the checkpoint
+func (c *mustGatherCheckpoint) append(line interface{}) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// finish removes the checkpoint once the output is complete
+func (c *mustGatherCheckpoint) finish() error {
+	if c == nil {
+		return nil
+	}
+	c.close()
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// close ends the run's checkpoint, leaving it on disk for --resume unless
+// finish removed it
+func (c *mustGatherCheckpoint) close() {
+	if c == nil {
+		return
+	}
+	c.file.Close()
+	if activeCheckpoint == c {
+		activeCheckpoint = nil
+	}
+}
+
+func (c *mustGatherCheckpoint) relative(path string) string {
+	if relative, err := filepath.Rel(c.root, path); err == nil {
+		return filepath.ToSlash(relative)
+	}
+	return path
+}
+
+// processCheckpointedFile processes one must-gather file into resourceMap,
+// unless the checkpoint shows an earlier run already did
+func processCheckpointedFile(path string, resourceMap map[string][]interface{}) error {
+	if activeCheckpoint == nil {
+		return processMustGatherFile(path, resourceMap)
+	}
+	if activeCheckpoint.done(path) {
+		return nil
+	}
+
+	fileResources := make(map[string][]interface{})
+	err := processMustGatherFile(path, fileResources)
+	for key, items := range fileResources {
+		resourceMap[key] = append(resourceMap[key], items...)
+	}
+	if err == nil {
+		activeCheckpoint.record(path, fileResources)
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMustGatherCheckpointResume(t *testing.T) {
+	defer func(savedResume, savedCheckpoint bool) {
+		resume, checkpointMustGather = savedResume, savedCheckpoint
+	}(resume, checkpointMustGather)
+
+	configMap := func(name string) interface{} {
+		return map[string]interface{}{"kind": "ConfigMap", "metadata": map[string]interface{}{"name": name}}
+	}
+
+	tests := []struct {
+		name          string
+		source        string
+		resume        bool
+		checkpoint    bool
+		truncate      bool
+		wantDone      bool
+		wantResources map[string][]interface{}
+	}{
+		{name: "resume continues the checkpoint", source: "mg", resume: true, wantDone: true, wantResources: map[string][]interface{}{"v1-configmaps": {configMap("a")}}},
+		{name: "a cut-short last line is ignored", source: "mg", resume: true, truncate: true, wantDone: true, wantResources: map[string][]interface{}{"v1-configmaps": {configMap("a")}}},
+		{name: "without resume the checkpoint is replaced", source: "mg", checkpoint: true, wantResources: map[string][]interface{}{}},
+		{name: "a checkpoint of another must-gather is replaced", source: "other", resume: true, wantResources: map[string][]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			root := filepath.Join(dir, "must-gather")
+			file := filepath.Join(root, "namespaces", "shop", "core", "configmaps.yaml")
+
+			// An interrupted --checkpoint run that processed one file
+			resume, checkpointMustGather = false, true
+			first, err := openMustGatherCheckpoint(dir, "mg", root)
+			if err != nil {
+				t.Fatalf("openMustGatherCheckpoint() error = %v", err)
+			}
+			first.record(file, map[string][]interface{}{"v1-configmaps": {configMap("a")}})
+			first.close()
+			if tt.truncate {
+				f, _ := os.OpenFile(first.path, os.O_WRONLY|os.O_APPEND, 0644)
+				f.WriteString(`{"file":"namespaces/shop/co`)
+				f.Close()
+			}
+
+			resume, checkpointMustGather = tt.resume, tt.checkpoint
+			second, err := openMustGatherCheckpoint(dir, tt.source, root)
+			if err != nil {
+				t.Fatalf("openMustGatherCheckpoint() error = %v", err)
+			}
+			if got := second.done(file); got != tt.wantDone {
+				t.Errorf("done() = %v, want %v", got, tt.wantDone)
+			}
+			resourceMap := make(map[string][]interface{})
+			second.seed(resourceMap)
+			if !reflect.DeepEqual(resourceMap, tt.wantResources) {
+				t.Errorf("seed() = %v, want %v", resourceMap, tt.wantResources)
+			}
+
+			if err := second.finish(); err != nil {
+				t.Fatalf("finish() error = %v", err)
+			}
+			if _, err := os.Stat(second.path); !os.IsNotExist(err) {
+				t.Errorf("checkpoint left after finish: %v", err)
+			}
+		})
+	}
+}
+
+func TestMustGatherCheckpointOptIn(t *testing.T) {
+	defer func(savedResume, savedCheckpoint bool) {
+		resume, checkpointMustGather = savedResume, savedCheckpoint
+	}(resume, checkpointMustGather)
+	resume, checkpointMustGather = false, false
+
+	dir := t.TempDir()
+	checkpoint, err := openMustGatherCheckpoint(dir, "mg", filepath.Join(dir, "must-gather"))
+	if err != nil || checkpoint != nil {
+		t.Fatalf("openMustGatherCheckpoint() = %v, %v; want no checkpoint without --checkpoint or --resume", checkpoint, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, checkpointFile)); !os.IsNotExist(err) {
+		t.Errorf("checkpoint file written without --checkpoint or --resume: %v", err)
+	}
+}
+
+func TestMustGatherCheckpointNil(t *testing.T) {
+	var checkpoint *mustGatherCheckpoint
+	resourceMap := make(map[string][]interface{})
+
+	checkpoint.seed(resourceMap)
+	checkpoint.record("file.yaml", map[string][]interface{}{"v1-configmaps": nil})
+	if checkpoint.done("file.yaml") || len(resourceMap) != 0 {
+		t.Errorf("nil checkpoint recorded progress")
+	}
+	if err := checkpoint.finish(); err != nil {
+		t.Errorf("finish() error = %v", err)
+	}
+	checkpoint.close()
+}
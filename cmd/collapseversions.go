@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// collapsedVersionAnnotation records the apiVersion an item was read with when
+// --collapse-versions files several versions of a kind together
+const collapsedVersionAnnotation = "k8s-resource-collector/api-version"
+
+// mustGatherResourceKey files a must-gather object by groupVersion-resource,
+// or by group-resource with --collapse-versions so a CR stored under two
+// served versions ends up in one file, each item annotated with its version
+func mustGatherResourceKey(apiVersion, kind string, object map[string]interface{}) string {
+	if !collapseVersions {
+		return makeResourceKey(apiVersion, kind)
+	}
+
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = make(map[string]interface{})
+		object["metadata"] = metadata
+	}
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		annotations = make(map[string]interface{})
+		metadata["annotations"] = annotations
+	}
+	annotations[collapsedVersionAnnotation] = apiVersion
+
+	// Keep the group, drop the version: "example.com/v1" -> "example.com-widgets";
+	// core kinds have no group and are keyed by resource alone
+	group, _, found := strings.Cut(apiVersion, "/")
+	if !found {
+		return strings.TrimPrefix(makeResourceKey("", kind), "-")
+	}
+	return makeResourceKey(group, kind)
+}
+
+// dedupeCollapsedVersions drops the extra copies of an object that was found
+// under more than one version of its kind, keeping the first one read
+func dedupeCollapsedVersions(resourceMap map[string][]interface{}) {
+	if !collapseVersions {
+		return
+	}
+
+	for key, items := range resourceMap {
+		seen := make(map[string]bool)
+		kept := items[:0]
+		for _, item := range items {
+			itemMap, _ := item.(map[string]interface{})
+			metadata, _ := itemMap["metadata"].(map[string]interface{})
+			name, _ := metadata["name"].(string)
+			namespace, _ := metadata["namespace"].(string)
+			id := namespace + "/" + name
+
+			if name != "" && seen[id] {
+				if verbose {
+					fmt.Printf("  %s: %s found under several versions, keeping the first\n", key, id)
+				}
+				continue
+			}
+			seen[id] = true
+			kept = append(kept, item)
+		}
+		resourceMap[key] = kept
+	}
+}
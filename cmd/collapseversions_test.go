@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMustGatherResourceKey(t *testing.T) {
+	defer func(saved bool) { collapseVersions = saved }(collapseVersions)
+
+	tests := []struct {
+		name         string
+		collapse     bool
+		apiVersion   string
+		kind         string
+		wantKey      string
+		wantAnnotate bool
+	}{
+		{name: "core kind", apiVersion: "v1", kind: "ConfigMap", wantKey: "v1-configmaps"},
+		{name: "grouped kind", apiVersion: "example.com/v1", kind: "Widget", wantKey: "example.com-v1-widgets"},
+		{name: "collapsed grouped kind", collapse: true, apiVersion: "example.com/v1beta1", kind: "Widget", wantKey: "example.com-widgets", wantAnnotate: true},
+		{name: "collapsed core kind", collapse: true, apiVersion: "v1", kind: "ConfigMap", wantKey: "configmaps", wantAnnotate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collapseVersions = tt.collapse
+			object := map[string]interface{}{"metadata": map[string]interface{}{"name": "a"}}
+			if got := mustGatherResourceKey(tt.apiVersion, tt.kind, object); got != tt.wantKey {
+				t.Errorf("mustGatherResourceKey() = %q, want %q", got, tt.wantKey)
+			}
+			annotations, _ := object["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+			if got := annotations[collapsedVersionAnnotation]; tt.wantAnnotate && got != tt.apiVersion || !tt.wantAnnotate && got != nil {
+				t.Errorf("version annotation = %v, want annotated %v", got, tt.wantAnnotate)
+			}
+		})
+	}
+}
+
+func TestSplitResourceKey(t *testing.T) {
+	tests := []struct {
+		key              string
+		wantGroupVersion string
+		wantResource     string
+	}{
+		{key: "v1-configmaps", wantGroupVersion: "v1", wantResource: "configmaps"},
+		{key: "apps-v1-deployments", wantGroupVersion: "apps-v1", wantResource: "deployments"},
+		{key: "cert-manager.io-v1-certificates", wantGroupVersion: "cert-manager.io-v1", wantResource: "certificates"},
+		{key: "example.com-widgets", wantGroupVersion: "example.com", wantResource: "widgets"},
+		{key: "configmaps", wantGroupVersion: "", wantResource: "configmaps"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			groupVersion, resource := splitResourceKey(tt.key)
+			if groupVersion != tt.wantGroupVersion || resource != tt.wantResource {
+				t.Errorf("splitResourceKey(%q) = %q, %q, want %q, %q", tt.key, groupVersion, resource, tt.wantGroupVersion, tt.wantResource)
+			}
+		})
+	}
+}
+
+func TestDedupeCollapsedVersions(t *testing.T) {
+	defer func(saved bool) { collapseVersions = saved }(collapseVersions)
+	collapseVersions = true
+
+	item := func(name, version string) interface{} {
+		return map[string]interface{}{"apiVersion": version, "metadata": map[string]interface{}{"name": name, "namespace": "shop"}}
+	}
+	resourceMap := map[string][]interface{}{
+		"example.com-widgets": {item("a", "v1"), item("b", "v1"), item("a", "v1beta1")},
+	}
+	dedupeCollapsedVersions(resourceMap)
+
+	want := []interface{}{item("a", "v1"), item("b", "v1")}
+	if got := resourceMap["example.com-widgets"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupeCollapsedVersions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// customColumnsDirName holds one CSV per resource when --custom-columns is set
+const customColumnsDirName = "columns"
+
+// missingColumnValue is written for fields an object does not have, like kubectl
+const missingColumnValue = "<none>"
+
+// customColumn is one HEADER:.json.path entry of --custom-columns
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+var (
+	// customColumnSpecs are the parsed --custom-columns entries
+	customColumnSpecs []customColumn
+	// customColumnsDir is where the CSVs of the current run go
+	customColumnsDir string
+)
+
+// parseCustomColumns parses kubectl-style columns, e.g.
+// "NAME:.metadata.name,REPLICAS:.spec.replicas"
+func parseCustomColumns(value string) ([]customColumn, error) {
+	var columns []customColumn
+	for _, entry := range parseList(value) {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --custom-columns entry %q: expected <HEADER>:<json path>", entry)
+		}
+
+		// Accept both ".spec.replicas" and "{.spec.replicas}"
+		expression := parts[1]
+		if !strings.HasPrefix(expression, "{") {
+			expression = "{" + expression + "}"
+		}
+
+		path := jsonpath.New(parts[0]).AllowMissingKeys(true)
+		if err := path.Parse(expression); err != nil {
+			return nil, fmt.Errorf("invalid --custom-columns path %q: %w", parts[1], err)
+		}
+		columns = append(columns, customColumn{header: parts[0], path: path})
+	}
+	return columns, nil
+}
+
+// writeCustomColumns evaluates --custom-columns against every object of a
+// resource and writes the rows as <group-version>-<resource>.csv
+func writeCustomColumns(resourceName, groupVersion string, list *unstructured.UnstructuredList) error {
+	if len(customColumnSpecs) == 0 {
+		return nil
+	}
+
+	var data bytes.Buffer
+	writer := csv.NewWriter(&data)
+
+	header := make([]string, len(customColumnSpecs))
+	for i, column := range customColumnSpecs {
+		header[i] = column.header
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, item := range list.Items {
+		row := make([]string, len(customColumnSpecs))
+		for i, column := range customColumnSpecs {
+			var value bytes.Buffer
+			if err := column.path.Execute(&value, item.Object); err != nil || value.Len() == 0 {
+				row[i] = missingColumnValue
+				continue
+			}
+			row[i] = value.String()
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(customColumnsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create columns directory: %w", err)
+	}
+
+	filePath := filepath.Join(customColumnsDir, strings.TrimSuffix(formatFilename(resourceName, groupVersion), ".yaml")+".csv")
+	if err := writeOutputFile(filePath, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	return nil
+}
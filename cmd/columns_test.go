@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseCustomColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		wantHeaders []string
+		wantErr     bool
+	}{
+		{name: "bare and braced paths", value: "NAME:.metadata.name,REPLICAS:{.spec.replicas}", wantHeaders: []string{"NAME", "REPLICAS"}},
+		{name: "missing path", value: "NAME:", wantErr: true},
+		{name: "missing header", value: ":.metadata.name", wantErr: true},
+		{name: "invalid path", value: "NAME:.metadata[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := parseCustomColumns(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCustomColumns(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			var headers []string
+			for _, column := range columns {
+				headers = append(headers, column.header)
+			}
+			if strings.Join(headers, ",") != strings.Join(tt.wantHeaders, ",") {
+				t.Errorf("headers = %v, want %v", headers, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestWriteCustomColumns(t *testing.T) {
+	defer func(specs []customColumn, dir string) {
+		customColumnSpecs, customColumnsDir = specs, dir
+	}(customColumnSpecs, customColumnsDir)
+
+	var err error
+	customColumnSpecs, err = parseCustomColumns("NAME:.metadata.name,REPLICAS:.spec.replicas,IMAGE:.spec.template.spec.containers[0].image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	customColumnsDir = filepath.Join(t.TempDir(), customColumnsDirName)
+
+	list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "web"},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"template": map[string]interface{}{"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"image": "nginx:1.25, patched"}},
+				}},
+			},
+		}},
+		{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "paused"}}},
+	}}
+
+	if err := writeCustomColumns("deployments", "apps/v1", list); err != nil {
+		t.Fatalf("writeCustomColumns() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(customColumnsDir, strings.TrimSuffix(formatFilename("deployments", "apps/v1"), ".yaml")+".csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "NAME,REPLICAS,IMAGE\nweb,3,\"nginx:1.25, patched\"\npaused,<none>,<none>\n"
+	if string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+}
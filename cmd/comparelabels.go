@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// noLabelValue groups objects that do not carry the --compare-labels label
+const noLabelValue = "<none>"
+
+// labelDrift counts object presence across two clusters for one label value
+type labelDrift struct {
+	onlyIn1 int
+	onlyIn2 int
+	inBoth  int
+}
+
+// generateLabelGroupedDiff groups object-level differences by the value of a
+// label, e.g. "team-a: 3 only in cluster-a, 1 only in cluster-b, 12 in both"
+func generateLabelGroupedDiff(file1, file2, label, cluster1Name, cluster2Name string) (string, error) {
+	objects1, err := loadCollectionObjects(file1)
+	if err != nil {
+		return "", err
+	}
+	objects2, err := loadCollectionObjects(file2)
+	if err != nil {
+		return "", err
+	}
+
+	drift := make(map[string]*labelDrift)
+	groupFor := func(item map[string]interface{}) *labelDrift {
+		value := objectLabel(item, label)
+		if drift[value] == nil {
+			drift[value] = &labelDrift{}
+		}
+		return drift[value]
+	}
+
+	for key, item := range objects1 {
+		if _, ok := objects2[key]; ok {
+			groupFor(item).inBoth++
+		} else {
+			groupFor(item).onlyIn1++
+		}
+	}
+	for key, item := range objects2 {
+		if _, ok := objects1[key]; !ok {
+			groupFor(item).onlyIn2++
+		}
+	}
+
+	var values []string
+	for value := range drift {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("\n=== Objects by label %s ===\n", label))
+	for _, value := range values {
+		d := drift[value]
+		report.WriteString(fmt.Sprintf("%s: %d only in %s, %d only in %s, %d in both\n",
+			value, d.onlyIn1, cluster1Name, d.onlyIn2, cluster2Name, d.inBoth))
+	}
+
+	return report.String(), nil
+}
+
+// objectLabel returns the value of a label on a decoded object
+func objectLabel(item map[string]interface{}, label string) string {
+	metadata, _ := item["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	if value, ok := labels[label].(string); ok && value != "" {
+		return value
+	}
+	return noLabelValue
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestObjectLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		item map[string]interface{}
+		want string
+	}{
+		{"labelled", map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": "a"}}}, "a"},
+		{"other labels only", map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}}}, noLabelValue},
+		{"empty value", map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": ""}}}, noLabelValue},
+		{"no metadata", map[string]interface{}{}, noLabelValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := objectLabel(tt.item, "team"); got != tt.want {
+				t.Errorf("objectLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateLabelGroupedDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	file1 := write("cluster-a.yaml", `--- # Resource: configmaps
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: shared
+    namespace: shop
+    labels:
+      team: a
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: only-a
+    namespace: shop
+    labels:
+      team: a
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: unlabelled
+    namespace: shop
+`)
+	file2 := write("cluster-b.yaml", `--- # Resource: configmaps
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: shared
+    namespace: shop
+    labels:
+      team: a
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: only-b
+    namespace: shop
+    labels:
+      team: b
+`)
+
+	report, err := generateLabelGroupedDiff(file1, file2, "team", "cluster-a", "cluster-b")
+	if err != nil {
+		t.Fatalf("generateLabelGroupedDiff() error = %v", err)
+	}
+
+	want := []string{
+		"=== Objects by label team ===",
+		"<none>: 1 only in cluster-a, 0 only in cluster-b, 0 in both",
+		"a: 1 only in cluster-a, 0 only in cluster-b, 1 in both",
+		"b: 0 only in cluster-a, 1 only in cluster-b, 0 in both",
+	}
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("report =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
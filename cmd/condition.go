@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// conditionMatch is one --condition entry: a status condition type (or
+// "phase" for .status.phase) and the status it must, or must not, have
+type conditionMatch struct {
+	condition string
+	status    string
+	negate    bool
+}
+
+// conditionMatches are the parsed --condition entries; an object is kept
+// when it matches any of them
+var conditionMatches []conditionMatch
+
+// parseConditions parses "Ready=False,Available!=True,phase=Pending"
+func parseConditions(value string) ([]conditionMatch, error) {
+	var matches []conditionMatch
+	for _, entry := range parseList(value) {
+		match := conditionMatch{}
+		condition, status, found := strings.Cut(entry, "!=")
+		if found {
+			match.negate = true
+		} else {
+			condition, status, found = strings.Cut(entry, "=")
+		}
+		if !found || condition == "" || status == "" {
+			return nil, fmt.Errorf("invalid --condition entry %q: expected <type>=<status> or <type>!=<status>", entry)
+		}
+		match.condition = condition
+		match.status = status
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// hasMatchingCondition reports whether an object matches any --condition
+// entry. Objects without the condition type (or without a phase) never
+// match, also for != entries.
+func hasMatchingCondition(obj *unstructured.Unstructured) bool {
+	for _, match := range conditionMatches {
+		status, found := objectConditionStatus(obj, match.condition)
+		if found && strings.EqualFold(status, match.status) != match.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// objectConditionStatus returns .status.phase for "phase", otherwise the
+// status of the condition with this type in .status.conditions
+func objectConditionStatus(obj *unstructured.Unstructured, condition string) (string, bool) {
+	if strings.EqualFold(condition, "phase") {
+		phase, found, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		return phase, found && phase != ""
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, entry := range conditions {
+		conditionMap, ok := entry.(map[string]interface{})
+		if !ok || conditionMap["type"] != condition {
+			continue
+		}
+		status, ok := conditionMap["status"].(string)
+		return status, ok
+	}
+	return "", false
+}
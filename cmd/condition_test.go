@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseConditions(t *testing.T) {
+	matches, err := parseConditions("Ready=False, Available!=True,phase=Pending")
+	if err != nil {
+		t.Fatalf("parseConditions() error = %v", err)
+	}
+	want := []conditionMatch{
+		{condition: "Ready", status: "False"},
+		{condition: "Available", status: "True", negate: true},
+		{condition: "phase", status: "Pending"},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("parseConditions() = %+v, want %+v", matches, want)
+	}
+
+	for _, value := range []string{"Ready", "=False", "Ready=", "Ready!="} {
+		if _, err := parseConditions(value); err == nil {
+			t.Errorf("parseConditions(%q) should fail", value)
+		}
+	}
+}
+
+func TestHasMatchingCondition(t *testing.T) {
+	defer func(saved []conditionMatch) { conditionMatches = saved }(conditionMatches)
+
+	withStatus := func(status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Pod", "status": status}}
+	}
+	notReady := withStatus(map[string]interface{}{
+		"phase": "Running",
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "PodScheduled", "status": "True"},
+			map[string]interface{}{"type": "Ready", "status": "False"},
+		},
+	})
+	pending := withStatus(map[string]interface{}{"phase": "Pending"})
+
+	tests := []struct {
+		name       string
+		conditions string
+		obj        *unstructured.Unstructured
+		want       bool
+	}{
+		{"condition status", "Ready=False", notReady, true},
+		{"status is case-insensitive", "Ready=false", notReady, true},
+		{"other status", "Ready=True", notReady, false},
+		{"negated", "Ready!=True", notReady, true},
+		{"negated match", "PodScheduled!=True", notReady, false},
+		{"missing condition never matches", "Ready!=True", pending, false},
+		{"phase", "phase=Pending", pending, true},
+		{"any entry may match", "Ready=True,phase=Pending", pending, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := parseConditions(tt.conditions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conditionMatches = matches
+			if got := hasMatchingCondition(tt.obj); got != tt.want {
+				t.Errorf("hasMatchingCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
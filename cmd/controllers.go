@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// controllerInventoryFile is written next to the collection when --group-by-controller is set
+const controllerInventoryFile = "controller-inventory.txt"
+
+// ownedObject is one collected object and the controller owner reference it points at
+type ownedObject struct {
+	kind          string
+	namespace     string
+	name          string
+	controllerUID string
+	// controllerKind and controllerName describe the controller when it was not collected
+	controllerKind string
+	controllerName string
+}
+
+var (
+	// byController is set by --group-by-controller
+	byController bool
+
+	// ownershipNodes are the objects of the current run, keyed by UID
+	ownershipNodes map[string]ownedObject
+)
+
+// recordOwnership adds the collected objects to the ownership graph. It runs
+// before the transforms, since --strip-metadata removes the UIDs it links by.
+func recordOwnership(list *unstructured.UnstructuredList) {
+	if !byController {
+		return
+	}
+
+	if ownershipNodes == nil {
+		ownershipNodes = make(map[string]ownedObject)
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		uid := string(item.GetUID())
+		if uid == "" {
+			continue
+		}
+
+		node := ownedObject{kind: item.GetKind(), namespace: item.GetNamespace(), name: item.GetName()}
+		for _, ref := range item.GetOwnerReferences() {
+			if ref.Controller != nil && *ref.Controller {
+				node.controllerUID = string(ref.UID)
+				node.controllerKind = ref.Kind
+				node.controllerName = ref.Name
+				break
+			}
+		}
+		ownershipNodes[uid] = node
+	}
+}
+
+// writeControllerInventory walks the controller references up to each
+// top-level controller and writes every controller with the objects it
+// manages, so Pods appear under their ReplicaSet under their Deployment
+func writeControllerInventory(path string) error {
+	if !byController {
+		return nil
+	}
+
+	// Objects whose controller was not collected hang off a placeholder root
+	children := make(map[string][]string)
+	missing := make(map[string]ownedObject)
+	var roots []string
+	standalone := 0
+	for uid, node := range ownershipNodes {
+		switch {
+		case node.controllerUID == "":
+			roots = append(roots, uid)
+		case ownershipNodes[node.controllerUID].kind == "":
+			if _, ok := missing[node.controllerUID]; !ok {
+				missing[node.controllerUID] = ownedObject{kind: node.controllerKind, namespace: node.namespace, name: node.controllerName}
+				roots = append(roots, node.controllerUID)
+			}
+			children[node.controllerUID] = append(children[node.controllerUID], uid)
+		default:
+			children[node.controllerUID] = append(children[node.controllerUID], uid)
+		}
+	}
+
+	lookup := func(uid string) ownedObject {
+		if node, ok := ownershipNodes[uid]; ok {
+			return node
+		}
+		return missing[uid]
+	}
+	byName := func(uids []string) {
+		sort.Slice(uids, func(i, j int) bool {
+			a, b := lookup(uids[i]), lookup(uids[j])
+			if a.namespace != b.namespace {
+				return a.namespace < b.namespace
+			}
+			if a.kind != b.kind {
+				return a.kind < b.kind
+			}
+			return a.name < b.name
+		})
+	}
+	byName(roots)
+
+	var report strings.Builder
+	report.WriteString("=== Objects by Controller ===\n\n")
+
+	controllers := 0
+	owned := 0
+	visited := make(map[string]bool)
+	var walk func(uid string, depth int)
+	walk = func(uid string, depth int) {
+		// Guard against ownership cycles in hand-edited objects
+		if visited[uid] {
+			return
+		}
+		visited[uid] = true
+
+		node := lookup(uid)
+		label := node.kind + " " + node.name
+		if node.namespace != "" {
+			label = node.kind + " " + node.namespace + "/" + node.name
+		}
+		if _, ok := missing[uid]; ok {
+			label += " (not collected)"
+		}
+		report.WriteString(strings.Repeat("  ", depth) + label + "\n")
+		if depth > 0 {
+			owned++
+		}
+
+		byName(children[uid])
+		for _, child := range children[uid] {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		if len(children[root]) == 0 {
+			standalone++
+			continue
+		}
+		controllers++
+		walk(root, 0)
+	}
+
+	if controllers == 0 {
+		report.WriteString("No controller-managed objects collected\n")
+	}
+	report.WriteString(fmt.Sprintf("\n%d top-level controllers managing %d objects, %d objects without a controller\n", controllers, owned, standalone))
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write controller inventory %s: %w", path, err)
+	}
+
+	fmt.Printf("Controller inventory: %s (%d top-level controllers)\n", path, controllers)
+	return nil
+}
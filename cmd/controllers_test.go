@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// controlledObject builds an object owned by the controller with ownerUID, if any
+func controlledObject(kind, name, uid, ownerKind, ownerName, ownerUID string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{"kind": kind}}
+	obj.SetNamespace("shop")
+	obj.SetName(name)
+	obj.SetUID(types.UID(uid))
+	if ownerUID != "" {
+		controller := true
+		obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: ownerKind, Name: ownerName, UID: types.UID(ownerUID), Controller: &controller}})
+	}
+	return obj
+}
+
+func TestRecordOwnershipDisabled(t *testing.T) {
+	defer func(enabled bool, nodes map[string]ownedObject) {
+		byController = enabled
+		ownershipNodes = nodes
+	}(byController, ownershipNodes)
+	byController = false
+	ownershipNodes = nil
+
+	recordOwnership(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		controlledObject("ConfigMap", "settings", "cm-1", "", "", ""),
+	}})
+	if ownershipNodes != nil {
+		t.Errorf("ownershipNodes = %v, want nil without --group-by-controller", ownershipNodes)
+	}
+}
+
+func TestWriteControllerInventory(t *testing.T) {
+	defer func(enabled bool, nodes map[string]ownedObject) {
+		byController = enabled
+		ownershipNodes = nodes
+	}(byController, ownershipNodes)
+	byController = true
+	ownershipNodes = nil
+
+	recordOwnership(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		controlledObject("Deployment", "web", "deploy-1", "", "", ""),
+		controlledObject("ReplicaSet", "web-abc", "rs-1", "Deployment", "web", "deploy-1"),
+		controlledObject("Pod", "web-abc-2", "pod-2", "ReplicaSet", "web-abc", "rs-1"),
+		controlledObject("Pod", "web-abc-1", "pod-1", "ReplicaSet", "web-abc", "rs-1"),
+		controlledObject("Pod", "agent-x", "pod-3", "DaemonSet", "agent", "ds-1"),
+		controlledObject("ConfigMap", "settings", "cm-1", "", "", ""),
+	}})
+
+	path := filepath.Join(t.TempDir(), controllerInventoryFile)
+	if err := writeControllerInventory(path); err != nil {
+		t.Fatalf("writeControllerInventory() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `=== Objects by Controller ===
+
+DaemonSet shop/agent (not collected)
+  Pod shop/agent-x
+Deployment shop/web
+  ReplicaSet shop/web-abc
+    Pod shop/web-abc-1
+    Pod shop/web-abc-2
+
+2 top-level controllers managing 4 objects, 1 objects without a controller
+`
+	if string(data) != want {
+		t.Errorf("inventory =\n%s\nwant\n%s", data, want)
+	}
+}
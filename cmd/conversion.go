@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// conversionFailures are the resources whose CRD conversion webhook failed in the current run
+var conversionFailures []string
+
+// isConversionWebhookError reports whether a List failed because the API
+// server could not reach the CRD's conversion webhook. The server answers with
+// an InternalError whose message names the webhook, e.g.
+// "conversion webhook for example.com/v1beta1, Kind=Widget failed: Post ...".
+func isConversionWebhookError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "conversion webhook for")
+}
+
+// listWithConversionFallback lists a resource and tells conversion webhook
+// outages apart from other failures. With --retry-storage-version it lists
+// the CRD's storage version instead, which objects stored in that version can
+// be served in without calling the webhook. It returns the group version the
+// items were read with.
+func listWithConversionFallback(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, namespaced bool) (*unstructured.UnstructuredList, string, error) {
+	list, err := listResourceScoped(dynamicClient, gvr, namespaced)
+	if err == nil || !isConversionWebhookError(err) {
+		return list, gvr.GroupVersion().String(), err
+	}
+
+	conversionFailures = append(conversionFailures, gvr.Resource+"."+gvr.Group)
+	webhookErr := fmt.Errorf("conversion webhook unavailable for %s: %w", gvr.Resource, err)
+	if !storageRetry {
+		return nil, "", webhookErr
+	}
+
+	storageVersion, lookupErr := crdStorageVersion(dynamicClient, gvr)
+	if lookupErr != nil {
+		return nil, "", fmt.Errorf("%w (storage version lookup failed: %v)", webhookErr, lookupErr)
+	}
+	if storageVersion == gvr.Version {
+		return nil, "", fmt.Errorf("%w (already listing the storage version %s)", webhookErr, storageVersion)
+	}
+
+	storageGVR := gvr
+	storageGVR.Version = storageVersion
+	if verbose {
+		fmt.Printf("  %s: conversion webhook unavailable, retrying with storage version %s\n", gvr.Resource, storageGVR.GroupVersion())
+	}
+
+	list, err = listResourceScoped(dynamicClient, storageGVR, namespaced)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w (retry with storage version %s failed: %v)", webhookErr, storageVersion, err)
+	}
+	fmt.Printf("Warning: %s read with storage version %s because its conversion webhook is unavailable\n", gvr.Resource, storageGVR.GroupVersion())
+	return list, storageGVR.GroupVersion().String(), nil
+}
+
+// crdStorageVersion reads the version a CRD persists its objects in
+func crdStorageVersion(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	crd, err := dynamicClient.Resource(crdGVR).Get(ctx, gvr.Resource+"."+gvr.Group, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, entry := range versions {
+		version, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if storage, _ := version["storage"].(bool); storage {
+			name, _ := version["name"].(string)
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no storage version in CRD %s.%s", gvr.Resource, gvr.Group)
+}
+
+// printConversionSummary names the resources whose conversion webhook was
+// unavailable, so a webhook outage is not mistaken for RBAC or empty results
+func printConversionSummary() {
+	if len(conversionFailures) == 0 {
+		return
+	}
+	sort.Strings(conversionFailures)
+	fmt.Printf("Conversion webhook unavailable: %s\n", strings.Join(conversionFailures, ", "))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// conversionDynamic serves widgets only in their storage version, as an API
+// server does when the CRD's conversion webhook is down; with failAll even
+// the storage version cannot be listed
+type conversionDynamic struct {
+	dynamic.Interface
+	storage string
+	failAll bool
+	widgets *unstructured.UnstructuredList
+}
+
+func (d *conversionDynamic) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &conversionResource{dynamic: d, gvr: gvr}
+}
+
+type conversionResource struct {
+	dynamic.NamespaceableResourceInterface
+	dynamic *conversionDynamic
+	gvr     schema.GroupVersionResource
+}
+
+func (r *conversionResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if r.dynamic.failAll || r.gvr.Version != r.dynamic.storage {
+		return nil, apierrors.NewInternalError(errors.New("conversion webhook for example.com/" + r.gvr.Version + ", Kind=Widget failed: Post \"https://widget-webhook.example.svc:443/convert\": connection refused"))
+	}
+	return r.dynamic.widgets, nil
+}
+
+func (r *conversionResource) Get(ctx context.Context, name string, opts metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if r.gvr != crdGVR || name != "widgets.example.com" {
+		return nil, apierrors.NewNotFound(r.gvr.GroupResource(), name)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1beta1", "storage": false},
+				map[string]interface{}{"name": r.dynamic.storage, "storage": true},
+			},
+		},
+	}}, nil
+}
+
+func TestIsConversionWebhookError(t *testing.T) {
+	webhookErr := apierrors.NewInternalError(errors.New("conversion webhook for example.com/v1beta1, Kind=Widget failed: EOF"))
+	if !isConversionWebhookError(webhookErr) {
+		t.Errorf("isConversionWebhookError(%v) = false, want true", webhookErr)
+	}
+	if isConversionWebhookError(apierrors.NewInternalError(errors.New("etcd leader changed"))) {
+		t.Error("isConversionWebhookError() of another internal error = true, want false")
+	}
+	if isConversionWebhookError(nil) {
+		t.Error("isConversionWebhookError(nil) = true, want false")
+	}
+}
+
+func TestListWithConversionFallback(t *testing.T) {
+	defer func(retry bool, failures []string, chunk int64) {
+		storageRetry, conversionFailures, chunkSize = retry, failures, chunk
+	}(storageRetry, conversionFailures, chunkSize)
+	chunkSize = 0
+
+	widgets := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: map[string]interface{}{"kind": "Widget"}}}}
+	client := &conversionDynamic{storage: "v1", widgets: widgets}
+	beta := schema.GroupVersionResource{Group: "example.com", Version: "v1beta1", Resource: "widgets"}
+
+	t.Run("reported without --retry-storage-version", func(t *testing.T) {
+		storageRetry, conversionFailures = false, nil
+		_, _, err := listWithConversionFallback(client, beta, false)
+		if err == nil || !strings.Contains(err.Error(), "conversion webhook unavailable for widgets") {
+			t.Errorf("error = %v, want conversion webhook unavailable", err)
+		}
+		if len(conversionFailures) != 1 || conversionFailures[0] != "widgets.example.com" {
+			t.Errorf("conversionFailures = %v, want [widgets.example.com]", conversionFailures)
+		}
+	})
+
+	t.Run("read with the storage version", func(t *testing.T) {
+		storageRetry, conversionFailures = true, nil
+		list, groupVersion, err := listWithConversionFallback(client, beta, false)
+		if err != nil {
+			t.Fatalf("listWithConversionFallback() error = %v", err)
+		}
+		if groupVersion != "example.com/v1" || len(list.Items) != 1 {
+			t.Errorf("listWithConversionFallback() = %d items with %s, want 1 item with example.com/v1", len(list.Items), groupVersion)
+		}
+	})
+
+	t.Run("storage version already listed", func(t *testing.T) {
+		storageRetry, conversionFailures = true, nil
+		broken := &conversionDynamic{storage: "v1", failAll: true}
+		stored := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+		_, _, err := listWithConversionFallback(broken, stored, false)
+		if err == nil || !strings.Contains(err.Error(), "already listing the storage version v1") {
+			t.Errorf("error = %v, want already listing the storage version v1", err)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// crdsGVR is the CustomResourceDefinition resource
+var crdsGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// crdsInventoryFile is written next to the CRDs in --collect-crds-only mode
+const crdsInventoryFile = "crds-inventory.txt"
+
+// runCRDsOnlyMode collects only the CustomResourceDefinitions and writes an
+// inventory of their group, scope and versions
+func runCRDsOnlyMode(dynamicClient dynamic.Interface) error {
+	startTime := time.Now()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	list, err := collectFocusedList(dynamicClient, crdsGVR)
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(outputDir, formatFilename(crdsGVR.Resource, crdsGVR.GroupVersion().String()))
+
+	inventoryPath := filepath.Join(outputDir, crdsInventoryFile)
+	if err := writeOutputFile(inventoryPath, []byte(formatCRDInventory(list.Items)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", inventoryPath, err)
+	}
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== CRD Inventory Summary ===\n")
+	fmt.Printf("CustomResourceDefinitions: %d\n", len(list.Items))
+	fmt.Printf("Saved to: %s\n", filePath)
+	fmt.Printf("Inventory: %s\n", inventoryPath)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("=============================\n")
+
+	return nil
+}
+
+// formatCRDInventory renders one line per CRD, sorted by group then name, e.g.
+// "certificates.cert-manager.io  group=cert-manager.io  scope=Namespaced  versions=v1 (served, storage)"
+func formatCRDInventory(crds []unstructured.Unstructured) string {
+	sort.Slice(crds, func(i, j int) bool {
+		gi, _, _ := unstructured.NestedString(crds[i].Object, "spec", "group")
+		gj, _, _ := unstructured.NestedString(crds[j].Object, "spec", "group")
+		if gi != gj {
+			return gi < gj
+		}
+		return crds[i].GetName() < crds[j].GetName()
+	})
+
+	var inventory strings.Builder
+	inventory.WriteString(fmt.Sprintf("=== CustomResourceDefinitions (%d) ===\n", len(crds)))
+	for i := range crds {
+		crd := crds[i].Object
+		group, _, _ := unstructured.NestedString(crd, "spec", "group")
+		scope, _, _ := unstructured.NestedString(crd, "spec", "scope")
+		versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+
+		var versionDetails []string
+		for _, version := range versions {
+			v, ok := version.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := v["name"].(string)
+
+			var flags []string
+			if served, _ := v["served"].(bool); served {
+				flags = append(flags, "served")
+			}
+			if storage, _ := v["storage"].(bool); storage {
+				flags = append(flags, "storage")
+			}
+			if deprecated, _ := v["deprecated"].(bool); deprecated {
+				flags = append(flags, "deprecated")
+			}
+			if len(flags) == 0 {
+				flags = append(flags, "not served")
+			}
+			versionDetails = append(versionDetails, fmt.Sprintf("%s (%s)", name, strings.Join(flags, ", ")))
+		}
+
+		inventory.WriteString(fmt.Sprintf("%s  group=%s  scope=%s  versions=%s\n",
+			crds[i].GetName(), group, scope, strings.Join(versionDetails, "; ")))
+	}
+
+	return inventory.String()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func crdObject(name, group, scope string, versions ...interface{}) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       map[string]interface{}{"group": group, "scope": scope, "versions": versions},
+	}}
+}
+
+func TestFormatCRDInventory(t *testing.T) {
+	crds := []unstructured.Unstructured{
+		crdObject("widgets.example.com", "example.com", "Cluster",
+			map[string]interface{}{"name": "v1alpha1"},
+		),
+		crdObject("issuers.cert-manager.io", "cert-manager.io", "Namespaced",
+			map[string]interface{}{"name": "v1", "served": true, "storage": true},
+		),
+		crdObject("certificates.cert-manager.io", "cert-manager.io", "Namespaced",
+			map[string]interface{}{"name": "v1alpha2", "served": true, "deprecated": true},
+			map[string]interface{}{"name": "v1", "served": true, "storage": true},
+		),
+	}
+
+	want := `=== CustomResourceDefinitions (3) ===
+certificates.cert-manager.io  group=cert-manager.io  scope=Namespaced  versions=v1alpha2 (served, deprecated); v1 (served, storage)
+issuers.cert-manager.io  group=cert-manager.io  scope=Namespaced  versions=v1 (served, storage)
+widgets.example.com  group=example.com  scope=Cluster  versions=v1alpha1 (not served)
+`
+	if got := formatCRDInventory(crds); got != want {
+		t.Errorf("formatCRDInventory() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunCRDsOnlyMode(t *testing.T) {
+	defer func(saved string) { outputDir = saved }(outputDir)
+	outputDir = t.TempDir()
+
+	client := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+		"customresourcedefinitions": {
+			Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"},
+			Items: []unstructured.Unstructured{
+				crdObject("widgets.example.com", "example.com", "Namespaced", map[string]interface{}{"name": "v1", "served": true, "storage": true}),
+			},
+		},
+	}}
+
+	if err := runCRDsOnlyMode(client); err != nil {
+		t.Fatalf("runCRDsOnlyMode() error = %v", err)
+	}
+
+	groupVersion := crdsGVR.GroupVersion().String()
+	if _, err := os.Stat(filepath.Join(outputDir, formatFilename(crdsGVR.Resource, groupVersion))); err != nil {
+		t.Errorf("CRD collection not written: %v", err)
+	}
+	inventory, err := os.ReadFile(filepath.Join(outputDir, crdsInventoryFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "=== CustomResourceDefinitions (1) ===\nwidgets.example.com  group=example.com  scope=Namespaced  versions=v1 (served, storage)\n"
+	if string(inventory) != want {
+		t.Errorf("inventory =\n%s\nwant\n%s", inventory, want)
+	}
+}
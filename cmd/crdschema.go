@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// schemaReportFile is written next to the collection when --output-json-schema is set
+const schemaReportFile = "crd-schema-report.txt"
+
+// crdGVR is the CustomResourceDefinition resource the schemas are read from
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+var (
+	// schemaCheck is set by --output-json-schema
+	schemaCheck bool
+	// crdSchemas are the openAPIV3Schema of every served CRD version, keyed by group/version/Kind
+	crdSchemas map[string]map[string]interface{}
+	// schemaViolations are the findings of the current run, one line per violation
+	schemaViolations []string
+	// schemaValidated counts the custom resources checked against a schema
+	schemaValidated int
+)
+
+// prepareCRDSchemas reads the CRD schemas once up front, so custom resources
+// can be validated whatever order their CRDs are collected in
+func prepareCRDSchemas(dynamicClient dynamic.Interface) error {
+	crdSchemas = nil
+	if !schemaCheck {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := dynamicClient.Resource(crdGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list CRDs for --output-json-schema: %w", err)
+	}
+
+	crdSchemas = make(map[string]map[string]interface{})
+	for i := range list.Items {
+		crd := list.Items[i].Object
+		group, _, _ := unstructured.NestedString(crd, "spec", "group")
+		kind, _, _ := unstructured.NestedString(crd, "spec", "names", "kind")
+		versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+		for _, entry := range versions {
+			version, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(version, "name")
+			openAPISchema, found, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+			if found {
+				crdSchemas[group+"/"+name+"/"+kind] = openAPISchema
+			}
+		}
+	}
+
+	if verbose {
+		fmt.Printf("Loaded %d CRD version schemas\n", len(crdSchemas))
+	}
+	return nil
+}
+
+// validateCustomResources checks each custom resource in the list against its
+// CRD version's schema. It runs before the transforms, which would otherwise
+// be reported as violations (e.g. REDACTED values failing a pattern).
+func validateCustomResources(list *unstructured.UnstructuredList) {
+	if len(crdSchemas) == 0 {
+		return
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		gv, err := schema.ParseGroupVersion(item.GetAPIVersion())
+		if err != nil {
+			continue
+		}
+		openAPISchema, ok := crdSchemas[gv.Group+"/"+gv.Version+"/"+item.GetKind()]
+		if !ok {
+			continue
+		}
+
+		schemaValidated++
+		name := item.GetName()
+		if item.GetNamespace() != "" {
+			name = item.GetNamespace() + "/" + name
+		}
+		var violations []string
+		validateSchemaValue(item.Object, openAPISchema, "", &violations)
+		for _, violation := range violations {
+			schemaViolations = append(schemaViolations, fmt.Sprintf("  %s %s (%s): %s", item.GetKind(), name, item.GetAPIVersion(), violation))
+		}
+	}
+}
+
+// validateSchemaValue checks the structural schema constraints the API server
+// enforces on create: type, required, properties, additionalProperties, items,
+// enum, string length and pattern, numeric bounds and list sizes. Unknown
+// fields are reported since a stricter server would prune or reject them.
+// CEL rules (x-kubernetes-validations) and allOf/anyOf/oneOf/not are not evaluated.
+func validateSchemaValue(value interface{}, openAPISchema map[string]interface{}, path string, violations *[]string) {
+	if value == nil {
+		return
+	}
+	field := path
+	if field == "" {
+		field = "<root>"
+	}
+
+	if intOrString, _ := openAPISchema["x-kubernetes-int-or-string"].(bool); intOrString {
+		switch value.(type) {
+		case string, int64, float64:
+		default:
+			*violations = append(*violations, fmt.Sprintf("%s: must be an integer or a string", field))
+		}
+		return
+	}
+
+	schemaType, _ := openAPISchema["type"].(string)
+	if schemaType != "" && !matchesSchemaType(value, schemaType) {
+		*violations = append(*violations, fmt.Sprintf("%s: must be of type %s", field, schemaType))
+		return
+	}
+
+	if enum, ok := openAPISchema["enum"].([]interface{}); ok && len(enum) > 0 {
+		allowed := false
+		for _, candidate := range enum {
+			if fmt.Sprint(candidate) == fmt.Sprint(value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			*violations = append(*violations, fmt.Sprintf("%s: %v is not one of %v", field, value, enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateSchemaObject(v, openAPISchema, path, violations)
+	case []interface{}:
+		if min, ok := schemaNumber(openAPISchema, "minItems"); ok && float64(len(v)) < min {
+			*violations = append(*violations, fmt.Sprintf("%s: must have at least %v items", field, min))
+		}
+		if max, ok := schemaNumber(openAPISchema, "maxItems"); ok && float64(len(v)) > max {
+			*violations = append(*violations, fmt.Sprintf("%s: must have at most %v items", field, max))
+		}
+		if items, ok := openAPISchema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchemaValue(item, items, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case string:
+		if min, ok := schemaNumber(openAPISchema, "minLength"); ok && float64(len([]rune(v))) < min {
+			*violations = append(*violations, fmt.Sprintf("%s: must be at least %v characters", field, min))
+		}
+		if max, ok := schemaNumber(openAPISchema, "maxLength"); ok && float64(len([]rune(v))) > max {
+			*violations = append(*violations, fmt.Sprintf("%s: must be at most %v characters", field, max))
+		}
+		if pattern, ok := openAPISchema["pattern"].(string); ok {
+			// Patterns RE2 cannot compile (e.g. lookaheads) are skipped
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				*violations = append(*violations, fmt.Sprintf("%s: %q does not match %s", field, v, pattern))
+			}
+		}
+	case int64, float64:
+		number := toFloat(v)
+		if min, ok := schemaNumber(openAPISchema, "minimum"); ok {
+			if exclusive, _ := openAPISchema["exclusiveMinimum"].(bool); number < min || (exclusive && number == min) {
+				*violations = append(*violations, fmt.Sprintf("%s: %v is below the minimum %v", field, v, min))
+			}
+		}
+		if max, ok := schemaNumber(openAPISchema, "maximum"); ok {
+			if exclusive, _ := openAPISchema["exclusiveMaximum"].(bool); number > max || (exclusive && number == max) {
+				*violations = append(*violations, fmt.Sprintf("%s: %v is above the maximum %v", field, v, max))
+			}
+		}
+	}
+}
+
+// validateSchemaObject checks required fields and the known and additional
+// properties of an object. apiVersion, kind and metadata of the object itself
+// (and of embedded resources) are validated by the API server, not by the CRD schema.
+func validateSchemaObject(object map[string]interface{}, openAPISchema map[string]interface{}, path string, violations *[]string) {
+	properties, _ := openAPISchema["properties"].(map[string]interface{})
+	additional, _ := openAPISchema["additionalProperties"].(map[string]interface{})
+	preserveUnknown, _ := openAPISchema["x-kubernetes-preserve-unknown-fields"].(bool)
+	embedded, _ := openAPISchema["x-kubernetes-embedded-resource"].(bool)
+
+	if required, ok := openAPISchema["required"].([]interface{}); ok {
+		for _, entry := range required {
+			if name, ok := entry.(string); ok {
+				if _, present := object[name]; !present {
+					*violations = append(*violations, fmt.Sprintf("%s: required field is missing", joinSchemaPath(path, name)))
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if (path == "" || embedded) && (key == "apiVersion" || key == "kind" || key == "metadata") {
+			continue
+		}
+		child := joinSchemaPath(path, key)
+		if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+			validateSchemaValue(object[key], propertySchema, child, violations)
+			continue
+		}
+		if additional != nil {
+			validateSchemaValue(object[key], additional, child, violations)
+			continue
+		}
+		if allowed, _ := openAPISchema["additionalProperties"].(bool); allowed || preserveUnknown {
+			continue
+		}
+		*violations = append(*violations, fmt.Sprintf("%s: unknown field", child))
+	}
+}
+
+func matchesSchemaType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		switch v := value.(type) {
+		case int64:
+			return true
+		case float64:
+			return v == float64(int64(v))
+		}
+		return false
+	case "number":
+		switch value.(type) {
+		case int64, float64:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+func schemaNumber(openAPISchema map[string]interface{}, key string) (float64, bool) {
+	switch v := openAPISchema[key].(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func toFloat(value interface{}) float64 {
+	if v, ok := value.(int64); ok {
+		return float64(v)
+	}
+	v, _ := value.(float64)
+	return v
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// writeSchemaReport lists the custom resources that do not conform to their CRD's schema
+func writeSchemaReport(path string) error {
+	if !schemaCheck {
+		return nil
+	}
+
+	var report strings.Builder
+	report.WriteString("=== Custom Resource Schema Validation ===\n")
+	report.WriteString(fmt.Sprintf("\n%d custom resources validated against %d CRD version schemas\n", schemaValidated, len(crdSchemas)))
+	writeReportSection(&report, "Schema violations", schemaViolations)
+	if len(schemaViolations) == 0 {
+		report.WriteString("\nAll custom resources conform to their CRD schemas\n")
+	}
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write schema report %s: %w", path, err)
+	}
+
+	fmt.Printf("Schema report: %s (%d violations, %d custom resources validated)\n", path, len(schemaViolations), schemaValidated)
+	return nil
+}
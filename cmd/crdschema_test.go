@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// widgetSchema is a CRD openAPIV3Schema exercising each supported constraint
+func widgetSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"replicas"},
+				"properties": map[string]interface{}{
+					"replicas": map[string]interface{}{"type": "integer", "minimum": int64(1), "maximum": int64(5)},
+					"mode":     map[string]interface{}{"type": "string", "enum": []interface{}{"fast", "slow"}},
+					"name":     map[string]interface{}{"type": "string", "pattern": "^[a-z]+$", "maxLength": int64(5)},
+					"tags":     map[string]interface{}{"type": "array", "maxItems": int64(1), "items": map[string]interface{}{"type": "string"}},
+					"port":     map[string]interface{}{"x-kubernetes-int-or-string": true},
+					"labels":   map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+					"extra":    map[string]interface{}{"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateSchemaValue(t *testing.T) {
+	object := map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "w"},
+		"spec": map[string]interface{}{
+			"replicas": int64(7),
+			"mode":     "medium",
+			"name":     "Web-Server",
+			"tags":     []interface{}{"a", int64(2)},
+			"port":     true,
+			"labels":   map[string]interface{}{"app": int64(1)},
+			"extra":    map[string]interface{}{"anything": "goes"},
+			"bogus":    "x",
+		},
+		"status": map[string]interface{}{},
+	}
+
+	var violations []string
+	validateSchemaValue(object, widgetSchema(), "", &violations)
+
+	want := []string{
+		"spec.bogus: unknown field",
+		"spec.labels.app: must be of type string",
+		"spec.mode: medium is not one of [fast slow]",
+		"spec.name: must be at most 5 characters",
+		`spec.name: "Web-Server" does not match ^[a-z]+$`,
+		"spec.port: must be an integer or a string",
+		"spec.replicas: 7 is above the maximum 5",
+		"spec.tags: must have at most 1 items",
+		"spec.tags[1]: must be of type string",
+		"status: unknown field",
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("violations =\n%q\nwant\n%q", violations, want)
+	}
+
+	var valid []string
+	validateSchemaValue(map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": float64(3), "mode": "fast", "port": "http"},
+	}, widgetSchema(), "", &valid)
+	if len(valid) != 0 {
+		t.Errorf("conforming object violations = %q, want none", valid)
+	}
+}
+
+func TestValidateCustomResources(t *testing.T) {
+	defer func(schemas map[string]map[string]interface{}, violations []string, validated int) {
+		crdSchemas = schemas
+		schemaViolations = violations
+		schemaValidated = validated
+	}(crdSchemas, schemaViolations, schemaValidated)
+	crdSchemas = map[string]map[string]interface{}{"example.com/v1/Widget": widgetSchema()}
+	schemaViolations = nil
+	schemaValidated = 0
+
+	widget := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"spec":       map[string]interface{}{},
+	}}
+	widget.SetNamespace("shop")
+	widget.SetName("w")
+	configMap := unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+	configMap.SetName("settings")
+
+	validateCustomResources(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{widget, configMap}})
+
+	if schemaValidated != 1 {
+		t.Errorf("schemaValidated = %d, want 1", schemaValidated)
+	}
+	want := []string{"  Widget shop/w (example.com/v1): spec.replicas: required field is missing"}
+	if !reflect.DeepEqual(schemaViolations, want) {
+		t.Errorf("schemaViolations = %q, want %q", schemaViolations, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// crdTargetName is the CRD (<plural>.<group>) kept from the CRD list for --crd
+var crdTargetName string
+
+// resolveCRDTarget resolves a --crd GROUP/KIND (e.g. cert-manager.io/Certificate)
+// to the GVR of its custom resources at the group's preferred version. The
+// kind is matched case-insensitively and may also be given as the plural or
+// singular resource name.
+func resolveCRDTarget(discoveryClient discovery.DiscoveryInterface, target string) (schema.GroupVersionResource, error) {
+	group, kind, found := strings.Cut(target, "/")
+	if !found || group == "" || kind == "" || strings.Contains(kind, "/") {
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid --crd %q: expected GROUP/KIND (e.g. cert-manager.io/Certificate)", target)
+	}
+
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("failed to discover API groups: %w", err)
+	}
+
+	for _, apiGroup := range groups.Groups {
+		if apiGroup.Name != group {
+			continue
+		}
+
+		// Prefer the preferred version, then any other served version
+		candidates := []string{apiGroup.PreferredVersion.GroupVersion}
+		for _, version := range apiGroup.Versions {
+			if version.GroupVersion != apiGroup.PreferredVersion.GroupVersion {
+				candidates = append(candidates, version.GroupVersion)
+			}
+		}
+
+		for _, candidate := range candidates {
+			resources, err := discoveryClient.ServerResourcesForGroupVersion(candidate)
+			if err != nil {
+				continue
+			}
+			for _, r := range resources.APIResources {
+				if strings.Contains(r.Name, "/") {
+					continue
+				}
+				if strings.EqualFold(r.Kind, kind) || strings.EqualFold(r.Name, kind) || strings.EqualFold(r.SingularName, kind) {
+					gv, err := schema.ParseGroupVersion(candidate)
+					if err != nil {
+						return schema.GroupVersionResource{}, err
+					}
+					return gv.WithResource(r.Name), nil
+				}
+			}
+		}
+		return schema.GroupVersionResource{}, fmt.Errorf("--crd %q: group %s serves no kind %s", target, group, kind)
+	}
+
+	return schema.GroupVersionResource{}, fmt.Errorf("--crd %q: API group %s is not served by this cluster", target, group)
+}
+
+// isCRDTarget keeps only the targeted CustomResourceDefinition out of the CRD list
+func isCRDTarget(obj *unstructured.Unstructured) bool {
+	return obj.GetKind() != "CustomResourceDefinition" || obj.GetName() == crdTargetName
+}
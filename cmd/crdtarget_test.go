@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestResolveCRDTarget(t *testing.T) {
+	// v1 is preferred but only v1alpha1 serves Challenge
+	client := &stubDiscovery{resources: []*metav1.APIResourceList{
+		{GroupVersion: "cert-manager.io/v1", APIResources: []metav1.APIResource{
+			{Name: "certificates", SingularName: "certificate", Kind: "Certificate", Namespaced: true},
+			{Name: "certificates/status", Kind: "Certificate", Namespaced: true},
+		}},
+		{GroupVersion: "cert-manager.io/v1alpha1", APIResources: []metav1.APIResource{
+			{Name: "challenges", SingularName: "challenge", Kind: "Challenge", Namespaced: true},
+		}},
+	}}
+
+	tests := []struct {
+		target  string
+		want    schema.GroupVersionResource
+		wantErr string
+	}{
+		{target: "cert-manager.io/Certificate", want: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}},
+		{target: "cert-manager.io/certificates", want: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}},
+		{target: "cert-manager.io/challenge", want: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1alpha1", Resource: "challenges"}},
+		{target: "cert-manager.io/Issuer", wantErr: "serves no kind Issuer"},
+		{target: "example.com/Widget", wantErr: "is not served by this cluster"},
+		{target: "Certificate", wantErr: "expected GROUP/KIND"},
+		{target: "cert-manager.io/v1/Certificate", wantErr: "expected GROUP/KIND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := resolveCRDTarget(client, tt.target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveCRDTarget(%q) error = %v, want %q", tt.target, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveCRDTarget(%q) error = %v", tt.target, err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveCRDTarget(%q) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCRDTarget(t *testing.T) {
+	defer func(saved string) { crdTargetName = saved }(crdTargetName)
+	crdTargetName = "certificates.cert-manager.io"
+
+	object := func(kind, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": kind}}
+		obj.SetName(name)
+		return obj
+	}
+
+	if !isCRDTarget(object("CustomResourceDefinition", "certificates.cert-manager.io")) {
+		t.Error("isCRDTarget() of the targeted CRD = false, want true")
+	}
+	if isCRDTarget(object("CustomResourceDefinition", "issuers.cert-manager.io")) {
+		t.Error("isCRDTarget() of another CRD = true, want false")
+	}
+	if !isCRDTarget(object("Certificate", "web-tls")) {
+		t.Error("isCRDTarget() of a custom resource = false, want true")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	storageClassGVR     = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}
+	csiDriverGVR        = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csidrivers"}
+	csiNodeGVR          = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csinodes"}
+	volumeAttachmentGVR = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "volumeattachments"}
+)
+
+// defaultClassAnnotations mark the default StorageClass (the beta one is still honoured)
+var defaultClassAnnotations = []string{
+	"storageclass.kubernetes.io/is-default-class",
+	"storageclass.beta.kubernetes.io/is-default-class",
+}
+
+// storageClassInfo is the summary of one StorageClass
+type storageClassInfo struct {
+	Name          string
+	Provisioner   string
+	Default       bool
+	ReclaimPolicy string
+	BindingMode   string
+	Expansion     bool
+}
+
+// volumeAttachmentInfo is the summary of one VolumeAttachment
+type volumeAttachmentInfo struct {
+	Name     string
+	Attacher string
+	Node     string
+	Volume   string
+	Attached bool
+	Error    string
+}
+
+// runStorageCSIMode collects StorageClasses, CSIDrivers, CSINodes and
+// VolumeAttachments and maps each StorageClass to its provisioner, whether
+// that driver is installed and on how many nodes it is registered, so volume
+// provisioning and attachment problems can be traced in one place
+func runStorageCSIMode(dynamicClient dynamic.Interface) error {
+	startTime := time.Now()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	classList, err := collectFocusedList(dynamicClient, storageClassGVR)
+	if err != nil {
+		return err
+	}
+	driverList, err := collectFocusedList(dynamicClient, csiDriverGVR)
+	if err != nil {
+		return err
+	}
+	nodeList, err := collectFocusedList(dynamicClient, csiNodeGVR)
+	if err != nil {
+		return err
+	}
+	attachmentList, err := collectFocusedList(dynamicClient, volumeAttachmentGVR)
+	if err != nil {
+		return err
+	}
+
+	var classes []storageClassInfo
+	defaults := 0
+	for i := range classList.Items {
+		class := storageClassInfoOf(&classList.Items[i])
+		if class.Default {
+			defaults++
+		}
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i].Name < classes[j].Name })
+
+	drivers := make(map[string]bool)
+	for _, driver := range driverList.Items {
+		drivers[driver.GetName()] = true
+	}
+
+	// Nodes each driver is registered on, from .spec.drivers of the CSINodes
+	driverNodes := make(map[string]int)
+	for _, node := range nodeList.Items {
+		registered, _, _ := unstructured.NestedSlice(node.Object, "spec", "drivers")
+		for _, entry := range registered {
+			if driver, ok := entry.(map[string]interface{}); ok {
+				if name, ok := driver["name"].(string); ok {
+					driverNodes[name]++
+				}
+			}
+		}
+	}
+
+	var attachments []volumeAttachmentInfo
+	failing := 0
+	for i := range attachmentList.Items {
+		attachment := volumeAttachmentInfoOf(&attachmentList.Items[i])
+		if !attachment.Attached || attachment.Error != "" {
+			failing++
+		}
+		attachments = append(attachments, attachment)
+	}
+	// Attachments that are not attached, or report an error, first
+	sort.Slice(attachments, func(i, j int) bool {
+		iFailing := !attachments[i].Attached || attachments[i].Error != ""
+		jFailing := !attachments[j].Attached || attachments[j].Error != ""
+		if iFailing != jFailing {
+			return iFailing
+		}
+		return attachments[i].Name < attachments[j].Name
+	})
+
+	report := formatStorageCSIReport(classes, drivers, driverNodes, len(nodeList.Items), attachments, defaults)
+	reportPath := filepath.Join(outputDir, "storage-csi-summary.txt")
+	if err := writeOutputFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reportPath, err)
+	}
+	fmt.Print(report)
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== Storage Layer Summary ===\n")
+	fmt.Printf("StorageClasses: %d (%d default)\n", len(classes), defaults)
+	fmt.Printf("CSIDrivers: %d\n", len(drivers))
+	fmt.Printf("CSINodes: %d\n", len(nodeList.Items))
+	fmt.Printf("VolumeAttachments: %d (%d not attached or failing)\n", len(attachments), failing)
+	fmt.Printf("Output directory: %s\n", outputDir)
+	fmt.Printf("Summary: %s\n", reportPath)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("=============================\n")
+
+	return nil
+}
+
+func storageClassInfoOf(class *unstructured.Unstructured) storageClassInfo {
+	info := storageClassInfo{Name: class.GetName(), ReclaimPolicy: "Delete", BindingMode: "Immediate"}
+	info.Provisioner, _, _ = unstructured.NestedString(class.Object, "provisioner")
+	for _, annotation := range defaultClassAnnotations {
+		if class.GetAnnotations()[annotation] == "true" {
+			info.Default = true
+		}
+	}
+	if policy, ok, _ := unstructured.NestedString(class.Object, "reclaimPolicy"); ok && policy != "" {
+		info.ReclaimPolicy = policy
+	}
+	if mode, ok, _ := unstructured.NestedString(class.Object, "volumeBindingMode"); ok && mode != "" {
+		info.BindingMode = mode
+	}
+	info.Expansion, _, _ = unstructured.NestedBool(class.Object, "allowVolumeExpansion")
+	return info
+}
+
+func volumeAttachmentInfoOf(attachment *unstructured.Unstructured) volumeAttachmentInfo {
+	info := volumeAttachmentInfo{Name: attachment.GetName()}
+	info.Attacher, _, _ = unstructured.NestedString(attachment.Object, "spec", "attacher")
+	info.Node, _, _ = unstructured.NestedString(attachment.Object, "spec", "nodeName")
+	info.Volume, _, _ = unstructured.NestedString(attachment.Object, "spec", "source", "persistentVolumeName")
+	if info.Volume == "" {
+		info.Volume = "<inline>"
+	}
+	info.Attached, _, _ = unstructured.NestedBool(attachment.Object, "status", "attached")
+	for _, field := range []string{"attachError", "detachError"} {
+		if message, ok, _ := unstructured.NestedString(attachment.Object, "status", field, "message"); ok && message != "" {
+			info.Error = strings.TrimSuffix(field, "Error") + ": " + message
+			break
+		}
+	}
+	return info
+}
+
+// storageClassDriverState describes whether a class's provisioner has a
+// CSIDriver and on how many of the CSINodes it is registered
+func storageClassDriverState(provisioner string, drivers map[string]bool, driverNodes map[string]int, nodes int) string {
+	switch {
+	case strings.HasPrefix(provisioner, "kubernetes.io/"):
+		return "in-tree"
+	case drivers[provisioner]:
+		return fmt.Sprintf("CSI, on %d/%d nodes", driverNodes[provisioner], nodes)
+	case driverNodes[provisioner] > 0:
+		return fmt.Sprintf("no CSIDriver object, on %d/%d nodes", driverNodes[provisioner], nodes)
+	}
+	return "DRIVER NOT INSTALLED"
+}
+
+// formatStorageCSIReport renders the StorageClass -> provisioner mapping, the
+// CSI drivers with their node registrations, then the VolumeAttachments
+func formatStorageCSIReport(classes []storageClassInfo, drivers map[string]bool, driverNodes map[string]int, nodes int, attachments []volumeAttachmentInfo, defaults int) string {
+	var report strings.Builder
+	report.WriteString("=== StorageClasses ===\n\n")
+
+	if len(classes) == 0 {
+		report.WriteString("No StorageClasses found\n")
+	} else {
+		table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "STORAGECLASS\tPROVISIONER\tDEFAULT\tRECLAIM\tBINDING\tEXPANSION\tDRIVER")
+		for _, c := range classes {
+			fmt.Fprintf(table, "%s\t%s\t%t\t%s\t%s\t%t\t%s\n", c.Name, c.Provisioner, c.Default, c.ReclaimPolicy, c.BindingMode, c.Expansion,
+				storageClassDriverState(c.Provisioner, drivers, driverNodes, nodes))
+		}
+		table.Flush()
+	}
+	switch {
+	case defaults == 0:
+		report.WriteString("\nWARNING: no default StorageClass; PVCs without storageClassName stay Pending\n")
+	case defaults > 1:
+		report.WriteString(fmt.Sprintf("\nWARNING: %d default StorageClasses; the most recently created one is used\n", defaults))
+	}
+
+	report.WriteString("\n=== CSI drivers ===\n\n")
+	names := make(map[string]bool)
+	for name := range drivers {
+		names[name] = true
+	}
+	for name := range driverNodes {
+		names[name] = true
+	}
+	if len(names) == 0 {
+		report.WriteString("No CSI drivers found\n")
+	} else {
+		var sorted []string
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+		table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "DRIVER\tCSIDRIVER OBJECT\tNODES")
+		for _, name := range sorted {
+			fmt.Fprintf(table, "%s\t%t\t%d/%d\n", name, drivers[name], driverNodes[name], nodes)
+		}
+		table.Flush()
+	}
+
+	report.WriteString("\n=== VolumeAttachments ===\n\n")
+	if len(attachments) == 0 {
+		report.WriteString("No VolumeAttachments found\n")
+	} else {
+		table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "ATTACHMENT\tATTACHER\tNODE\tPERSISTENTVOLUME\tATTACHED\tERROR")
+		for _, a := range attachments {
+			errorMessage := a.Error
+			if errorMessage == "" {
+				errorMessage = "-"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%t\t%s\n", a.Name, a.Attacher, a.Node, a.Volume, a.Attached, errorMessage)
+		}
+		table.Flush()
+	}
+
+	return report.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStorageClassInfoOf(t *testing.T) {
+	class := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":                 "StorageClass",
+		"provisioner":          "ebs.csi.aws.com",
+		"volumeBindingMode":    "WaitForFirstConsumer",
+		"allowVolumeExpansion": true,
+	}}
+	class.SetName("gp3")
+	class.SetAnnotations(map[string]string{"storageclass.beta.kubernetes.io/is-default-class": "true"})
+
+	want := storageClassInfo{Name: "gp3", Provisioner: "ebs.csi.aws.com", Default: true, ReclaimPolicy: "Delete", BindingMode: "WaitForFirstConsumer", Expansion: true}
+	if got := storageClassInfoOf(class); got != want {
+		t.Errorf("storageClassInfoOf() = %+v, want %+v", got, want)
+	}
+}
+
+func TestVolumeAttachmentInfoOf(t *testing.T) {
+	attachment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "VolumeAttachment",
+		"spec": map[string]interface{}{
+			"attacher": "ebs.csi.aws.com",
+			"nodeName": "worker-1",
+			"source":   map[string]interface{}{"inlineVolumeSpec": map[string]interface{}{}},
+		},
+		"status": map[string]interface{}{
+			"attached":    false,
+			"attachError": map[string]interface{}{"message": "volume is in use"},
+		},
+	}}
+	attachment.SetName("csi-1234")
+
+	want := volumeAttachmentInfo{Name: "csi-1234", Attacher: "ebs.csi.aws.com", Node: "worker-1", Volume: "<inline>", Error: "attach: volume is in use"}
+	if got := volumeAttachmentInfoOf(attachment); got != want {
+		t.Errorf("volumeAttachmentInfoOf() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStorageClassDriverState(t *testing.T) {
+	drivers := map[string]bool{"ebs.csi.aws.com": true}
+	driverNodes := map[string]int{"ebs.csi.aws.com": 2, "nfs.csi.k8s.io": 1}
+
+	tests := map[string]string{
+		"kubernetes.io/aws-ebs": "in-tree",
+		"ebs.csi.aws.com":       "CSI, on 2/3 nodes",
+		"nfs.csi.k8s.io":        "no CSIDriver object, on 1/3 nodes",
+		"rbd.csi.ceph.com":      "DRIVER NOT INSTALLED",
+	}
+	for provisioner, want := range tests {
+		if got := storageClassDriverState(provisioner, drivers, driverNodes, 3); got != want {
+			t.Errorf("storageClassDriverState(%q) = %q, want %q", provisioner, got, want)
+		}
+	}
+}
+
+func TestFormatStorageCSIReport(t *testing.T) {
+	classes := []storageClassInfo{
+		{Name: "fast", Provisioner: "ebs.csi.aws.com", Default: true, ReclaimPolicy: "Delete", BindingMode: "Immediate"},
+		{Name: "slow", Provisioner: "ebs.csi.aws.com", Default: true, ReclaimPolicy: "Retain", BindingMode: "Immediate"},
+	}
+	report := formatStorageCSIReport(classes, map[string]bool{"ebs.csi.aws.com": true}, map[string]int{"ebs.csi.aws.com": 3}, 3, nil, 2)
+
+	for _, want := range []string{
+		"fast          ebs.csi.aws.com  true     Delete   Immediate  false      CSI, on 3/3 nodes\n",
+		"WARNING: 2 default StorageClasses",
+		"ebs.csi.aws.com  true              3/3\n",
+		"No VolumeAttachments found\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+
+	if report := formatStorageCSIReport(nil, nil, nil, 0, nil, 0); !strings.Contains(report, "WARNING: no default StorageClass") {
+		t.Errorf("report without StorageClasses is missing the no-default warning:\n%s", report)
+	}
+}
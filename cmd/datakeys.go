@@ -0,0 +1,46 @@
+package main
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	// keptConfigMapKeys are the parsed --configmap-keys; empty keeps every key
+	keptConfigMapKeys []string
+	// keptSecretKeys are the parsed --secret-keys; empty keeps every key
+	keptSecretKeys []string
+)
+
+// filterDataKeys keeps only the --configmap-keys entries of ConfigMap data and
+// binaryData, and the --secret-keys entries of Secret data and stringData
+func filterDataKeys(list *unstructured.UnstructuredList) {
+	if len(keptConfigMapKeys) == 0 && len(keptSecretKeys) == 0 {
+		return
+	}
+
+	for i := range list.Items {
+		var kept []string
+		var fields []string
+		switch list.Items[i].GetKind() {
+		case "ConfigMap":
+			kept, fields = keptConfigMapKeys, []string{"data", "binaryData"}
+		case "Secret":
+			kept, fields = keptSecretKeys, []string{"data", "stringData"}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+
+		for _, field := range fields {
+			values, ok := list.Items[i].Object[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key := range values {
+				if !contains(kept, key) {
+					delete(values, key)
+				}
+			}
+		}
+	}
+}
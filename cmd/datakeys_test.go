@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFilterDataKeys(t *testing.T) {
+	defer func(configMapKeys, secretKeys []string) {
+		keptConfigMapKeys, keptSecretKeys = configMapKeys, secretKeys
+	}(keptConfigMapKeys, keptSecretKeys)
+
+	newList := func() *unstructured.UnstructuredList {
+		return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+			{Object: map[string]interface{}{
+				"kind":       "ConfigMap",
+				"data":       map[string]interface{}{"app.yaml": "a", "debug": "b"},
+				"binaryData": map[string]interface{}{"app.yaml": "YQ==", "cert.der": "Yg=="},
+			}},
+			{Object: map[string]interface{}{
+				"kind":       "Secret",
+				"data":       map[string]interface{}{"ca.crt": "Yw==", "token": "dA=="},
+				"stringData": map[string]interface{}{"password": "p"},
+			}},
+			{Object: map[string]interface{}{
+				"kind": "Pod",
+				"data": map[string]interface{}{"debug": "kept"},
+			}},
+		}}
+	}
+
+	tests := []struct {
+		name          string
+		configMapKeys []string
+		secretKeys    []string
+		want          []map[string]interface{}
+	}{
+		{
+			name: "no filters keep everything",
+			want: []map[string]interface{}{
+				{"data": map[string]interface{}{"app.yaml": "a", "debug": "b"}, "binaryData": map[string]interface{}{"app.yaml": "YQ==", "cert.der": "Yg=="}},
+				{"data": map[string]interface{}{"ca.crt": "Yw==", "token": "dA=="}, "stringData": map[string]interface{}{"password": "p"}},
+				{"data": map[string]interface{}{"debug": "kept"}},
+			},
+		},
+		{
+			name:          "configmap keys only",
+			configMapKeys: []string{"app.yaml"},
+			want: []map[string]interface{}{
+				{"data": map[string]interface{}{"app.yaml": "a"}, "binaryData": map[string]interface{}{"app.yaml": "YQ=="}},
+				{"data": map[string]interface{}{"ca.crt": "Yw==", "token": "dA=="}, "stringData": map[string]interface{}{"password": "p"}},
+				{"data": map[string]interface{}{"debug": "kept"}},
+			},
+		},
+		{
+			name:       "secret keys only",
+			secretKeys: []string{"ca.crt"},
+			want: []map[string]interface{}{
+				{"data": map[string]interface{}{"app.yaml": "a", "debug": "b"}, "binaryData": map[string]interface{}{"app.yaml": "YQ==", "cert.der": "Yg=="}},
+				{"data": map[string]interface{}{"ca.crt": "Yw=="}, "stringData": map[string]interface{}{}},
+				{"data": map[string]interface{}{"debug": "kept"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keptConfigMapKeys, keptSecretKeys = tt.configMapKeys, tt.secretKeys
+			list := newList()
+			filterDataKeys(list)
+			for i, want := range tt.want {
+				for field, values := range want {
+					if got := list.Items[i].Object[field]; !reflect.DeepEqual(got, values) {
+						t.Errorf("%s %s = %v, want %v", list.Items[i].GetKind(), field, got, values)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// noisyFields are paths ignored by the deep diff because they always differ
+// between clusters (or between two collections of the same cluster)
+var noisyFields = []string{
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.creationTimestamp",
+	"metadata.generation",
+	"metadata.managedFields",
+	"metadata.selfLink",
+	"metadata.annotations.kubectl.kubernetes.io/last-applied-configuration",
+	"status",
+}
+
+// fieldChange is a single differing field between two versions of an object
+type fieldChange struct {
+	Path   string
+	Before interface{}
+	After  interface{}
+	// Present flags whether the field exists on each side
+	InBefore bool
+	InAfter  bool
+}
+
+// loadCollectionObjects reads a single-file collection and indexes its objects
+// by kind/namespace/name (see collectionObjectKey)
+func loadCollectionObjects(file string) (map[string]map[string]interface{}, error) {
+	blocks, err := parseAllResourcesFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]map[string]interface{})
+	for _, block := range blocks {
+		var list map[string]interface{}
+		if err := yaml.Unmarshal([]byte(block.Content), &list); err != nil {
+			continue
+		}
+
+		items, _ := list["items"].([]interface{})
+		for _, item := range items {
+			key := collectionObjectKey(item)
+			if key == "" {
+				continue
+			}
+
+			// Collapsed generated names share a key; the first name stands in for the group
+			if existing, ok := objects[key]; ok && objectKey(existing) < objectKey(item) {
+				continue
+			}
+			objects[key] = item.(map[string]interface{})
+		}
+	}
+
+	return objects, nil
+}
+
+// generateDeepDiff renders the field-level differences of objects present in
+// both collections, e.g. "spec.replicas: 3 -> 5"
+func generateDeepDiff(file1, file2 string) (string, error) {
+	objects1, err := loadCollectionObjects(file1)
+	if err != nil {
+		return "", err
+	}
+	objects2, err := loadCollectionObjects(file2)
+	if err != nil {
+		return "", err
+	}
+
+	var keys []string
+	for key := range objects1 {
+		if _, ok := objects2[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var report strings.Builder
+	changedObjects := 0
+	report.WriteString("\n=== Field-level differences ===\n")
+	for _, key := range keys {
+		changes := diffObjects(objects1[key], objects2[key])
+		if len(changes) == 0 {
+			continue
+		}
+
+		changedObjects++
+		report.WriteString(fmt.Sprintf("\n%s\n", key))
+		for _, change := range changes {
+			report.WriteString(fmt.Sprintf("  %s: %s -> %s\n",
+				change.Path, formatFieldValue(change.Before, change.InBefore), formatFieldValue(change.After, change.InAfter)))
+		}
+
+		// The changed YAML lines with their surroundings
+		if contextLines > 0 {
+			hunks, err := formatObjectDiff(objects1[key], objects2[key], contextLines)
+			if err != nil {
+				return "", fmt.Errorf("failed to diff %s: %w", key, err)
+			}
+			report.WriteString(hunks)
+		}
+	}
+	report.WriteString(fmt.Sprintf("\nObjects in both: %d, with differences: %d\n", len(keys), changedObjects))
+
+	return report.String(), nil
+}
+
+// diffObjects compares two objects field by field, skipping noisyFields
+func diffObjects(before, after map[string]interface{}) []fieldChange {
+	var changes []fieldChange
+	diffValues("", before, after, true, true, &changes)
+	return changes
+}
+
+func diffValues(path string, before, after interface{}, inBefore, inAfter bool, changes *[]fieldChange) {
+	if isNoisyField(path) {
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		keys := make(map[string]bool)
+		for key := range beforeMap {
+			keys[key] = true
+		}
+		for key := range afterMap {
+			keys[key] = true
+		}
+
+		var sorted []string
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		for _, key := range sorted {
+			b, inB := beforeMap[key]
+			a, inA := afterMap[key]
+			diffValues(joinFieldPath(path, key), b, a, inB, inA, changes)
+		}
+		return
+	}
+
+	beforeSlice, beforeIsSlice := before.([]interface{})
+	afterSlice, afterIsSlice := after.([]interface{})
+	if beforeIsSlice && afterIsSlice {
+		length := len(beforeSlice)
+		if len(afterSlice) > length {
+			length = len(afterSlice)
+		}
+		for i := 0; i < length; i++ {
+			var b, a interface{}
+			inB, inA := i < len(beforeSlice), i < len(afterSlice)
+			if inB {
+				b = beforeSlice[i]
+			}
+			if inA {
+				a = afterSlice[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), b, a, inB, inA, changes)
+		}
+		return
+	}
+
+	if inBefore == inAfter && reflect.DeepEqual(before, after) {
+		return
+	}
+
+	*changes = append(*changes, fieldChange{
+		Path:     path,
+		Before:   before,
+		After:    after,
+		InBefore: inBefore,
+		InAfter:  inAfter,
+	})
+}
+
+// joinFieldPath appends a map key to a dotted field path
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isNoisyField reports whether a path is (or is inside) an ignored field
+func isNoisyField(path string) bool {
+	for _, noisy := range noisyFields {
+		if path == noisy || strings.HasPrefix(path, noisy+".") || strings.HasPrefix(path, noisy+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// formatFieldValue renders a field value compactly for the diff report
+func formatFieldValue(value interface{}, present bool) string {
+	if !present {
+		return "<absent>"
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
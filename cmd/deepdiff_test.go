@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffObjects(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   []fieldChange
+	}{
+		{
+			name:   "identical",
+			before: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1}},
+			after:  map[string]interface{}{"spec": map[string]interface{}{"replicas": 1}},
+		},
+		{
+			name:   "changed, added and removed fields",
+			before: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1, "paused": true}},
+			after:  map[string]interface{}{"spec": map[string]interface{}{"replicas": 3, "minReadySeconds": 5}},
+			want: []fieldChange{
+				{Path: "spec.minReadySeconds", After: 5, InAfter: true},
+				{Path: "spec.paused", Before: true, InBefore: true},
+				{Path: "spec.replicas", Before: 1, After: 3, InBefore: true, InAfter: true},
+			},
+		},
+		{
+			name:   "list items by index",
+			before: map[string]interface{}{"args": []interface{}{"a", "b"}},
+			after:  map[string]interface{}{"args": []interface{}{"a", "c", "d"}},
+			want: []fieldChange{
+				{Path: "args[1]", Before: "b", After: "c", InBefore: true, InAfter: true},
+				{Path: "args[2]", After: "d", InAfter: true},
+			},
+		},
+		{
+			name: "noisy fields are ignored",
+			before: map[string]interface{}{
+				"metadata": map[string]interface{}{"uid": "1", "resourceVersion": "10", "annotations": map[string]interface{}{
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				}},
+				"status": map[string]interface{}{"phase": "Running"},
+			},
+			after: map[string]interface{}{
+				"metadata": map[string]interface{}{"uid": "2", "resourceVersion": "20", "annotations": map[string]interface{}{}},
+				"status":   map[string]interface{}{"phase": "Pending"},
+			},
+		},
+		{
+			name:   "a field that changes type",
+			before: map[string]interface{}{"data": map[string]interface{}{"key": "value"}},
+			after:  map[string]interface{}{"data": "value"},
+			want:   []fieldChange{{Path: "data", Before: map[string]interface{}{"key": "value"}, After: "value", InBefore: true, InAfter: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffObjects(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffObjects() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFieldValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		present bool
+		want    string
+	}{
+		{name: "absent", present: false, want: "<absent>"},
+		{name: "string", value: "nginx:1.25", present: true, want: "nginx:1.25"},
+		{name: "number", value: 3, present: true, want: "3"},
+		{name: "map", value: map[string]interface{}{"a": 1}, present: true, want: `{"a":1}`},
+		{name: "list", value: []interface{}{"a", "b"}, present: true, want: `["a","b"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatFieldValue(tt.value, tt.present); got != tt.want {
+				t.Errorf("formatFieldValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
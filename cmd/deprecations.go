@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// deprecatedUsage records a deprecated resource type that still has instances
+type deprecatedUsage struct {
+	GroupVersion string
+	Resource     string
+	Instances    int64
+	RemovedIn    string
+}
+
+// deprecatedInUse collects deprecated resources found in use during --fail-on-deprecated
+var deprecatedInUse []deprecatedUsage
+
+// deprecationRuleFor returns the rule for a resource regardless of cluster version
+func deprecationRuleFor(resource metav1.APIResource, groupVersion string) *DeprecationRule {
+	for _, rule := range getDeprecationRules() {
+		if rule.GroupVersion == groupVersion && rule.Resource == resource.Name {
+			return &rule
+		}
+	}
+	return nil
+}
+
+// recordDeprecatedServed checks the deprecation rules against every version
+// the server serves, not only the preferred versions collection discovers: an
+// API scheduled for removal is rarely its group's preferred version, so it
+// would otherwise never be seen by --fail-on-deprecated
+func recordDeprecatedServed(discoveryClient discovery.DiscoveryInterface, dynamic dynamic.Interface, clusterVersion *ClusterVersion) {
+	if !failOnDeprecated || clusterVersion == nil {
+		return
+	}
+
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		fmt.Printf("Warning: failed to list API groups for --fail-on-deprecated: %v\n", err)
+		return
+	}
+	served := make(map[string]bool)
+	for _, group := range groups.Groups {
+		for _, version := range group.Versions {
+			served[version.GroupVersion] = true
+		}
+	}
+
+	for _, rule := range getDeprecationRules() {
+		if !served[rule.GroupVersion] {
+			continue
+		}
+		resource := metav1.APIResource{Name: rule.Resource}
+		if deprecated, _, _, _ := isDeprecated(resource, rule.GroupVersion, clusterVersion); deprecated {
+			recordDeprecatedInUse(dynamic, resource, rule.GroupVersion)
+		}
+	}
+}
+
+// recordDeprecatedInUse counts the instances of a deprecated resource, so the
+// --fail-on-deprecated gate can report them. Only resources at or above
+// --deprecated-threshold are counted.
+func recordDeprecatedInUse(dynamic dynamic.Interface, resource metav1.APIResource, groupVersion string) {
+	if !failOnDeprecated {
+		return
+	}
+
+	rule := deprecationRuleFor(resource, groupVersion)
+	if rule == nil {
+		return
+	}
+	if deprecatedThreshold == "removed" && rule.RemovedIn == "" {
+		return
+	}
+
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return
+	}
+
+	count, err := countInstances(dynamic, gv.WithResource(resource.Name))
+	if err != nil {
+		if verbose {
+			fmt.Printf("  %s: could not check for deprecated instances: %v\n", resource.Name, err)
+		}
+		return
+	}
+	if count == 0 {
+		return
+	}
+
+	deprecatedInUse = append(deprecatedInUse, deprecatedUsage{
+		GroupVersion: groupVersion,
+		Resource:     resource.Name,
+		Instances:    count,
+		RemovedIn:    rule.RemovedIn,
+	})
+}
+
+// countInstances returns the number of instances of a resource using a single-item
+// List; the server reports the rest through remainingItemCount
+func countInstances(dynamic dynamic.Interface, gvr schema.GroupVersionResource) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := dynamic.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(len(list.Items))
+	if remaining := list.GetRemainingItemCount(); remaining != nil {
+		count += *remaining
+	}
+	return count, nil
+}
+
+// checkDeprecatedInUse prints the deprecated resources found in use and fails the
+// run when --fail-on-deprecated is set and any were found
+func checkDeprecatedInUse() error {
+	if !failOnDeprecated || len(deprecatedInUse) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n=== Deprecated Resources In Use ===\n")
+	for _, usage := range deprecatedInUse {
+		removal := ""
+		if usage.RemovedIn != "" {
+			removal = fmt.Sprintf(" (removed in %s)", usage.RemovedIn)
+		}
+		fmt.Printf("- %s/%s: %d instances%s\n", usage.GroupVersion, usage.Resource, usage.Instances, removal)
+	}
+	fmt.Printf("===================================\n")
+
+	return fmt.Errorf("found %d deprecated resource types in use (--fail-on-deprecated, threshold %q)",
+		len(deprecatedInUse), deprecatedThreshold)
+}
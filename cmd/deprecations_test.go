@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// stubDynamic serves fixed lists by resource; resources without a list are empty
+type stubDynamic struct {
+	dynamic.Interface
+	lists map[string]*unstructured.UnstructuredList
+	errs  map[string]error
+}
+
+func (d *stubDynamic) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &stubNamespaceableResource{dynamic: d, gvr: gvr}
+}
+
+type stubNamespaceableResource struct {
+	dynamic.NamespaceableResourceInterface
+	dynamic   *stubDynamic
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+func (r *stubNamespaceableResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &stubNamespaceableResource{dynamic: r.dynamic, gvr: r.gvr, namespace: namespace}
+}
+
+func (r *stubNamespaceableResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if err := r.dynamic.errs[r.gvr.Resource]; err != nil {
+		return nil, err
+	}
+	list, ok := r.dynamic.lists[r.gvr.Resource]
+	if !ok {
+		return &unstructured.UnstructuredList{}, nil
+	}
+	if r.namespace == "" {
+		return list, nil
+	}
+	filtered := &unstructured.UnstructuredList{Object: list.Object}
+	for _, item := range list.Items {
+		if item.GetNamespace() == r.namespace {
+			filtered.Items = append(filtered.Items, item)
+		}
+	}
+	return filtered, nil
+}
+
+// stubDiscovery serves fixed resource lists by group version
+type stubDiscovery struct {
+	discovery.DiscoveryInterface
+	resources []*metav1.APIResourceList
+	// failed group versions are served without their resources
+	failed map[schema.GroupVersion]error
+}
+
+func (d *stubDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for _, list := range d.resources {
+		if list.GroupVersion == groupVersion {
+			return list, nil
+		}
+	}
+	return nil, fmt.Errorf("group version %s not found", groupVersion)
+}
+
+// ServerGroups lists the groups of the served resource lists, each group's
+// first list being its preferred version
+func (d *stubDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	groups := &metav1.APIGroupList{}
+	index := make(map[string]int)
+	for _, list := range d.resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		version := metav1.GroupVersionForDiscovery{GroupVersion: list.GroupVersion, Version: gv.Version}
+		i, ok := index[gv.Group]
+		if !ok {
+			i = len(groups.Groups)
+			index[gv.Group] = i
+			groups.Groups = append(groups.Groups, metav1.APIGroup{Name: gv.Group, PreferredVersion: version})
+		}
+		groups.Groups[i].Versions = append(groups.Groups[i].Versions, version)
+	}
+	return groups, nil
+}
+
+// ServerGroupsAndResources returns every group with the resources of the
+// group versions that did not fail
+func (d *stubDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	groupList, err := d.ServerGroups()
+	if err != nil {
+		return nil, nil, err
+	}
+	var groups []*metav1.APIGroup
+	for i := range groupList.Groups {
+		groups = append(groups, &groupList.Groups[i])
+	}
+	var resources []*metav1.APIResourceList
+	for _, list := range d.resources {
+		gv, _ := schema.ParseGroupVersion(list.GroupVersion)
+		if _, failed := d.failed[gv]; !failed {
+			resources = append(resources, list)
+		}
+	}
+	if len(d.failed) > 0 {
+		return groups, resources, &discovery.ErrGroupDiscoveryFailed{Groups: d.failed}
+	}
+	return groups, resources, nil
+}
+
+func TestRecordDeprecatedInUse(t *testing.T) {
+	defer func(fail bool, threshold string, inUse []deprecatedUsage) {
+		failOnDeprecated, deprecatedThreshold, deprecatedInUse = fail, threshold, inUse
+	}(failOnDeprecated, deprecatedThreshold, deprecatedInUse)
+
+	// One item returned, the rest reported through remainingItemCount
+	remaining := int64(4)
+	cronJobs := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: map[string]interface{}{}}}}
+	cronJobs.SetRemainingItemCount(&remaining)
+	client := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{"cronjobs": cronJobs}}
+
+	tests := []struct {
+		name         string
+		fail         bool
+		threshold    string
+		groupVersion string
+		resource     string
+		want         []deprecatedUsage
+	}{
+		{name: "gate off", threshold: "deprecated", groupVersion: "batch/v1beta1", resource: "cronjobs"},
+		{
+			name: "removed API in use", fail: true, threshold: "removed", groupVersion: "batch/v1beta1", resource: "cronjobs",
+			want: []deprecatedUsage{{GroupVersion: "batch/v1beta1", Resource: "cronjobs", Instances: 5, RemovedIn: "1.25"}},
+		},
+		{name: "deprecated but not removed below the threshold", fail: true, threshold: "removed", groupVersion: "v1", resource: "componentstatuses"},
+		{name: "deprecated API without instances", fail: true, threshold: "deprecated", groupVersion: "v1", resource: "componentstatuses"},
+		{name: "no rule", fail: true, threshold: "deprecated", groupVersion: "batch/v1", resource: "cronjobs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failOnDeprecated, deprecatedThreshold, deprecatedInUse = tt.fail, tt.threshold, nil
+			recordDeprecatedInUse(client, metav1.APIResource{Name: tt.resource}, tt.groupVersion)
+
+			if len(deprecatedInUse) != len(tt.want) {
+				t.Fatalf("deprecatedInUse = %+v, want %+v", deprecatedInUse, tt.want)
+			}
+			for i := range tt.want {
+				if deprecatedInUse[i] != tt.want[i] {
+					t.Errorf("deprecatedInUse[%d] = %+v, want %+v", i, deprecatedInUse[i], tt.want[i])
+				}
+			}
+
+			err := checkDeprecatedInUse()
+			if (err != nil) != (len(tt.want) > 0) {
+				t.Errorf("checkDeprecatedInUse() error = %v, want one for %d resources", err, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRecordDeprecatedServed(t *testing.T) {
+	defer func(fail bool, threshold string, inUse []deprecatedUsage) {
+		failOnDeprecated, deprecatedThreshold, deprecatedInUse = fail, threshold, inUse
+	}(failOnDeprecated, deprecatedThreshold, deprecatedInUse)
+	failOnDeprecated, deprecatedThreshold, deprecatedInUse = true, "deprecated", nil
+
+	// batch/v1beta1 is served but not preferred, so discovery alone would miss it
+	discoveryClient := &stubDiscovery{resources: []*metav1.APIResourceList{
+		{GroupVersion: "batch/v1", APIResources: []metav1.APIResource{{Name: "cronjobs"}}},
+		{GroupVersion: "batch/v1beta1", APIResources: []metav1.APIResource{{Name: "cronjobs"}}},
+	}}
+	cronJobs := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: map[string]interface{}{}}}}
+	client := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{"cronjobs": cronJobs}}
+
+	recordDeprecatedServed(discoveryClient, client, &ClusterVersion{Major: 1, Minor: 24})
+
+	var found []string
+	for _, usage := range deprecatedInUse {
+		found = append(found, usage.GroupVersion+"/"+usage.Resource)
+	}
+	if got := strings.Join(found, ","); got != "batch/v1beta1/cronjobs" {
+		t.Errorf("deprecated resources in use = %s, want batch/v1beta1/cronjobs", got)
+	}
+}
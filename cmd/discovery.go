@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/yaml"
+)
+
+// discoveryManifestFile is written next to the collection when --emit-discovery is set
+const discoveryManifestFile = "discovery.yaml"
+
+// discoveryManifest records what the API server exposed at collection time
+type discoveryManifest struct {
+	CollectedAt         string                   `json:"collectedAt"`
+	Groups              []discoveryManifestGroup `json:"groups"`
+	FailedGroupVersions map[string]string        `json:"failedGroupVersions,omitempty"`
+}
+
+type discoveryManifestGroup struct {
+	Name             string                     `json:"name"`
+	PreferredVersion string                     `json:"preferredVersion"`
+	Versions         []discoveryManifestVersion `json:"versions"`
+}
+
+type discoveryManifestVersion struct {
+	GroupVersion string                      `json:"groupVersion"`
+	Resources    []discoveryManifestResource `json:"resources"`
+}
+
+type discoveryManifestResource struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`
+	Namespaced bool     `json:"namespaced"`
+	Verbs      []string `json:"verbs"`
+}
+
+// writeDiscoveryManifest writes every group, version and resource (including
+// subresources) the cluster exposes to path. Group versions that failed
+// discovery are listed with their error instead of failing the collection.
+func writeDiscoveryManifest(discoveryClient discovery.DiscoveryInterface, path string) error {
+	groups, resourceLists, err := discoveryClient.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return fmt.Errorf("failed to discover API groups: %w", err)
+	}
+
+	manifest := discoveryManifest{CollectedAt: time.Now().UTC().Format(time.RFC3339)}
+
+	if failed, ok := err.(*discovery.ErrGroupDiscoveryFailed); ok {
+		manifest.FailedGroupVersions = make(map[string]string)
+		for gv, gvErr := range failed.Groups {
+			manifest.FailedGroupVersions[gv.String()] = gvErr.Error()
+		}
+	}
+
+	resourcesByGV := make(map[string][]discoveryManifestResource)
+	for _, list := range resourceLists {
+		var resources []discoveryManifestResource
+		for _, resource := range list.APIResources {
+			resources = append(resources, discoveryManifestResource{
+				Name:       resource.Name,
+				Kind:       resource.Kind,
+				Namespaced: resource.Namespaced,
+				Verbs:      resource.Verbs,
+			})
+		}
+		sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+		resourcesByGV[list.GroupVersion] = resources
+	}
+
+	for _, group := range groups {
+		manifestGroup := discoveryManifestGroup{
+			Name:             group.Name,
+			PreferredVersion: group.PreferredVersion.Version,
+		}
+		for _, version := range group.Versions {
+			gv := schema.GroupVersion{Group: group.Name, Version: version.Version}.String()
+			manifestGroup.Versions = append(manifestGroup.Versions, discoveryManifestVersion{
+				GroupVersion: gv,
+				Resources:    resourcesByGV[gv],
+			})
+		}
+		manifest.Groups = append(manifest.Groups, manifestGroup)
+	}
+
+	yamlData, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discovery manifest: %w", err)
+	}
+
+	if err := writeOutputFile(path, yamlData, 0644); err != nil {
+		return fmt.Errorf("failed to write discovery manifest %s: %w", path, err)
+	}
+
+	if verbose {
+		fmt.Printf("Discovery manifest saved to %s\n", path)
+	}
+
+	return nil
+}
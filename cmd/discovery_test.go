@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWriteDiscoveryManifest(t *testing.T) {
+	discoveryClient := &stubDiscovery{
+		resources: []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{
+				{Name: "pods/status", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get"}},
+				{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+				{Name: "nodes", Kind: "Node", Verbs: metav1.Verbs{"list"}},
+			}},
+			{GroupVersion: "metrics.k8s.io/v1beta1", APIResources: []metav1.APIResource{{Name: "pods", Kind: "PodMetrics"}}},
+		},
+		failed: map[schema.GroupVersion]error{{Group: "metrics.k8s.io", Version: "v1beta1"}: errors.New("service unavailable")},
+	}
+
+	path := filepath.Join(t.TempDir(), discoveryManifestFile)
+	if err := writeDiscoveryManifest(discoveryClient, path); err != nil {
+		t.Fatalf("writeDiscoveryManifest() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest discoveryManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest is not YAML: %v", err)
+	}
+
+	if manifest.CollectedAt == "" {
+		t.Error("manifest has no collectedAt")
+	}
+	if want := map[string]string{"metrics.k8s.io/v1beta1": "service unavailable"}; !reflect.DeepEqual(manifest.FailedGroupVersions, want) {
+		t.Errorf("failedGroupVersions = %v, want %v", manifest.FailedGroupVersions, want)
+	}
+
+	wantGroups := []discoveryManifestGroup{
+		{Name: "", PreferredVersion: "v1", Versions: []discoveryManifestVersion{{GroupVersion: "v1", Resources: []discoveryManifestResource{
+			{Name: "nodes", Kind: "Node", Verbs: []string{"list"}},
+			{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "pods/status", Kind: "Pod", Namespaced: true, Verbs: []string{"get"}},
+		}}}},
+		{Name: "metrics.k8s.io", PreferredVersion: "v1beta1", Versions: []discoveryManifestVersion{{GroupVersion: "metrics.k8s.io/v1beta1"}}},
+	}
+	if !reflect.DeepEqual(manifest.Groups, wantGroups) {
+		t.Errorf("groups = %+v, want %+v", manifest.Groups, wantGroups)
+	}
+}
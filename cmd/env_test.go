@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestEnvVarName(t *testing.T) {
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{flag: "output", want: "KRC_OUTPUT"},
+		{flag: "max-file-size", want: "KRC_MAX_FILE_SIZE"},
+		{flag: "kubeconfig1", want: "KRC_KUBECONFIG1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			if got := envVarName(tt.flag); got != tt.want {
+				t.Errorf("envVarName(%q) = %q, want %q", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		wantOutput  string
+		wantVerbose bool
+		wantWorkers int
+		wantErr     bool
+	}{
+		{name: "defaults", wantOutput: "./output", wantWorkers: 4},
+		{
+			name:       "environment sets unset flags",
+			env:        map[string]string{"KRC_OUTPUT": "/tmp/out", "KRC_VERBOSE": "true", "KRC_MAX_WORKERS": "8"},
+			wantOutput: "/tmp/out", wantVerbose: true, wantWorkers: 8,
+		},
+		{
+			name:       "command line wins over the environment",
+			args:       []string{"--output", "/cli", "--max-workers=2"},
+			env:        map[string]string{"KRC_OUTPUT": "/env", "KRC_MAX_WORKERS": "8"},
+			wantOutput: "/cli", wantWorkers: 2,
+		},
+		{
+			name:    "invalid value",
+			env:     map[string]string{"KRC_MAX_WORKERS": "many"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			output := fs.String("output", "./output", "")
+			verbose := fs.Bool("verbose", false, "")
+			workers := fs.Int("max-workers", 4, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := applyEnvOverrides(fs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyEnvOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *output != tt.wantOutput || *verbose != tt.wantVerbose || *workers != tt.wantWorkers {
+				t.Errorf("output=%q verbose=%v workers=%d, want %q %v %d",
+					*output, *verbose, *workers, tt.wantOutput, tt.wantVerbose, tt.wantWorkers)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// errorsFile is written next to the collection when any resource fails
+const errorsFile = "errors.json"
+
+// collectionError records why a resource could not be collected
+type collectionError struct {
+	Resource     string `json:"resource"`
+	GroupVersion string `json:"groupVersion"`
+	Error        string `json:"error"`
+}
+
+// collectionErrors are the failed resources of the current run
+var collectionErrors []collectionError
+
+// recordCollectionError keeps the error of a failed resource for errors.json
+func recordCollectionError(resourceName, groupVersion string, err error) {
+	collectionErrors = append(collectionErrors, collectionError{
+		Resource:     resourceName,
+		GroupVersion: groupVersion,
+		Error:        err.Error(),
+	})
+}
+
+// writeCollectionErrors writes the failed resources to path, so non-verbose
+// runs still show which resources failed and why. A stale file from an earlier
+// run is removed when nothing failed.
+func writeCollectionErrors(path string) error {
+	if len(collectionErrors) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale %s: %w", path, err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(collectionErrors, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection errors: %w", err)
+	}
+
+	if err := writeOutputFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCollectionErrors(t *testing.T) {
+	defer func(saved []collectionError) { collectionErrors = saved }(collectionErrors)
+
+	path := filepath.Join(t.TempDir(), errorsFile)
+
+	collectionErrors = nil
+	recordCollectionError("pods", "v1", errors.New("forbidden"))
+	recordCollectionError("widgets", "example.com/v1", errors.New("timeout"))
+	if err := writeCollectionErrors(path); err != nil {
+		t.Fatalf("writeCollectionErrors() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written []collectionError
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("%s is not JSON: %v", errorsFile, err)
+	}
+	want := []collectionError{
+		{Resource: "pods", GroupVersion: "v1", Error: "forbidden"},
+		{Resource: "widgets", GroupVersion: "example.com/v1", Error: "timeout"},
+	}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("errors = %+v, want %+v", written, want)
+	}
+
+	// A later run without failures removes the stale file
+	collectionErrors = nil
+	if err := writeCollectionErrors(path); err != nil {
+		t.Fatalf("writeCollectionErrors() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stale %s kept: %v", errorsFile, err)
+	}
+	if err := writeCollectionErrors(path); err != nil {
+		t.Errorf("writeCollectionErrors() without a file error = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Lifecycle events emitted to the --events-stream
+const (
+	eventDiscoveryStarted  = "discovery_started"
+	eventDiscoveryFinished = "discovery_finished"
+	eventDiscoveryError    = "discovery_error"
+	eventResourceStarted   = "resource_started"
+	eventResourceFinished  = "resource_finished"
+	eventResourceSkipped   = "resource_skipped"
+	eventResourceError     = "resource_error"
+	eventSummary           = "summary"
+)
+
+var (
+	eventWriter io.Writer
+	eventMutex  sync.Mutex
+)
+
+// openEventStream enables the JSON event stream. The path "-" or "stderr"
+// writes to stderr; anything else is created (or truncated) as a file.
+// The returned function closes the stream.
+func openEventStream(path string) (func() error, error) {
+	if path == "-" || path == "stderr" {
+		eventWriter = os.Stderr
+		return func() error { return nil }, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create events stream %s: %w", path, err)
+	}
+	recordOutputFile(path)
+	eventWriter = file
+
+	return func() error {
+		eventMutex.Lock()
+		defer eventMutex.Unlock()
+		eventWriter = nil
+		return file.Close()
+	}, nil
+}
+
+// emitEvent writes a single JSON line for a lifecycle event. It is a no-op
+// when no event stream is configured, so callers can emit unconditionally.
+func emitEvent(event string, fields map[string]interface{}) {
+	eventMutex.Lock()
+	defer eventMutex.Unlock()
+
+	if eventWriter == nil {
+		return
+	}
+
+	record := make(map[string]interface{}, len(fields)+2)
+	for key, value := range fields {
+		record[key] = value
+	}
+	record["time"] = time.Now().Format(time.RFC3339Nano)
+	record["event"] = event
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	eventWriter.Write(append(line, '\n'))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEventStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	closeEvents, err := openEventStream(path)
+	if err != nil {
+		t.Fatalf("openEventStream() error = %v", err)
+	}
+
+	emitEvent(eventResourceFinished, map[string]interface{}{"resource": "pods", "count": 3})
+	emitEvent(eventSummary, map[string]interface{}{"event": "overridden", "collected": 1})
+	if err := closeEvents(); err != nil {
+		t.Fatalf("close error = %v", err)
+	}
+	// Emitting after the stream is closed is a no-op
+	emitEvent(eventResourceStarted, nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	tests := []struct {
+		event  string
+		fields map[string]interface{}
+	}{
+		{event: eventResourceFinished, fields: map[string]interface{}{"resource": "pods", "count": float64(3)}},
+		{event: eventSummary, fields: map[string]interface{}{"collected": float64(1)}},
+	}
+	if len(lines) != len(tests) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(tests), data)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+				t.Fatalf("line %d is not JSON: %v", i+1, err)
+			}
+			if record["event"] != tt.event {
+				t.Errorf("event = %v, want %s", record["event"], tt.event)
+			}
+			if _, ok := record["time"].(string); !ok {
+				t.Errorf("record has no time: %v", record)
+			}
+			for key, want := range tt.fields {
+				if record[key] != want {
+					t.Errorf("%s = %v, want %v", key, record[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenEventStreamInvalidPath(t *testing.T) {
+	if _, err := openEventStream(filepath.Join(t.TempDir(), "missing", "events.jsonl")); err == nil {
+		t.Error("openEventStream() into a missing directory succeeded")
+	}
+}
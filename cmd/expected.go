@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// expectedReportFile is written next to the collection when --expected is set
+const expectedReportFile = "expected-report.txt"
+
+var (
+	// expectedObjects are the objects of the --expected manifest, keyed by kind/namespace/name
+	expectedObjects map[string]map[string]interface{}
+	// expectedScopes are the kinds of the manifest with the namespaces they appear in;
+	// only collected objects in these scopes can be unexpected
+	expectedScopes map[string]map[string]bool
+	// expectedFound are the collected objects matching the manifest, cut down to the manifest's fields
+	expectedFound map[string]map[string]interface{}
+	// unexpectedObjects are collected objects in scope that the manifest does not list
+	unexpectedObjects []string
+)
+
+// loadExpectedManifest reads a multi-document YAML manifest, as applied by
+// kubectl or a GitOps tool. List documents contribute their items.
+func loadExpectedManifest(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read expected manifest: %w", err)
+	}
+
+	expectedObjects = make(map[string]map[string]interface{})
+	expectedScopes = make(map[string]map[string]bool)
+	for i, document := range splitYAMLDocuments(string(data)) {
+		var object map[string]interface{}
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil {
+			return fmt.Errorf("failed to parse document %d of %s: %w", i+1, path, err)
+		}
+		if object == nil {
+			continue
+		}
+
+		items := []interface{}{object}
+		if kind, _ := object["kind"].(string); strings.HasSuffix(kind, "List") {
+			items, _ = object["items"].([]interface{})
+		}
+		for _, item := range items {
+			key := objectKey(item)
+			if key == "" {
+				return fmt.Errorf("document %d of %s has an object without kind or metadata.name", i+1, path)
+			}
+			expectedObjects[key] = item.(map[string]interface{})
+
+			kind, namespace := expectedKindNamespace(key)
+			if expectedScopes[kind] == nil {
+				expectedScopes[kind] = make(map[string]bool)
+			}
+			expectedScopes[kind][namespace] = true
+		}
+	}
+
+	if len(expectedObjects) == 0 {
+		return fmt.Errorf("expected manifest %s contains no objects", path)
+	}
+	return nil
+}
+
+// splitYAMLDocuments splits a multi-document YAML stream on "---" lines
+func splitYAMLDocuments(data string) []string {
+	var documents []string
+	var current strings.Builder
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasPrefix(line, "---") {
+			documents = append(documents, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteString(line + "\n")
+	}
+	return append(documents, current.String())
+}
+
+func expectedKindNamespace(key string) (string, string) {
+	parts := strings.SplitN(key, "/", 3)
+	return parts[0], parts[1]
+}
+
+// recordExpectedMatches checks the collected objects of the manifest's kinds
+// against the manifest. Matching objects are kept, cut down to the fields the
+// manifest sets, so defaulted and server-populated fields are not differences.
+func recordExpectedMatches(list *unstructured.UnstructuredList) {
+	if expectedObjects == nil {
+		return
+	}
+
+	for i := range list.Items {
+		key := objectKey(list.Items[i].Object)
+		if key == "" {
+			continue
+		}
+		kind, namespace := expectedKindNamespace(key)
+		if expectedScopes[kind] == nil {
+			continue
+		}
+
+		expected, ok := expectedObjects[key]
+		if !ok {
+			if expectedScopes[kind][namespace] {
+				unexpectedObjects = append(unexpectedObjects, key)
+			}
+			continue
+		}
+
+		// Round-trip through JSON so numbers compare like the parsed manifest's
+		pruned := pruneToExpected(list.Items[i].Object, expected)
+		data, err := json.Marshal(pruned)
+		if err != nil {
+			continue
+		}
+		var normalized map[string]interface{}
+		if err := json.Unmarshal(data, &normalized); err != nil {
+			continue
+		}
+		expectedFound[key] = normalized
+	}
+}
+
+// pruneToExpected keeps only the parts of a live value that the expected value sets
+func pruneToExpected(live, expected interface{}) interface{} {
+	liveMap, liveIsMap := live.(map[string]interface{})
+	expectedMap, expectedIsMap := expected.(map[string]interface{})
+	if liveIsMap && expectedIsMap {
+		pruned := make(map[string]interface{})
+		for key, value := range expectedMap {
+			if liveValue, ok := liveMap[key]; ok {
+				pruned[key] = pruneToExpected(liveValue, value)
+			}
+		}
+		return pruned
+	}
+
+	liveSlice, liveIsSlice := live.([]interface{})
+	expectedSlice, expectedIsSlice := expected.([]interface{})
+	if liveIsSlice && expectedIsSlice {
+		pruned := make([]interface{}, len(liveSlice))
+		for i, value := range liveSlice {
+			if i < len(expectedSlice) {
+				pruned[i] = pruneToExpected(value, expectedSlice[i])
+			} else {
+				pruned[i] = value
+			}
+		}
+		return pruned
+	}
+
+	return live
+}
+
+// writeExpectedReport lists the expected objects missing from the cluster,
+// the collected objects the manifest does not list, and the field differences
+// of the objects present in both
+func writeExpectedReport(path string) error {
+	if expectedObjects == nil {
+		return nil
+	}
+
+	var keys []string
+	for key := range expectedObjects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var missing, differing []string
+	differingCount := 0
+	for _, key := range keys {
+		live, ok := expectedFound[key]
+		if !ok {
+			missing = append(missing, "  "+key)
+			continue
+		}
+
+		changes := diffObjects(expectedObjects[key], live)
+		if len(changes) == 0 {
+			continue
+		}
+		differingCount++
+		differing = append(differing, "  "+key)
+		for _, change := range changes {
+			differing = append(differing, fmt.Sprintf("    %s: %s -> %s",
+				change.Path, formatFieldValue(change.Before, change.InBefore), formatFieldValue(change.After, change.InAfter)))
+		}
+	}
+
+	var unexpected []string
+	for _, key := range unexpectedObjects {
+		unexpected = append(unexpected, "  "+key)
+	}
+	sort.Strings(unexpected)
+
+	var report strings.Builder
+	report.WriteString("=== Expected Resources ===\n")
+	report.WriteString(fmt.Sprintf("\nExpected: %d, present: %d, missing: %d, unexpected: %d, differing: %d\n",
+		len(expectedObjects), len(expectedObjects)-len(missing), len(missing), len(unexpected), differingCount))
+	writeReportSection(&report, "Missing from the cluster", missing)
+	writeReportSection(&report, "Not in the expected manifest", unexpected)
+	report.WriteString(fmt.Sprintf("\nDiffering from the manifest (%d), expected -> live:\n", differingCount))
+	for _, line := range differing {
+		report.WriteString(line + "\n")
+	}
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write expected report %s: %w", path, err)
+	}
+
+	fmt.Printf("Expected report: %s (%d missing, %d unexpected, %d differing)\n", path, len(missing), len(unexpected), differingCount)
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const expectedManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+    namespace: shop
+  data:
+    mode: fast
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: gone
+    namespace: shop
+`
+
+// liveObject builds a collected object with the given fields
+func liveObject(apiVersion, kind, namespace, name string, fields map[string]interface{}) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": apiVersion, "kind": kind}}
+	for key, value := range fields {
+		obj.Object[key] = value
+	}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestLoadExpectedManifest(t *testing.T) {
+	defer func(objects map[string]map[string]interface{}, scopes map[string]map[string]bool) {
+		expectedObjects, expectedScopes = objects, scopes
+	}(expectedObjects, expectedScopes)
+
+	path := filepath.Join(t.TempDir(), "expected.yaml")
+	if err := os.WriteFile(path, []byte(expectedManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadExpectedManifest(path); err != nil {
+		t.Fatalf("loadExpectedManifest() error = %v", err)
+	}
+
+	for _, key := range []string{"Deployment.apps/shop/web", "ConfigMap/shop/settings", "ConfigMap/shop/gone"} {
+		if _, ok := expectedObjects[key]; !ok {
+			t.Errorf("expectedObjects is missing %s", key)
+		}
+	}
+	if len(expectedObjects) != 3 {
+		t.Errorf("expectedObjects has %d objects, want 3", len(expectedObjects))
+	}
+	want := map[string]map[string]bool{"Deployment.apps": {"shop": true}, "ConfigMap": {"shop": true}}
+	if !reflect.DeepEqual(expectedScopes, want) {
+		t.Errorf("expectedScopes = %v, want %v", expectedScopes, want)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.yaml")
+	if err := os.WriteFile(empty, []byte("---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadExpectedManifest(empty); err == nil {
+		t.Error("loadExpectedManifest() of a manifest without objects should fail")
+	}
+}
+
+func TestPruneToExpected(t *testing.T) {
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"strategy": "RollingUpdate",
+			"ports":    []interface{}{map[string]interface{}{"port": int64(80), "protocol": "TCP"}, "extra"},
+		},
+		"status": map[string]interface{}{"readyReplicas": int64(3)},
+	}
+	expected := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": float64(3),
+			"ports":    []interface{}{map[string]interface{}{"port": float64(80)}},
+			"missing":  "x",
+		},
+	}
+
+	want := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"ports":    []interface{}{map[string]interface{}{"port": int64(80)}, "extra"},
+		},
+	}
+	if got := pruneToExpected(live, expected); !reflect.DeepEqual(got, want) {
+		t.Errorf("pruneToExpected() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteExpectedReport(t *testing.T) {
+	defer func(objects map[string]map[string]interface{}, scopes map[string]map[string]bool, found map[string]map[string]interface{}, unexpected []string) {
+		expectedObjects, expectedScopes, expectedFound, unexpectedObjects = objects, scopes, found, unexpected
+	}(expectedObjects, expectedScopes, expectedFound, unexpectedObjects)
+	expectedFound = make(map[string]map[string]interface{})
+	unexpectedObjects = nil
+
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "expected.yaml")
+	if err := os.WriteFile(manifest, []byte(expectedManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadExpectedManifest(manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	recordExpectedMatches(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		liveObject("apps/v1", "Deployment", "shop", "web", map[string]interface{}{
+			"spec":   map[string]interface{}{"replicas": int64(5), "paused": false},
+			"status": map[string]interface{}{"readyReplicas": int64(5)},
+		}),
+		liveObject("apps/v1", "Deployment", "billing", "api", nil),
+	}})
+	recordExpectedMatches(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		liveObject("v1", "ConfigMap", "shop", "settings", map[string]interface{}{
+			"data": map[string]interface{}{"mode": "fast", "debug": "true"},
+		}),
+		liveObject("v1", "ConfigMap", "shop", "extra", nil),
+	}})
+
+	path := filepath.Join(dir, expectedReportFile)
+	if err := writeExpectedReport(path); err != nil {
+		t.Fatalf("writeExpectedReport() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `=== Expected Resources ===
+
+Expected: 3, present: 2, missing: 1, unexpected: 1, differing: 1
+
+Missing from the cluster (1):
+  ConfigMap/shop/gone
+
+Not in the expected manifest (1):
+  ConfigMap/shop/extra
+
+Differing from the manifest (1), expected -> live:
+  Deployment.apps/shop/web
+    spec.replicas: 3 -> 5
+`
+	if string(data) != want {
+		t.Errorf("report =\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// runExplainMode prints how a resource would be handled by a collection with
+// the current flags, without collecting anything. The resource can be given by
+// plural name, singular name, kind or short name.
+func runExplainMode(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, name string) error {
+	clusterVersion, err := detectClusterVersion(discoveryClient, dynamicClient)
+	if err != nil {
+		fmt.Printf("Warning: failed to detect cluster version: %v\n", err)
+		clusterVersion = nil
+	}
+
+	resources, err := discoverResources(discoveryClient)
+	if err != nil {
+		return fmt.Errorf("failed to discover API resources: %w", err)
+	}
+
+	found := false
+	for _, resourceList := range resources {
+		for _, resource := range resourceList.APIResources {
+			if !matchesResourceName(resource, name) {
+				continue
+			}
+			found = true
+			explainResource(resource, resourceList.GroupVersion, clusterVersion)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("resource %q is not served by this cluster", name)
+	}
+
+	return nil
+}
+
+// matchesResourceName reports whether name refers to the resource
+func matchesResourceName(resource metav1.APIResource, name string) bool {
+	name = strings.ToLower(name)
+	if resource.Name == name || resource.SingularName == name || strings.ToLower(resource.Kind) == name {
+		return true
+	}
+	return contains(resource.ShortNames, name)
+}
+
+// explainResource prints the collection decision for one discovered resource
+func explainResource(resource metav1.APIResource, groupVersion string, clusterVersion *ClusterVersion) {
+	fmt.Printf("\n=== %s (%s) ===\n", resource.Name, groupVersion)
+	fmt.Printf("GVR:        %s/%s\n", groupVersion, resource.Name)
+	if resource.Kind != "" {
+		fmt.Printf("Kind:       %s\n", resource.Kind)
+	}
+	fmt.Printf("Namespaced: %t\n", resource.Namespaced)
+	fmt.Printf("Verbs:      %s\n", strings.Join(resource.Verbs, ", "))
+
+	deprecated := false
+	if clusterVersion == nil {
+		fmt.Printf("Deprecated: unknown (cluster version not detected)\n")
+	} else if skip, msg := shouldSkipResource(resource, groupVersion, clusterVersion); skip {
+		deprecated = true
+		fmt.Printf("Deprecated: yes - %s\n", msg)
+	} else {
+		fmt.Printf("Deprecated: no\n")
+	}
+
+	decision := "yes"
+	if reason := exclusionReason(resource); reason != "" {
+		decision = "no - " + reason
+	} else if deprecated {
+		decision = "no - skipped as deprecated"
+	}
+	fmt.Printf("Collected:  %s\n", decision)
+
+	var filters []string
+	for _, filter := range activeItemFilters() {
+		filters = append(filters, filter.name)
+	}
+	if len(filters) == 0 {
+		filters = append(filters, "none")
+	}
+	fmt.Printf("Filters:    %s\n", strings.Join(filters, ", "))
+}
+
+// exclusionReason explains why isCollectable would reject a resource, or
+// returns "" if it would be collected
+func exclusionReason(resource metav1.APIResource) string {
+	if isBlockedResource(resource.Name) {
+		return "blocked by policy file " + blocklistPath
+	}
+	if isExcludedResource(resource.Name) {
+		return "excluded by --exclude-resources or --secure"
+	}
+	if strings.Contains(resource.Name, "/") {
+		if !includeSubresource(resource.Name) {
+			return "subresource not requested with --include-subresources"
+		}
+		if !contains(resource.Verbs, "get") {
+			return "subresource does not support get"
+		}
+		return ""
+	}
+
+	var missing []string
+	for _, verb := range requiredVerbs {
+		if !contains(resource.Verbs, verb) {
+			missing = append(missing, verb)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("missing required verbs: %s", strings.Join(missing, ", "))
+	}
+
+	return ""
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMatchesResourceName(t *testing.T) {
+	deployments := metav1.APIResource{Name: "deployments", SingularName: "deployment", Kind: "Deployment", ShortNames: []string{"deploy"}}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"deployments", true},
+		{"deployment", true},
+		{"Deployment", true},
+		{"deploy", true},
+		{"DEPLOY", true},
+		{"deploys", false},
+		{"pods", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesResourceName(deployments, tt.name); got != tt.want {
+				t.Errorf("matchesResourceName(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExclusionReason(t *testing.T) {
+	defer func(blocked, excluded, verbs []string, subs string) {
+		blockedResources, excludedResources, requiredVerbs, subresources = blocked, excluded, verbs, subs
+	}(blockedResources, excludedResources, requiredVerbs, subresources)
+	blockedResources = []string{"secrets"}
+	excludedResources = []string{"events"}
+	requiredVerbs = []string{"list", "get"}
+	subresources = "deployments/status"
+
+	tests := []struct {
+		name     string
+		resource metav1.APIResource
+		want     string
+	}{
+		{"collected", metav1.APIResource{Name: "pods", Verbs: []string{"list", "get"}}, ""},
+		{"blocked", metav1.APIResource{Name: "secrets", Verbs: []string{"list", "get"}}, "blocked by policy file " + blocklistPath},
+		{"excluded", metav1.APIResource{Name: "events", Verbs: []string{"list", "get"}}, "excluded by --exclude-resources or --secure"},
+		{"missing verbs", metav1.APIResource{Name: "tokenreviews", Verbs: []string{"create"}}, "missing required verbs: list, get"},
+		{"requested subresource", metav1.APIResource{Name: "deployments/status", Verbs: []string{"get"}}, ""},
+		{"subresource without get", metav1.APIResource{Name: "deployments/status", Verbs: []string{"patch"}}, "subresource does not support get"},
+		{"unrequested subresource", metav1.APIResource{Name: "pods/status", Verbs: []string{"get"}}, "subresource not requested with --include-subresources"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exclusionReason(tt.resource); got != tt.want {
+				t.Errorf("exclusionReason(%s) = %q, want %q", tt.resource.Name, got, tt.want)
+			}
+		})
+	}
+}
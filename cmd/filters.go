@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// itemFilter is a client-side filter applied to every collected list.
+// Objects for which keep returns false are dropped before writing.
+type itemFilter struct {
+	name string
+	keep func(obj *unstructured.Unstructured) bool
+}
+
+// filteredCounts tracks how many objects each filter dropped in the current run
+var filteredCounts = make(map[string]int)
+
+// activeItemFilters returns the filters enabled by flags
+func activeItemFilters() []itemFilter {
+	var filters []itemFilter
+
+	if excludeOwned {
+		filters = append(filters, itemFilter{name: "exclude-owned", keep: isNotControlled})
+	}
+
+	if olderThan > 0 {
+		cutoff := time.Now().Add(-olderThan)
+		filters = append(filters, itemFilter{name: "older-than", keep: func(obj *unstructured.Unstructured) bool {
+			return isOlderThan(obj, cutoff)
+		}})
+	}
+
+	if len(conditionMatches) > 0 {
+		filters = append(filters, itemFilter{name: "condition", keep: hasMatchingCondition})
+	}
+
+	if restartTriage {
+		filters = append(filters, itemFilter{name: "restarts", keep: hasRestarts})
+	}
+
+	if crdTargetName != "" {
+		filters = append(filters, itemFilter{name: "crd", keep: isCRDTarget})
+	}
+
+	if activeOperator != nil {
+		filters = append(filters, itemFilter{name: "operator", keep: isOperatorFootprint})
+	}
+
+	return filters
+}
+
+// applyItemFilters drops objects rejected by any active filter, in place
+func applyItemFilters(list *unstructured.UnstructuredList) {
+	filters := activeItemFilters()
+	if len(filters) == 0 {
+		return
+	}
+
+	kept := list.Items[:0]
+	for i := range list.Items {
+		keep := true
+		for _, filter := range filters {
+			if !filter.keep(&list.Items[i]) {
+				filteredCounts[filter.name]++
+				keep = false
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, list.Items[i])
+		}
+	}
+	list.Items = kept
+}
+
+// isNotControlled reports whether an object has no controller owner reference,
+// i.e. it was created by a user rather than generated by a controller
+func isNotControlled(obj *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			return false
+		}
+	}
+	return true
+}
+
+// isOlderThan reports whether an object was created before the cutoff.
+// Objects without a creationTimestamp are not considered old.
+func isOlderThan(obj *unstructured.Unstructured, cutoff time.Time) bool {
+	created := obj.GetCreationTimestamp()
+	return !created.IsZero() && created.Time.Before(cutoff)
+}
+
+// applyItemTransforms rewrites the kept objects in place before they are written.
+// Anonymizing runs last so it also sees rewritten values.
+func applyItemTransforms(list *unstructured.UnstructuredList) {
+	filterDataKeys(list)
+	rewriteImageRegistries(list)
+	redactSecretValues(list)
+	decodeSecretValues(list)
+	redactMatchingValues(list)
+	stripObjectMetadata(list)
+	pruneDefaultValues(list)
+	anonymizeList(list)
+}
+
+// printFilterSummary adds per-filter drop counts to the collection summary
+func printFilterSummary() {
+	if len(filteredCounts) == 0 {
+		return
+	}
+
+	var names []string
+	total := 0
+	for name, count := range filteredCounts {
+		names = append(names, name)
+		total += count
+	}
+	sort.Strings(names)
+
+	var details []string
+	for _, name := range names {
+		details = append(details, fmt.Sprintf("%s: %d", name, filteredCounts[name]))
+	}
+	fmt.Printf("Filtered out: %d objects (%s)\n", total, strings.Join(details, ", "))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestApplyItemFilters(t *testing.T) {
+	defer func(owned bool, age time.Duration, counts map[string]int) {
+		excludeOwned, olderThan, filteredCounts = owned, age, counts
+	}(excludeOwned, olderThan, filteredCounts)
+
+	controller := true
+	object := func(name string, age time.Duration, controlled bool) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]interface{}{"kind": "Pod"}}
+		obj.SetName(name)
+		if age > 0 {
+			obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+		}
+		if controlled {
+			obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", Controller: &controller}})
+		}
+		return obj
+	}
+	items := func() []unstructured.Unstructured {
+		return []unstructured.Unstructured{
+			object("old-standalone", 48*time.Hour, false),
+			object("old-owned", 48*time.Hour, true),
+			object("new-standalone", time.Minute, false),
+			object("no-timestamp", 0, false),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		owned       bool
+		olderThan   time.Duration
+		wantNames   []string
+		wantDropped map[string]int
+	}{
+		{name: "no filters", wantNames: []string{"old-standalone", "old-owned", "new-standalone", "no-timestamp"}, wantDropped: map[string]int{}},
+		{name: "exclude owned", owned: true, wantNames: []string{"old-standalone", "new-standalone", "no-timestamp"}, wantDropped: map[string]int{"exclude-owned": 1}},
+		{name: "older than", olderThan: 24 * time.Hour, wantNames: []string{"old-standalone", "old-owned"}, wantDropped: map[string]int{"older-than": 2}},
+		{
+			name: "first rejecting filter is counted", owned: true, olderThan: 24 * time.Hour,
+			wantNames: []string{"old-standalone"}, wantDropped: map[string]int{"exclude-owned": 1, "older-than": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			excludeOwned, olderThan, filteredCounts = tt.owned, tt.olderThan, make(map[string]int)
+			list := &unstructured.UnstructuredList{Items: items()}
+			applyItemFilters(list)
+
+			var names []string
+			for _, item := range list.Items {
+				names = append(names, item.GetName())
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("kept %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("kept %v, want %v", names, tt.wantNames)
+					break
+				}
+			}
+			for name, want := range tt.wantDropped {
+				if filteredCounts[name] != want {
+					t.Errorf("filteredCounts[%s] = %d, want %d", name, filteredCounts[name], want)
+				}
+			}
+			if len(filteredCounts) != len(tt.wantDropped) {
+				t.Errorf("filteredCounts = %v, want %v", filteredCounts, tt.wantDropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/client-go/util/homedir"
+)
+
+// Fleet options
+var (
+	kubeContext      string
+	allContexts      bool
+	parallelClusters int
+)
+
+// fleetSummaryFile is written at the root of a fleet collection
+const fleetSummaryFile = "fleet-summary.txt"
+
+// fleetOwnedFlags are set per cluster by runFleetMode and dropped from the
+// arguments passed on to each cluster's collection
+var fleetOwnedFlags = map[string]bool{
+	"all-contexts":      true,
+	"parallel-clusters": true,
+	"context":           true,
+	"output":            true,
+	"file":              true,
+	"output-url":        true,
+}
+
+// unsafeDirNameChars are replaced in context names used as directory names;
+// EKS contexts, for one, are ARNs with colons and slashes
+var unsafeDirNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// fleetCommand starts the collection of one cluster; a variable for tests
+var fleetCommand = func(args ...string) (*exec.Cmd, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the collector binary: %w", err)
+	}
+	return exec.Command(executable, args...), nil
+}
+
+// fleetResult is the outcome of collecting one context
+type fleetResult struct {
+	context  string
+	output   string
+	duration time.Duration
+	err      error
+}
+
+// kubeconfigContexts returns the context names of the kubeconfig files a
+// --kubeconfig value (or $KUBECONFIG, or ~/.kube/config) selects, sorted
+func kubeconfigContexts(kubeconfigPath string) ([]string, error) {
+	paths := kubeconfigPaths(kubeconfigPath)
+	if len(paths) == 0 {
+		paths = []string{filepath.Join(homedir.HomeDir(), ".kube", "config")}
+	}
+	rules, err := mergedKubeconfigRules(paths)
+	if err != nil {
+		return nil, err
+	}
+	config, err := rules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	var names []string
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("--all-contexts: no contexts found in %s", strings.Join(paths, ", "))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// contextDirName turns a context name into a directory name
+func contextDirName(context string) string {
+	return unsafeDirNameChars.ReplaceAllString(context, "_")
+}
+
+// checkContextDirNames fails when two contexts map to the same directory name,
+// e.g. "a/b" and "a:b", since their children would write to the same place
+func checkContextDirNames(contexts []string) error {
+	seen := make(map[string]string)
+	for _, name := range contexts {
+		dirName := contextDirName(name)
+		if other, ok := seen[dirName]; ok {
+			return fmt.Errorf("--all-contexts: contexts %q and %q would both be collected into %s; rename one of them", other, name, dirName)
+		}
+		seen[dirName] = name
+	}
+	return nil
+}
+
+// fleetMappingDir holds the --anonymize mapping of each context, as
+// <context>.yaml. Like the mapping of a single collection it lies beside the
+// root, so publishing the root never publishes the mappings.
+func fleetMappingDir(root string) string {
+	return strings.TrimSuffix(anonymizeMappingPath(root), filepath.Ext(anonymizeMappingFile))
+}
+
+// fleetChildArgs returns the arguments for collecting one context: the
+// original ones without the flags runFleetMode sets per cluster
+func fleetChildArgs(fs *flag.FlagSet, args []string) []string {
+	var kept []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, hasValue := parseFlagArg(arg)
+		if name == "" {
+			kept = append(kept, arg)
+			continue
+		}
+
+		takesValue := !hasValue && !isBoolFlag(fs, name)
+		if fleetOwnedFlags[name] {
+			if takesValue {
+				i++
+			}
+			continue
+		}
+		kept = append(kept, arg)
+		if takesValue && i+1 < len(args) {
+			i++
+			kept = append(kept, args[i])
+		}
+	}
+	return kept
+}
+
+// parseFlagArg returns the flag name of a -name, --name or --name=value
+// argument and whether the value is part of it; "" for other arguments
+func parseFlagArg(arg string) (string, bool) {
+	if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+		return "", false
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if before, _, found := strings.Cut(name, "="); found {
+		return before, true
+	}
+	return name, false
+}
+
+// isBoolFlag reports whether a flag takes no separate value
+func isBoolFlag(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// runFleetMode collects every context of the kubeconfig, up to
+// --parallel-clusters at a time. Each cluster is collected by a child process
+// of this binary with --context, its own output under <root>/<context> and
+// its log in <root>/<context>.log, so one failing cluster does not stop the
+// others. With --anonymize, each mapping goes to fleetMappingDir instead of
+// the child's default beside <root>/<context>, which is inside the root. A
+// summary of all clusters ends the run.
+func runFleetMode() error {
+	contexts, err := kubeconfigContexts(kubeconfig)
+	if err != nil {
+		return err
+	}
+	if err := checkContextDirNames(contexts); err != nil {
+		return err
+	}
+
+	root := outputDir
+	if isSingleFileMode() {
+		if outputFile == "" {
+			outputFile = "./output/all-resources.yaml"
+		}
+		root = filepath.Dir(outputFile)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if anonymize {
+		if err := os.MkdirAll(fleetMappingDir(root), 0700); err != nil {
+			return fmt.Errorf("failed to create anonymize mapping directory: %w", err)
+		}
+	}
+
+	fmt.Printf("Collecting %d contexts, %d at a time, into %s\n", len(contexts), parallelClusters, root)
+
+	args := fleetChildArgs(flag.CommandLine, os.Args[1:])
+	results := make([]fleetResult, len(contexts))
+	slots := make(chan struct{}, parallelClusters)
+	var wg sync.WaitGroup
+	for i, name := range contexts {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			results[i] = collectContext(root, name, args)
+			status := "done"
+			if results[i].err != nil {
+				status = "FAILED: " + results[i].err.Error()
+			}
+			fmt.Printf("  %s: %s (%v)\n", name, status, results[i].duration.Round(time.Second))
+		}(i, name)
+	}
+	wg.Wait()
+
+	summary := formatFleetSummary(results)
+	fmt.Print("\n" + summary)
+	if err := writeOutputFile(filepath.Join(root, fleetSummaryFile), []byte(summary), 0644); err != nil {
+		return fmt.Errorf("failed to write fleet summary: %w", err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d clusters failed; see %s", failed, len(results), filepath.Join(root, fleetSummaryFile))
+	}
+	return nil
+}
+
+// collectContext collects one context in a child process, logging its output
+// to <root>/<context>.log
+func collectContext(root, context string, args []string) fleetResult {
+	dirName := contextDirName(context)
+	result := fleetResult{context: context, output: filepath.Join(root, dirName)}
+
+	childArgs := append([]string{"--all-contexts=false", "--context", context}, args...)
+	if isSingleFileMode() {
+		result.output = filepath.Join(root, dirName, filepath.Base(outputFile))
+		childArgs = append(childArgs, "--file", result.output)
+	} else {
+		childArgs = append(childArgs, "--output", result.output)
+	}
+	if outputURL != "" {
+		childArgs = append(childArgs, "--output-url", contextOutputURL(outputURL, dirName))
+	}
+	if anonymize {
+		childArgs = append(childArgs, "--anonymize-mapping", filepath.Join(fleetMappingDir(root), dirName+".yaml"))
+	}
+
+	logPath := filepath.Join(root, dirName+".log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		result.err = fmt.Errorf("failed to create log file: %w", err)
+		return result
+	}
+	defer logFile.Close()
+
+	cmd, err := fleetCommand(childArgs...)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	start := time.Now()
+	err = cmd.Run()
+	result.duration = time.Since(start)
+	if err != nil {
+		result.err = fmt.Errorf("%s (log: %s)", lastErrorLine(logPath, err), logPath)
+	}
+	return result
+}
+
+// contextOutputURL nests a cluster's uploads under its own path
+func contextOutputURL(rawURL, dirName string) string {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	target.Path = path.Join("/", target.Path, dirName)
+	if target.Scheme == "s3" {
+		target.Path = strings.TrimPrefix(target.Path, "/")
+	}
+	return target.String()
+}
+
+// lastErrorLine returns the "Error: ..." line a failed collection printed
+// last, or the process error if there is none
+func lastErrorLine(logPath string, processErr error) string {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return processErr.Error()
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if message, found := strings.CutPrefix(lines[i], "Error: "); found {
+			return message
+		}
+	}
+	return processErr.Error()
+}
+
+// formatFleetSummary renders one row per cluster and the totals
+func formatFleetSummary(results []fleetResult) string {
+	var report strings.Builder
+	report.WriteString("=== Fleet Summary ===\n\n")
+
+	failed := 0
+	table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CONTEXT\tSTATUS\tDURATION\tOUTPUT")
+	for _, result := range results {
+		status, output := "ok", result.output
+		if result.err != nil {
+			status, output = "FAILED", result.err.Error()
+			failed++
+		}
+		fmt.Fprintf(table, "%s\t%s\t%v\t%s\n", result.context, status, result.duration.Round(time.Second), output)
+	}
+	table.Flush()
+
+	fmt.Fprintf(&report, "\nClusters: %d collected, %d failed\n", len(results)-failed, failed)
+	return report.String()
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFleetChildArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("output", "", "")
+	fs.String("file", "", "")
+	fs.String("context", "", "")
+	fs.String("output-url", "", "")
+	fs.String("namespace", "", "")
+	fs.Int("parallel-clusters", 1, "")
+	fs.Bool("all-contexts", false, "")
+	fs.Bool("verbose", false, "")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "drops the per-cluster flags",
+			args: []string{"--all-contexts", "--parallel-clusters", "4", "--output", "/tmp/fleet", "--namespace", "prod"},
+			want: []string{"--namespace", "prod"},
+		},
+		{
+			name: "keeps bool flags without eating the next argument",
+			args: []string{"-verbose", "--namespace=prod", "--output=/tmp/fleet", "--all-contexts=true"},
+			want: []string{"-verbose", "--namespace=prod"},
+		},
+		{
+			name: "drops the single file and upload targets",
+			args: []string{"--file", "/tmp/all.yaml", "--output-url", "s3://backups", "--all-contexts"},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fleetChildArgs(fs, tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fleetChildArgs(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubeconfigContexts(t *testing.T) {
+	dir := t.TempDir()
+	path := writeKubeconfig(t, dir, "config", `apiVersion: v1
+kind: Config
+clusters:
+- name: shared
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: prod
+  context:
+    cluster: shared
+- name: arn:aws:eks:eu-west-1:123456789012:cluster/dev
+  context:
+    cluster: shared
+`)
+
+	contexts, err := kubeconfigContexts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"arn:aws:eks:eu-west-1:123456789012:cluster/dev", "prod"}
+	if !reflect.DeepEqual(contexts, want) {
+		t.Errorf("kubeconfigContexts() = %v, want %v", contexts, want)
+	}
+	if got := contextDirName(contexts[0]); got != "arn_aws_eks_eu-west-1_123456789012_cluster_dev" {
+		t.Errorf("contextDirName(%q) = %q", contexts[0], got)
+	}
+
+	empty := writeKubeconfig(t, dir, "empty", "apiVersion: v1\nkind: Config\n")
+	if _, err := kubeconfigContexts(empty); err == nil || !strings.Contains(err.Error(), "no contexts found") {
+		t.Errorf("kubeconfigContexts() on a kubeconfig without contexts error = %v", err)
+	}
+}
+
+func TestContextOutputURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"s3://backups/clusters", "s3://backups/clusters/prod"},
+		{"s3://backups", "s3://backups/prod"},
+		{"https://artifacts.example.com/collections/?sig=abc", "https://artifacts.example.com/collections/prod?sig=abc"},
+	}
+
+	for _, tt := range tests {
+		if got := contextOutputURL(tt.url, "prod"); got != tt.want {
+			t.Errorf("contextOutputURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCheckContextDirNames(t *testing.T) {
+	tests := []struct {
+		contexts []string
+		wantErr  string
+	}{
+		{[]string{"prod", "staging"}, ""},
+		{[]string{"arn:aws:eks:us-east-1:123:cluster/prod", "prod"}, ""},
+		{[]string{"a/b", "a:b"}, `contexts "a/b" and "a:b" would both be collected into a_b`},
+		{[]string{"team a", "team_a"}, `contexts "team a" and "team_a"`},
+	}
+
+	for _, tt := range tests {
+		err := checkContextDirNames(tt.contexts)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("checkContextDirNames(%q) error = %v", tt.contexts, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("checkContextDirNames(%q) error = %v, want %q", tt.contexts, err, tt.wantErr)
+		}
+	}
+}
+
+// TestFleetHelperProcess stands in for the collector binary in TestRunFleetMode
+func TestFleetHelperProcess(t *testing.T) {
+	if os.Getenv("KRC_FLEET_HELPER") != "1" {
+		return
+	}
+	args := flag.Args()
+	for i, arg := range args {
+		if arg == "--context" && args[i+1] == "broken" {
+			fmt.Println("Collecting resources...")
+			fmt.Println("Error: failed to get kubeconfig: connection refused")
+			os.Exit(1)
+		}
+	}
+	fmt.Println(strings.Join(args, " "))
+	os.Exit(0)
+}
+
+func TestRunFleetMode(t *testing.T) {
+	defer func(config, dir, file string, parallel int, anon bool, command func(...string) (*exec.Cmd, error)) {
+		kubeconfig, outputDir, outputFile, parallelClusters, anonymize, fleetCommand = config, dir, file, parallel, anon, command
+	}(kubeconfig, outputDir, outputFile, parallelClusters, anonymize, fleetCommand)
+
+	dir := t.TempDir()
+	kubeconfig = writeKubeconfig(t, dir, "config", `apiVersion: v1
+kind: Config
+clusters:
+- name: shared
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: broken
+  context:
+    cluster: shared
+- name: prod
+  context:
+    cluster: shared
+- name: staging
+  context:
+    cluster: shared
+`)
+	outputDir, outputFile, parallelClusters, anonymize = filepath.Join(dir, "fleet"), "", 2, true
+	fleetCommand = func(args ...string) (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestFleetHelperProcess", "--"}, args...)...)
+		cmd.Env = append(os.Environ(), "KRC_FLEET_HELPER=1")
+		return cmd, nil
+	}
+
+	err := runFleetMode()
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 clusters failed") {
+		t.Fatalf("runFleetMode() error = %v, want 1 of 3 clusters failed", err)
+	}
+
+	log, err := os.ReadFile(filepath.Join(outputDir, "prod.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "--context prod") || !strings.Contains(string(log), "--output "+filepath.Join(outputDir, "prod")) {
+		t.Errorf("prod.log = %q, want the child's --context and --output", log)
+	}
+	// The mapping must not land inside the fleet root, which may be published
+	if mapping := filepath.Join(dir, "fleet-anonymize-mapping", "prod.yaml"); !strings.Contains(string(log), "--anonymize-mapping "+mapping) {
+		t.Errorf("prod.log = %q, want the child's --anonymize-mapping %s", log, mapping)
+	}
+
+	summary, err := os.ReadFile(filepath.Join(outputDir, fleetSummaryFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"broken", "FAILED", "failed to get kubeconfig: connection refused", "staging", "Clusters: 2 collected, 1 failed"} {
+		if !strings.Contains(string(summary), want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
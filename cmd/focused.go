@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// collectFocusedList lists one resource of a focused mode and writes it to the
+// output directory. The list is sanitized like a full collection before it is
+// written, and the sanitized list is returned so summaries show the same values.
+func collectFocusedList(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	applyItemTransforms(list)
+
+	yamlData, err := yaml.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s to YAML: %w", gvr.Resource, err)
+	}
+
+	groupVersion := gvr.GroupVersion().String()
+	filePath := filepath.Join(outputDir, formatFilename(gvr.Resource, groupVersion))
+	if err := writeOutputFile(filePath, []byte(formatHeader(gvr.Resource, groupVersion)+string(yamlData)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestCollectFocusedList(t *testing.T) {
+	defer func(dir string, strip bool, patterns []*regexp.Regexp, count int) {
+		outputDir, stripMetadata, redactPatterns, valuesRedacted = dir, strip, patterns, count
+	}(outputDir, stripMetadata, redactPatterns, valuesRedacted)
+
+	var err error
+	redactPatterns, err = compileRedactPatterns([]string{"^node-a$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripMetadata = true
+	outputDir = t.TempDir()
+
+	lease := informerObject("coordination.k8s.io/v1", "Lease", "kube-system", "kube-scheduler")
+	lease.SetUID("1234")
+	if err := unstructured.SetNestedField(lease.Object, "node-a", "spec", "holderIdentity"); err != nil {
+		t.Fatal(err)
+	}
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{leasesGVR: "LeaseList"}, lease)
+
+	list, err := collectFocusedList(fakeClient, leasesGVR)
+	if err != nil {
+		t.Fatalf("collectFocusedList() error = %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("collectFocusedList() returned %d items, want 1", len(list.Items))
+	}
+	if holder, _, _ := unstructured.NestedString(list.Items[0].Object, "spec", "holderIdentity"); holder != redactedValue {
+		t.Errorf("returned holderIdentity = %q, want %q", holder, redactedValue)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, formatFilename(leasesGVR.Resource, leasesGVR.GroupVersion().String())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"node-a", "uid: \"1234\""} {
+		if strings.Contains(string(data), leaked) {
+			t.Errorf("written list contains %q:\n%s", leaked, data)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// checkCollectionGates runs the post-collection checks that can fail a run
+// that otherwise collected successfully
+func checkCollectionGates(resourceCounts map[string]int) error {
+	if err := checkEmptyCollection(resourceCounts); err != nil {
+		return err
+	}
+	if err := checkMinCounts(resourceCounts); err != nil {
+		return err
+	}
+	return checkDeprecatedInUse()
+}
+
+// checkEmptyCollection warns when not a single object was collected, which
+// almost always means the wrong context or credentials that see nothing
+// rather than an empty cluster; with --fail-on-empty it is an error. A
+// --resume run may legitimately collect nothing new, so it is not checked.
+func checkEmptyCollection(resourceCounts map[string]int) error {
+	if resume {
+		return nil
+	}
+
+	total := 0
+	for _, count := range resourceCounts {
+		total += count
+	}
+	if total > 0 {
+		return nil
+	}
+
+	fmt.Printf("\nWarning: no objects were collected from any resource. Check the kubeconfig context and that the credentials can list resources in this cluster.\n")
+	if failOnEmpty {
+		return fmt.Errorf("--fail-on-empty: the collection is empty")
+	}
+	return nil
+}
+
+// parseMinCounts parses --assert-min values such as "pods=1,nodes=3"
+func parseMinCounts(value string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, entry := range parseList(value) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid --assert-min entry %q: expected <resource>=<count>", entry)
+		}
+
+		minimum, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || minimum < 0 {
+			return nil, fmt.Errorf("invalid --assert-min entry %q: count must be a non-negative integer", entry)
+		}
+
+		counts[strings.TrimSpace(parts[0])] = minimum
+	}
+	return counts, nil
+}
+
+// checkMinCounts compares collected item counts against --assert-min
+func checkMinCounts(resourceCounts map[string]int) error {
+	if len(minCounts) == 0 {
+		return nil
+	}
+
+	var resources []string
+	for resource := range minCounts {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	failed := 0
+	fmt.Printf("\n=== Count Assertions ===\n")
+	for _, resource := range resources {
+		count, err := assertedCount(resourceCounts, resource)
+		if err != nil {
+			fmt.Printf("FAIL: %v\n", err)
+			failed++
+			continue
+		}
+		status := "PASS"
+		if count < minCounts[resource] {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%s: %s >= %d (collected %d)\n", status, resource, minCounts[resource], count)
+	}
+	fmt.Printf("========================\n")
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d --assert-min checks failed", failed, len(minCounts))
+	}
+	return nil
+}
+
+// resourceCountKey names a resource in the collected counts the way kubectl
+// does: <resource> for the core group and <resource>.<group> otherwise, so
+// core and events.k8s.io events are counted apart
+func resourceCountKey(resourceName, groupVersion string) string {
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil || gv.Group == "" {
+		return resourceName
+	}
+	return resourceName + "." + gv.Group
+}
+
+// assertedCount returns the collected count for an --assert-min entry. A bare
+// name that is not a core resource matches the one group serving it; when
+// several groups do, the entry must name the group.
+func assertedCount(resourceCounts map[string]int, resource string) (int, error) {
+	if count, ok := resourceCounts[resource]; ok {
+		return count, nil
+	}
+
+	var matches []string
+	for key := range resourceCounts {
+		if name, _, found := strings.Cut(key, "."); found && name == resource {
+			matches = append(matches, key)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, nil
+	case 1:
+		return resourceCounts[matches[0]], nil
+	}
+	sort.Strings(matches)
+	return 0, fmt.Errorf("%s is served by several groups (%s); qualify it with its group", resource, strings.Join(matches, ", "))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMinCounts(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]int
+		wantErr bool
+	}{
+		{value: "", want: map[string]int{}},
+		{value: "pods=1,nodes=3", want: map[string]int{"pods": 1, "nodes": 3}},
+		{value: " pods = 2 , events.events.k8s.io=0", want: map[string]int{"pods": 2, "events.events.k8s.io": 0}},
+		{value: "pods", wantErr: true},
+		{value: "=1", wantErr: true},
+		{value: "pods=-1", wantErr: true},
+		{value: "pods=many", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMinCounts(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMinCounts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMinCounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceCountKey(t *testing.T) {
+	tests := []struct {
+		resource     string
+		groupVersion string
+		want         string
+	}{
+		{resource: "pods", groupVersion: "v1", want: "pods"},
+		{resource: "events", groupVersion: "events.k8s.io/v1", want: "events.events.k8s.io"},
+		{resource: "deployments", groupVersion: "apps/v1", want: "deployments.apps"},
+		{resource: "widgets", groupVersion: "a/b/c", want: "widgets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupVersion+"/"+tt.resource, func(t *testing.T) {
+			if got := resourceCountKey(tt.resource, tt.groupVersion); got != tt.want {
+				t.Errorf("resourceCountKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssertedCount(t *testing.T) {
+	counts := map[string]int{
+		"pods":                 5,
+		"events":               7,
+		"events.events.k8s.io": 3,
+		"deployments.apps":     2,
+		"widgets.a.example":    1,
+		"widgets.b.example":    4,
+	}
+
+	tests := []struct {
+		resource string
+		want     int
+		wantErr  bool
+	}{
+		{resource: "pods", want: 5},
+		{resource: "events", want: 7},
+		{resource: "events.events.k8s.io", want: 3},
+		{resource: "deployments", want: 2},
+		{resource: "widgets", wantErr: true},
+		{resource: "widgets.b.example", want: 4},
+		{resource: "secrets", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			got, err := assertedCount(counts, tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("assertedCount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("assertedCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCollectionGates(t *testing.T) {
+	defer func(deprecated bool, counts map[string]int) {
+		failOnDeprecated, minCounts = deprecated, counts
+	}(failOnDeprecated, minCounts)
+	failOnDeprecated = false
+
+	tests := []struct {
+		name      string
+		minCounts map[string]int
+		counts    map[string]int
+		wantErr   bool
+	}{
+		{name: "assertions met", minCounts: map[string]int{"pods": 2, "deployments": 1}, counts: map[string]int{"pods": 2, "deployments.apps": 1}},
+		{name: "assertion failed", minCounts: map[string]int{"pods": 3}, counts: map[string]int{"pods": 2}, wantErr: true},
+		{name: "ambiguous assertion fails", minCounts: map[string]int{"events": 1}, counts: map[string]int{"events.a": 1, "events.b": 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minCounts = tt.minCounts
+			if err := checkCollectionGates(tt.counts); (err != nil) != tt.wantErr {
+				t.Errorf("checkCollectionGates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckEmptyCollection(t *testing.T) {
+	defer func(r, empty bool) { resume, failOnEmpty = r, empty }(resume, failOnEmpty)
+
+	tests := []struct {
+		name      string
+		resume    bool
+		failEmpty bool
+		counts    map[string]int
+		wantErr   bool
+	}{
+		{name: "objects collected", failEmpty: true, counts: map[string]int{"pods": 0, "nodes": 1}},
+		{name: "empty collection warns", counts: map[string]int{"pods": 0}},
+		{name: "empty collection fails with --fail-on-empty", failEmpty: true, counts: map[string]int{"pods": 0}, wantErr: true},
+		{name: "no resources fails with --fail-on-empty", failEmpty: true, counts: map[string]int{}, wantErr: true},
+		{name: "empty resumed collection is not checked", resume: true, failEmpty: true, counts: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resume, failOnEmpty = tt.resume, tt.failEmpty
+			if err := checkEmptyCollection(tt.counts); (err != nil) != tt.wantErr {
+				t.Errorf("checkEmptyCollection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// collapsedNameSuffix replaces the random part of a collapsed generated name
+const collapsedNameSuffix = "-*"
+
+// stableNamePrefix returns the part of a generated object name that survives
+// a rollout: the generateName prefix, with the pod-template-hash removed so
+// that "web-7d9f8c5b4-x2x7k" (Pod) and "web-7d9f8c5b4" (ReplicaSet) both
+// become "web". It returns "" for names that are not generated.
+func stableNamePrefix(itemMap map[string]interface{}) string {
+	metadata, _ := itemMap["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	generateName, _ := metadata["generateName"].(string)
+	labels, _ := metadata["labels"].(map[string]interface{})
+	templateHash, _ := labels["pod-template-hash"].(string)
+
+	prefix := ""
+	switch {
+	case generateName != "":
+		prefix = strings.TrimSuffix(generateName, "-")
+	case templateHash != "" && strings.HasSuffix(name, "-"+templateHash):
+		// ReplicaSets are named <deployment>-<hash> without a generateName
+		prefix = name
+	default:
+		return ""
+	}
+
+	if templateHash != "" {
+		prefix = strings.TrimSuffix(prefix, "-"+templateHash)
+	}
+
+	return prefix
+}
+
+// collectionObjectKey indexes an object for the object-level diffs. With
+// --collapse-generated-names, objects with generated names are keyed by their
+// stable prefix, e.g. "Pod/shop/web-*", so the random suffixes of two
+// collections do not show up as unrelated objects on both sides.
+func collectionObjectKey(item interface{}) string {
+	key := objectKey(item)
+	if !collapseGenerated || key == "" {
+		return key
+	}
+
+	itemMap := item.(map[string]interface{})
+	prefix := stableNamePrefix(itemMap)
+	if prefix == "" {
+		return key
+	}
+
+	metadata, _ := itemMap["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+
+	return fmt.Sprintf("%s/%s/%s%s", qualifiedKind(itemMap), namespace, prefix, collapsedNameSuffix)
+}
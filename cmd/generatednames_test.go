@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func generatedObject(apiVersion, kind, name, generateName, templateHash string) map[string]interface{} {
+	metadata := map[string]interface{}{"name": name, "namespace": "shop"}
+	if generateName != "" {
+		metadata["generateName"] = generateName
+	}
+	if templateHash != "" {
+		metadata["labels"] = map[string]interface{}{"pod-template-hash": templateHash}
+	}
+	return map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "metadata": metadata}
+}
+
+func TestStableNamePrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		item map[string]interface{}
+		want string
+	}{
+		{"deployment pod", generatedObject("v1", "Pod", "web-7d9f8c5b4-x2x7k", "web-7d9f8c5b4-", "7d9f8c5b4"), "web"},
+		{"replicaset", generatedObject("apps/v1", "ReplicaSet", "web-7d9f8c5b4", "", "7d9f8c5b4"), "web"},
+		{"job pod", generatedObject("v1", "Pod", "backup-28291-abcde", "backup-28291-", ""), "backup-28291"},
+		{"named object", generatedObject("v1", "ConfigMap", "app", "", ""), ""},
+		{"hash label on an unrelated name", generatedObject("v1", "Pod", "standalone", "", "7d9f8c5b4"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stableNamePrefix(tt.item); got != tt.want {
+				t.Errorf("stableNamePrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectionObjectKey(t *testing.T) {
+	defer func(saved bool) { collapseGenerated = saved }(collapseGenerated)
+
+	pod := generatedObject("v1", "Pod", "web-7d9f8c5b4-x2x7k", "web-7d9f8c5b4-", "7d9f8c5b4")
+	replicaSet := generatedObject("apps/v1", "ReplicaSet", "web-7d9f8c5b4", "", "7d9f8c5b4")
+	configMap := generatedObject("v1", "ConfigMap", "app", "", "")
+
+	tests := []struct {
+		name     string
+		collapse bool
+		item     map[string]interface{}
+		want     string
+	}{
+		{"pod kept as is", false, pod, "Pod/shop/web-7d9f8c5b4-x2x7k"},
+		{"pod collapsed", true, pod, "Pod/shop/web-*"},
+		{"replicaset collapsed with its group", true, replicaSet, "ReplicaSet.apps/shop/web-*"},
+		{"named object unchanged", true, configMap, "ConfigMap/shop/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collapseGenerated = tt.collapse
+			if got := collectionObjectKey(tt.item); got != tt.want {
+				t.Errorf("collectionObjectKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// stringList is a repeatable flag; each occurrence may also hold a comma-separated list
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, parseList(value)...)
+	return nil
+}
+
+// explicitGVRs are the --gvr resources to collect instead of running discovery
+var explicitGVRs []schema.GroupVersionResource
+
+// parseGVRs parses group/version/resource triples. Core resources are written
+// with an empty group ("/v1/pods") or as version/resource ("v1/pods").
+func parseGVRs(values []string) ([]schema.GroupVersionResource, error) {
+	var gvrs []schema.GroupVersionResource
+	for _, value := range values {
+		parts := strings.Split(value, "/")
+		if len(parts) == 2 {
+			parts = append([]string{""}, parts...)
+		}
+		if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid --gvr %q: expected group/version/resource (e.g. apps/v1/deployments or v1/pods)", value)
+		}
+		gvrs = append(gvrs, schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]})
+	}
+	return gvrs, nil
+}
+
+// discoverResources returns the resources to collect: the --gvr resources when
+// given, otherwise the server's preferred resources from discovery
+func discoverResources(discoveryClient discovery.DiscoveryInterface) ([]*metav1.APIResourceList, error) {
+	if len(explicitGVRs) == 0 {
+		var lists []*metav1.APIResourceList
+		var partialErr error
+		err := withRetry("discovery", func() error {
+			var err error
+			lists, err = discoveryClient.ServerPreferredResources()
+			// Only retry when nothing came back; a partial result is not
+			// improved by discovering every group again
+			if len(lists) > 0 && discovery.IsGroupDiscoveryFailedError(err) {
+				partialErr = err
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return lists, err
+		}
+		return lists, partialErr
+	}
+
+	var lists []*metav1.APIResourceList
+	byGroupVersion := make(map[string]*metav1.APIResourceList)
+	served := make(map[string]map[string]metav1.APIResource)
+	for _, gvr := range explicitGVRs {
+		groupVersion := gvr.GroupVersion().String()
+		list, ok := byGroupVersion[groupVersion]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: groupVersion}
+			byGroupVersion[groupVersion] = list
+			lists = append(lists, list)
+			served[groupVersion] = servedResources(discoveryClient, groupVersion)
+		}
+
+		// The scope decides whether the resource is listed per namespace, so
+		// take it from the group version's discovery document when available
+		if resource, ok := served[groupVersion][gvr.Resource]; ok {
+			list.APIResources = append(list.APIResources, resource)
+			continue
+		}
+		// Without discovery the scope and verbs are unknown; List reports if
+		// the verbs are missing and a cluster-wide list covers both scopes
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:  gvr.Resource,
+			Verbs: metav1.Verbs(requiredVerbs),
+		})
+	}
+
+	return lists, nil
+}
+
+// servedResources returns the top-level resources of one group version by
+// name, or nil when its discovery document cannot be read
+func servedResources(discoveryClient discovery.DiscoveryInterface, groupVersion string) map[string]metav1.APIResource {
+	list, err := discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		fmt.Printf("Warning: failed to discover %s; the scope of its --gvr resources is unknown: %v\n", groupVersion, err)
+		return nil
+	}
+	resources := make(map[string]metav1.APIResource, len(list.APIResources))
+	for _, resource := range list.APIResources {
+		if !strings.Contains(resource.Name, "/") {
+			resources[resource.Name] = resource
+		}
+	}
+	return resources
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseGVRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []schema.GroupVersionResource
+		wantErr bool
+	}{
+		{name: "group resource", values: []string{"apps/v1/deployments"}, want: []schema.GroupVersionResource{{Group: "apps", Version: "v1", Resource: "deployments"}}},
+		{name: "core with empty group", values: []string{"/v1/pods"}, want: []schema.GroupVersionResource{{Version: "v1", Resource: "pods"}}},
+		{name: "core without group", values: []string{"v1/pods"}, want: []schema.GroupVersionResource{{Version: "v1", Resource: "pods"}}},
+		{name: "missing resource", values: []string{"apps/v1/"}, wantErr: true},
+		{name: "too many parts", values: []string{"a/b/c/d"}, wantErr: true},
+		{name: "resource only", values: []string{"pods"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGVRs(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGVRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGVRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverResourcesExplicitScope(t *testing.T) {
+	defer func(gvrs []schema.GroupVersionResource, verbs []string) {
+		explicitGVRs, requiredVerbs = gvrs, verbs
+	}(explicitGVRs, requiredVerbs)
+	requiredVerbs = []string{"list"}
+
+	client := &stubDiscovery{resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true},
+			{Name: "nodes", Kind: "Node", Verbs: metav1.Verbs{"get", "list"}},
+		}},
+	}}
+
+	tests := []struct {
+		name           string
+		gvr            schema.GroupVersionResource
+		wantNamespaced bool
+		wantKind       string
+	}{
+		{name: "namespaced resource", gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, wantNamespaced: true, wantKind: "Pod"},
+		{name: "cluster-scoped resource", gvr: schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, wantKind: "Node"},
+		{name: "resource missing from discovery", gvr: schema.GroupVersionResource{Version: "v1", Resource: "widgets"}},
+		{name: "group version missing from discovery", gvr: schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explicitGVRs = []schema.GroupVersionResource{tt.gvr}
+			lists, err := discoverResources(client)
+			if err != nil {
+				t.Fatalf("discoverResources() error = %v", err)
+			}
+			if len(lists) != 1 || len(lists[0].APIResources) != 1 {
+				t.Fatalf("discoverResources() = %v, want one resource", lists)
+			}
+			resource := lists[0].APIResources[0]
+			if resource.Name != tt.gvr.Resource || resource.Namespaced != tt.wantNamespaced || resource.Kind != tt.wantKind {
+				t.Errorf("resource = %+v, want name %s namespaced %v kind %q", resource, tt.gvr.Resource, tt.wantNamespaced, tt.wantKind)
+			}
+			if len(resource.Verbs) == 0 {
+				t.Errorf("resource %s has no verbs", resource.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// helmChartNamePattern is what Helm accepts as a chart name
+var helmChartNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+var (
+	// helmTemplates counts the template files written in the current run
+	helmTemplates int
+	// helmNamespaces and helmImages are the values.yaml defaults of the
+	// --helm-values placeholders, keyed by value name
+	helmNamespaces map[string]interface{}
+	helmImages     map[string]interface{}
+)
+
+// writeHelmTemplates writes each object of a list to its own file under
+// templates/, in the --kustomize layout. Existing "{{" in the objects is
+// escaped so Helm renders it literally; with --helm-values, namespaces and
+// container images become .Values placeholders.
+func writeHelmTemplates(outputDir string, list *unstructured.UnstructuredList) error {
+	templatesDir := filepath.Join(outputDir, "templates")
+	for i := range list.Items {
+		item := list.Items[i].DeepCopy()
+		delete(item.Object, "status")
+		item.Object = escapeHelmActions(item.Object).(map[string]interface{})
+		if helmValues {
+			parameterizeHelmObject(item)
+		}
+
+		relativePath, err := objectFilePath(templatesDir, item)
+		if err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(item.Object)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s %s to YAML: %w", item.GetKind(), item.GetName(), err)
+		}
+		if err := writeFileAtomic(filepath.Join(templatesDir, relativePath), data); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", relativePath, err)
+		}
+		helmTemplates++
+	}
+	return nil
+}
+
+// escapeHelmActions rewrites "{{" in string values as {{ "{{" }}, e.g. in
+// ConfigMaps holding templates of other tools
+func escapeHelmActions(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = escapeHelmActions(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = escapeHelmActions(child)
+		}
+	case string:
+		return strings.ReplaceAll(v, "{{", `{{ "{{" }}`)
+	}
+	return value
+}
+
+// parameterizeHelmObject replaces the object's namespace (or, for a Namespace,
+// its name) and its container images with .Values placeholders, recording
+// the original values as defaults
+func parameterizeHelmObject(item *unstructured.Unstructured) {
+	if helmNamespaces == nil {
+		helmNamespaces = make(map[string]interface{})
+		helmImages = make(map[string]interface{})
+	}
+
+	if namespace := item.GetNamespace(); namespace != "" {
+		helmNamespaces[namespace] = namespace
+		item.SetNamespace(fmt.Sprintf("{{ index .Values.namespaces %q }}", namespace))
+	} else if item.GetKind() == "Namespace" && item.GroupVersionKind().Group == "" {
+		helmNamespaces[item.GetName()] = item.GetName()
+		item.SetName(fmt.Sprintf("{{ index .Values.namespaces %q }}", item.GetName()))
+	}
+
+	parameterizeContainerImages(item.Object)
+}
+
+func parameterizeContainerImages(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "containers" || key == "initContainers" {
+				if containers, ok := child.([]interface{}); ok {
+					for _, container := range containers {
+						if containerMap, ok := container.(map[string]interface{}); ok {
+							if image, ok := containerMap["image"].(string); ok && image != "" {
+								containerMap["image"] = fmt.Sprintf("{{ index .Values.images %q }}", helmImageKey(image))
+							}
+						}
+					}
+				}
+				continue
+			}
+			parameterizeContainerImages(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			parameterizeContainerImages(child)
+		}
+	}
+}
+
+// helmImageKey names an image's value after its repository, without registry,
+// tag or digest ("quay.io/org/app:v1" -> "app"). Different images with the
+// same repository name get a numeric suffix.
+func helmImageKey(image string) string {
+	name, _, _ := strings.Cut(image, "@")
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		name = name[slash+1:]
+	}
+	name, _, _ = strings.Cut(name, ":")
+
+	key := name
+	for n := 2; ; n++ {
+		existing, found := helmImages[key]
+		if !found {
+			helmImages[key] = image
+			return key
+		}
+		if existing == image {
+			return key
+		}
+		key = fmt.Sprintf("%s-%d", name, n)
+	}
+}
+
+// writeHelmChart completes the chart at the root of the output directory
+// with Chart.yaml and values.yaml, which is empty unless --helm-values
+// recorded placeholder defaults
+func writeHelmChart(outputDir string) error {
+	if helmChart == "" {
+		return nil
+	}
+
+	chart := map[string]interface{}{
+		"apiVersion":  "v2",
+		"name":        helmChart,
+		"description": "Generated by k8s-resource-collector from the objects of a live cluster",
+		"type":        "application",
+		"version":     "0.1.0",
+	}
+	data, err := yaml.Marshal(chart)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Chart.yaml: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(outputDir, "Chart.yaml"), data); err != nil {
+		return fmt.Errorf("failed to write Chart.yaml: %w", err)
+	}
+
+	var values []byte
+	if len(helmNamespaces) > 0 || len(helmImages) > 0 {
+		values, err = yaml.Marshal(map[string]interface{}{
+			"namespaces": helmNamespaces,
+			"images":     helmImages,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal values.yaml: %w", err)
+		}
+	}
+	if err := writeFileAtomic(filepath.Join(outputDir, "values.yaml"), values); err != nil {
+		return fmt.Errorf("failed to write values.yaml: %w", err)
+	}
+
+	fmt.Printf("Helm chart: %s (%d templates)\n", outputDir, helmTemplates)
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestEscapeHelmActions(t *testing.T) {
+	object := map[string]interface{}{
+		"data": map[string]interface{}{"alert.tmpl": "{{ .Labels.severity }}"},
+		"args": []interface{}{"--format={{json .}}"},
+		"port": int64(8080),
+	}
+
+	want := map[string]interface{}{
+		"data": map[string]interface{}{"alert.tmpl": `{{ "{{" }} .Labels.severity }}`},
+		"args": []interface{}{`--format={{ "{{" }}json .}}`},
+		"port": int64(8080),
+	}
+	if got := escapeHelmActions(object); !reflect.DeepEqual(got, want) {
+		t.Errorf("escapeHelmActions() = %v, want %v", got, want)
+	}
+}
+
+func TestHelmImageKey(t *testing.T) {
+	defer func(saved map[string]interface{}) { helmImages = saved }(helmImages)
+	helmImages = make(map[string]interface{})
+
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"quay.io/org/app:v1", "app"},
+		{"quay.io/org/app:v1", "app"},
+		{"docker.io/other/app:v2", "app-2"},
+		{"registry.local:5000/proxy@sha256:abc", "proxy"},
+		{"nginx", "nginx"},
+	}
+
+	for _, tt := range tests {
+		if got := helmImageKey(tt.image); got != tt.want {
+			t.Errorf("helmImageKey(%q) = %q, want %q", tt.image, got, tt.want)
+		}
+	}
+	if helmImages["app-2"] != "docker.io/other/app:v2" {
+		t.Errorf("helmImages = %v, want the default of app-2 recorded", helmImages)
+	}
+}
+
+func TestParameterizeHelmObject(t *testing.T) {
+	defer func(namespaces, images map[string]interface{}) {
+		helmNamespaces, helmImages = namespaces, images
+	}(helmNamespaces, helmImages)
+	helmNamespaces, helmImages = nil, nil
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"initContainers": []interface{}{map[string]interface{}{"name": "migrate", "image": "quay.io/shop/web:v3"}},
+			"containers":     []interface{}{map[string]interface{}{"name": "web", "image": "quay.io/shop/web:v3"}},
+		}}},
+	}}
+	deployment.SetNamespace("shop")
+	deployment.SetName("web")
+	namespace := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Namespace"}}
+	namespace.SetName("shop")
+
+	parameterizeHelmObject(deployment)
+	parameterizeHelmObject(namespace)
+
+	if got := deployment.GetNamespace(); got != `{{ index .Values.namespaces "shop" }}` {
+		t.Errorf("deployment namespace = %q", got)
+	}
+	if got := namespace.GetName(); got != `{{ index .Values.namespaces "shop" }}` {
+		t.Errorf("namespace name = %q", got)
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", field)
+		if image := containers[0].(map[string]interface{})["image"]; image != `{{ index .Values.images "web" }}` {
+			t.Errorf("%s image = %v", field, image)
+		}
+	}
+	if !reflect.DeepEqual(helmNamespaces, map[string]interface{}{"shop": "shop"}) ||
+		!reflect.DeepEqual(helmImages, map[string]interface{}{"web": "quay.io/shop/web:v3"}) {
+		t.Errorf("values defaults = %v, %v", helmNamespaces, helmImages)
+	}
+}
+
+func TestWriteHelmChart(t *testing.T) {
+	defer func(chart string, namespaces, images map[string]interface{}) {
+		helmChart, helmNamespaces, helmImages = chart, namespaces, images
+	}(helmChart, helmNamespaces, helmImages)
+	helmChart = "shop"
+
+	dir := t.TempDir()
+	helmNamespaces, helmImages = nil, nil
+	if err := writeHelmChart(dir); err != nil {
+		t.Fatalf("writeHelmChart() error = %v", err)
+	}
+	chart, err := os.ReadFile(filepath.Join(dir, "Chart.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(chart), "apiVersion: v2\n") || !strings.Contains(string(chart), "name: shop\n") {
+		t.Errorf("Chart.yaml =\n%s", chart)
+	}
+	if values, err := os.ReadFile(filepath.Join(dir, "values.yaml")); err != nil || len(values) != 0 {
+		t.Errorf("values.yaml without --helm-values = %q, %v, want empty", values, err)
+	}
+
+	helmNamespaces = map[string]interface{}{"shop": "shop"}
+	helmImages = map[string]interface{}{"web": "quay.io/shop/web:v3"}
+	if err := writeHelmChart(dir); err != nil {
+		t.Fatalf("writeHelmChart() error = %v", err)
+	}
+	values, err := os.ReadFile(filepath.Join(dir, "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "images:\n  web: quay.io/shop/web:v3\nnamespaces:\n  shop: shop\n"
+	if string(values) != want {
+		t.Errorf("values.yaml =\n%s\nwant\n%s", values, want)
+	}
+}
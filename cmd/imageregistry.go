@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// registryRewrite maps an image prefix to its replacement
+type registryRewrite struct {
+	from string
+	to   string
+}
+
+var (
+	// registryRewrites are the parsed --image-registry-map entries, longest prefix first
+	registryRewrites []registryRewrite
+	// imagesRewritten counts rewritten image references in the current run
+	imagesRewritten int
+)
+
+// parseRegistryMap parses "docker.io/=mirror.example.com/,quay.io/=mirror.example.com/quay/"
+func parseRegistryMap(value string) ([]registryRewrite, error) {
+	var rewrites []registryRewrite
+	for _, entry := range parseList(value) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --image-registry-map entry %q: expected <from>=<to>", entry)
+		}
+		rewrites = append(rewrites, registryRewrite{from: parts[0], to: parts[1]})
+	}
+
+	// The most specific prefix wins
+	sort.SliceStable(rewrites, func(i, j int) bool {
+		return len(rewrites[i].from) > len(rewrites[j].from)
+	})
+
+	return rewrites, nil
+}
+
+// rewriteImageRegistries rewrites the image of every container and init
+// container in the list, wherever the pod spec is nested (Pods, workloads,
+// CronJobs, custom resources embedding a pod template)
+func rewriteImageRegistries(list *unstructured.UnstructuredList) {
+	if len(registryRewrites) == 0 {
+		return
+	}
+
+	for i := range list.Items {
+		rewriteContainerImages(list.Items[i].Object)
+	}
+}
+
+func rewriteContainerImages(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "containers" || key == "initContainers" {
+				if containers, ok := child.([]interface{}); ok {
+					for _, container := range containers {
+						if containerMap, ok := container.(map[string]interface{}); ok {
+							if image, ok := containerMap["image"].(string); ok {
+								containerMap["image"] = rewriteImage(image)
+							}
+						}
+					}
+				}
+				continue
+			}
+			rewriteContainerImages(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			rewriteContainerImages(child)
+		}
+	}
+}
+
+// rewriteImage applies the first matching rewrite. Images without a registry
+// host (e.g. "nginx:1.25") are matched as their docker.io form.
+func rewriteImage(image string) string {
+	candidates := []string{image}
+	if full := fullDockerHubImage(image); full != image {
+		candidates = append(candidates, full)
+	}
+
+	for _, rewrite := range registryRewrites {
+		for _, candidate := range candidates {
+			if strings.HasPrefix(candidate, rewrite.from) {
+				imagesRewritten++
+				return rewrite.to + strings.TrimPrefix(candidate, rewrite.from)
+			}
+		}
+	}
+
+	return image
+}
+
+// fullDockerHubImage expands an image without a registry host to its docker.io
+// reference: "nginx" -> "docker.io/library/nginx", "org/app" -> "docker.io/org/app"
+func fullDockerHubImage(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image
+	}
+	if !found {
+		return "docker.io/library/" + image
+	}
+	return "docker.io/" + image
+}
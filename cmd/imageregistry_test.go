@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseRegistryMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []registryRewrite
+		wantErr bool
+	}{
+		{
+			name:  "longest prefix first",
+			value: "docker.io/=mirror.example.com/,docker.io/library/=mirror.example.com/lib/",
+			want: []registryRewrite{
+				{from: "docker.io/library/", to: "mirror.example.com/lib/"},
+				{from: "docker.io/", to: "mirror.example.com/"},
+			},
+		},
+		{name: "missing replacement", value: "quay.io/=", wantErr: true},
+		{name: "no separator", value: "quay.io/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRegistryMap(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRegistryMap(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseRegistryMap(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("rewrite %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteImage(t *testing.T) {
+	defer func(saved []registryRewrite, count int) {
+		registryRewrites, imagesRewritten = saved, count
+	}(registryRewrites, imagesRewritten)
+
+	var err error
+	registryRewrites, err = parseRegistryMap("docker.io/=mirror.example.com/,quay.io/=mirror.example.com/quay/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx:1.25", "mirror.example.com/library/nginx:1.25"},
+		{"org/app:v1", "mirror.example.com/org/app:v1"},
+		{"docker.io/org/app", "mirror.example.com/org/app"},
+		{"quay.io/prometheus/node-exporter", "mirror.example.com/quay/prometheus/node-exporter"},
+		{"registry.k8s.io/pause:3.9", "registry.k8s.io/pause:3.9"},
+		{"localhost:5000/app", "localhost:5000/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := rewriteImage(tt.image); got != tt.want {
+				t.Errorf("rewriteImage(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteImageRegistries(t *testing.T) {
+	defer func(saved []registryRewrite, count int) {
+		registryRewrites, imagesRewritten = saved, count
+	}(registryRewrites, imagesRewritten)
+	registryRewrites = []registryRewrite{{from: "docker.io/", to: "mirror.example.com/"}}
+	imagesRewritten = 0
+
+	cronJob := unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "CronJob",
+		"spec": map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": "busybox"}},
+				"containers":     []interface{}{map[string]interface{}{"name": "job", "image": "quay.io/org/job"}},
+			}},
+		}}},
+	}}
+	list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{cronJob}}
+
+	rewriteImageRegistries(list)
+
+	podSpec := list.Items[0].Object["spec"].(map[string]interface{})["jobTemplate"].(map[string]interface{})["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	if got := podSpec["initContainers"].([]interface{})[0].(map[string]interface{})["image"]; got != "mirror.example.com/library/busybox" {
+		t.Errorf("init container image = %v, want mirror.example.com/library/busybox", got)
+	}
+	if got := podSpec["containers"].([]interface{})[0].(map[string]interface{})["image"]; got != "quay.io/org/job" {
+		t.Errorf("unmatched container image = %v, want it unchanged", got)
+	}
+	if imagesRewritten != 1 {
+		t.Errorf("imagesRewritten = %d, want 1", imagesRewritten)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// resourceBlock is one "--- # Resource: <name>" section of a single-file collection
+type resourceBlock struct {
+	Name    string
+	Content string
+}
+
+// runImportMode splits an existing all-resources.yaml into one file per resource type
+func runImportMode() error {
+	startTime := time.Now()
+
+	if _, err := os.Stat(importFile); err != nil {
+		return fmt.Errorf("import file not found: %s", importFile)
+	}
+
+	if verbose {
+		fmt.Printf("Importing resources from: %s\n", importFile)
+	}
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(importOutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Clean directory if requested
+	if clean {
+		if err := cleanDirectory(importOutputDir); err != nil {
+			return fmt.Errorf("failed to clean output directory: %w", err)
+		}
+	}
+
+	importedCount, err := importAllResourcesFile(importFile, importOutputDir)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== Import Summary ===\n")
+	fmt.Printf("Successfully imported: %d resource files\n", importedCount)
+	fmt.Printf("Output directory: %s\n", importOutputDir)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("======================\n")
+
+	return nil
+}
+
+// importAllResourcesFile writes each resource block of a single-file collection to
+// its own file in outputDir. Blocks are streamed straight to their output file, so
+// memory use does not grow with the input size. With --split-by-namespace, each block
+// is further split into <outputDir>/<namespace>/<resource>.yaml, with cluster-scoped
+// items under _cluster; this needs one block in memory at a time to parse its List.
+// It returns the number of files written.
+func importAllResourcesFile(inputFile, outputDir string) (int, error) {
+	written := 0
+	seen := make(map[string]int)
+
+	err := streamAllResourcesFile(inputFile, func(blockName string) (io.WriteCloser, error) {
+		if isBlockedResource(blockName) {
+			if verbose {
+				fmt.Printf("  %s: SKIPPED - blocked by policy\n", blockName)
+			}
+			return discardSink{Writer: io.Discard}, nil
+		}
+
+		// The same resource name can appear under two groups (e.g. events)
+		seen[blockName]++
+		name := blockName
+		if seen[blockName] > 1 {
+			name = fmt.Sprintf("%s-%d", blockName, seen[blockName])
+		}
+
+		if splitByNamespace {
+			return &blockCollector{
+				name: blockName,
+				done: func(block resourceBlock) error {
+					n, err := writeBlockByNamespace(block, name, outputDir)
+					if err != nil {
+						if verbose {
+							fmt.Printf("  %s: ERROR - %v\n", block.Name, err)
+						}
+						return nil
+					}
+					written += n
+					return nil
+				},
+			}, nil
+		}
+
+		filePath := filepath.Join(outputDir, formatFilename(name, ""))
+		file, err := os.Create(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
+		recordOutputFile(filePath)
+
+		sink := &fileSink{file: file, writer: bufio.NewWriter(file), done: func() {
+			if verbose {
+				fmt.Printf("  %s: SUCCESS - Saved to %s\n", blockName, filePath)
+			}
+			written++
+		}}
+		if _, err := sink.Write([]byte(formatHeader(blockName, ""))); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
+
+		return sink, nil
+	})
+
+	return written, err
+}
+
+// writeBlockByNamespace splits the List in a resource block by item namespace
+func writeBlockByNamespace(block resourceBlock, name, outputDir string) (int, error) {
+	var list map[string]interface{}
+	if err := yaml.Unmarshal([]byte(block.Content), &list); err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", block.Name, err)
+	}
+
+	items, _ := list["items"].([]interface{})
+	byNamespace := make(map[string][]interface{})
+	for _, item := range items {
+		namespace := "_cluster"
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			if metadata, ok := itemMap["metadata"].(map[string]interface{}); ok {
+				if ns, ok := metadata["namespace"].(string); ok && ns != "" {
+					namespace = ns
+				}
+			}
+		}
+		byNamespace[namespace] = append(byNamespace[namespace], item)
+	}
+
+	var namespaces []string
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		dir := filepath.Join(outputDir, namespace)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+
+		yamlData, err := yaml.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      byNamespace[namespace],
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal %s to YAML: %w", block.Name, err)
+		}
+
+		filePath := filepath.Join(dir, formatFilename(name, ""))
+		if err := writeOutputFile(filePath, []byte(formatHeader(block.Name, "")+string(yamlData)), 0644); err != nil {
+			return 0, fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
+	}
+
+	if verbose {
+		fmt.Printf("  %s: SUCCESS - Saved %d items across %d namespaces\n", block.Name, len(items), len(namespaces))
+	}
+
+	return len(namespaces), nil
+}
+
+// fileSink streams a resource block to its output file
+type fileSink struct {
+	file   *os.File
+	writer *bufio.Writer
+	done   func()
+}
+
+func (f *fileSink) Write(p []byte) (int, error) {
+	return f.writer.Write(p)
+}
+
+func (f *fileSink) Close() error {
+	if err := f.writer.Flush(); err != nil {
+		f.file.Close()
+		return fmt.Errorf("failed to write file %s: %w", f.file.Name(), err)
+	}
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", f.file.Name(), err)
+	}
+	f.done()
+	return nil
+}
+
+// blockCollector buffers a whole resource block for callers that need to parse it
+type blockCollector struct {
+	name    string
+	content strings.Builder
+	done    func(resourceBlock) error
+}
+
+func (b *blockCollector) Write(p []byte) (int, error) {
+	return b.content.Write(p)
+}
+
+func (b *blockCollector) Close() error {
+	return b.done(resourceBlock{Name: b.name, Content: b.content.String()})
+}
+
+// parseAllResourcesFile splits a single-file collection into its resource blocks
+func parseAllResourcesFile(inputFile string) ([]resourceBlock, error) {
+	var blocks []resourceBlock
+
+	err := streamAllResourcesFile(inputFile, func(name string) (io.WriteCloser, error) {
+		return &blockCollector{name: name, done: func(block resourceBlock) error {
+			blocks = append(blocks, block)
+			return nil
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// streamAllResourcesFile reads a single-file collection line by line. For each
+// "--- # Resource: <name>" block it calls open, writes the block's lines to the
+// returned writer and closes it when the block ends.
+func streamAllResourcesFile(inputFile string, open func(name string) (io.WriteCloser, error)) error {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", inputFile, err)
+	}
+	defer file.Close()
+
+	var current io.WriteCloser
+	closeCurrent := func() error {
+		if current == nil {
+			return nil
+		}
+		err := current.Close()
+		current = nil
+		return err
+	}
+	// Release the open block on early returns
+	defer closeCurrent()
+
+	scanner := bufio.NewScanner(file)
+	// Allow long lines (e.g. large annotations or embedded certificates)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "--- # Resource:") {
+			if err := closeCurrent(); err != nil {
+				return err
+			}
+			parts := strings.SplitN(line, ":", 2)
+			if current, err = open(strings.TrimSpace(parts[1])); err != nil {
+				return err
+			}
+			continue
+		}
+		if current != nil {
+			if _, err := io.WriteString(current, line+"\n"); err != nil {
+				return fmt.Errorf("failed to write resource block: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", inputFile, err)
+	}
+
+	return closeCurrent()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// importFixture is a single-file collection with a namespaced resource, a
+// cluster-scoped one and a resource name that appears under two groups
+const importFixture = `# Generated by k8s-resource-collector
+--- # Resource: configmaps
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: app
+    namespace: shop
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: db
+    namespace: data
+--- # Resource: nodes
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: worker
+--- # Resource: events
+apiVersion: v1
+kind: List
+items: []
+--- # Resource: events
+apiVersion: v1
+kind: List
+items: []
+`
+
+func TestImportAllResourcesFile(t *testing.T) {
+	defer func(split bool, blocked []string) {
+		splitByNamespace, blockedResources = split, blocked
+	}(splitByNamespace, blockedResources)
+
+	tests := []struct {
+		name      string
+		split     bool
+		blocked   []string
+		wantFiles map[string][]string // file -> content it must hold
+		wantCount int
+	}{
+		{
+			name: "one file per resource block",
+			wantFiles: map[string][]string{
+				"configmaps.yaml": {"# Resource: configmaps", "name: app", "name: db"},
+				"nodes.yaml":      {"name: worker"},
+				"events.yaml":     {"# Resource: events"},
+				"events-2.yaml":   {"# Resource: events"},
+			},
+			wantCount: 4,
+		},
+		{
+			// Empty blocks have no namespace to be written to
+			name:  "split by namespace",
+			split: true,
+			wantFiles: map[string][]string{
+				"shop/configmaps.yaml": {"name: app"},
+				"data/configmaps.yaml": {"name: db"},
+				"_cluster/nodes.yaml":  {"name: worker"},
+			},
+			wantCount: 3,
+		},
+		{
+			name:      "blocked resources are skipped",
+			blocked:   []string{"configmaps"},
+			wantFiles: map[string][]string{"nodes.yaml": {"name: worker"}, "events.yaml": nil, "events-2.yaml": nil},
+			wantCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splitByNamespace, blockedResources = tt.split, tt.blocked
+			dir := t.TempDir()
+			input := filepath.Join(dir, "all-resources.yaml")
+			if err := os.WriteFile(input, []byte(importFixture), 0644); err != nil {
+				t.Fatal(err)
+			}
+			outputDir := filepath.Join(dir, "out")
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			count, err := importAllResourcesFile(input, outputDir)
+			if err != nil {
+				t.Fatalf("importAllResourcesFile() error = %v", err)
+			}
+			if count != tt.wantCount {
+				t.Errorf("importAllResourcesFile() = %d, want %d", count, tt.wantCount)
+			}
+
+			var got []string
+			filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					relative, _ := filepath.Rel(outputDir, path)
+					got = append(got, filepath.ToSlash(relative))
+				}
+				return nil
+			})
+			var want []string
+			for file := range tt.wantFiles {
+				want = append(want, file)
+			}
+			sort.Strings(got)
+			sort.Strings(want)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Fatalf("files = %v, want %v", got, want)
+			}
+
+			for file, contents := range tt.wantFiles {
+				data, _ := os.ReadFile(filepath.Join(outputDir, file))
+				for _, content := range contents {
+					if !strings.Contains(string(data), content) {
+						t.Errorf("%s missing %q:\n%s", file, content, data)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseAllResourcesFile(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "all-resources.yaml")
+	if err := os.WriteFile(input, []byte(importFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := parseAllResourcesFile(input)
+	if err != nil {
+		t.Fatalf("parseAllResourcesFile() error = %v", err)
+	}
+	var names []string
+	for _, block := range blocks {
+		names = append(names, block.Name)
+	}
+	if got, want := strings.Join(names, ","), "configmaps,nodes,events,events"; got != want {
+		t.Errorf("block names = %s, want %s", got, want)
+	}
+	if !strings.Contains(blocks[0].Content, "name: app") || strings.Contains(blocks[0].Content, "name: worker") {
+		t.Errorf("configmaps block holds the wrong items:\n%s", blocks[0].Content)
+	}
+}
+
+// recordingSink records what was written to it and whether it was closed
+type recordingSink struct {
+	strings.Builder
+	closed bool
+}
+
+func (r *recordingSink) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestStreamAllResourcesFile(t *testing.T) {
+	longLine := "    tls.crt: " + strings.Repeat("A", 200*1024)
+
+	tests := []struct {
+		name       string
+		input      string
+		wantBlocks []string
+		wantBodies []string
+		wantErr    bool
+	}{
+		{
+			name:       "lines before the first block are skipped",
+			input:      "# Generated by k8s-resource-collector\n--- # Resource: pods\nitems: []\n--- # Resource: nodes\nitems:\n- a\n",
+			wantBlocks: []string{"pods", "nodes"},
+			wantBodies: []string{"items: []\n", "items:\n- a\n"},
+		},
+		{
+			name:       "long lines are streamed",
+			input:      "--- # Resource: secrets\n" + longLine + "\n",
+			wantBlocks: []string{"secrets"},
+			wantBodies: []string{longLine + "\n"},
+		},
+		{
+			name:    "lines over the limit fail",
+			input:   "--- # Resource: secrets\n" + strings.Repeat("A", 2*1024*1024) + "\n",
+			wantErr: true,
+		},
+		{name: "no blocks", input: "apiVersion: v1\nkind: List\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := filepath.Join(t.TempDir(), "all-resources.yaml")
+			if err := os.WriteFile(input, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			var sinks []*recordingSink
+			err := streamAllResourcesFile(input, func(name string) (io.WriteCloser, error) {
+				names = append(names, name)
+				sink := &recordingSink{}
+				sinks = append(sinks, sink)
+				return sink, nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("streamAllResourcesFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, sink := range sinks {
+				if !sink.closed {
+					t.Errorf("block %s left open", names[i])
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.wantBlocks, ",") {
+				t.Fatalf("blocks = %v, want %v", names, tt.wantBlocks)
+			}
+			for i, sink := range sinks {
+				if sink.String() != tt.wantBodies[i] {
+					t.Errorf("block %s = %.80q, want %.80q", names[i], sink.String(), tt.wantBodies[i])
+				}
+			}
+		})
+	}
+}
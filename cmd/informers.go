@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Informer-backed listing (--watch-interval)
+//
+// Repeated snapshots of one cluster would re-List every resource each time.
+// Instead, the first List of a resource starts a shared informer for it: one
+// List to fill a local cache, then a watch that keeps the cache current. Later
+// Lists, and every later snapshot, are answered from the cache. This trades
+// memory (the whole cluster is held in memory) for far fewer requests.
+//
+// Resources that cannot be watched (no watch verb, metrics.k8s.io, RBAC that
+// allows list but not watch) fall back to a plain List every time. Lists with
+// a label or field selector are not served from the cache either.
+
+// informerDynamicClient answers List from per-resource informer caches
+type informerDynamicClient struct {
+	dynamic.Interface
+
+	mu      sync.Mutex
+	caches  map[schema.GroupVersionResource]*resourceCache
+	stopped bool
+}
+
+// resourceCache is the informer of one resource. ready is closed once the
+// cache has synced or the informer has been given up on (err or uncached set).
+type resourceCache struct {
+	informer cache.SharedIndexInformer
+	stop     chan struct{}
+	ready    chan struct{}
+	uncached bool
+	err      error
+}
+
+// newInformerDynamicClient wraps a dynamic client so that List is served from
+// informer caches; Stop ends every informer it started
+func newInformerDynamicClient(dynamicClient dynamic.Interface) *informerDynamicClient {
+	return &informerDynamicClient{
+		Interface: dynamicClient,
+		caches:    make(map[schema.GroupVersionResource]*resourceCache),
+	}
+}
+
+// Resource returns a client whose List reads the resource's informer cache
+func (c *informerDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	parent := c.Interface.Resource(gvr)
+	if strings.Contains(gvr.Resource, "/") {
+		return parent
+	}
+	return &cachedResource{NamespaceableResourceInterface: parent, client: c, gvr: gvr}
+}
+
+// Stop ends the watches of every informer
+func (c *informerDynamicClient) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	for _, rc := range c.caches {
+		if rc.informer != nil {
+			close(rc.stop)
+			rc.informer = nil
+		}
+	}
+}
+
+// CachedResources returns the number of resources served from a cache
+func (c *informerDynamicClient) CachedResources() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, rc := range c.caches {
+		if rc.informer != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// informer returns the synced informer of a resource, starting it on first
+// use; nil means the resource is listed directly
+func (c *informerDynamicClient) informer(ctx context.Context, gvr schema.GroupVersionResource) (cache.SharedIndexInformer, error) {
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		return nil, nil
+	}
+	rc, found := c.caches[gvr]
+	if !found {
+		rc = &resourceCache{stop: make(chan struct{}), ready: make(chan struct{})}
+		c.caches[gvr] = rc
+	}
+	c.mu.Unlock()
+
+	if !found {
+		c.startInformer(ctx, gvr, rc)
+	}
+
+	select {
+	case <-rc.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rc.err != nil {
+		return nil, rc.err
+	}
+	if rc.uncached {
+		return nil, nil
+	}
+	return rc.informer, nil
+}
+
+// startInformer runs the informer of a resource until its cache has synced.
+// The first List or watch error gives up on it: permanent errors (forbidden,
+// watch not supported) mark the resource uncached, and a timeout drops the
+// informer so that the next snapshot tries again.
+func (c *informerDynamicClient) startInformer(ctx context.Context, gvr schema.GroupVersionResource, rc *resourceCache) {
+	defer close(rc.ready)
+
+	informer := dynamicinformer.NewFilteredDynamicInformer(c.Interface, gvr, metav1.NamespaceAll, 0,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil).Informer()
+	failed := make(chan error, 1)
+	if err := informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	}); err != nil {
+		rc.uncached = true
+		return
+	}
+
+	go informer.Run(rc.stop)
+	synced := make(chan struct{})
+	go func() {
+		if cache.WaitForCacheSync(rc.stop, informer.HasSynced) {
+			close(synced)
+		}
+	}()
+
+	select {
+	case <-synced:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.stopped {
+			close(rc.stop)
+			rc.uncached = true
+			return
+		}
+		rc.informer = informer
+		if verbose {
+			fmt.Printf("  %s: caching with an informer\n", gvr.Resource)
+		}
+	case err := <-failed:
+		close(rc.stop)
+		rc.uncached = true
+		if verbose {
+			fmt.Printf("  %s: cannot be cached (%v), listing it directly\n", gvr.Resource, err)
+		}
+	case <-ctx.Done():
+		close(rc.stop)
+		rc.err = ctx.Err()
+		c.mu.Lock()
+		delete(c.caches, gvr)
+		c.mu.Unlock()
+	}
+}
+
+// cachedResource is the cluster-wide client of a cached resource
+type cachedResource struct {
+	dynamic.NamespaceableResourceInterface
+	client *informerDynamicClient
+	gvr    schema.GroupVersionResource
+}
+
+// Namespace returns a client whose List reads one namespace of the cache
+func (r *cachedResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &cachedNamespacedResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace), resource: r, namespace: namespace}
+}
+
+// List returns the cached objects of every namespace
+func (r *cachedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return r.list(ctx, opts, metav1.NamespaceAll, r.NamespaceableResourceInterface)
+}
+
+// list serves a List from the cache, or from the API when the resource is not
+// cached or the options select a subset the cache cannot filter
+func (r *cachedResource) list(ctx context.Context, opts metav1.ListOptions, namespace string, direct dynamic.ResourceInterface) (*unstructured.UnstructuredList, error) {
+	if opts.LabelSelector != "" || opts.FieldSelector != "" || opts.Continue != "" {
+		return direct.List(ctx, opts)
+	}
+	informer, err := r.client.informer(ctx, r.gvr)
+	if err != nil {
+		return nil, err
+	}
+	if informer == nil {
+		return direct.List(ctx, opts)
+	}
+
+	var objects []interface{}
+	if namespace == metav1.NamespaceAll {
+		objects = informer.GetStore().List()
+	} else if objects, err = informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace); err != nil {
+		return nil, err
+	}
+	return cachedList(r.gvr, objects, informer.LastSyncResourceVersion()), nil
+}
+
+// cachedNamespacedResource is the client of one namespace of a cached resource
+type cachedNamespacedResource struct {
+	dynamic.ResourceInterface
+	resource  *cachedResource
+	namespace string
+}
+
+// List returns the cached objects of the namespace
+func (r *cachedNamespacedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return r.resource.list(ctx, opts, r.namespace, r.ResourceInterface)
+}
+
+// cachedList copies cached objects into a list ordered like the API server
+// orders them, by namespace and name. Collectors modify the objects they
+// list, so the cache must never be handed out.
+func cachedList(gvr schema.GroupVersionResource, objects []interface{}, resourceVersion string) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetAPIVersion(gvr.GroupVersion().String())
+	list.SetResourceVersion(resourceVersion)
+	for _, object := range objects {
+		if item, ok := object.(*unstructured.Unstructured); ok {
+			list.Items = append(list.Items, *item.DeepCopy())
+		}
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].GetNamespace() != list.Items[j].GetNamespace() {
+			return list.Items[i].GetNamespace() < list.Items[j].GetNamespace()
+		}
+		return list.Items[i].GetName() < list.Items[j].GetName()
+	})
+	if len(list.Items) > 0 {
+		list.SetKind(list.Items[0].GetKind() + "List")
+	}
+	return list
+}
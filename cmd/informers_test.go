@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	informerPodsGVR    = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	informerMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+)
+
+func informerObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	return object
+}
+
+// countLists counts the List requests the fake client received for a resource
+func countLists(client *dynamicfake.FakeDynamicClient, gvr schema.GroupVersionResource) int {
+	count := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "list" && action.GetResource() == gvr {
+			count++
+		}
+	}
+	return count
+}
+
+func TestInformerDynamicClient(t *testing.T) {
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{informerPodsGVR: "PodList", informerMetricsGVR: "PodMetricsList"},
+		informerObject("v1", "Pod", "prod", "web"),
+		informerObject("v1", "Pod", "dev", "api"),
+	)
+	metricsClient := fakeClient.Resource(informerMetricsGVR).Namespace("prod")
+	if _, err := metricsClient.Create(context.Background(), informerObject("metrics.k8s.io/v1beta1", "PodMetrics", "prod", "web"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// metrics.k8s.io cannot be watched
+	fakeClient.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		if action.GetResource().Group == "metrics.k8s.io" {
+			return true, nil, errors.New("the server does not allow this method on the requested resource")
+		}
+		return false, nil, nil
+	})
+
+	client := newInformerDynamicClient(fakeClient)
+	defer client.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := client.Resource(informerPodsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list.Items) != 2 || list.Items[0].GetNamespace() != "dev" || list.GetKind() != "PodList" {
+		t.Fatalf("List() = %d items (%s first), kind %q; want dev/api and prod/web as a PodList", len(list.Items), list.Items[0].GetNamespace(), list.GetKind())
+	}
+
+	t.Run("later lists read the cache", func(t *testing.T) {
+		list.Items[0].SetLabels(map[string]string{"changed": "by a collector"})
+
+		namespaced, err := client.Resource(informerPodsGVR).Namespace("prod").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(namespaced.Items) != 1 || namespaced.Items[0].GetName() != "web" {
+			t.Errorf("Namespace(prod).List() = %v, want prod/web", namespaced.Items)
+		}
+		again, err := client.Resource(informerPodsGVR).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.Items[0].GetLabels() != nil {
+			t.Errorf("a change to a listed object leaked into the cache: %v", again.Items[0].GetLabels())
+		}
+		if lists := countLists(fakeClient, informerPodsGVR); lists != 1 {
+			t.Errorf("%d List requests for pods, want the informer's 1", lists)
+		}
+	})
+
+	t.Run("the cache follows changes", func(t *testing.T) {
+		if _, err := fakeClient.Resource(informerPodsGVR).Namespace("prod").Create(ctx, informerObject("v1", "Pod", "prod", "worker"), metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			list, err := client.Resource(informerPodsGVR).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list.Items) == 3 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("cache still has %d pods, want 3", len(list.Items))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("selectors and unwatchable resources are listed directly", func(t *testing.T) {
+		if _, err := client.Resource(informerPodsGVR).List(ctx, metav1.ListOptions{LabelSelector: "app=web"}); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			metrics, err := client.Resource(informerMetricsGVR).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("List() of metrics error = %v", err)
+			}
+			if len(metrics.Items) != 1 {
+				t.Errorf("List() of metrics = %d items, want 1", len(metrics.Items))
+			}
+		}
+		if lists := countLists(fakeClient, informerPodsGVR); lists != 2 {
+			t.Errorf("%d List requests for pods, want the informer's and the selector's", lists)
+		}
+		// The informer's own List, then one per call once it gave up
+		if lists := countLists(fakeClient, informerMetricsGVR); lists != 3 {
+			t.Errorf("%d List requests for metrics, want 3", lists)
+		}
+		if cached := client.CachedResources(); cached != 1 {
+			t.Errorf("CachedResources() = %d, want 1", cached)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// truncatedResources are the resources left out of the current run once
+// --max-total-items was reached
+var truncatedResources []string
+
+// itemCapReached reports whether the collection already holds --max-total-items
+// items; the resource that crosses the cap is still written in full
+func itemCapReached(itemCount int) bool {
+	return maxTotalItems > 0 && itemCount >= maxTotalItems
+}
+
+// skipForItemCap records a resource that was not collected because of the cap
+func skipForItemCap(resourceName, groupVersion string) {
+	if len(truncatedResources) == 0 {
+		fmt.Printf("Warning: --max-total-items %d reached, not collecting further resources\n", maxTotalItems)
+	}
+	truncatedResources = append(truncatedResources, resourceName+" ("+groupVersion+")")
+	emitEvent(eventResourceSkipped, map[string]interface{}{
+		"resource":     resourceName,
+		"groupVersion": groupVersion,
+		"reason":       "max total items reached",
+	})
+}
+
+// printItemCapSummary reports a collection truncated by --max-total-items
+func printItemCapSummary(itemCount int) {
+	if len(truncatedResources) == 0 {
+		return
+	}
+	fmt.Printf("TRUNCATED: item cap of %d reached at %d items, %d resources not collected\n", maxTotalItems, itemCount, len(truncatedResources))
+	if verbose {
+		fmt.Printf("  Not collected: %s\n", strings.Join(truncatedResources, ", "))
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestItemCapReached(t *testing.T) {
+	defer func(saved int) { maxTotalItems = saved }(maxTotalItems)
+
+	tests := []struct {
+		name      string
+		cap       int
+		itemCount int
+		want      bool
+	}{
+		{"no limit", 0, 1000000, false},
+		{"below cap", 100, 99, false},
+		{"at cap", 100, 100, true},
+		{"past cap", 100, 250, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxTotalItems = tt.cap
+			if got := itemCapReached(tt.itemCount); got != tt.want {
+				t.Errorf("itemCapReached(%d) with cap %d = %v, want %v", tt.itemCount, tt.cap, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipForItemCap(t *testing.T) {
+	defer func(saved []string, cap int) {
+		truncatedResources = saved
+		maxTotalItems = cap
+	}(truncatedResources, maxTotalItems)
+	truncatedResources = nil
+	maxTotalItems = 10
+
+	skipForItemCap("configmaps", "v1")
+	skipForItemCap("deployments", "apps/v1")
+
+	want := []string{"configmaps (v1)", "deployments (apps/v1)"}
+	if !reflect.DeepEqual(truncatedResources, want) {
+		t.Errorf("truncatedResources = %v, want %v", truncatedResources, want)
+	}
+}
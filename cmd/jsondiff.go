@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	diffFormatText = "text"
+	diffFormatJSON = "json"
+)
+
+// diffFormat is the --diff-format of the comparison report
+var diffFormat = diffFormatText
+
+// diffCluster is one side of a JSON comparison report
+type diffCluster struct {
+	Name      string `json:"name"`
+	Resources int    `json:"resources"`
+}
+
+// jsonDiffReport is the comparison report written with --diff-format json.
+// Unlike the text report, it always lists the common resources.
+type jsonDiffReport struct {
+	GeneratedAt    string      `json:"generatedAt"`
+	Cluster1       diffCluster `json:"cluster1"`
+	Cluster2       diffCluster `json:"cluster2"`
+	OnlyInCluster1 []string    `json:"onlyInCluster1"`
+	OnlyInCluster2 []string    `json:"onlyInCluster2"`
+	Common         []string    `json:"common"`
+}
+
+// validateDiffFormat checks --diff-format against the other comparison flags
+func validateDiffFormat() error {
+	switch diffFormat {
+	case diffFormatText:
+		return nil
+	case diffFormatJSON:
+	default:
+		return fmt.Errorf("invalid --diff-format %q: must be text or json", diffFormat)
+	}
+
+	if !isComparisonMode() && !isMustGatherComparisonMode() {
+		return fmt.Errorf("--diff-format requires comparison mode (--kubeconfig1 and --kubeconfig2, or --must-gather1 and --must-gather2)")
+	}
+	if compareSummaryOnly {
+		return fmt.Errorf("--compare-summary-only writes no diff report and cannot be used with --diff-format json")
+	}
+	if deepDiff || compareLabel != "" || changedNamespaces {
+		return fmt.Errorf("--deep, --compare-labels and --only-changed-namespaces add text sections and cannot be used with --diff-format json")
+	}
+	return nil
+}
+
+// diffFileExtension returns the extension of the comparison report file
+func diffFileExtension() string {
+	if diffFormat == diffFormatJSON {
+		return ".json"
+	}
+	return ".txt"
+}
+
+// formatJSONDiff renders the --diff-format json comparison report
+func formatJSONDiff(resources1, resources2 []string, cluster1Name, cluster2Name string) ([]byte, error) {
+	report := jsonDiffReport{
+		GeneratedAt:    time.Now().Format(time.RFC3339),
+		Cluster1:       diffCluster{Name: cluster1Name, Resources: len(resources1)},
+		Cluster2:       diffCluster{Name: cluster2Name, Resources: len(resources2)},
+		OnlyInCluster1: nonNilStrings(findUniqueResources(resources1, resources2)),
+		OnlyInCluster2: nonNilStrings(findUniqueResources(resources2, resources1)),
+		Common:         nonNilStrings(findCommonResources(resources1, resources2)),
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal diff report: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// nonNilStrings makes empty lists encode as [] rather than null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
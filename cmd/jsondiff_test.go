@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateDiffJSON(t *testing.T) {
+	defer func(format string, list bool) { diffFormat, listCommon = format, list }(diffFormat, listCommon)
+	diffFormat, listCommon = diffFormatJSON, false
+
+	dir := t.TempDir()
+	file1 := filepath.Join(dir, "cluster-a.yaml")
+	file2 := filepath.Join(dir, "cluster-b.yaml")
+	if err := os.WriteFile(file1, []byte("--- # Resource: pods\n--- # Resource: services\n--- # Resource: widgets\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file2, []byte("--- # Resource: pods\n--- # Resource: services\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "diff.json")
+	if err := generateDiff(file1, file2, output, "cluster-a", "cluster-b"); err != nil {
+		t.Fatalf("generateDiff() error = %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report jsonDiffReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, data)
+	}
+	want := jsonDiffReport{
+		GeneratedAt:    report.GeneratedAt,
+		Cluster1:       diffCluster{Name: "cluster-a", Resources: 3},
+		Cluster2:       diffCluster{Name: "cluster-b", Resources: 2},
+		OnlyInCluster1: []string{"widgets"},
+		OnlyInCluster2: []string{},
+		Common:         []string{"pods", "services"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+}
+
+func TestValidateDiffFormat(t *testing.T) {
+	defer func(format, kc1, kc2, label string, summary, deep bool) {
+		diffFormat, kubeconfig1, kubeconfig2, compareLabel, compareSummaryOnly, deepDiff = format, kc1, kc2, label, summary, deep
+	}(diffFormat, kubeconfig1, kubeconfig2, compareLabel, compareSummaryOnly, deepDiff)
+
+	tests := []struct {
+		name       string
+		format     string
+		comparison bool
+		deep       bool
+		wantErr    bool
+	}{
+		{name: "text outside comparison mode", format: "text"},
+		{name: "json in comparison mode", format: "json", comparison: true},
+		{name: "json outside comparison mode", format: "json", wantErr: true},
+		{name: "json with --deep", format: "json", comparison: true, deep: true, wantErr: true},
+		{name: "unknown format", format: "xml", comparison: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffFormat, compareLabel, compareSummaryOnly, deepDiff = tt.format, "", false, tt.deep
+			kubeconfig1, kubeconfig2 = "", ""
+			if tt.comparison {
+				kubeconfig1, kubeconfig2 = "a.kubeconfig", "b.kubeconfig"
+			}
+			if err := validateDiffFormat(); (err != nil) != tt.wantErr {
+				t.Errorf("validateDiffFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
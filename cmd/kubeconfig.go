@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// pathList is a flag that accumulates paths: repeating the flag or passing a
+// comma-separated value both add paths, stored comma-joined
+type pathList string
+
+func (p *pathList) String() string {
+	return string(*p)
+}
+
+func (p *pathList) Set(value string) error {
+	if *p == "" {
+		*p = pathList(value)
+	} else {
+		*p = pathList(string(*p) + "," + value)
+	}
+	return nil
+}
+
+// kubeconfigPaths returns the kubeconfig files to load: the comma-separated
+// --kubeconfig value, or else the KUBECONFIG list (separated like PATH)
+func kubeconfigPaths(flagValue string) []string {
+	if flagValue != "" {
+		return splitKubeconfigPaths(flagValue, ",")
+	}
+	return splitKubeconfigPaths(os.Getenv("KUBECONFIG"), string(filepath.ListSeparator))
+}
+
+// splitKubeconfigPaths splits a list of paths, dropping blanks
+func splitKubeconfigPaths(value string, separator string) []string {
+	var paths []string
+	for _, path := range strings.Split(value, separator) {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// mergedKubeconfigRules returns loading rules that merge several kubeconfig
+// files the way kubectl merges a KUBECONFIG list: for each map key (context,
+// cluster, user) the first file that sets it wins, and the first file with a
+// current-context picks it
+func mergedKubeconfigRules(paths []string) (*clientcmd.ClientConfigLoadingRules, error) {
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, fmt.Errorf("kubeconfig file not found at %s", path)
+		}
+	}
+	return &clientcmd.ClientConfigLoadingRules{Precedence: paths}, nil
+}
+
+// buildMergedConfig builds a rest.Config from several kubeconfig files
+func buildMergedConfig(paths []string) (*rest.Config, error) {
+	rules, err := mergedKubeconfigRules(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := contextOverrides(rules)
+	if err := checkExecPlugin(rules, overrides); err != nil {
+		return nil, err
+	}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build config from kubeconfig files %s: %w", strings.Join(paths, ", "), err)
+	}
+
+	return config, nil
+}
+
+// loadKubeconfig loads and merges the kubeconfig files of a --kubeconfig value
+func loadKubeconfig(value string) (*clientcmdapi.Config, error) {
+	paths := splitKubeconfigPaths(value, ",")
+	if len(paths) == 1 {
+		return clientcmd.LoadFromFile(paths[0])
+	}
+
+	rules, err := mergedKubeconfigRules(paths)
+	if err != nil {
+		return nil, err
+	}
+	return rules.Load()
+}
+
+// buildConfigFromFile builds a rest.Config from a single kubeconfig file,
+// falling back to another context when it has no current-context
+func buildConfigFromFile(path string) (*rest.Config, error) {
+	rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: path}
+	overrides := contextOverrides(rules)
+	if err := checkExecPlugin(rules, overrides); err != nil {
+		return nil, err
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// contextOverrides selects the --context context, or fallbackContext for
+// kubeconfigs without a current-context; otherwise the kubeconfig's own
+// current-context is used
+func contextOverrides(rules *clientcmd.ClientConfigLoadingRules) *clientcmd.ConfigOverrides {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeContext != "" {
+		overrides.CurrentContext = kubeContext
+	} else if config, err := rules.Load(); err == nil && config.CurrentContext == "" {
+		overrides.CurrentContext = fallbackContext(config)
+	}
+	return overrides
+}
+
+// checkExecPlugin fails early with an actionable error when the user of the
+// selected context authenticates through an exec plugin (aws-iam-authenticator,
+// gke-gcloud-auth-plugin, ...) that is not installed. client-go would only
+// report it later as an opaque transport error on the first request.
+func checkExecPlugin(rules *clientcmd.ClientConfigLoadingRules, overrides *clientcmd.ConfigOverrides) error {
+	config, err := rules.Load()
+	if err != nil {
+		return nil // Reported by the client config itself
+	}
+
+	contextName := config.CurrentContext
+	if overrides.CurrentContext != "" {
+		contextName = overrides.CurrentContext
+	}
+	context, ok := config.Contexts[contextName]
+	if !ok {
+		return nil
+	}
+	authInfo, ok := config.AuthInfos[context.AuthInfo]
+	if !ok || authInfo.Exec == nil || authInfo.Exec.Command == "" {
+		return nil
+	}
+
+	if _, err := exec.LookPath(authInfo.Exec.Command); err == nil {
+		return nil
+	}
+
+	message := fmt.Sprintf("kubeconfig user %q authenticates with the exec plugin %q, which was not found in PATH; install it or add its directory to PATH",
+		context.AuthInfo, authInfo.Exec.Command)
+	if hint := execPluginHint(authInfo.Exec.Command); hint != "" {
+		message += " (" + hint + ")"
+	}
+	if authInfo.Exec.InstallHint != "" {
+		message += "\n" + strings.TrimSpace(authInfo.Exec.InstallHint)
+	}
+	return fmt.Errorf("%s", message)
+}
+
+// execPluginHint names where the common cloud exec plugins come from
+func execPluginHint(command string) string {
+	switch filepath.Base(command) {
+	case "aws-iam-authenticator":
+		return "EKS: https://github.com/kubernetes-sigs/aws-iam-authenticator, or regenerate the kubeconfig with \"aws eks update-kubeconfig\" to use the aws CLI"
+	case "aws":
+		return "EKS: install the AWS CLI v2"
+	case "gke-gcloud-auth-plugin":
+		return "GKE: gcloud components install gke-gcloud-auth-plugin"
+	case "kubelogin":
+		return "AKS: https://azure.github.io/kubelogin/"
+	}
+	return ""
+}
+
+// fallbackContext returns the context to use when a kubeconfig has no
+// current-context, as generated by "kubectl config set-cluster" automation:
+// the first context by name, or "" when none is defined
+func fallbackContext(config *clientcmdapi.Config) string {
+	var names []string
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// clusterNameFromServer derives a cluster name from the server host of the
+// first cluster by name, e.g. "api.prod.example.com" for
+// https://api.prod.example.com:6443
+func clusterNameFromServer(config *clientcmdapi.Config) string {
+	var names []string
+	for name := range config.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		server, err := url.Parse(config.Clusters[name].Server)
+		if err == nil && server.Hostname() != "" {
+			return server.Hostname()
+		}
+	}
+	return ""
+}
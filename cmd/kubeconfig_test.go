@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// writeKubeconfig writes a kubeconfig file into dir and returns its path
+func writeKubeconfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPathListSet(t *testing.T) {
+	var paths pathList
+	for _, value := range []string{"a.yaml", "b.yaml,c.yaml"} {
+		if err := paths.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	if got := paths.String(); got != "a.yaml,b.yaml,c.yaml" {
+		t.Errorf("pathList = %q, want a.yaml,b.yaml,c.yaml", got)
+	}
+}
+
+func TestKubeconfigPaths(t *testing.T) {
+	separator := string(filepath.ListSeparator)
+	t.Setenv("KUBECONFIG", strings.Join([]string{"/env/a", "", " /env/b "}, separator))
+
+	tests := []struct {
+		name      string
+		flagValue string
+		want      []string
+	}{
+		{"flag wins over KUBECONFIG", "/flag/a, /flag/b,", []string{"/flag/a", "/flag/b"}},
+		{"KUBECONFIG without the flag", "", []string{"/env/a", "/env/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kubeconfigPaths(tt.flagValue); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kubeconfigPaths(%q) = %v, want %v", tt.flagValue, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKubeconfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	first := writeKubeconfig(t, dir, "first", `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: shared
+  cluster:
+    server: https://first.example.com
+contexts:
+- name: prod
+  context:
+    cluster: shared
+    user: admin
+users:
+- name: admin
+  user:
+    token: first
+`)
+	second := writeKubeconfig(t, dir, "second", `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: shared
+  cluster:
+    server: https://second.example.com
+- name: dev
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+users:
+- name: admin
+  user:
+    token: second
+`)
+
+	config, err := loadKubeconfig(first + "," + second)
+	if err != nil {
+		t.Fatalf("loadKubeconfig() error = %v", err)
+	}
+
+	if config.CurrentContext != "prod" {
+		t.Errorf("current-context = %q, want prod from the first file", config.CurrentContext)
+	}
+	if got := config.Clusters["shared"].Server; got != "https://first.example.com" {
+		t.Errorf("shared cluster server = %q, want the first file's", got)
+	}
+	if got := config.AuthInfos["admin"].Token; got != "first" {
+		t.Errorf("admin token = %q, want the first file's", got)
+	}
+	if _, ok := config.Contexts["dev"]; !ok {
+		t.Error("context dev from the second file was not merged")
+	}
+
+	if _, err := loadKubeconfig(first + "," + filepath.Join(dir, "missing")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("loadKubeconfig() with a missing file error = %v, want not found", err)
+	}
+}
+
+func TestGetClusterNameFallback(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		kubeconfig string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name: "current context",
+			kubeconfig: `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod-cluster
+`,
+			want: "prod-cluster",
+		},
+		{
+			name: "first context by name without current-context",
+			kubeconfig: `apiVersion: v1
+kind: Config
+clusters:
+- name: a-cluster
+  cluster:
+    server: https://a.example.com
+- name: b-cluster
+  cluster:
+    server: https://b.example.com
+contexts:
+- name: b
+  context:
+    cluster: b-cluster
+- name: a
+  context:
+    cluster: a-cluster
+`,
+			want: "a-cluster",
+		},
+		{
+			name: "server host without contexts",
+			kubeconfig: `apiVersion: v1
+kind: Config
+clusters:
+- name: generated
+  cluster:
+    server: https://api.prod.example.com:6443
+`,
+			want: "api.prod.example.com",
+		},
+		{
+			name:       "nothing to fall back to",
+			kubeconfig: "apiVersion: v1\nkind: Config\n",
+			wantErr:    true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeKubeconfig(t, dir, fmt.Sprintf("kubeconfig-%d", i), tt.kubeconfig)
+			got, err := getClusterName(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getClusterName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getClusterName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildConfigFromFileFallbackContext(t *testing.T) {
+	path := writeKubeconfig(t, t.TempDir(), "kubeconfig", `apiVersion: v1
+kind: Config
+clusters:
+- name: a-cluster
+  cluster:
+    server: https://a.example.com
+- name: b-cluster
+  cluster:
+    server: https://b.example.com
+contexts:
+- name: b
+  context:
+    cluster: b-cluster
+    user: admin
+- name: a
+  context:
+    cluster: a-cluster
+    user: admin
+users:
+- name: admin
+  user:
+    token: secret
+`)
+
+	config, err := buildConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("buildConfigFromFile() error = %v", err)
+	}
+	if config.Host != "https://a.example.com" {
+		t.Errorf("host = %q, want the first context's cluster https://a.example.com", config.Host)
+	}
+}
+
+func TestCheckExecPlugin(t *testing.T) {
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "kubelogin"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	kubeconfig := func(user string) string {
+		return `apiVersion: v1
+kind: Config
+current-context: main
+clusters:
+- name: main
+  cluster:
+    server: https://main.example.com
+contexts:
+- name: main
+  context:
+    cluster: main
+    user: main
+users:
+- name: main
+  user:
+` + user
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		wantErrs []string
+	}{
+		{name: "token user", user: "    token: secret\n"},
+		{
+			name: "installed plugin",
+			user: "    exec:\n      apiVersion: client.authentication.k8s.io/v1beta1\n      command: kubelogin\n",
+		},
+		{
+			name: "missing plugin with a known hint",
+			user: "    exec:\n      apiVersion: client.authentication.k8s.io/v1beta1\n      command: gke-gcloud-auth-plugin\n      installHint: Install it with gcloud.\n",
+			wantErrs: []string{
+				`kubeconfig user "main" authenticates with the exec plugin "gke-gcloud-auth-plugin", which was not found in PATH`,
+				"(GKE: gcloud components install gke-gcloud-auth-plugin)",
+				"\nInstall it with gcloud.",
+			},
+		},
+		{
+			name:     "missing unknown plugin",
+			user:     "    exec:\n      apiVersion: client.authentication.k8s.io/v1beta1\n      command: corp-login\n",
+			wantErrs: []string{`exec plugin "corp-login", which was not found in PATH; install it or add its directory to PATH`},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeKubeconfig(t, dir, fmt.Sprintf("kubeconfig-%d", i), kubeconfig(tt.user))
+			err := checkExecPlugin(&clientcmd.ClientConfigLoadingRules{ExplicitPath: path}, &clientcmd.ConfigOverrides{})
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("checkExecPlugin() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("checkExecPlugin() error = nil, want a missing plugin error")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("checkExecPlugin() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecPluginHint(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"aws-iam-authenticator", "EKS: https://github.com/kubernetes-sigs/aws-iam-authenticator"},
+		{"/usr/local/bin/aws", "EKS: install the AWS CLI v2"},
+		{"kubelogin", "AKS: https://azure.github.io/kubelogin/"},
+		{"corp-login", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := execPluginHint(tt.command); !strings.HasPrefix(got, tt.want) || (tt.want == "") != (got == "") {
+				t.Errorf("execPluginHint(%q) = %q, want it to start with %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
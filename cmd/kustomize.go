@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// kustomizationFile is the kustomize base written at the root of the output directory
+const kustomizationFile = "kustomization.yaml"
+
+// kustomizeResources are the object files written in the current run,
+// relative to the output directory
+var kustomizeResources []string
+
+// writeKustomizeObjects writes each object of a list to its own file,
+// <namespace>/<kind>[.<group>]-<name>.yaml, with cluster-scoped objects under
+// _cluster/. Status is dropped since it cannot be applied.
+func writeKustomizeObjects(outputDir string, list *unstructured.UnstructuredList) error {
+	for i := range list.Items {
+		item := list.Items[i].DeepCopy()
+		delete(item.Object, "status")
+
+		relativePath, err := objectFilePath(outputDir, item)
+		if err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(item.Object)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s %s to YAML: %w", item.GetKind(), item.GetName(), err)
+		}
+		if err := writeFileAtomic(filepath.Join(outputDir, relativePath), data); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", relativePath, err)
+		}
+		kustomizeResources = append(kustomizeResources, filepath.ToSlash(relativePath))
+	}
+	return nil
+}
+
+// objectFilePath returns <namespace>/<kind>[.<group>]-<name>.yaml for an
+// object, relative to dir, creating its namespace directory under dir
+func objectFilePath(dir string, item *unstructured.Unstructured) (string, error) {
+	namespaceDir := item.GetNamespace()
+	if namespaceDir == "" {
+		namespaceDir = "_cluster"
+	}
+	if err := os.MkdirAll(filepath.Join(dir, namespaceDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", filepath.Join(dir, namespaceDir), err)
+	}
+
+	kind := strings.ToLower(item.GetKind())
+	if group := item.GroupVersionKind().Group; group != "" {
+		kind += "." + group
+	}
+	return filepath.Join(namespaceDir, formatFilename(kind+"-"+item.GetName(), "")), nil
+}
+
+// writeKustomization lists every written object file in kustomization.yaml,
+// so the output directory can be used as a kustomize base
+func writeKustomization(outputDir string) error {
+	if !kustomizeBase {
+		return nil
+	}
+
+	sort.Strings(kustomizeResources)
+	kustomization := map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  kustomizeResources,
+	}
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", kustomizationFile, err)
+	}
+
+	path := filepath.Join(outputDir, kustomizationFile)
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	fmt.Printf("Kustomization: %s (%d resources)\n", path, len(kustomizeResources))
+	return nil
+}
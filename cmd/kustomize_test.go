@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestObjectFilePath(t *testing.T) {
+	dir := t.TempDir()
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment"}}
+	deployment.SetNamespace("shop")
+	deployment.SetName("web")
+	namespace := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Namespace"}}
+	namespace.SetName("shop")
+
+	tests := []struct {
+		item *unstructured.Unstructured
+		want string
+	}{
+		{deployment, filepath.Join("shop", "deployment.apps-web.yaml")},
+		{namespace, filepath.Join("_cluster", "namespace-shop.yaml")},
+	}
+
+	for _, tt := range tests {
+		got, err := objectFilePath(dir, tt.item)
+		if err != nil {
+			t.Fatalf("objectFilePath(%s) error = %v", tt.item.GetName(), err)
+		}
+		if got != tt.want {
+			t.Errorf("objectFilePath(%s %s) = %q, want %q", tt.item.GetKind(), tt.item.GetName(), got, tt.want)
+		}
+		if info, err := os.Stat(filepath.Join(dir, filepath.Dir(got))); err != nil || !info.IsDir() {
+			t.Errorf("namespace directory of %s not created: %v", got, err)
+		}
+	}
+}
+
+func TestWriteKustomization(t *testing.T) {
+	defer func(enabled bool, resources []string) {
+		kustomizeBase, kustomizeResources = enabled, resources
+	}(kustomizeBase, kustomizeResources)
+	kustomizeBase, kustomizeResources = true, nil
+
+	dir := t.TempDir()
+	configMap := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"data":       map[string]interface{}{"mode": "fast"},
+		"status":     map[string]interface{}{"observed": true},
+	}}
+	configMap.SetNamespace("shop")
+	configMap.SetName("settings")
+	clusterRole := unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole"}}
+	clusterRole.SetName("reader")
+
+	if err := writeKustomizeObjects(dir, &unstructured.UnstructuredList{Items: []unstructured.Unstructured{configMap, clusterRole}}); err != nil {
+		t.Fatalf("writeKustomizeObjects() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "shop", "configmap-settings.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "status") || !strings.Contains(string(data), "mode: fast") {
+		t.Errorf("object file =\n%s\nwant data without status", data)
+	}
+	if _, ok := configMap.Object["status"]; !ok {
+		t.Error("writeKustomizeObjects() dropped status from the collected object")
+	}
+
+	if err := writeKustomization(dir); err != nil {
+		t.Fatalf("writeKustomization() error = %v", err)
+	}
+	kustomization, err := os.ReadFile(filepath.Join(dir, kustomizationFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- _cluster/clusterrole.rbac.authorization.k8s.io-reader.yaml
+- shop/configmap-settings.yaml
+`
+	if string(kustomization) != want {
+		t.Errorf("kustomization.yaml =\n%s\nwant\n%s", kustomization, want)
+	}
+}
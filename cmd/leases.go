@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// leasesGVR is the leader-election and heartbeat Lease resource
+var leasesGVR = schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}
+
+// leaseHolder is the summary of one Lease
+type leaseHolder struct {
+	Key         string // namespace/name
+	Holder      string
+	Age         time.Duration // since the last renewal; negative when never renewed
+	Duration    int64
+	Transitions int64
+}
+
+// runLeasesMode collects Leases and summarizes who holds each one and how long
+// ago it was renewed; a lease renewed later than its duration is stale, and a
+// high transition count points at leader-election flapping
+func runLeasesMode(dynamicClient dynamic.Interface) error {
+	startTime := time.Now()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	list, err := collectFocusedList(dynamicClient, leasesGVR)
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(outputDir, formatFilename(leasesGVR.Resource, leasesGVR.GroupVersion().String()))
+
+	var holders []leaseHolder
+	stale := 0
+	for i := range list.Items {
+		h := leaseHolderOf(&list.Items[i], startTime)
+		if isStaleLease(h) {
+			stale++
+		}
+		holders = append(holders, h)
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i].Key < holders[j].Key })
+
+	report := formatLeasesReport(holders)
+	reportPath := filepath.Join(outputDir, "leases-summary.txt")
+	if err := writeOutputFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reportPath, err)
+	}
+	fmt.Print(report)
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== Lease Summary ===\n")
+	fmt.Printf("Leases: %d (%d stale)\n", len(holders), stale)
+	fmt.Printf("Saved to: %s\n", filePath)
+	fmt.Printf("Summary: %s\n", reportPath)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("=====================\n")
+
+	return nil
+}
+
+// leaseHolderOf reads the holder and renewal details from a Lease's .spec
+func leaseHolderOf(lease *unstructured.Unstructured, now time.Time) leaseHolder {
+	h := leaseHolder{Key: lease.GetNamespace() + "/" + lease.GetName(), Age: -1}
+
+	h.Holder, _, _ = unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	h.Duration, _, _ = unstructured.NestedInt64(lease.Object, "spec", "leaseDurationSeconds")
+	h.Transitions, _, _ = unstructured.NestedInt64(lease.Object, "spec", "leaseTransitions")
+
+	if renewTime, ok, _ := unstructured.NestedString(lease.Object, "spec", "renewTime"); ok {
+		if renewed, err := time.Parse(time.RFC3339Nano, renewTime); err == nil {
+			h.Age = now.Sub(renewed)
+		}
+	}
+
+	return h
+}
+
+// isStaleLease reports whether a held lease was not renewed within its duration
+func isStaleLease(h leaseHolder) bool {
+	if h.Holder == "" || h.Duration <= 0 {
+		return false
+	}
+	return h.Age < 0 || h.Age > time.Duration(h.Duration)*time.Second
+}
+
+// formatLeasesReport renders one row per Lease, stale ones marked
+func formatLeasesReport(holders []leaseHolder) string {
+	var report strings.Builder
+	report.WriteString("=== Leases ===\n\n")
+
+	table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "LEASE\tHOLDER\tRENEWED\tDURATION\tTRANSITIONS\tSTATE")
+	for _, h := range holders {
+		holder := h.Holder
+		if holder == "" {
+			holder = "<none>"
+		}
+		renewed := "never"
+		if h.Age >= 0 {
+			renewed = h.Age.Round(time.Second).String() + " ago"
+		}
+		state := "ok"
+		switch {
+		case h.Holder == "":
+			state = "unheld"
+		case isStaleLease(h):
+			state = "STALE"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%ds\t%d\t%s\n", h.Key, holder, renewed, h.Duration, h.Transitions, state)
+	}
+	table.Flush()
+
+	return report.String()
+}
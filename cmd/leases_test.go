@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLeaseHolderOf(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	lease := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Lease",
+		"spec": map[string]interface{}{
+			"holderIdentity":       "controller-0",
+			"leaseDurationSeconds": int64(15),
+			"leaseTransitions":     int64(4),
+			"renewTime":            "2026-10-16T11:59:50.000000Z",
+		},
+	}}
+	lease.SetNamespace("kube-system")
+	lease.SetName("kube-controller-manager")
+
+	want := leaseHolder{Key: "kube-system/kube-controller-manager", Holder: "controller-0", Age: 10 * time.Second, Duration: 15, Transitions: 4}
+	if got := leaseHolderOf(lease, now); got != want {
+		t.Errorf("leaseHolderOf() = %+v, want %+v", got, want)
+	}
+
+	never := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Lease", "spec": map[string]interface{}{}}}
+	if got := leaseHolderOf(never, now); got.Age >= 0 {
+		t.Errorf("never renewed lease age = %v, want negative", got.Age)
+	}
+}
+
+func TestIsStaleLease(t *testing.T) {
+	tests := []struct {
+		name  string
+		lease leaseHolder
+		want  bool
+	}{
+		{"renewed within duration", leaseHolder{Holder: "a", Duration: 15, Age: 10 * time.Second}, false},
+		{"renewed too long ago", leaseHolder{Holder: "a", Duration: 15, Age: 20 * time.Second}, true},
+		{"held but never renewed", leaseHolder{Holder: "a", Duration: 15, Age: -1}, true},
+		{"unheld", leaseHolder{Duration: 15, Age: time.Hour}, false},
+		{"no duration", leaseHolder{Holder: "a", Age: time.Hour}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isStaleLease(tt.lease); got != tt.want {
+				t.Errorf("isStaleLease(%+v) = %v, want %v", tt.lease, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLeasesReport(t *testing.T) {
+	report := formatLeasesReport([]leaseHolder{
+		{Key: "kube-system/scheduler", Holder: "node-1", Age: 3 * time.Second, Duration: 15, Transitions: 2},
+		{Key: "kube-system/stuck", Holder: "node-2", Age: 5 * time.Minute, Duration: 15, Transitions: 40},
+		{Key: "shop/idle", Age: -1},
+	})
+
+	want := `=== Leases ===
+
+LEASE                  HOLDER  RENEWED   DURATION  TRANSITIONS  STATE
+kube-system/scheduler  node-1  3s ago    15s       2            ok
+kube-system/stuck      node-2  5m0s ago  15s       40           STALE
+shop/idle              <none>  never     0s        0            unheld
+`
+	if report != want {
+		t.Errorf("report =\n%s\nwant\n%s", report, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultLogsTimeout bounds the whole log collection phase
+const defaultLogsTimeout = 5 * time.Minute
+
+// logsClient is used to fetch container logs; only set when --collect-logs is enabled
+var logsClient kubernetes.Interface
+
+// logsCollected and logErrors track container log collection in the current run
+var (
+	logsCollected int
+	logErrors     int
+)
+
+// collectPodLogs writes the last --log-tail-lines lines of every container of
+// running pods to <logsDir>/<namespace>/<pod>/<container>.log. All requests share
+// one --logs-timeout deadline; whatever has not been fetched by then is skipped.
+func collectPodLogs(logsDir string) {
+	ctx, cancel := context.WithTimeout(context.Background(), logsTimeout)
+	defer cancel()
+
+	pods, err := logsClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Printf("Warning: failed to list pods for log collection: %v\n", err)
+		logErrors++
+		return
+	}
+
+	if verbose {
+		fmt.Printf("Collecting container logs to: %s\n", logsDir)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		for _, container := range pod.Spec.Containers {
+			if ctx.Err() != nil {
+				fmt.Printf("Warning: --logs-timeout of %v reached, remaining container logs skipped\n", logsTimeout)
+				return
+			}
+
+			if err := collectContainerLog(ctx, &pod, container.Name, logsDir); err != nil {
+				if verbose {
+					fmt.Printf("  logs %s/%s/%s: ERROR - %v\n", pod.Namespace, pod.Name, container.Name, err)
+				}
+				logErrors++
+				continue
+			}
+			logsCollected++
+		}
+	}
+}
+
+// collectContainerLog streams one container's log tail to its file
+func collectContainerLog(ctx context.Context, pod *corev1.Pod, container, logsDir string) error {
+	tailLines := int64(logTailLines)
+	stream, err := logsClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tailLines,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	dir := filepath.Join(logsDir, pod.Namespace, pod.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	filePath := filepath.Join(dir, container+".log")
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+	recordOutputFile(filePath)
+	defer file.Close()
+
+	if _, err := io.Copy(file, stream); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// printLogsSummary reports container log collection, if enabled
+func printLogsSummary() {
+	if !collectLogs {
+		return
+	}
+	fmt.Printf("Container logs: %d collected, %d errors\n", logsCollected, logErrors)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestCollectPodLogs(t *testing.T) {
+	defer func(client kubernetes.Interface, timeout time.Duration, tail, collected, errs int) {
+		logsClient, logsTimeout, logTailLines, logsCollected, logErrors = client, timeout, tail, collected, errs
+	}(logsClient, logsTimeout, logTailLines, logsCollected, logErrors)
+
+	pod := func(name string, phase corev1.PodPhase, containers ...string) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"}, Status: corev1.PodStatus{Phase: phase}}
+		for _, container := range containers {
+			p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Name: container})
+		}
+		return p
+	}
+	pods := corev1.PodList{Items: []corev1.Pod{
+		pod("web", corev1.PodRunning, "app", "sidecar"),
+		pod("broken", corev1.PodRunning, "app"),
+		pod("done", corev1.PodSucceeded, "app"),
+	}}
+
+	var tailLines []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/pods":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(pods)
+		case "/api/v1/namespaces/shop/pods/web/log":
+			tailLines = append(tailLines, r.URL.Query().Get("tailLines"))
+			w.Write([]byte("log of " + r.URL.Query().Get("container") + "\n"))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logsClient, logsTimeout, logTailLines, logsCollected, logErrors = client, time.Minute, 50, 0, 0
+
+	logsDir := t.TempDir()
+	collectPodLogs(logsDir)
+
+	if logsCollected != 2 || logErrors != 1 {
+		t.Errorf("collected %d, errors %d, want 2 and 1", logsCollected, logErrors)
+	}
+	for _, container := range []string{"app", "sidecar"} {
+		data, err := os.ReadFile(filepath.Join(logsDir, "shop", "web", container+".log"))
+		if err != nil {
+			t.Errorf("log of %s not written: %v", container, err)
+		} else if string(data) != "log of "+container+"\n" {
+			t.Errorf("log of %s = %q", container, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(logsDir, "shop", "done")); !os.IsNotExist(err) {
+		t.Errorf("logs of a pod that is not running were collected")
+	}
+	for _, tail := range tailLines {
+		if tail != "50" {
+			t.Errorf("tailLines = %s, want 50", tail)
+		}
+	}
+}
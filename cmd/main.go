@@ -2,20 +2,24 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/yaml"
 )
@@ -33,6 +37,132 @@ var (
 	singleFile  bool
 	clean       bool
 	compareMode bool
+
+	// Output options
+	maxFileSize    string
+	outputPerGroup bool
+	eventsFile     string
+	emitDiscovery  bool
+	separatorStyle string
+	serveAddr      string
+	splitLarge     bool
+	kustomizeBase  bool
+	helmChart      string
+	helmValues     bool
+	largeThreshold int
+	outputFormat   string
+	preserveOrder  bool
+	embedEvents    bool
+	customColumns  string
+	validateOutput bool
+
+	// Comparison options
+	compareSummaryOnly bool
+	deepDiff           bool
+	diffOnly           bool
+	compareLabel       string
+	listCommon         bool
+	collapseGenerated  bool
+	changedNamespaces  bool
+	contextLines       int
+	baselineFile       string
+	expectedFile       string
+	threeWayBaseline   string
+	compareNamespace   string
+
+	// Import options
+	importFile       string
+	importOutputDir  string
+	splitByNamespace bool
+	decodePath       string
+
+	// Must-gather options
+	collapseVersions bool
+
+	// Gate options
+	failOnDeprecated    bool
+	deprecatedThreshold string
+	assertMin           string
+	minCounts           map[string]int
+	failOnEmpty         bool
+
+	// Filter options
+	excludeOwned bool
+	olderThan    time.Duration
+	conditions   string
+	anonymize    bool
+	anonSeed     string
+	registryMap  string
+	anonMapping  string
+
+	// Transform options
+	normalizeVersions bool
+	configMapKeys     string
+	secretKeys        string
+	pruneDefaults     bool
+	pruneDefaultsFile string
+
+	// Sanitizing options
+	secure           bool
+	redactSecrets    bool
+	stripMetadata    bool
+	excludeResources string
+	includeResources string
+	blocklistFile    string
+	redactRegexes    regexList
+	decodeSecrets    bool
+
+	// Collection options
+	consistent    bool
+	requireVerbs  string
+	requiredVerbs []string
+	subresources  string
+	gvrFlags      stringList
+	resume        bool
+	chunkSize     int64
+	maxTotalItems int
+	storageRetry  bool
+	crdTarget     string
+	operatorName  string
+	restartTriage bool
+	minRestarts   int
+	retries       int
+	retryBackoff  time.Duration
+
+	// Namespace options
+	allNamespacesExplicit bool
+	timeoutPerNamespace   time.Duration
+
+	// Focused modes
+	apiServicesMode     bool
+	crdsOnlyMode        bool
+	webhooksMode        bool
+	leasesMode          bool
+	autoscalersMode     bool
+	storageCSIMode      bool
+	rbacGraphMode       bool
+	explainResourceName string
+
+	// Log options
+	collectLogs  bool
+	logTailLines int
+	logsTimeout  time.Duration
+
+	// Connection options
+	proxyURL string
+
+	// Signing options
+	sign           bool
+	signingKeyPath string
+
+	// Publish options
+	pushRef string
+
+	// signingKey is the parsed --signing-key; only set with --sign
+	signingKey ed25519.PrivateKey
+
+	// maxFileSizeBytes is the parsed --max-file-size; only set for single file mode
+	maxFileSizeBytes int64
 )
 
 // DeprecationRule defines when a resource API is deprecated
@@ -43,6 +173,7 @@ type DeprecationRule struct {
 	ReplacementGV       string // e.g., "discovery.k8s.io/v1"
 	ReplacementResource string // e.g., "endpointslices"
 	IsOpenShift         bool   // true if this is an OpenShift-specific deprecation
+	RemovedIn           string // e.g., "1.36"; empty if no removal is scheduled
 }
 
 // ClusterVersion holds version information
@@ -52,29 +183,248 @@ type ClusterVersion struct {
 	IsOpenShift    bool
 	OpenShiftMajor int
 	OpenShiftMinor int
+	// OpenShiftVersionSource records where the OpenShift version came from:
+	// "clusterversion" (read from the cluster) or "estimated" (from the Kubernetes minor)
+	OpenShiftVersionSource string
 }
 
 func main() {
-	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	// Subcommands are dispatched before the collection flags are parsed
+	if len(os.Args) > 1 && os.Args[1] == "merge" {
+		if err := runMergeCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	flag.Var((*pathList)(&kubeconfig), "kubeconfig", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config); repeat or comma-separate to merge several files")
+	flag.StringVar(&kubeContext, "context", "", "Kubeconfig context to collect from instead of the current-context")
+	flag.BoolVar(&allContexts, "all-contexts", false, "Collect every context of the kubeconfig, each into <output>/<context> with its log in <output>/<context>.log, and write fleet-summary.txt")
+	flag.IntVar(&parallelClusters, "parallel-clusters", 1, "With --all-contexts, number of clusters to collect at the same time")
 	flag.StringVar(&kubeconfig1, "kubeconfig1", "", "Path to first kubeconfig for cluster comparison")
 	flag.StringVar(&kubeconfig2, "kubeconfig2", "", "Path to second kubeconfig for cluster comparison")
-	flag.StringVar(&mustGather, "must-gather", "", "Path to must-gather directory for offline processing")
+	flag.StringVar(&mustGather, "must-gather", "", "Path to must-gather directory for offline processing, or an http(s):// or s3:// URL of a .tar.gz must-gather")
+	flag.StringVar(&maxArchiveSize, "max-archive-size", maxArchiveSize, "Stop extracting a remote must-gather archive once its files add up to more than this size (e.g. 50GB)")
+	flag.StringVar(&maxArchiveFileSize, "max-archive-file-size", maxArchiveFileSize, "Stop extracting a remote must-gather archive at a file larger than this size (e.g. 4GB)")
 	flag.StringVar(&mustGather1, "must-gather1", "", "Path to first must-gather directory for comparison")
 	flag.StringVar(&mustGather2, "must-gather2", "", "Path to second must-gather directory for comparison")
+	flag.BoolVar(&collapseVersions, "collapse-versions", false, "Must-gather mode: file all served versions of a kind together (annotated with each item's apiVersion) instead of one file per version")
 	flag.StringVar(&outputDir, "output", "./output", "Output directory for collected resources")
 	flag.StringVar(&outputFile, "file", "", "Output file for single file mode")
 	flag.BoolVar(&verbose, "verbose", false, "Enable verbose output")
 	flag.BoolVar(&singleFile, "single-file", false, "Collect all resources to a single YAML file")
 	flag.BoolVar(&clean, "clean", false, "Clean output directory before collection")
 	flag.BoolVar(&compareMode, "compare", false, "Enable comparison mode (requires kubeconfig1 and kubeconfig2)")
+	flag.BoolVar(&compareSummaryOnly, "compare-summary-only", false, "In comparison mode, only report summary counts without writing per-cluster collections")
+	flag.BoolVar(&deepDiff, "deep", false, "In comparison mode, also report field-level changes for objects present in both")
+	flag.StringVar(&expectedFile, "expected", "", "After collecting, check the cluster against this manifest of expected objects and write expected-report.txt")
+	flag.StringVar(&baselineFile, "baseline", "", "Single file mode: after collecting, diff against this earlier single-file collection and write drift-report.txt")
+	flag.StringVar(&threeWayBaseline, "compare-three-way", "", "In comparison mode, also compare both collections against this known-good single-file collection and report shared vs unique drift")
+	flag.StringVar(&compareNamespace, "compare-namespace", "", "In must-gather comparison mode, compare only the objects of this namespace (and the Namespace itself)")
+	flag.IntVar(&contextLines, "context-lines", defaultContextLines, "With --deep, unchanged YAML lines shown around each change, like diff -U (0 shows only the changed field paths)")
+	flag.BoolVar(&diffOnly, "diff-only", false, "In comparison mode, collect into temporary files and keep only the diff report")
+	flag.BoolVar(&listCommon, "list-common", false, "In comparison mode, list every resource present in both clusters instead of only counting them")
+	flag.StringVar(&diffFormat, "diff-format", diffFormat, "Comparison report format: \"text\" or \"json\" (resource lists only, always including the common resources)")
+	flag.BoolVar(&collapseGenerated, "collapse-generated-names", false, "In comparison mode, match objects with generated names (pods, replicasets) by their stable prefix in --deep, --compare-labels and --only-changed-namespaces")
+	flag.BoolVar(&changedNamespaces, "only-changed-namespaces", false, "In comparison mode, also report object changes per namespace, listing only namespaces where something changed")
+	flag.StringVar(&compareLabel, "compare-labels", "", "In comparison mode, also group object-level differences by this label, e.g. team or app.kubernetes.io/part-of")
+	flag.StringVar(&importFile, "import", "", "Split an existing all-resources.yaml into one file per resource type")
+	flag.StringVar(&importOutputDir, "import-output", "./output-import", "Output directory for import mode")
+	flag.BoolVar(&splitByNamespace, "split-by-namespace", false, "Further split each resource into <namespace>/<resource>.yaml (cluster-scoped under _cluster)")
+	flag.StringVar(&decodePath, "decode", "", "Convert the .pb files of a --format protobuf collection (directory or file) to YAML files next to them, then exit")
+	flag.BoolVar(&failOnEmpty, "fail-on-empty", false, "Exit non-zero if no objects at all were collected, which usually means the wrong context or credentials")
+	flag.BoolVar(&failOnDeprecated, "fail-on-deprecated", false, "Exit non-zero if instances of deprecated resources are found in use")
+	flag.StringVar(&deprecatedThreshold, "deprecated-threshold", "deprecated", "What --fail-on-deprecated fails on: \"deprecated\" (any deprecated API) or \"removed\" (only APIs with a scheduled removal)")
+	flag.StringVar(&assertMin, "assert-min", "", "Fail unless collected item counts meet these minimums, e.g. pods=1,nodes=3")
+	flag.StringVar(&maxFileSize, "max-file-size", "", "Roll single file output over to numbered part files once this size is exceeded (e.g. 100MB)")
+	flag.StringVar(&outputURL, "output-url", "", "After collecting, upload the output to s3://bucket/prefix or PUT each file under an http(s):// URL")
+	flag.IntVar(&uploadConcurrency, "upload-concurrency", defaultUploadConcurrency, "Number of parallel uploads with --output-url")
+	flag.Float64Var(&uploadRate, "upload-rate", defaultUploadRate, "Uploads started per second with --output-url (0 for no limit)")
+	flag.IntVar(&uploadRetries, "upload-retries", defaultUploadRetries, "Retries of an upload the backend throttles (HTTP 429/503, S3 SlowDown) with --output-url")
+	flag.StringVar(&uploadCompression, "upload-compression", compressionAuto, "Compression of files uploaded with --output-url: \"gzip\" (stored with Content-Encoding gzip), \"none\", or \"auto\" to gzip single file output only")
+	flag.BoolVar(&outputPerGroup, "output-per-group", false, "Nest directory output by API group and version: <output>/<group>/<version>/<resource>.yaml")
+	flag.BoolVar(&excludeOwned, "exclude-owned", false, "Drop objects managed by a controller (ownerReferences with controller: true)")
+	flag.DurationVar(&olderThan, "older-than", 0, "Keep only objects created longer ago than this (e.g. 720h), to find stale resources; objects without a creationTimestamp are dropped")
+	flag.StringVar(&conditions, "condition", "", "Keep only objects with a matching status condition, e.g. Ready=False or Available!=True; \"phase\" matches .status.phase (comma-separated, any may match)")
+	flag.BoolVar(&anonymize, "anonymize", false, "Replace namespace names, node names, IPs and hostnames with stable pseudonyms and write the mapping beside the output directory")
+	flag.StringVar(&anonMapping, "anonymize-mapping", "", "With --anonymize, write the private mapping to this path instead of <output>-anonymize-mapping.yaml; it must be outside the output directory")
+	flag.StringVar(&anonSeed, "seed", "", "With --anonymize, derive pseudonyms from a hash keyed with this seed, so the same names get the same pseudonyms in every run")
+	flag.StringVar(&configMapKeys, "configmap-keys", "", "Comma-separated keys to keep in ConfigMap data and binaryData; other keys are dropped")
+	flag.StringVar(&secretKeys, "secret-keys", "", "Comma-separated keys to keep in Secret data and stringData; other keys are dropped")
+	flag.BoolVar(&normalizeVersions, "normalize-api-versions", false, "Rewrite deprecated apiVersions in collected objects to their replacements from the deprecation rules")
+	flag.BoolVar(&pruneDefaults, "prune-defaults", false, "Remove fields that hold their server-defaulted value (e.g. dnsPolicy: ClusterFirst) from well-known kinds")
+	flag.StringVar(&pruneDefaultsFile, "prune-defaults-file", "", "YAML list of {kind, path, value} rules that replaces the built-in --prune-defaults set")
+	flag.StringVar(&registryMap, "image-registry-map", "", "Rewrite container image prefixes before writing, e.g. docker.io/=registry.example.com/ (comma-separated)")
+	flag.BoolVar(&secure, "secure", false, "Safe-to-share profile: --redact-secrets, --strip-metadata and exclude secrets, tokens and CSRs (see --include-resources)")
+	flag.BoolVar(&redactSecrets, "redact-secrets", false, "Replace every value in Secret data and stringData with REDACTED")
+	flag.Var(&redactRegexes, "redact-regex", "Replace any string value matching this regular expression with REDACTED; repeatable")
+	flag.BoolVar(&decodeSecrets, "decode-secrets", false, "Write Secret data values that decode to text as plain stringData, marked with an annotation (UNSAFE: output contains readable secrets)")
+	flag.BoolVar(&stripMetadata, "strip-metadata", false, "Remove uid, resourceVersion, creationTimestamp, generation, managedFields, selfLink and the last-applied annotation")
+	flag.StringVar(&excludeResources, "exclude-resources", "", "Comma-separated resource names not to collect, e.g. events,secrets")
+	flag.StringVar(&blocklistFile, "blocklist", "", "Policy file of resource names that are never collected, one per line; overrides --include-resources (default "+defaultBlocklistFile+" if it exists)")
+	flag.StringVar(&includeResources, "include-resources", "", "Comma-separated resource names to collect even if excluded by --exclude-resources or --secure")
+	flag.StringVar(&requireVerbs, "require-verbs", "list", "Comma-separated verbs a resource must support to be collected")
+	flag.StringVar(&subresources, "include-subresources", "", "Comma-separated subresources to collect: \"status\" for every resource, or <resource>/status for specific ones")
+	flag.StringVar(&crdTarget, "crd", "", "Collect only the custom resources of this CRD, given as GROUP/KIND (e.g. cert-manager.io/Certificate), plus the CRD itself")
+	flag.StringVar(&operatorName, "operator", "", "OpenShift/OLM: collect only what this operator's ClusterServiceVersion owns (CRDs, custom resources, APIServices) plus its Deployments, Subscription and CSV")
+	flag.BoolVar(&restartTriage, "collect-pods-with-restarts", false, "Collect only pods with a container that restarted at least --min-restarts times, to find crashing workloads")
+	flag.IntVar(&minRestarts, "min-restarts", 1, "Restart count from which --collect-pods-with-restarts keeps a pod")
+	flag.Var(&gvrFlags, "gvr", "Collect exactly this group/version/resource (e.g. apps/v1/deployments, v1/pods) without discovery; repeatable")
+	flag.StringVar(&explainResourceName, "explain", "", "Show how a resource (e.g. pods) would be collected with the given flags, then exit without collecting")
+	flag.StringVar(&presetName, "preset", "", "Start from a named set of flags (see --list-presets); flags given on the command line or in KRC_* variables win")
+	flag.BoolVar(&listPresets, "list-presets", false, "List the presets accepted by --preset and the flags each sets, then exit")
+	flag.BoolVar(&crdsOnlyMode, "collect-crds-only", false, "Only collect CustomResourceDefinitions and write an inventory of their groups, scopes and versions")
+	flag.BoolVar(&autoscalersMode, "collect-autoscalers", false, "Only collect HPAs (and VPAs if installed) and summarize current vs desired replicas and metrics")
+	flag.BoolVar(&storageCSIMode, "collect-storage-classes-and-csi", false, "Only collect StorageClasses, CSIDrivers, CSINodes and VolumeAttachments and map each StorageClass to its provisioner and driver")
+	flag.BoolVar(&rbacGraphMode, "collect-rbac-graph", false, "Only collect Roles, ClusterRoles and their bindings and resolve which verbs each user, group and service account has on which resources")
+	flag.BoolVar(&leasesMode, "collect-leases", false, "Only collect Leases and summarize each holder, renew time and staleness for leader-election debugging")
+	flag.BoolVar(&webhooksMode, "webhooks", false, "Only collect admission webhook configurations and summarize each webhook's target, failurePolicy and caBundle")
+	flag.BoolVar(&apiServicesMode, "apiservices", false, "Only collect APIServices and report the health of aggregated APIs")
+	flag.BoolVar(&allNamespacesExplicit, "all-namespaces-explicit", false, "List namespaces first and collect namespaced resources one namespace at a time (for per-namespace RBAC)")
+	flag.DurationVar(&timeoutPerNamespace, "timeout-per-namespace", 0, "With --all-namespaces-explicit, total time each namespace may take across all resources before it is skipped (0 = no budget)")
+	flag.IntVar(&maxTotalItems, "max-total-items", 0, "Stop collecting further resources once this many items were collected in total (0 means no limit)")
+	flag.IntVar(&retries, "retries", 3, "Retry List and discovery calls that fail transiently (timeouts, throttling, server errors) this many times; authentication and permission errors are not retried")
+	flag.DurationVar(&retryBackoff, "retry-backoff", time.Second, "Wait before the first retry, doubling after each further attempt")
+	flag.BoolVar(&storageRetry, "retry-storage-version", false, "When a CRD's conversion webhook is unavailable, retry listing its custom resources with the CRD's storage version")
+	flag.Int64Var(&chunkSize, "chunk-size", 0, "List resources in pages of this many items (0 lists everything in one request); progress is shown with --verbose")
+	flag.BoolVar(&resume, "resume", false, "Directory mode: skip resources whose files an earlier, interrupted run already wrote; must-gather mode: skip the files an interrupted run already processed")
+	flag.BoolVar(&checkpointMustGather, "checkpoint", false, "Must-gather mode: record each processed file in .must-gather-checkpoint.jsonl next to the output, so an interrupted run can be continued with --resume")
+	flag.BoolVar(&consistent, "consistent", false, "Best-effort point-in-time snapshot: list every resource at the same resourceVersion")
+	flag.DurationVar(&watchInterval, "watch-interval", 0, "Keep collecting: write a snapshot into <output>/<timestamp> at this interval, serving Lists from informer caches instead of re-listing (e.g. 5m)")
+	flag.IntVar(&watchCount, "watch-count", 0, "With --watch-interval, stop after this many snapshots (0 = until interrupted)")
+	flag.StringVar(&outputFormat, "format", "yaml", "Output format: \"yaml\", \"ndjson\" (one JSON object per line; must-gather single file processing only) or \"protobuf\" (built-in resources listed and stored as Kubernetes protobuf; live directory mode only)")
+	flag.BoolVar(&validateOutput, "validate-output", false, "Single file mode: re-read the written file as a multi-document YAML stream and fail if any document does not parse")
+	flag.StringVar(&separatorStyle, "separator-style", "commented", "What precedes each resource block in single file output: \"commented\" (--- # Resource: <name>), \"plain\" (---) or \"none\"")
+	flag.BoolVar(&byController, "group-by-controller", false, "Write controller-inventory.txt next to the output, listing each top-level controller with the objects it manages")
+	flag.BoolVar(&kustomizeBase, "kustomize", false, "Directory mode: write one file per object (without status or server-populated metadata) plus a kustomization.yaml listing them")
+	flag.StringVar(&helmChart, "helm-chart", "", "Directory mode: write the output directory as a Helm chart of this name, one template per object under templates/")
+	flag.BoolVar(&helmValues, "helm-values", false, "With --helm-chart, replace namespaces and container images with .Values placeholders whose defaults go to values.yaml")
+	flag.BoolVar(&splitLarge, "split-large-resources", false, "Directory mode: write namespaced resources with at least --large-threshold items as one file per namespace")
+	flag.IntVar(&largeThreshold, "large-threshold", defaultLargeThreshold, "Item count from which --split-large-resources splits a resource by namespace")
+	flag.StringVar(&serveAddr, "serve", "", "After collecting, serve the collected objects read-only over HTTP on this address (e.g. :8080, which listens on loopback only) until stopped")
+	flag.BoolVar(&schemaCheck, "output-json-schema", false, "Validate each custom resource against its CRD's OpenAPI v3 schema and write violations to crd-schema-report.txt next to the output")
+	flag.BoolVar(&stuckReport, "stuck-report", false, "Write the objects stuck in Terminating (deletionTimestamp set, finalizers still present) to stuck-report.txt next to the output")
+	flag.BoolVar(&nsSummary, "namespace-summary", false, "Write per-namespace object counts by kind, pod phases and not-ready workloads to namespace-summary.txt next to the output")
+	flag.BoolVar(&netpolReport, "netpol-report", false, "Write a per-namespace NetworkPolicy summary (default-deny, pods isolated or not) to netpol-report.txt next to the output")
+	flag.BoolVar(&pdbReport, "pdb-report", false, "Write the Deployments and StatefulSets that no PodDisruptionBudget selects to pdb-report.txt next to the output")
+	flag.BoolVar(&storageReport, "storage-report", false, "Write a PV/PVC inventory with capacity by StorageClass to storage-report.txt next to the output")
+	flag.BoolVar(&tableOutput, "table", false, "Also write each resource as the server-side table kubectl get shows (extra columns like READY and RESTARTS) under tables/")
+	flag.StringVar(&customColumns, "custom-columns", "", "Also write the given kubectl-style columns of every object to columns/<resource>.csv, e.g. NAME:.metadata.name,REPLICAS:.spec.replicas")
+	flag.BoolVar(&embedEvents, "embed-events", false, "Directory mode: append each object's Events as a comment block to its resource file")
+	flag.BoolVar(&preserveOrder, "preserve-order", false, "Write object keys in the conventional apiVersion, kind, metadata, spec, status order instead of alphabetically")
+	flag.BoolVar(&collectMetrics, "collect-metrics", false, "Also snapshot node and pod CPU/memory usage from metrics.k8s.io into metrics/ and metrics-snapshot.txt")
+	flag.BoolVar(&quotaReport, "quota-report", false, "Write a per-namespace summary of ResourceQuota usage and LimitRanges to quota-report.txt next to the output")
+	flag.BoolVar(&emitDiscovery, "emit-discovery", false, "Write the cluster's full discovery output (groups, versions, resources, verbs) to discovery.yaml next to the output")
+	flag.StringVar(&eventsFile, "events-stream", "", "Write collection progress as JSON lines to this file (\"-\" or \"stderr\" for stderr)")
+	flag.BoolVar(&collectLogs, "collect-logs", false, "Also save recent logs of running pods' containers to <output>/logs/<namespace>/<pod>/<container>.log")
+	flag.IntVar(&logTailLines, "log-tail-lines", 100, "Number of log lines to keep per container with --collect-logs")
+	flag.DurationVar(&logsTimeout, "logs-timeout", defaultLogsTimeout, "Overall time limit for fetching container logs with --collect-logs")
+	flag.BoolVar(&sign, "sign", false, "Write checksums.sha256 of the output and a detached ed25519 signature over it (requires --signing-key)")
+	flag.StringVar(&signingKeyPath, "signing-key", "", "Path to an ed25519 private key (PKCS#8 PEM) for --sign")
+	flag.StringVar(&pushRef, "push", "", "After collecting, package the output as a tarball and push it as an OCI artifact, e.g. oci://registry.example.com/team/snapshots:v1")
+	flag.StringVar(&proxyURL, "proxy-url", "", "HTTP(S) or SOCKS5 proxy for reaching the API server (hosts in NO_PROXY are still reached directly)")
 	flag.Parse()
 
+	// Any flag can also be set through a KRC_* environment variable
+	if err := applyEnvOverrides(flag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if listPresets {
+		fmt.Print(formatPresets())
+		return
+	}
+	if err := applyPreset(flag.CommandLine, presetName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	if err := runCollector(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// isOfflineMode reports whether the run reads must-gathers, an import file or
+// a protobuf collection to decode instead of a live cluster
+func isOfflineMode() bool {
+	return mustGather != "" || mustGather1 != "" || mustGather2 != "" || importFile != "" || decodePath != ""
+}
+
+// liveOnlyFlags are the report flags that read objects from a live collection
+var liveOnlyFlags = []struct {
+	name    string
+	enabled *bool
+}{
+	{"quota-report", &quotaReport},
+	{"storage-report", &storageReport},
+	{"pdb-report", &pdbReport},
+	{"netpol-report", &netpolReport},
+	{"namespace-summary", &nsSummary},
+	{"stuck-report", &stuckReport},
+	{"table", &tableOutput},
+	{"collect-metrics", &collectMetrics},
+	{"group-by-controller", &byController},
+	{"output-json-schema", &schemaCheck},
+}
+
+// requireLiveMode rejects a flag that only applies to live collections when
+// the run reads must-gathers, an import file or a protobuf collection instead
+func requireLiveMode(flagName string) error {
+	if isOfflineMode() {
+		return fmt.Errorf("--%s applies to live collections and cannot be used with must-gather, import or decode mode", flagName)
+	}
+	return nil
+}
+
+// focusedModeFlags are the flags that replace the collection with one focused
+// summary of a few resources
+var focusedModeFlags = []struct {
+	name    string
+	enabled *bool
+}{
+	{"collect-crds-only", &crdsOnlyMode},
+	{"collect-autoscalers", &autoscalersMode},
+	{"collect-storage-classes-and-csi", &storageCSIMode},
+	{"collect-rbac-graph", &rbacGraphMode},
+	{"collect-leases", &leasesMode},
+	{"webhooks", &webhooksMode},
+	{"apiservices", &apiServicesMode},
+}
+
+// activeFocusedMode returns the flag name of the focused mode in use, or ""
+func activeFocusedMode() string {
+	for _, mode := range focusedModeFlags {
+		if *mode.enabled {
+			return mode.name
+		}
+	}
+	return ""
+}
+
+// isComparisonMode reports whether two live clusters are compared
+func isComparisonMode() bool {
+	return compareMode || (kubeconfig1 != "" && kubeconfig2 != "")
+}
+
+// isMustGatherComparisonMode reports whether two must-gathers are compared
+func isMustGatherComparisonMode() bool {
+	return mustGather1 != "" || mustGather2 != ""
+}
+
+// isSingleFileMode reports whether output goes to a single file
+func isSingleFileMode() bool {
+	return singleFile || outputFile != ""
+}
+
+// isLiveDirectoryMode reports whether one live cluster is collected into an
+// output directory, the mode most output layouts apply to
+func isLiveDirectoryMode() bool {
+	return !isSingleFileMode() && !isOfflineMode() && !isComparisonMode()
+}
+
 func runCollector() error {
 	// Validate mutually exclusive flags
 	if mustGather != "" && kubeconfig != "" {
@@ -85,269 +435,1154 @@ func runCollector() error {
 		return fmt.Errorf("--must-gather cannot be used with --kubeconfig1 or --kubeconfig2")
 	}
 
-	if (mustGather1 != "" || mustGather2 != "") && (kubeconfig != "" || kubeconfig1 != "" || kubeconfig2 != "") {
+	if isMustGatherComparisonMode() && (kubeconfig != "" || kubeconfig1 != "" || kubeconfig2 != "") {
 		return fmt.Errorf("--must-gather1/2 cannot be used with --kubeconfig flags; use one mode or the other")
 	}
 
-	if mustGather != "" && (mustGather1 != "" || mustGather2 != "") {
+	if mustGather != "" && isMustGatherComparisonMode() {
 		return fmt.Errorf("--must-gather cannot be used with --must-gather1 or --must-gather2; use either single or comparison mode")
 	}
 
-	// Check if must-gather comparison mode is enabled
-	if mustGather1 != "" && mustGather2 != "" {
-		return runMustGatherComparisonMode()
+	if importFile != "" && (kubeconfig != "" || kubeconfig1 != "" || kubeconfig2 != "" || mustGather != "" || mustGather1 != "" || mustGather2 != "") {
+		return fmt.Errorf("--import works offline and cannot be used with --kubeconfig or --must-gather flags")
 	}
 
-	if mustGather1 != "" || mustGather2 != "" {
-		return fmt.Errorf("must-gather comparison mode requires both --must-gather1 and --must-gather2")
+	if decodePath != "" && (kubeconfig != "" || kubeconfig1 != "" || kubeconfig2 != "" || mustGather != "" || isMustGatherComparisonMode() || importFile != "") {
+		return fmt.Errorf("--decode works offline and cannot be used with --kubeconfig, --must-gather or --import flags")
 	}
 
-	// Check if must-gather mode is enabled
-	if mustGather != "" {
-		return runMustGatherMode()
+	if err := validateProxyURL(proxyURL); err != nil {
+		return err
 	}
 
-	// Check if comparison mode is enabled
-	if compareMode || (kubeconfig1 != "" && kubeconfig2 != "") {
-		return runComparisonMode()
+	if collectLogs {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--collect-logs needs a single live cluster and cannot be used with must-gather, import, decode or comparison mode")
+		}
+		if logTailLines <= 0 {
+			return fmt.Errorf("--log-tail-lines must be greater than 0")
+		}
 	}
 
-	// Determine output mode
-	if outputFile != "" {
-		singleFile = true
-	} else if singleFile {
-		outputFile = "./output/all-resources.yaml"
+	if isOfflineMode() && (secure || redactSecrets || stripMetadata || excludeResources != "") {
+		return fmt.Errorf("--secure, --redact-secrets, --strip-metadata and --exclude-resources apply to live collections and cannot be used with must-gather, import or decode mode")
 	}
 
-	// Use kubeconfig1 if provided (fallback when kubeconfig is not used), otherwise fall back to kubeconfig
-	configPath := kubeconfig
-	if configPath == "" && kubeconfig1 != "" {
-		configPath = kubeconfig1
+	if secure {
+		if collectLogs {
+			return fmt.Errorf("--secure cannot be used with --collect-logs; container logs are not sanitized")
+		}
+		if embedEvents {
+			return fmt.Errorf("--secure cannot be used with --embed-events; event messages are not sanitized")
+		}
+		if tableOutput {
+			return fmt.Errorf("--secure cannot be used with --table; table cells are not sanitized")
+		}
+		if schemaCheck {
+			return fmt.Errorf("--secure cannot be used with --output-json-schema; the schema report names objects and quotes values before sanitizing")
+		}
 	}
 
-	// Parse kubeconfig
-	config, err := parseKubeConfig(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to get kubeconfig: %w", err)
+	if anonMapping != "" && !anonymize {
+		return fmt.Errorf("--anonymize-mapping requires --anonymize")
 	}
 
-	// Create clients
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
-	if err != nil {
-		return fmt.Errorf("failed to create discovery client: %w", err)
+	if anonSeed != "" && !anonymize {
+		return fmt.Errorf("--seed requires --anonymize")
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(config)
-	if err != nil {
-		return fmt.Errorf("failed to create dynamic client: %w", err)
+	if anonymize {
+		if isOfflineMode() {
+			return fmt.Errorf("--anonymize applies to live collections and cannot be used with must-gather, import or decode mode")
+		}
+		if collectLogs {
+			return fmt.Errorf("--anonymize cannot be used with --collect-logs; container logs are not anonymized")
+		}
+		if anonMapping != "" && !isSingleFileMode() && isWithinDir(anonMapping, outputDir) {
+			return fmt.Errorf("--anonymize-mapping must be outside the output directory %s", outputDir)
+		}
+		if tableOutput {
+			return fmt.Errorf("--anonymize cannot be used with --table; table cells are not anonymized")
+		}
+		if collectMetrics {
+			return fmt.Errorf("--anonymize cannot be used with --collect-metrics; metrics are not anonymized")
+		}
+		if embedEvents {
+			return fmt.Errorf("--anonymize cannot be used with --embed-events; event messages are not anonymized")
+		}
+		if byController {
+			return fmt.Errorf("--anonymize cannot be used with --group-by-controller; the controller inventory is not anonymized")
+		}
+		if schemaCheck {
+			return fmt.Errorf("--anonymize cannot be used with --output-json-schema; the schema report is not anonymized")
+		}
+		if mode := activeFocusedMode(); mode != "" {
+			return fmt.Errorf("--anonymize cannot be used with --%s; the focused summary names users, holders and services that are not anonymized", mode)
+		}
+		anonymizer = newPseudonymizer(anonSeed)
 	}
 
-	if singleFile {
-		// Single file mode
-		if outputFile == "" {
-			outputFile = "./output/all-resources.yaml"
-		}
+	applySecureProfile()
 
-		// Ensure output directory exists
-		if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
-			return fmt.Errorf("failed to create output directory: %w", err)
-		}
+	if err := loadBlocklist(blocklistFile); err != nil {
+		return err
+	}
 
-		// Clean file if requested
-		if clean {
-			if err := os.Remove(outputFile); err != nil && !os.IsNotExist(err) {
-				return fmt.Errorf("failed to clean output file: %w", err)
-			}
-		}
+	keptConfigMapKeys = parseList(configMapKeys)
+	keptSecretKeys = parseList(secretKeys)
 
-		return collectAllResourcesToSingleFile(discoveryClient, dynamicClient, outputFile)
-	} else {
-		// Directory mode
-		// Ensure output directory exists
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return fmt.Errorf("failed to create output directory: %w", err)
+	if customColumns != "" {
+		if isOfflineMode() {
+			return fmt.Errorf("--custom-columns applies to live collections and cannot be used with must-gather, import or decode mode")
+		}
+		columns, err := parseCustomColumns(customColumns)
+		if err != nil {
+			return err
 		}
+		customColumnSpecs = columns
+	}
 
-		// Clean directory if requested
-		if clean {
-			if err := cleanDirectory(outputDir); err != nil {
-				return fmt.Errorf("failed to clean output directory: %w", err)
+	if pruneDefaultsFile != "" && !pruneDefaults {
+		return fmt.Errorf("--prune-defaults-file requires --prune-defaults")
+	}
+	if pruneDefaults {
+		defaultRules = builtinDefaultRules
+		if pruneDefaultsFile != "" {
+			rules, err := loadDefaultRules(pruneDefaultsFile)
+			if err != nil {
+				return err
 			}
+			defaultRules = rules
 		}
-
-		return collectResources(discoveryClient, dynamicClient, outputDir)
 	}
-}
 
-func parseKubeConfig(kubeconfigPath string) (*rest.Config, error) {
-	var configPath string
-
-	// Priority: flag > environment variable > default location
-	if kubeconfigPath != "" {
-		configPath = kubeconfigPath
-	} else if envKubeconfig := os.Getenv("KUBECONFIG"); envKubeconfig != "" {
-		configPath = envKubeconfig
-	} else {
-		configPath = filepath.Join(homedir.HomeDir(), ".kube", "config")
+	if decodeSecrets {
+		if secure || redactSecrets || len(redactRegexes) > 0 {
+			return fmt.Errorf("--decode-secrets cannot be combined with --secure, --redact-secrets or --redact-regex")
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: --decode-secrets writes Secret values as readable plain text. Keep the output on a trusted machine; do not share, upload or commit it.\n")
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("kubeconfig file not found at %s", configPath)
+	if len(redactRegexes) > 0 {
+		if schemaCheck {
+			return fmt.Errorf("--redact-regex cannot be used with --output-json-schema; the schema report quotes values before redaction")
+		}
+		patterns, err := compileRedactPatterns(redactRegexes)
+		if err != nil {
+			return err
+		}
+		redactPatterns = patterns
 	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build config from kubeconfig: %w", err)
+	if conditions != "" {
+		matches, err := parseConditions(conditions)
+		if err != nil {
+			return err
+		}
+		conditionMatches = matches
 	}
 
-	return config, nil
-}
-
-// detectClusterVersion detects the Kubernetes and OpenShift versions
-func detectClusterVersion(discovery discovery.DiscoveryInterface) (*ClusterVersion, error) {
-	serverVersion, err := discovery.ServerVersion()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get server version: %w", err)
+	if registryMap != "" {
+		rewrites, err := parseRegistryMap(registryMap)
+		if err != nil {
+			return err
+		}
+		registryRewrites = rewrites
 	}
 
-	cv := &ClusterVersion{}
+	if kubeContext != "" && (isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--context applies to live collections from --kubeconfig")
+	}
 
-	// Parse Kubernetes version
-	major, err := strconv.Atoi(strings.TrimSuffix(serverVersion.Major, "+"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse major version: %w", err)
+	if allContexts {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--all-contexts applies to live collections from --kubeconfig")
+		}
+		if kubeContext != "" {
+			return fmt.Errorf("--all-contexts and --context cannot be used together")
+		}
+		if eventsFile != "" || anonMapping != "" {
+			return fmt.Errorf("--all-contexts cannot share one --events-stream or --anonymize-mapping file between clusters")
+		}
+		if parallelClusters < 1 {
+			return fmt.Errorf("--parallel-clusters must be at least 1")
+		}
+	} else if parallelClusters != 1 {
+		return fmt.Errorf("--parallel-clusters requires --all-contexts")
 	}
-	cv.Major = major
 
-	minor, err := strconv.Atoi(strings.TrimSuffix(serverVersion.Minor, "+"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse minor version: %w", err)
+	if outputURL != "" {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--output-url applies to live collections in directory or single file mode")
+		}
+		if _, err := parseOutputURL(outputURL); err != nil {
+			return err
+		}
+		if uploadConcurrency < 1 {
+			return fmt.Errorf("--upload-concurrency must be at least 1")
+		}
+		if uploadRate < 0 || uploadRetries < 0 {
+			return fmt.Errorf("--upload-rate and --upload-retries must not be negative")
+		}
+		if uploadCompression != compressionAuto && uploadCompression != compressionGzip && uploadCompression != compressionNone {
+			return fmt.Errorf("invalid --upload-compression %q: must be \"auto\", \"gzip\" or \"none\"", uploadCompression)
+		}
 	}
-	cv.Minor = minor
 
-	// Check if this is OpenShift by looking for OpenShift-specific API groups
-	apiGroups, err := discovery.ServerGroups()
-	if err == nil {
-		for _, group := range apiGroups.Groups {
-			if strings.Contains(group.Name, "openshift.io") {
-				cv.IsOpenShift = true
-				break
+	for _, f := range liveOnlyFlags {
+		if *f.enabled {
+			if err := requireLiveMode(f.name); err != nil {
+				return err
 			}
 		}
 	}
 
-	// Try to detect OpenShift version from the platform
-	if cv.IsOpenShift {
-		// OpenShift version is typically v1.X.Y where X maps to OpenShift 4.X
-		// For example, Kubernetes 1.27 = OpenShift 4.14
-		cv.OpenShiftMajor = 4
-		// Rough mapping (this can vary, but gives us a good approximation)
-		if cv.Minor >= 27 {
-			cv.OpenShiftMinor = 14 + (cv.Minor - 27)
+	if serveAddr != "" {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--serve applies to live collections from a single cluster and cannot be used with must-gather, import, decode or comparison mode")
+		}
+		if allContexts || watchInterval != 0 {
+			return fmt.Errorf("--serve serves a single collection and cannot be combined with --all-contexts or --watch-interval")
 		}
 	}
 
-	if verbose {
-		fmt.Printf("Detected Kubernetes version: %d.%d\n", cv.Major, cv.Minor)
-		if cv.IsOpenShift {
-			fmt.Printf("Detected OpenShift cluster (estimated version: %d.%d)\n",
-				cv.OpenShiftMajor, cv.OpenShiftMinor)
+	switch separatorStyle {
+	case "commented":
+	case "plain", "none":
+		// Comparison parses the resource markers of the collected files
+		if isComparisonMode() || isMustGatherComparisonMode() {
+			return fmt.Errorf("--separator-style %s cannot be used in comparison mode, which relies on the commented markers", separatorStyle)
 		}
+	default:
+		return fmt.Errorf("invalid --separator-style %q: must be commented, plain or none", separatorStyle)
 	}
 
-	return cv, nil
-}
+	if validateOutput {
+		if !isSingleFileMode() {
+			return fmt.Errorf("--validate-output applies to single file output (--single-file or --file)")
+		}
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--validate-output applies to live single file collections")
+		}
+		if separatorStyle == "none" {
+			return fmt.Errorf("--validate-output needs document separators and cannot be used with --separator-style none")
+		}
+	}
 
-// getDeprecationRules returns a list of known deprecation rules
-func getDeprecationRules() []DeprecationRule {
-	return []DeprecationRule{
-		{
-			GroupVersion:        "v1",
-			Resource:            "componentstatuses",
-			DeprecatedFrom:      "1.19",
-			ReplacementGV:       "", // No replacement - component status is deprecated without replacement
-			ReplacementResource: "",
-			IsOpenShift:         false,
-		},
-		{
-			GroupVersion:        "v1",
-			Resource:            "endpoints",
-			DeprecatedFrom:      "1.33",
-			ReplacementGV:       "discovery.k8s.io/v1",
-			ReplacementResource: "endpointslices",
-			IsOpenShift:         false,
-		},
-		{
-			GroupVersion:        "apps.openshift.io/v1",
-			Resource:            "deploymentconfigs",
-			DeprecatedFrom:      "4.14",
-			ReplacementGV:       "", // DeploymentConfigs should be migrated to standard Deployments
-			ReplacementResource: "",
-			IsOpenShift:         true,
-		},
+	if helmChart != "" {
+		if !isLiveDirectoryMode() {
+			return fmt.Errorf("--helm-chart applies to live directory mode collections")
+		}
+		if kustomizeBase || resume || splitLarge || outputPerGroup || embedEvents {
+			return fmt.Errorf("--helm-chart writes its own layout and cannot be combined with --kustomize, --resume, --split-large-resources, --output-per-group or --embed-events")
+		}
+		if !helmChartNamePattern.MatchString(helmChart) {
+			return fmt.Errorf("invalid --helm-chart name %q: use lowercase letters, digits and dashes", helmChart)
+		}
+		stripMetadata = true
 	}
-}
 
-// isDeprecated checks if a resource is deprecated based on cluster version
-// Returns: (isDeprecated, replacementGV, replacementResource, message)
-func isDeprecated(resource metav1.APIResource, groupVersion string, clusterVersion *ClusterVersion) (bool, string, string, string) {
-	rules := getDeprecationRules()
+	if helmValues && helmChart == "" {
+		return fmt.Errorf("--helm-values requires --helm-chart")
+	}
 
-	for _, rule := range rules {
-		// Check if this rule applies to this resource
-		if rule.GroupVersion != groupVersion || rule.Resource != resource.Name {
-			continue
+	switch outputFormat {
+	case "yaml":
+	case "ndjson":
+		if mustGather == "" || !isSingleFileMode() {
+			return fmt.Errorf("--format ndjson is only supported for must-gather single file processing (--must-gather with --single-file or --file)")
 		}
-
-		// Check if we should apply OpenShift-specific rules
-		if rule.IsOpenShift && !clusterVersion.IsOpenShift {
-			continue
+	case "protobuf":
+		if !isLiveDirectoryMode() {
+			return fmt.Errorf("--format protobuf applies to live directory mode collections")
 		}
-
-		// Parse the deprecation version
-		parts := strings.Split(rule.DeprecatedFrom, ".")
-		if len(parts) < 2 {
-			continue
+		if kustomizeBase || helmChart != "" || resume || splitLarge || embedEvents {
+			return fmt.Errorf("--format protobuf cannot be combined with --kustomize, --helm-chart, --resume, --split-large-resources or --embed-events")
 		}
+	default:
+		return fmt.Errorf("invalid --format %q: must be yaml, ndjson or protobuf", outputFormat)
+	}
 
-		depMajor, err1 := strconv.Atoi(parts[0])
-		depMinor, err2 := strconv.Atoi(parts[1])
-		if err1 != nil || err2 != nil {
-			continue
+	if splitLarge {
+		if !isLiveDirectoryMode() {
+			return fmt.Errorf("--split-large-resources applies to live directory mode collections")
 		}
-
-		// Compare versions
-		var isDeprecated bool
-		if rule.IsOpenShift {
-			// Compare against OpenShift version
-			if clusterVersion.OpenShiftMajor > depMajor ||
-				(clusterVersion.OpenShiftMajor == depMajor && clusterVersion.OpenShiftMinor >= depMinor) {
-				isDeprecated = true
-			}
-		} else {
-			// Compare against Kubernetes version
-			if clusterVersion.Major > depMajor ||
-				(clusterVersion.Major == depMajor && clusterVersion.Minor >= depMinor) {
-				isDeprecated = true
-			}
+		if largeThreshold <= 0 {
+			return fmt.Errorf("--large-threshold must be positive")
 		}
+		// A split resource has no single file for --resume to find, and an
+		// interrupted split cannot be told from a complete one
+		if resume {
+			return fmt.Errorf("--split-large-resources cannot be combined with --resume")
+		}
+	}
 
-		if isDeprecated {
-			var msg string
-			if rule.ReplacementGV != "" && rule.ReplacementResource != "" {
-				msg = fmt.Sprintf("Using %s/%s instead of deprecated %s/%s",
-					rule.ReplacementGV, rule.ReplacementResource, groupVersion, resource.Name)
-			} else {
-				msg = fmt.Sprintf("Skipping deprecated %s/%s (no replacement available)",
-					groupVersion, resource.Name)
-			}
-			return true, rule.ReplacementGV, rule.ReplacementResource, msg
+	if kustomizeBase {
+		if !isLiveDirectoryMode() {
+			return fmt.Errorf("--kustomize applies to live directory mode collections")
 		}
+		if resume || splitLarge || outputPerGroup || embedEvents {
+			return fmt.Errorf("--kustomize writes its own layout and cannot be combined with --resume, --split-large-resources, --output-per-group or --embed-events")
+		}
+		// Objects must be re-appliable to another cluster
+		stripMetadata = true
 	}
 
-	return false, "", "", ""
-}
+	if embedEvents && !isLiveDirectoryMode() {
+		return fmt.Errorf("--embed-events applies to live directory mode collections")
+	}
+
+	if collapseVersions && mustGather == "" && mustGather1 == "" && mustGather2 == "" {
+		return fmt.Errorf("--collapse-versions only applies to must-gather processing")
+	}
+
+	if rbacGraphMode && (autoscalersMode || leasesMode || webhooksMode || apiServicesMode || crdsOnlyMode || isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--collect-rbac-graph needs a single live cluster and cannot be used with another focused mode, must-gather, import, decode or comparison mode")
+	}
+
+	if storageCSIMode && (autoscalersMode || rbacGraphMode || leasesMode || webhooksMode || apiServicesMode || crdsOnlyMode || isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--collect-storage-classes-and-csi needs a single live cluster and cannot be used with another focused mode, must-gather, import, decode or comparison mode")
+	}
+
+	if expectedFile != "" {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--expected applies to live collections from a single cluster and cannot be used with must-gather, import, decode or comparison mode")
+		}
+		if anonymize {
+			return fmt.Errorf("--expected cannot be used with --anonymize; anonymized names never match the manifest")
+		}
+		if err := loadExpectedManifest(expectedFile); err != nil {
+			return err
+		}
+	}
+
+	if baselineFile != "" {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--baseline applies to a single live collection and cannot be used with must-gather, import, decode or comparison mode")
+		}
+		if !isSingleFileMode() {
+			return fmt.Errorf("--baseline needs single file mode (--single-file or --file)")
+		}
+		if maxFileSize != "" {
+			return fmt.Errorf("--baseline diffs a single output file and cannot be used with --max-file-size")
+		}
+		if separatorStyle != "commented" {
+			return fmt.Errorf("--baseline relies on the commented resource markers and cannot be used with --separator-style %s", separatorStyle)
+		}
+		if _, err := os.Stat(baselineFile); err != nil {
+			return fmt.Errorf("baseline file: %w", err)
+		}
+	}
+
+	if explainResourceName != "" && (isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--explain needs a single live cluster and cannot be used with must-gather, import, decode or comparison mode")
+	}
+
+	if autoscalersMode && (leasesMode || webhooksMode || apiServicesMode || crdsOnlyMode || isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--collect-autoscalers needs a single live cluster and cannot be used with another focused mode, must-gather, import, decode or comparison mode")
+	}
+
+	if leasesMode && (webhooksMode || apiServicesMode || crdsOnlyMode || isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--collect-leases needs a single live cluster and cannot be used with another focused mode, must-gather, import, decode or comparison mode")
+	}
+
+	if webhooksMode && (apiServicesMode || crdsOnlyMode || isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--webhooks needs a single live cluster and cannot be used with --apiservices, --collect-crds-only, must-gather, import, decode or comparison mode")
+	}
+
+	if crdsOnlyMode && (apiServicesMode || isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--collect-crds-only needs a single live cluster and cannot be used with --apiservices, must-gather, import, decode or comparison mode")
+	}
+
+	if apiServicesMode && (isOfflineMode() || isComparisonMode()) {
+		return fmt.Errorf("--apiservices needs a single live cluster and cannot be used with must-gather, import, decode or comparison mode")
+	}
+
+	if maxTotalItems < 0 {
+		return fmt.Errorf("--max-total-items must not be negative")
+	}
+
+	if olderThan < 0 {
+		return fmt.Errorf("--older-than must not be negative")
+	}
+	if retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	}
+	if retryBackoff < 0 {
+		return fmt.Errorf("--retry-backoff must not be negative")
+	}
+
+	if chunkSize < 0 {
+		return fmt.Errorf("--chunk-size must not be negative")
+	}
+
+	if timeoutPerNamespace != 0 && (!allNamespacesExplicit || timeoutPerNamespace < 0) {
+		return fmt.Errorf("--timeout-per-namespace must be positive and requires --all-namespaces-explicit")
+	}
+
+	if allNamespacesExplicit && isOfflineMode() {
+		return fmt.Errorf("--all-namespaces-explicit applies to live collections and cannot be used with must-gather, import or decode mode")
+	}
+
+	if resume {
+		if clean {
+			return fmt.Errorf("--resume and --clean are mutually exclusive")
+		}
+		if isMustGatherComparisonMode() || importFile != "" || isComparisonMode() {
+			return fmt.Errorf("--resume only applies to live collections in directory mode and to must-gather processing")
+		}
+		if mustGather == "" && isSingleFileMode() {
+			return fmt.Errorf("--resume only applies to live collections in directory mode and to must-gather processing")
+		}
+	}
+
+	if err := parseArchiveLimits(); err != nil {
+		return err
+	}
+
+	if checkpointMustGather && (mustGather == "" || isMustGatherComparisonMode()) {
+		return fmt.Errorf("--checkpoint applies to must-gather processing")
+	}
+
+	if watchInterval != 0 {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--watch-interval applies to live collections in directory or single file mode")
+		}
+		if watchInterval < minWatchInterval {
+			return fmt.Errorf("--watch-interval must be at least %v", minWatchInterval)
+		}
+		if allContexts || resume || consistent || outputURL != "" {
+			return fmt.Errorf("--watch-interval cannot be combined with --all-contexts, --resume, --consistent or --output-url")
+		}
+	}
+	if watchCount < 0 || (watchCount > 0 && watchInterval == 0) {
+		return fmt.Errorf("--watch-count must not be negative and requires --watch-interval")
+	}
+
+	if diffOnly && !isComparisonMode() {
+		return fmt.Errorf("--diff-only requires comparison mode (--kubeconfig1 and --kubeconfig2)")
+	}
+
+	if sign {
+		if signingKeyPath == "" {
+			return fmt.Errorf("--sign requires --signing-key")
+		}
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--sign applies to live collections in directory or single file mode")
+		}
+		key, err := loadSigningKey(signingKeyPath)
+		if err != nil {
+			return err
+		}
+		signingKey = key
+	}
+
+	if pushRef != "" {
+		if isOfflineMode() || isComparisonMode() {
+			return fmt.Errorf("--push applies to live collections in directory or single file mode")
+		}
+		if _, err := parseOCIReference(pushRef); err != nil {
+			return err
+		}
+		if allContexts || watchInterval != 0 {
+			return fmt.Errorf("--push publishes a single collection and cannot be combined with --all-contexts or --watch-interval")
+		}
+		if decodeSecrets {
+			return fmt.Errorf("--decode-secrets is for local inspection and cannot be combined with --push")
+		}
+		if !redactSecrets && !isExcludedResource("secrets") && !contains(blockedResources, "secrets") {
+			fmt.Fprintf(os.Stderr, "WARNING: --push publishes Secrets unredacted; add --redact-secrets (or --secure) unless everyone with access to %s may read them.\n", pushRef)
+		}
+	}
+
+	if threeWayBaseline != "" {
+		if !isComparisonMode() && (mustGather1 == "" || mustGather2 == "") {
+			return fmt.Errorf("--compare-three-way requires comparison mode (--kubeconfig1 and --kubeconfig2, or --must-gather1 and --must-gather2)")
+		}
+		if compareSummaryOnly {
+			return fmt.Errorf("--compare-three-way needs the full collections and cannot be used with --compare-summary-only")
+		}
+		if _, err := os.Stat(threeWayBaseline); err != nil {
+			return fmt.Errorf("three-way baseline file: %w", err)
+		}
+	}
+
+	if compareNamespace != "" {
+		if mustGather1 == "" || mustGather2 == "" {
+			return fmt.Errorf("--compare-namespace requires must-gather comparison mode (--must-gather1 and --must-gather2)")
+		}
+		if threeWayBaseline != "" {
+			return fmt.Errorf("--compare-namespace cannot be combined with --compare-three-way, whose baseline is not filtered")
+		}
+	}
+
+	if deepDiff && compareSummaryOnly {
+		return fmt.Errorf("--deep needs the full collections and cannot be used with --compare-summary-only")
+	}
+
+	if contextLines < 0 {
+		return fmt.Errorf("--context-lines must not be negative")
+	}
+
+	if compareLabel != "" && compareSummaryOnly {
+		return fmt.Errorf("--compare-labels needs the full collections and cannot be used with --compare-summary-only")
+	}
+
+	if changedNamespaces && compareSummaryOnly {
+		return fmt.Errorf("--only-changed-namespaces needs the full collections and cannot be used with --compare-summary-only")
+	}
+
+	if err := validateDiffFormat(); err != nil {
+		return err
+	}
+
+	if collapseGenerated && !deepDiff && compareLabel == "" && !changedNamespaces {
+		return fmt.Errorf("--collapse-generated-names only affects object-level diffs and needs --deep, --compare-labels or --only-changed-namespaces")
+	}
+
+	// Parse the verbs a resource must support to be collected
+	requiredVerbs = parseList(requireVerbs)
+	if !contains(requiredVerbs, "list") {
+		return fmt.Errorf("--require-verbs must include \"list\"; resources are collected with List")
+	}
+
+	if err := validateSubresources(parseList(subresources)); err != nil {
+		return err
+	}
+
+	if len(gvrFlags) > 0 {
+		if isOfflineMode() {
+			return fmt.Errorf("--gvr applies to live collections and cannot be used with must-gather, import or decode mode")
+		}
+		gvrs, err := parseGVRs(gvrFlags)
+		if err != nil {
+			return err
+		}
+		explicitGVRs = gvrs
+	}
+
+	if crdTarget != "" {
+		if isOfflineMode() {
+			return fmt.Errorf("--crd applies to live collections and cannot be used with must-gather, import or decode mode")
+		}
+		if len(gvrFlags) > 0 {
+			return fmt.Errorf("--crd and --gvr are mutually exclusive")
+		}
+	}
+
+	if operatorName != "" {
+		if isOfflineMode() {
+			return fmt.Errorf("--operator applies to live collections and cannot be used with must-gather, import or decode mode")
+		}
+		if len(gvrFlags) > 0 || crdTarget != "" {
+			return fmt.Errorf("--operator cannot be combined with --gvr or --crd")
+		}
+	}
+
+	if restartTriage {
+		if isOfflineMode() {
+			return fmt.Errorf("--collect-pods-with-restarts applies to live collections and cannot be used with must-gather, import or decode mode")
+		}
+		if len(gvrFlags) > 0 || crdTarget != "" || operatorName != "" {
+			return fmt.Errorf("--collect-pods-with-restarts cannot be combined with --gvr, --crd or --operator")
+		}
+		if minRestarts < 1 {
+			return fmt.Errorf("--min-restarts must be at least 1")
+		}
+		explicitGVRs = []schema.GroupVersionResource{podGVR}
+	}
+
+	counts, err := parseMinCounts(assertMin)
+	if err != nil {
+		return err
+	}
+	minCounts = counts
+
+	if deprecatedThreshold != "deprecated" && deprecatedThreshold != "removed" {
+		return fmt.Errorf("invalid --deprecated-threshold %q: must be \"deprecated\" or \"removed\"", deprecatedThreshold)
+	}
+
+	// Open the --serve listener last, once every flag is known to be valid, so
+	// a busy address fails the run before collecting rather than after
+	if serveAddr != "" {
+		if err := listenForServe(); err != nil {
+			return err
+		}
+	}
+
+	// Open the JSON event stream if requested
+	if eventsFile != "" {
+		closeEvents, err := openEventStream(eventsFile)
+		if err != nil {
+			return err
+		}
+		defer closeEvents()
+	}
+
+	// Convert a protobuf collection back to YAML
+	if decodePath != "" {
+		return runDecodeMode()
+	}
+
+	// Check if import mode is enabled
+	if importFile != "" {
+		return runImportMode()
+	}
+
+	// Check if must-gather comparison mode is enabled
+	if mustGather1 != "" && mustGather2 != "" {
+		return runMustGatherComparisonMode()
+	}
+
+	if isMustGatherComparisonMode() {
+		return fmt.Errorf("must-gather comparison mode requires both --must-gather1 and --must-gather2")
+	}
+
+	// Check if must-gather mode is enabled
+	if mustGather != "" {
+		return runMustGatherMode()
+	}
+
+	// Check if comparison mode is enabled
+	if isComparisonMode() {
+		return runComparisonMode()
+	}
+
+	// Collect each kubeconfig context in turn
+	if allContexts {
+		return runFleetMode()
+	}
+
+	// Determine output mode
+	if outputFile != "" {
+		singleFile = true
+	} else if singleFile {
+		outputFile = "./output/all-resources.yaml"
+	}
+
+	// Use kubeconfig1 if provided (fallback when kubeconfig is not used), otherwise fall back to kubeconfig
+	configPath := kubeconfig
+	if configPath == "" && kubeconfig1 != "" {
+		configPath = kubeconfig1
+	}
+
+	// Parse kubeconfig
+	config, err := parseKubeConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+
+	// Create clients
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	var dynamicClient dynamic.Interface
+	dynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	if outputFormat == "protobuf" {
+		dynamicClient = newProtobufDynamicClient(config, dynamicClient, discoveryClient)
+	}
+	dynamicClient = newBlocklistDynamicClient(dynamicClient)
+
+	if collectLogs {
+		logsClient, err = kubernetes.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to create kubernetes client: %w", err)
+		}
+	}
+
+	// Explain how a resource would be handled, without collecting
+	if explainResourceName != "" {
+		return runExplainMode(discoveryClient, dynamicClient, explainResourceName)
+	}
+
+	// Focused aggregation layer health check
+	if apiServicesMode {
+		return runAPIServicesMode(dynamicClient)
+	}
+
+	// Focused CRD inventory
+	if crdsOnlyMode {
+		return runCRDsOnlyMode(dynamicClient)
+	}
+
+	// Focused admission webhook summary
+	if webhooksMode {
+		return runWebhooksMode(dynamicClient)
+	}
+
+	// Focused leader-election summary
+	if leasesMode {
+		return runLeasesMode(dynamicClient)
+	}
+
+	// Collect a single CRD and its custom resources, resolved from discovery
+	if crdTarget != "" {
+		gvr, err := resolveCRDTarget(discoveryClient, crdTarget)
+		if err != nil {
+			return err
+		}
+		explicitGVRs = []schema.GroupVersionResource{crdGVR, gvr}
+		crdTargetName = gvr.Resource + "." + gvr.Group
+		if verbose {
+			fmt.Printf("Resolved --crd %s to %s\n", crdTarget, gvr.String())
+		}
+	}
+
+	// Collect one operator's footprint, read from its ClusterServiceVersion
+	if operatorName != "" {
+		footprint, gvrs, err := resolveOperator(dynamicClient, operatorName)
+		if err != nil {
+			return err
+		}
+		explicitGVRs = gvrs
+		activeOperator = footprint
+		fmt.Printf("Operator %s: CSV %s/%s owns %d CRDs and %d APIServices\n",
+			operatorName, footprint.namespace, footprint.csvName, len(footprint.crds), len(footprint.apiServices))
+	}
+
+	// Focused autoscaling summary
+	if autoscalersMode {
+		return runAutoscalersMode(dynamicClient)
+	}
+
+	// Focused storage-layer snapshot
+	if storageCSIMode {
+		return runStorageCSIMode(dynamicClient)
+	}
+
+	// Focused RBAC subject-to-permission graph
+	if rbacGraphMode {
+		return runRBACGraphMode(dynamicClient)
+	}
+
+	// Repeated snapshots from informer caches
+	if watchInterval > 0 {
+		return runWatchMode(discoveryClient, dynamicClient)
+	}
+
+	if singleFile {
+		// Single file mode
+		if outputFile == "" {
+			outputFile = "./output/all-resources.yaml"
+		}
+
+		// Parse rotation size if requested
+		if maxFileSize != "" {
+			size, err := parseByteSize(maxFileSize)
+			if err != nil {
+				return fmt.Errorf("invalid --max-file-size: %w", err)
+			}
+			maxFileSizeBytes = size
+		}
+
+		// Ensure output directory exists
+		if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		// Clean file if requested
+		if clean {
+			if err := os.Remove(outputFile); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to clean output file: %w", err)
+			}
+			if err := cleanPartFiles(outputFile); err != nil {
+				return fmt.Errorf("failed to clean output file parts: %w", err)
+			}
+		}
+
+		if err := collectAllResourcesToSingleFile(discoveryClient, dynamicClient, outputFile); err != nil {
+			return err
+		}
+		if err := uploadOutputFile(outputFile); err != nil {
+			return err
+		}
+		return serveCollection()
+	} else {
+		// Directory mode
+		// Ensure output directory exists
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		// Clean directory if requested
+		if clean {
+			if err := cleanDirectory(outputDir); err != nil {
+				return fmt.Errorf("failed to clean output directory: %w", err)
+			}
+		}
+
+		if err := collectResources(discoveryClient, dynamicClient, outputDir); err != nil {
+			return err
+		}
+		if err := uploadOutputDir(outputDir); err != nil {
+			return err
+		}
+		return serveCollection()
+	}
+}
+
+func parseKubeConfig(kubeconfigPath string) (*rest.Config, error) {
+	// Several files are merged with kubectl's KUBECONFIG precedence
+	if paths := kubeconfigPaths(kubeconfigPath); len(paths) > 1 {
+		config, err := buildMergedConfig(paths)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyProxy(config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+
+	var configPath string
+
+	// Priority: flag > environment variable > default location
+	if kubeconfigPath != "" {
+		configPath = kubeconfigPath
+	} else if envKubeconfig := os.Getenv("KUBECONFIG"); envKubeconfig != "" {
+		configPath = envKubeconfig
+	} else {
+		configPath = filepath.Join(homedir.HomeDir(), ".kube", "config")
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("kubeconfig file not found at %s", configPath)
+	}
+
+	config, err := buildConfigFromFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build config from kubeconfig: %w", err)
+	}
+
+	if err := applyProxy(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// detectClusterVersion detects the Kubernetes and OpenShift versions
+func detectClusterVersion(discovery discovery.DiscoveryInterface, dynamic dynamic.Interface) (*ClusterVersion, error) {
+	var serverVersion *version.Info
+	err := withRetry("server version", func() error {
+		var err error
+		serverVersion, err = discovery.ServerVersion()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	cv := &ClusterVersion{}
+
+	// Parse Kubernetes version
+	major, minor, err := parseServerVersion(serverVersion.Major, serverVersion.Minor, serverVersion.GitVersion)
+	if err != nil {
+		return nil, err
+	}
+	cv.Major = major
+	cv.Minor = minor
+
+	// Check if this is OpenShift by looking for OpenShift-specific API groups
+	apiGroups, err := discovery.ServerGroups()
+	if err == nil {
+		for _, group := range apiGroups.Groups {
+			if strings.Contains(group.Name, "openshift.io") {
+				cv.IsOpenShift = true
+				break
+			}
+		}
+	}
+
+	// Try to detect OpenShift version from the platform
+	if cv.IsOpenShift {
+		// Prefer the real version reported by the ClusterVersion resource
+		if osMajor, osMinor, err := getOpenShiftVersion(dynamic); err == nil {
+			cv.OpenShiftMajor = osMajor
+			cv.OpenShiftMinor = osMinor
+			cv.OpenShiftVersionSource = "clusterversion"
+		} else {
+			if verbose {
+				fmt.Printf("Could not read OpenShift ClusterVersion: %v\n", err)
+			}
+			// OpenShift 4.X ships Kubernetes 1.(X+13)
+			// For example, Kubernetes 1.27 = OpenShift 4.14, Kubernetes 1.25 = OpenShift 4.12
+			if cv.Major == 1 && cv.Minor >= 14 {
+				cv.OpenShiftMajor = 4
+				cv.OpenShiftMinor = cv.Minor - 13
+				cv.OpenShiftVersionSource = "estimated"
+			}
+		}
+	}
+
+	if verbose {
+		fmt.Printf("Detected Kubernetes version: %d.%d\n", cv.Major, cv.Minor)
+		if cv.IsOpenShift {
+			switch cv.OpenShiftVersionSource {
+			case "clusterversion":
+				fmt.Printf("Detected OpenShift cluster (version: %d.%d)\n",
+					cv.OpenShiftMajor, cv.OpenShiftMinor)
+			case "estimated":
+				fmt.Printf("Detected OpenShift cluster (estimated version: %d.%d)\n",
+					cv.OpenShiftMajor, cv.OpenShiftMinor)
+			default:
+				fmt.Println("Detected OpenShift cluster (unknown version, OpenShift deprecation rules disabled)")
+			}
+		}
+	}
+
+	return cv, nil
+}
+
+// gitVersionPattern matches the leading major.minor of a GitVersion such as "v1.27.3-eks-a5565ad"
+var gitVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// parseServerVersion returns the Kubernetes major and minor version. Some managed
+// clusters report an empty or non-numeric Major/Minor (e.g. "", "27+"), so when
+// those can't be parsed it falls back to the GitVersion string.
+func parseServerVersion(majorStr, minorStr, gitVersion string) (int, int, error) {
+	major, majorErr := strconv.Atoi(strings.TrimSuffix(majorStr, "+"))
+	minor, minorErr := strconv.Atoi(strings.TrimSuffix(minorStr, "+"))
+	if majorErr == nil && minorErr == nil {
+		return major, minor, nil
+	}
+
+	matches := gitVersionPattern.FindStringSubmatch(strings.TrimSpace(gitVersion))
+	if matches == nil {
+		if majorErr != nil {
+			return 0, 0, fmt.Errorf("failed to parse major version %q (git version %q)", majorStr, gitVersion)
+		}
+		return 0, 0, fmt.Errorf("failed to parse minor version %q (git version %q)", minorStr, gitVersion)
+	}
+
+	// The pattern only matches digits, so these conversions cannot fail
+	major, _ = strconv.Atoi(matches[1])
+	minor, _ = strconv.Atoi(matches[2])
+
+	if verbose {
+		fmt.Printf("Parsed Kubernetes version from git version %s\n", gitVersion)
+	}
+
+	return major, minor, nil
+}
+
+// getOpenShiftVersion reads the OpenShift version from the cluster's ClusterVersion resource
+func getOpenShiftVersion(dynamic dynamic.Interface) (int, int, error) {
+	if dynamic == nil {
+		return 0, 0, fmt.Errorf("no dynamic client available")
+	}
+
+	gvr := schema.GroupVersionResource{
+		Group:    "config.openshift.io",
+		Version:  "v1",
+		Resource: "clusterversions",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	clusterVersion, err := dynamic.Resource(gvr).Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The desired version is what the cluster is running (or upgrading to)
+	version, _, _ := unstructured.NestedString(clusterVersion.Object, "status", "desired", "version")
+	if version == "" {
+		history, _, _ := unstructured.NestedSlice(clusterVersion.Object, "status", "history")
+		if len(history) > 0 {
+			if entry, ok := history[0].(map[string]interface{}); ok {
+				version, _ = entry["version"].(string)
+			}
+		}
+	}
+	if version == "" {
+		return 0, 0, fmt.Errorf("clusterversion has no version in status")
+	}
+
+	return parseMajorMinor(version)
+}
+
+// parseMajorMinor parses the major and minor components of a version such as "4.15.3"
+func parseMajorMinor(version string) (int, int, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid version %q", version)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	return major, minor, nil
+}
+
+// resetCollectionState clears per-run state, since comparison mode collects twice
+func resetCollectionState() {
+	deprecatedInUse = nil
+	filteredCounts = make(map[string]int)
+	logsCollected = 0
+	logErrors = 0
+	quotaLines = nil
+	storageVolumes = nil
+	pdbBudgets = nil
+	pdbWorkloads = nil
+	pdbCollected = false
+	kustomizeResources = nil
+	helmTemplates = 0
+	helmNamespaces = nil
+	helmImages = nil
+	netpolPolicies = nil
+	netpolPods = nil
+	netpolCollected = false
+	namespaceRollups = nil
+	stuckObjects = nil
+	ownershipNodes = nil
+	schemaViolations = nil
+	schemaValidated = 0
+	servedObjects = nil
+	servedAliases = nil
+	conversionFailures = nil
+	expectedFound = make(map[string]map[string]interface{})
+	unexpectedObjects = nil
+	storageClaims = nil
+	collectionErrors = nil
+	imagesRewritten = 0
+	truncatedResources = nil
+	valuesRedacted = 0
+	defaultsPruned = 0
+	secretsDecoded = 0
+	apiVersionsNormalized = 0
+	tablesWritten = 0
+	blockedSeen = make(map[string]bool)
+}
+
+// getDeprecationRules returns a list of known deprecation rules
+func getDeprecationRules() []DeprecationRule {
+	return []DeprecationRule{
+		{
+			GroupVersion:        "v1",
+			Resource:            "componentstatuses",
+			DeprecatedFrom:      "1.19",
+			ReplacementGV:       "", // No replacement - component status is deprecated without replacement
+			ReplacementResource: "",
+			IsOpenShift:         false,
+		},
+		{
+			GroupVersion:        "v1",
+			Resource:            "endpoints",
+			DeprecatedFrom:      "1.33",
+			ReplacementGV:       "discovery.k8s.io/v1",
+			ReplacementResource: "endpointslices",
+			IsOpenShift:         false,
+		},
+		{
+			GroupVersion:        "batch/v1beta1",
+			Resource:            "cronjobs",
+			DeprecatedFrom:      "1.21",
+			ReplacementGV:       "batch/v1",
+			ReplacementResource: "cronjobs",
+			IsOpenShift:         false,
+			RemovedIn:           "1.25",
+		},
+		{
+			GroupVersion:        "policy/v1beta1",
+			Resource:            "poddisruptionbudgets",
+			DeprecatedFrom:      "1.21",
+			ReplacementGV:       "policy/v1",
+			ReplacementResource: "poddisruptionbudgets",
+			IsOpenShift:         false,
+			RemovedIn:           "1.25",
+		},
+		{
+			GroupVersion:        "autoscaling/v2beta2",
+			Resource:            "horizontalpodautoscalers",
+			DeprecatedFrom:      "1.23",
+			ReplacementGV:       "autoscaling/v2",
+			ReplacementResource: "horizontalpodautoscalers",
+			IsOpenShift:         false,
+			RemovedIn:           "1.26",
+		},
+		{
+			GroupVersion:        "apps.openshift.io/v1",
+			Resource:            "deploymentconfigs",
+			DeprecatedFrom:      "4.14",
+			ReplacementGV:       "", // DeploymentConfigs should be migrated to standard Deployments
+			ReplacementResource: "",
+			IsOpenShift:         true,
+		},
+	}
+}
+
+// isDeprecated checks if a resource is deprecated based on cluster version
+// Returns: (isDeprecated, replacementGV, replacementResource, message)
+func isDeprecated(resource metav1.APIResource, groupVersion string, clusterVersion *ClusterVersion) (bool, string, string, string) {
+	rules := getDeprecationRules()
+
+	for _, rule := range rules {
+		// Check if this rule applies to this resource
+		if rule.GroupVersion != groupVersion || rule.Resource != resource.Name {
+			continue
+		}
+
+		// Check if we should apply OpenShift-specific rules
+		if rule.IsOpenShift && !clusterVersion.IsOpenShift {
+			continue
+		}
+
+		// OpenShift rules need a known OpenShift version to compare against
+		if rule.IsOpenShift && clusterVersion.OpenShiftMajor == 0 {
+			continue
+		}
+
+		// Parse the deprecation version
+		depMajor, depMinor, err := parseMajorMinor(rule.DeprecatedFrom)
+		if err != nil {
+			continue
+		}
+
+		// Compare versions
+		var isDeprecated bool
+		if rule.IsOpenShift {
+			// Compare against OpenShift version
+			if clusterVersion.OpenShiftMajor > depMajor ||
+				(clusterVersion.OpenShiftMajor == depMajor && clusterVersion.OpenShiftMinor >= depMinor) {
+				isDeprecated = true
+			}
+		} else {
+			// Compare against Kubernetes version
+			if clusterVersion.Major > depMajor ||
+				(clusterVersion.Major == depMajor && clusterVersion.Minor >= depMinor) {
+				isDeprecated = true
+			}
+		}
+
+		if isDeprecated {
+			var msg string
+			if rule.ReplacementGV != "" && rule.ReplacementResource != "" {
+				msg = fmt.Sprintf("Using %s/%s instead of deprecated %s/%s",
+					rule.ReplacementGV, rule.ReplacementResource, groupVersion, resource.Name)
+			} else {
+				msg = fmt.Sprintf("Skipping deprecated %s/%s (no replacement available)",
+					groupVersion, resource.Name)
+			}
+			return true, rule.ReplacementGV, rule.ReplacementResource, msg
+		}
+	}
+
+	return false, "", "", ""
+}
 
 // shouldSkipResource determines if a resource should be skipped
 // Returns: (shouldSkip, message)
@@ -367,32 +1602,75 @@ func collectResources(discovery discovery.DiscoveryInterface, dynamic dynamic.In
 	}
 
 	// Detect cluster version
-	clusterVersion, err := detectClusterVersion(discovery)
+	clusterVersion, err := detectClusterVersion(discovery, dynamic)
 	if err != nil {
 		fmt.Printf("Warning: failed to detect cluster version: %v\n", err)
 		fmt.Println("Continuing without deprecation checks...")
 		clusterVersion = nil
 	}
 
+	resetCollectionState()
+	customColumnsDir = filepath.Join(outputDir, customColumnsDirName)
+
+	// Pin a resourceVersion baseline for consistent snapshots
+	if err := prepareSnapshot(dynamic); err != nil {
+		return err
+	}
+
+	// Learn namespace and node names up front so they are replaced wherever they appear
+	prepareAnonymizer(dynamic)
+
+	// Count instances of every deprecated API still served, in any version
+	recordDeprecatedServed(discovery, dynamic, clusterVersion)
+
+	// List namespaces up front for per-namespace collection
+	if err := prepareNamespaces(dynamic); err != nil {
+		return err
+	}
+
+	// Read CRD schemas up front so custom resources can be validated as they are collected
+	if err := prepareCRDSchemas(dynamic); err != nil {
+		return err
+	}
+
+	// Index Events up front so each resource file can carry its objects' events
+	if err := prepareObjectEvents(dynamic); err != nil {
+		return err
+	}
+
+	// Record what the cluster exposes before collecting anything
+	if emitDiscovery {
+		if err := writeDiscoveryManifest(discovery, filepath.Join(outputDir, discoveryManifestFile)); err != nil {
+			return err
+		}
+	}
+
 	// Get all API resources
-	resources, err := discovery.ServerPreferredResources()
+	emitEvent(eventDiscoveryStarted, nil)
+	resources, err := discoverResources(discovery)
 	if err != nil {
+		emitEvent(eventDiscoveryError, map[string]interface{}{"error": err.Error()})
 		return fmt.Errorf("failed to discover API resources: %w", err)
 	}
+	emitEvent(eventDiscoveryFinished, map[string]interface{}{"groupVersions": len(resources)})
 
 	collectedCount := 0
 	errorCount := 0
 	skippedCount := 0
+	resumedCount := 0
+	itemCount := 0
+	resourceCounts := make(map[string]int)
+	seenResources := make(map[string]bool)
 
 	for _, resourceList := range resources {
 		for _, resource := range resourceList.APIResources {
-			// Skip subresources
-			if strings.Contains(resource.Name, "/") {
+			// Skip subresources (unless requested) and resources missing required verbs
+			if !isCollectable(resource) {
 				continue
 			}
 
-			// Only collect resources that support list and get verbs
-			if !contains(resource.Verbs, "list") || !contains(resource.Verbs, "get") {
+			// After an upgrade, discovery can list the same resource twice
+			if isDuplicateResource(seenResources, resource.Name, resourceList.GroupVersion) {
 				continue
 			}
 
@@ -402,47 +1680,224 @@ func collectResources(discovery discovery.DiscoveryInterface, dynamic dynamic.In
 					if verbose {
 						fmt.Printf("%s\n", msg)
 					}
+					emitEvent(eventResourceSkipped, map[string]interface{}{
+						"resource":     resource.Name,
+						"groupVersion": resourceList.GroupVersion,
+						"reason":       msg,
+					})
 					skippedCount++
 					continue
 				}
 			}
 
+			// Skip resources an interrupted earlier run already wrote
+			if resume && isAlreadyCollected(outputDir, resource.Name, resourceList.GroupVersion) {
+				filePath := collectedFilePath(outputDir, resource.Name, resourceList.GroupVersion)
+				// The kept file belongs to this collection, so it is signed and pushed with it
+				recordOutputFile(filePath)
+				// --assert-min counts the items an earlier run wrote too
+				if len(minCounts) > 0 {
+					if items, err := countFileItems(filePath); err == nil {
+						resourceCounts[resourceCountKey(resource.Name, resourceList.GroupVersion)] += items
+					} else {
+						fmt.Printf("Warning: failed to count the items of %s for --assert-min: %v\n", filePath, err)
+					}
+				}
+				if verbose {
+					fmt.Printf("Skipping %s (%s): already collected\n", resource.Name, resourceList.GroupVersion)
+				}
+				emitEvent(eventResourceSkipped, map[string]interface{}{
+					"resource":     resource.Name,
+					"groupVersion": resourceList.GroupVersion,
+					"reason":       "already collected",
+				})
+				resumedCount++
+				continue
+			}
+
+			// Stop taking on new resources once the global item cap is reached
+			if itemCapReached(itemCount) {
+				skipForItemCap(resource.Name, resourceList.GroupVersion)
+				continue
+			}
+
 			if verbose {
 				fmt.Printf("Collecting resource: %s (%s)\n", resource.Name, resourceList.GroupVersion)
 			}
-
-			err := collectResource(dynamic, resource, resourceList.GroupVersion, outputDir)
+			emitEvent(eventResourceStarted, map[string]interface{}{
+				"resource":     resource.Name,
+				"groupVersion": resourceList.GroupVersion,
+			})
+
+			items, err := collectWithRediscovery(discovery, resource, resourceList.GroupVersion, func(groupVersion string) (int, error) {
+				return collectResource(dynamic, resource, groupVersion, outputDir)
+			})
 			if err != nil {
 				if verbose {
 					fmt.Printf("  %s: ERROR - %v\n", resource.Name, err)
 				}
+				emitEvent(eventResourceError, map[string]interface{}{
+					"resource":     resource.Name,
+					"groupVersion": resourceList.GroupVersion,
+					"error":        err.Error(),
+				})
+				recordCollectionError(resource.Name, resourceList.GroupVersion, err)
 				errorCount++
 			} else {
+				emitEvent(eventResourceFinished, map[string]interface{}{
+					"resource":     resource.Name,
+					"groupVersion": resourceList.GroupVersion,
+					"items":        items,
+				})
 				collectedCount++
+				itemCount += items
+				resourceCounts[resourceCountKey(resource.Name, resourceList.GroupVersion)] += items
+
+				if err := writeResourceTable(discovery, resource, resourceList.GroupVersion, filepath.Join(outputDir, tablesDirName)); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				}
 			}
 		}
 	}
 
+	// Fetch container logs once pods have been collected
+	if _, ok := resourceCounts["pods"]; ok && collectLogs {
+		collectPodLogs(filepath.Join(outputDir, "logs"))
+	}
+
+	if err := writeAnonymizeMapping(anonymizeMappingPath(outputDir)); err != nil {
+		return err
+	}
+
+	if err := writeQuotaReport(filepath.Join(outputDir, quotaReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeStorageReport(filepath.Join(outputDir, storageReportFile)); err != nil {
+		return err
+	}
+
+	if err := writePDBReport(filepath.Join(outputDir, pdbReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeNetpolReport(filepath.Join(outputDir, netpolReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeNamespaceSummary(filepath.Join(outputDir, namespaceSummaryFile)); err != nil {
+		return err
+	}
+
+	if err := writeStuckReport(filepath.Join(outputDir, stuckReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeKustomization(outputDir); err != nil {
+		return err
+	}
+
+	if err := writeHelmChart(outputDir); err != nil {
+		return err
+	}
+
+	if err := writeControllerInventory(filepath.Join(outputDir, controllerInventoryFile)); err != nil {
+		return err
+	}
+
+	if err := writeSchemaReport(filepath.Join(outputDir, schemaReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeExpectedReport(filepath.Join(outputDir, expectedReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeMetricsSnapshot(dynamic, outputDir); err != nil {
+		return err
+	}
+
+	if err := writeCollectionErrors(filepath.Join(outputDir, errorsFile)); err != nil {
+		return err
+	}
+
+	if err := writeNamespaceManifest(filepath.Join(outputDir, namespaceManifestFile)); err != nil {
+		return err
+	}
+
+	// Sign last, so the checksums cover every file written above. Only this
+	// run's files are signed, the same ones that are pushed.
+	if err := writeSignedChecksums(outputDir, writtenOutputFiles(outputDir)); err != nil {
+		return err
+	}
+
+	// Push after signing, so the artifact carries the signature
+	if pushRef != "" {
+		if err := pushCollection(outputDir, writtenOutputFiles(outputDir)); err != nil {
+			return err
+		}
+	}
+
 	// Print summary
 	duration := time.Since(startTime)
+	emitEvent(eventSummary, map[string]interface{}{
+		"collected": collectedCount,
+		"skipped":   skippedCount,
+		"errors":    errorCount,
+		"items":     itemCount,
+		"duration":  duration.String(),
+	})
 	fmt.Printf("\n=== Collection Summary ===\n")
 	fmt.Printf("Successfully collected: %d resources\n", collectedCount)
 	if skippedCount > 0 {
 		fmt.Printf("Skipped deprecated: %d resources\n", skippedCount)
 	}
+	if resumedCount > 0 {
+		fmt.Printf("Already collected (resumed): %d resources\n", resumedCount)
+	}
 	fmt.Printf("Errors encountered: %d resources\n", errorCount)
+	if errorCount > 0 {
+		fmt.Printf("Error details: %s\n", filepath.Join(outputDir, errorsFile))
+	}
+	printItemCapSummary(itemCount)
+	printConversionSummary()
+	printSnapshotSummary()
+	printFilterSummary()
+	printBlockedSummary()
+	printLogsSummary()
+	printNamespaceSummary()
+	if len(registryRewrites) > 0 {
+		fmt.Printf("Images rewritten: %d\n", imagesRewritten)
+	}
+	if len(redactPatterns) > 0 {
+		fmt.Printf("Values redacted: %d\n", valuesRedacted)
+	}
+	if len(defaultRules) > 0 {
+		fmt.Printf("Default fields pruned: %d\n", defaultsPruned)
+	}
+	if decodeSecrets {
+		fmt.Printf("Secrets decoded: %d (output contains readable secret values)\n", secretsDecoded)
+	}
+	if normalizeVersions {
+		fmt.Printf("API versions normalized: %d\n", apiVersionsNormalized)
+	}
+	if tableOutput {
+		fmt.Printf("Tables written: %d\n", tablesWritten)
+	}
 	fmt.Printf("Output directory: %s\n", outputDir)
 	fmt.Printf("Duration: %v\n", duration)
 	fmt.Printf("========================\n")
 
-	return nil
+	return checkCollectionGates(resourceCounts)
 }
 
-func collectResource(dynamic dynamic.Interface, resource metav1.APIResource, groupVersion string, outputDir string) error {
+// collectResource lists all instances of a resource and writes them to their own
+// file in outputDir. It returns the number of items collected.
+func collectResource(dynamic dynamic.Interface, resource metav1.APIResource, groupVersion string, outputDir string) (int, error) {
 	// Parse group version
 	gv, err := schema.ParseGroupVersion(groupVersion)
 	if err != nil {
-		return fmt.Errorf("failed to parse group version: %w", err)
+		return 0, fmt.Errorf("failed to parse group version: %w", err)
 	}
 
 	// Create GVR
@@ -452,40 +1907,121 @@ func collectResource(dynamic dynamic.Interface, resource metav1.APIResource, gro
 		Resource: resource.Name,
 	}
 
-	// Get all instances of this resource across all namespaces
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// Get all instances of this resource across all namespaces; a CRD whose
+	// conversion webhook is down may be read with its storage version instead
+	unstructuredList, groupVersion, err := listWithConversionFallback(dynamic, gvr, resource.Namespaced)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get resource instances for %s: %w", resource.Name, err)
+	}
+
+	// Apply client-side filters
+	applyItemFilters(unstructuredList)
+	recordOwnership(unstructuredList)
+	validateCustomResources(unstructuredList)
+	applyItemTransforms(unstructuredList)
+	normalizeAPIVersions(unstructuredList, groupVersion, resource.Name)
+	recordQuotaUsage(resource.Name, unstructuredList)
+	recordStorage(resource.Name, unstructuredList)
+	recordPDBCoverage(resource.Name, unstructuredList)
+	recordNetworkPolicies(resource.Name, unstructuredList)
+	recordNamespaceSummary(resource.Name, unstructuredList)
+	recordStuckObjects(unstructuredList)
+	recordServedObjects(resource.Name, unstructuredList)
+	recordExpectedMatches(unstructuredList)
+	if err := writeCustomColumns(resource.Name, groupVersion, unstructuredList); err != nil {
+		return 0, fmt.Errorf("failed to write custom columns for %s: %w", resource.Name, err)
+	}
+
+	// A kustomize base or Helm chart holds one file per object; subresources cannot be applied
+	if kustomizeBase || helmChart != "" {
+		if strings.Contains(resource.Name, "/") {
+			return 0, nil
+		}
+		writeObjects := writeKustomizeObjects
+		if helmChart != "" {
+			writeObjects = writeHelmTemplates
+		}
+		if err := writeObjects(outputDir, unstructuredList); err != nil {
+			return 0, err
+		}
+		if verbose {
+			fmt.Printf("  %s: SUCCESS - Saved %d objects\n", resource.Name, len(unstructuredList.Items))
+		}
+		return len(unstructuredList.Items), nil
+	}
 
-	unstructuredList, err := dynamic.Resource(gvr).List(ctx, metav1.ListOptions{})
+	// Create filename and path
+	filePath, err := resourceFilePath(outputDir, resource.Name, gv)
 	if err != nil {
-		return fmt.Errorf("failed to get resource instances for %s: %w", resource.Name, err)
+		return 0, err
+	}
+
+	// Large resources are written one file per namespace
+	if isLargeResource(unstructuredList, resource.Namespaced) {
+		namespaces, err := writeSplitByNamespace(unstructuredList, resource.Name, groupVersion, filePath)
+		if err != nil {
+			return 0, err
+		}
+		if verbose {
+			fmt.Printf("  %s: SUCCESS - Saved %d items across %d namespaces\n", resource.Name, len(unstructuredList.Items), namespaces)
+		}
+		return len(unstructuredList.Items), nil
+	}
+
+	// Built-in resources are stored as protobuf; custom resources stay YAML
+	if outputFormat == "protobuf" {
+		if data, ok := encodeProtobufList(unstructuredList, groupVersion); ok {
+			filePath = strings.TrimSuffix(filePath, ".yaml") + protobufExtension
+			if err := writeFileAtomic(filePath, data); err != nil {
+				return 0, fmt.Errorf("failed to write file %s: %w", filePath, err)
+			}
+			if verbose {
+				fmt.Printf("  %s: SUCCESS - Saved to %s\n", resource.Name, filePath)
+			}
+			return len(unstructuredList.Items), nil
+		}
 	}
 
 	// Convert to YAML
-	yamlData, err := yaml.Marshal(unstructuredList)
+	yamlData, err := marshalResourceList(unstructuredList)
 	if err != nil {
-		return fmt.Errorf("failed to marshal %s to YAML: %w", resource.Name, err)
+		return 0, fmt.Errorf("failed to marshal %s to YAML: %w", resource.Name, err)
 	}
 
-	// Create filename and path
-	filename := formatFilename(resource.Name, groupVersion)
-	filePath := filepath.Join(outputDir, filename)
-
 	// Create header
 	header := formatHeader(resource.Name, groupVersion)
-	finalYaml := header + string(yamlData)
+	finalYaml := header + string(yamlData) + formatEmbeddedEvents(unstructuredList)
 
 	// Write to file
-	err = os.WriteFile(filePath, []byte(finalYaml), 0644)
+	err = writeFileAtomic(filePath, []byte(finalYaml))
 	if err != nil {
-		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+		return 0, fmt.Errorf("failed to write file %s: %w", filePath, err)
 	}
 
 	if verbose {
 		fmt.Printf("  %s: SUCCESS - Saved to %s\n", resource.Name, filePath)
 	}
 
-	return nil
+	return len(unstructuredList.Items), nil
+}
+
+// listResource lists all instances of a resource across all namespaces.
+// Subresources such as deployments/status are fetched per object of the parent.
+func listResource(dynamic dynamic.Interface, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+	if strings.Contains(gvr.Resource, "/") {
+		return listSubresource(dynamic, gvr)
+	}
+
+	var list *unstructured.UnstructuredList
+	err := withRetry("list "+gvr.Resource, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var err error
+		list, err = listPaged(ctx, dynamic.Resource(gvr), metav1.ListOptions{})
+		return err
+	})
+	return list, err
 }
 
 func collectAllResourcesToSingleFile(discovery discovery.DiscoveryInterface, dynamic dynamic.Interface, outputFile string) error {
@@ -496,33 +2032,70 @@ func collectAllResourcesToSingleFile(discovery discovery.DiscoveryInterface, dyn
 	}
 
 	// Detect cluster version
-	clusterVersion, err := detectClusterVersion(discovery)
+	clusterVersion, err := detectClusterVersion(discovery, dynamic)
 	if err != nil {
 		fmt.Printf("Warning: failed to detect cluster version: %v\n", err)
 		fmt.Println("Continuing without deprecation checks...")
 		clusterVersion = nil
 	}
 
+	resetCollectionState()
+	customColumnsDir = filepath.Join(filepath.Dir(outputFile), customColumnsDirName)
+
+	// Pin a resourceVersion baseline for consistent snapshots
+	if err := prepareSnapshot(dynamic); err != nil {
+		return err
+	}
+
+	// Learn namespace and node names up front so they are replaced wherever they appear
+	prepareAnonymizer(dynamic)
+
+	// Count instances of every deprecated API still served, in any version
+	recordDeprecatedServed(discovery, dynamic, clusterVersion)
+
+	// List namespaces up front for per-namespace collection
+	if err := prepareNamespaces(dynamic); err != nil {
+		return err
+	}
+
+	// Read CRD schemas up front so custom resources can be validated as they are collected
+	if err := prepareCRDSchemas(dynamic); err != nil {
+		return err
+	}
+
+	// Record what the cluster exposes before collecting anything
+	if emitDiscovery {
+		if err := writeDiscoveryManifest(discovery, filepath.Join(filepath.Dir(outputFile), discoveryManifestFile)); err != nil {
+			return err
+		}
+	}
+
 	// Get all API resources
-	resources, err := discovery.ServerPreferredResources()
+	emitEvent(eventDiscoveryStarted, nil)
+	resources, err := discoverResources(discovery)
 	if err != nil {
+		emitEvent(eventDiscoveryError, map[string]interface{}{"error": err.Error()})
 		return fmt.Errorf("failed to discover API resources: %w", err)
 	}
+	emitEvent(eventDiscoveryFinished, map[string]interface{}{"groupVersions": len(resources)})
 
-	var allResourcesYaml strings.Builder
+	writer := newSingleFileWriter(outputFile, maxFileSizeBytes)
 	collectedCount := 0
 	errorCount := 0
 	skippedCount := 0
+	itemCount := 0
+	resourceCounts := make(map[string]int)
+	seenResources := make(map[string]bool)
 
 	for _, resourceList := range resources {
 		for _, resource := range resourceList.APIResources {
-			// Skip subresources
-			if strings.Contains(resource.Name, "/") {
+			// Skip subresources (unless requested) and resources missing required verbs
+			if !isCollectable(resource) {
 				continue
 			}
 
-			// Only collect resources that support list and get verbs
-			if !contains(resource.Verbs, "list") || !contains(resource.Verbs, "get") {
+			// After an upgrade, discovery can list the same resource twice
+			if isDuplicateResource(seenResources, resource.Name, resourceList.GroupVersion) {
 				continue
 			}
 
@@ -532,83 +2105,431 @@ func collectAllResourcesToSingleFile(discovery discovery.DiscoveryInterface, dyn
 					if verbose {
 						fmt.Printf("%s\n", msg)
 					}
+					emitEvent(eventResourceSkipped, map[string]interface{}{
+						"resource":     resource.Name,
+						"groupVersion": resourceList.GroupVersion,
+						"reason":       msg,
+					})
 					skippedCount++
 					continue
 				}
 			}
 
-			if verbose {
-				fmt.Printf("Collecting resource: %s (%s)\n", resource.Name, resourceList.GroupVersion)
-			}
+			// Stop taking on new resources once the global item cap is reached
+			if itemCapReached(itemCount) {
+				skipForItemCap(resource.Name, resourceList.GroupVersion)
+				continue
+			}
+
+			if verbose {
+				fmt.Printf("Collecting resource: %s (%s)\n", resource.Name, resourceList.GroupVersion)
+			}
+			emitEvent(eventResourceStarted, map[string]interface{}{
+				"resource":     resource.Name,
+				"groupVersion": resourceList.GroupVersion,
+			})
+
+			var block strings.Builder
+			items, err := collectWithRediscovery(discovery, resource, resourceList.GroupVersion, func(groupVersion string) (int, error) {
+				block.Reset()
+				return collectResourceToBuffer(dynamic, resource, groupVersion, &block)
+			})
+			if err == nil {
+				err = writer.WriteBlock(block.String())
+			}
+			if err != nil {
+				if verbose {
+					fmt.Printf("  %s: ERROR - %v\n", resource.Name, err)
+				}
+				emitEvent(eventResourceError, map[string]interface{}{
+					"resource":     resource.Name,
+					"groupVersion": resourceList.GroupVersion,
+					"error":        err.Error(),
+				})
+				recordCollectionError(resource.Name, resourceList.GroupVersion, err)
+				errorCount++
+			} else {
+				emitEvent(eventResourceFinished, map[string]interface{}{
+					"resource":     resource.Name,
+					"groupVersion": resourceList.GroupVersion,
+					"items":        items,
+				})
+				collectedCount++
+				itemCount += items
+				resourceCounts[resourceCountKey(resource.Name, resourceList.GroupVersion)] += items
+
+				if err := writeResourceTable(discovery, resource, resourceList.GroupVersion, filepath.Join(filepath.Dir(outputFile), tablesDirName)); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				}
+			}
+		}
+	}
+
+	// Flush the last part to disk
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	// Catch a resource whose YAML broke the document stream
+	if validateOutput {
+		if err := validateSingleFileStream(writer.Paths()); err != nil {
+			return err
+		}
+	}
+
+	// Fetch container logs once pods have been collected
+	if _, ok := resourceCounts["pods"]; ok && collectLogs {
+		collectPodLogs(filepath.Join(filepath.Dir(outputFile), "logs"))
+	}
+
+	if err := writeAnonymizeMapping(anonymizeMappingPath(filepath.Dir(outputFile))); err != nil {
+		return err
+	}
+
+	if err := writeQuotaReport(filepath.Join(filepath.Dir(outputFile), quotaReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeStorageReport(filepath.Join(filepath.Dir(outputFile), storageReportFile)); err != nil {
+		return err
+	}
+
+	if err := writePDBReport(filepath.Join(filepath.Dir(outputFile), pdbReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeNetpolReport(filepath.Join(filepath.Dir(outputFile), netpolReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeNamespaceSummary(filepath.Join(filepath.Dir(outputFile), namespaceSummaryFile)); err != nil {
+		return err
+	}
+
+	if err := writeStuckReport(filepath.Join(filepath.Dir(outputFile), stuckReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeControllerInventory(filepath.Join(filepath.Dir(outputFile), controllerInventoryFile)); err != nil {
+		return err
+	}
+
+	if err := writeSchemaReport(filepath.Join(filepath.Dir(outputFile), schemaReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeExpectedReport(filepath.Join(filepath.Dir(outputFile), expectedReportFile)); err != nil {
+		return err
+	}
+
+	if err := writeMetricsSnapshot(dynamic, filepath.Dir(outputFile)); err != nil {
+		return err
+	}
+
+	if err := writeCollectionErrors(filepath.Join(filepath.Dir(outputFile), errorsFile)); err != nil {
+		return err
+	}
+
+	if err := writeNamespaceManifest(filepath.Join(filepath.Dir(outputFile), namespaceManifestFile)); err != nil {
+		return err
+	}
+
+	if err := writeBaselineDrift(outputFile); err != nil {
+		return err
+	}
+
+	// Sign and push every file this run wrote beside the output: the
+	// collection file and its parts, and the reports written above
+	if err := writeSignedChecksums(filepath.Dir(outputFile), writtenOutputFiles(filepath.Dir(outputFile))); err != nil {
+		return err
+	}
+
+	if pushRef != "" {
+		if err := pushCollection(filepath.Dir(outputFile), writtenOutputFiles(filepath.Dir(outputFile))); err != nil {
+			return err
+		}
+	}
+
+	// Print summary
+	duration := time.Since(startTime)
+	emitEvent(eventSummary, map[string]interface{}{
+		"collected": collectedCount,
+		"skipped":   skippedCount,
+		"errors":    errorCount,
+		"items":     itemCount,
+		"duration":  duration.String(),
+	})
+	fmt.Printf("\n=== Collection Summary ===\n")
+	fmt.Printf("Successfully collected: %d resources\n", collectedCount)
+	if skippedCount > 0 {
+		fmt.Printf("Skipped deprecated: %d resources\n", skippedCount)
+	}
+	fmt.Printf("Errors encountered: %d resources\n", errorCount)
+	if errorCount > 0 {
+		fmt.Printf("Error details: %s\n", filepath.Join(filepath.Dir(outputFile), errorsFile))
+	}
+	printItemCapSummary(itemCount)
+	printConversionSummary()
+	printSnapshotSummary()
+	printFilterSummary()
+	printBlockedSummary()
+	printLogsSummary()
+	printNamespaceSummary()
+	if len(registryRewrites) > 0 {
+		fmt.Printf("Images rewritten: %d\n", imagesRewritten)
+	}
+	if len(redactPatterns) > 0 {
+		fmt.Printf("Values redacted: %d\n", valuesRedacted)
+	}
+	if len(defaultRules) > 0 {
+		fmt.Printf("Default fields pruned: %d\n", defaultsPruned)
+	}
+	if decodeSecrets {
+		fmt.Printf("Secrets decoded: %d (output contains readable secret values)\n", secretsDecoded)
+	}
+	if normalizeVersions {
+		fmt.Printf("API versions normalized: %d\n", apiVersionsNormalized)
+	}
+	if tableOutput {
+		fmt.Printf("Tables written: %d\n", tablesWritten)
+	}
+	if parts := writer.Paths(); len(parts) > 1 {
+		fmt.Printf("Output files: %d parts (%s ... %s)\n", len(parts), parts[0], parts[len(parts)-1])
+	} else {
+		fmt.Printf("Output file: %s\n", outputFile)
+	}
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("========================\n")
+
+	return checkCollectionGates(resourceCounts)
+}
+
+// collectResourceToBuffer lists all instances of a resource and appends them as a
+// block to buffer. It returns the number of items collected.
+func collectResourceToBuffer(dynamic dynamic.Interface, resource metav1.APIResource, groupVersion string, buffer *strings.Builder) (int, error) {
+	// Parse group version
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse group version: %w", err)
+	}
+
+	// Create GVR
+	gvr := schema.GroupVersionResource{
+		Group:    gv.Group,
+		Version:  gv.Version,
+		Resource: resource.Name,
+	}
+
+	// Get all instances of this resource across all namespaces; a CRD whose
+	// conversion webhook is down may be read with its storage version instead
+	unstructuredList, groupVersion, err := listWithConversionFallback(dynamic, gvr, resource.Namespaced)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get resource instances for %s: %w", resource.Name, err)
+	}
+
+	// Apply client-side filters
+	applyItemFilters(unstructuredList)
+	recordOwnership(unstructuredList)
+	validateCustomResources(unstructuredList)
+	applyItemTransforms(unstructuredList)
+	normalizeAPIVersions(unstructuredList, groupVersion, resource.Name)
+	recordQuotaUsage(resource.Name, unstructuredList)
+	recordStorage(resource.Name, unstructuredList)
+	recordPDBCoverage(resource.Name, unstructuredList)
+	recordNetworkPolicies(resource.Name, unstructuredList)
+	recordNamespaceSummary(resource.Name, unstructuredList)
+	recordStuckObjects(unstructuredList)
+	recordServedObjects(resource.Name, unstructuredList)
+	recordExpectedMatches(unstructuredList)
+	if err := writeCustomColumns(resource.Name, groupVersion, unstructuredList); err != nil {
+		return 0, fmt.Errorf("failed to write custom columns for %s: %w", resource.Name, err)
+	}
+
+	// Convert to YAML
+	yamlData, err := marshalResourceList(unstructuredList)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s to YAML: %w", resource.Name, err)
+	}
+
+	// Add resource separator
+	buffer.WriteString(formatResourceSeparator(resource.Name))
+	buffer.WriteString(string(yamlData))
+	buffer.WriteString("\n")
+
+	return len(unstructuredList.Items), nil
+}
+
+// singleFileWriter writes resource blocks to the single output file. When
+// maxSize is set, it rolls over to numbered part files (all-resources.part2.yaml,
+// all-resources.part3.yaml, ...) before a block would push the current part
+// past the limit. Each part gets its own header in that case.
+type singleFileWriter struct {
+	basePath string
+	maxSize  int64
+	file     *os.File
+	size     int64
+	header   int64
+	paths    []string
+}
+
+func newSingleFileWriter(basePath string, maxSize int64) *singleFileWriter {
+	return &singleFileWriter{basePath: basePath, maxSize: maxSize}
+}
+
+// WriteBlock appends a resource block, rotating to a new part if needed.
+// A single block larger than maxSize is never split; it gets a part of its own.
+func (w *singleFileWriter) WriteBlock(block string) error {
+	if w.file == nil {
+		if err := w.openNextPart(); err != nil {
+			return err
+		}
+	} else if w.maxSize > 0 && w.size+int64(len(block)) > w.maxSize && w.size > w.header {
+		if err := w.openNextPart(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.WriteString(block)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", w.file.Name(), err)
+	}
+	return nil
+}
+
+// Close closes the current part. The base file is always created, even if no
+// blocks were written.
+func (w *singleFileWriter) Close() error {
+	if w.file == nil {
+		if err := w.openNextPart(); err != nil {
+			return err
+		}
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", w.file.Name(), err)
+	}
+	w.file = nil
+	return nil
+}
 
-			err := collectResourceToBuffer(dynamic, resource, resourceList.GroupVersion, &allResourcesYaml)
-			if err != nil {
-				if verbose {
-					fmt.Printf("  %s: ERROR - %v\n", resource.Name, err)
-				}
-				errorCount++
-			} else {
-				collectedCount++
-			}
+// Paths returns the files written so far, in part order.
+func (w *singleFileWriter) Paths() []string {
+	return w.paths
+}
+
+func (w *singleFileWriter) openNextPart() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", w.file.Name(), err)
 		}
 	}
 
-	// Write all resources to file
-	err = os.WriteFile(outputFile, []byte(allResourcesYaml.String()), 0644)
+	path := partFilePath(w.basePath, len(w.paths)+1)
+	file, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("failed to write file %s: %w", outputFile, err)
+		return fmt.Errorf("failed to create file %s: %w", path, err)
+	}
+	recordOutputFile(path)
+	w.file = file
+	w.paths = append(w.paths, path)
+	w.size = 0
+	w.header = 0
+
+	// Only rotated output gets per-part headers so the default output is unchanged
+	if w.maxSize > 0 {
+		header := formatPartHeader(len(w.paths))
+		n, err := file.WriteString(header)
+		w.size += int64(n)
+		w.header = int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write file %s: %w", path, err)
+		}
 	}
 
-	// Print summary
-	duration := time.Since(startTime)
-	fmt.Printf("\n=== Collection Summary ===\n")
-	fmt.Printf("Successfully collected: %d resources\n", collectedCount)
-	if skippedCount > 0 {
-		fmt.Printf("Skipped deprecated: %d resources\n", skippedCount)
+	if verbose && len(w.paths) > 1 {
+		fmt.Printf("Rolling over to %s\n", path)
 	}
-	fmt.Printf("Errors encountered: %d resources\n", errorCount)
-	fmt.Printf("Output file: %s\n", outputFile)
-	fmt.Printf("Duration: %v\n", duration)
-	fmt.Printf("========================\n")
 
 	return nil
 }
 
-func collectResourceToBuffer(dynamic dynamic.Interface, resource metav1.APIResource, groupVersion string, buffer *strings.Builder) error {
-	// Parse group version
-	gv, err := schema.ParseGroupVersion(groupVersion)
+// partFilePath returns the path of the given part; part 1 is the base path itself
+func partFilePath(basePath string, part int) string {
+	if part <= 1 {
+		return basePath
+	}
+	ext := filepath.Ext(basePath)
+	return fmt.Sprintf("%s.part%d%s", strings.TrimSuffix(basePath, ext), part, ext)
+}
+
+// cleanPartFiles removes rotated part files left over from a previous run
+func cleanPartFiles(basePath string) error {
+	ext := filepath.Ext(basePath)
+	matches, err := filepath.Glob(strings.TrimSuffix(basePath, ext) + ".part*" + ext)
 	if err != nil {
-		return fmt.Errorf("failed to parse group version: %w", err)
+		return err
+	}
+	for _, match := range matches {
+		if err := os.Remove(match); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if verbose {
+			fmt.Printf("Removed: %s\n", match)
+		}
 	}
+	return nil
+}
 
-	// Create GVR
-	gvr := schema.GroupVersionResource{
-		Group:    gv.Group,
-		Version:  gv.Version,
-		Resource: resource.Name,
+// parseByteSize parses sizes such as "500KB", "100MB" or "2GB" (binary multiples)
+func parseByteSize(input string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(input))
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.multiplier
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			break
+		}
 	}
 
-	// Get all instances of this resource across all namespaces
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expected a positive size such as 100MB, got %q", input)
+	}
+	return n * multiplier, nil
+}
 
-	unstructuredList, err := dynamic.Resource(gvr).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get resource instances for %s: %w", resource.Name, err)
+// resourceFilePath returns where a resource's file is written in directory mode.
+// By default this is a flat <output>/<group-version>-<resource>.yaml; with
+// --output-per-group it is <output>/<group>/<version>/<resource>.yaml, with the
+// core group under "_core". Nested directories are created as needed.
+func resourceFilePath(outputDir string, resourceName string, gv schema.GroupVersion) (string, error) {
+	if !outputPerGroup {
+		return filepath.Join(outputDir, formatFilename(resourceName, gv.String())), nil
 	}
 
-	// Convert to YAML
-	yamlData, err := yaml.Marshal(unstructuredList)
-	if err != nil {
-		return fmt.Errorf("failed to marshal %s to YAML: %w", resource.Name, err)
+	group := gv.Group
+	if group == "" {
+		group = "_core"
 	}
 
-	// Add resource comment
-	buffer.WriteString(fmt.Sprintf("--- # Resource: %s\n", resource.Name))
-	buffer.WriteString(string(yamlData))
-	buffer.WriteString("\n")
+	// API group and version names are DNS labels, so they are safe as directory names
+	dir := filepath.Join(outputDir, group, gv.Version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
 
-	return nil
+	return filepath.Join(dir, formatFilename(resourceName, "")), nil
 }
 
 func formatFilename(resourceName string, groupVersion string) string {
@@ -651,6 +2572,31 @@ func formatHeader(resourceName string, groupVersion string) string {
 	return header.String()
 }
 
+// formatResourceSeparator returns what precedes a resource block in single file
+// output. Only the default "commented" style can be read back by import, merge
+// and the comparison diff.
+func formatResourceSeparator(resourceName string) string {
+	switch separatorStyle {
+	case "plain":
+		return "---\n"
+	case "none":
+		return ""
+	default:
+		return fmt.Sprintf("--- # Resource: %s\n", resourceName)
+	}
+}
+
+func formatPartHeader(part int) string {
+	var header strings.Builder
+
+	header.WriteString("# Generated by k8s-resource-collector\n")
+	header.WriteString(fmt.Sprintf("# Generated at: %s\n", time.Now().Format(time.RFC3339)))
+	header.WriteString(fmt.Sprintf("# Part: %d\n", part))
+	header.WriteString("# ---\n\n")
+
+	return header.String()
+}
+
 func cleanDirectory(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil // Directory doesn't exist, nothing to clean
@@ -674,6 +2620,69 @@ func cleanDirectory(path string) error {
 	return nil
 }
 
+// isDuplicateResource reports whether a resource was already seen in this run,
+// warning about the duplicate; otherwise it marks the resource as seen.
+// Discovery lists one preferred version per group, so the same resource under
+// a second version of its group is a leftover of an upgrade and only the first
+// is collected. When the resources come from an explicit list instead of
+// discovery (--gvr, --crd, --operator or --collect-pods-with-restarts), every
+// version in that list is collected.
+func isDuplicateResource(seen map[string]bool, resourceName, groupVersion string) bool {
+	key := groupVersion + "/" + resourceName
+	if len(explicitGVRs) == 0 {
+		if gv, err := schema.ParseGroupVersion(groupVersion); err == nil {
+			key = gv.Group + "/" + resourceName
+		}
+	}
+	if !seen[key] {
+		seen[key] = true
+		return false
+	}
+
+	fmt.Printf("Warning: %s/%s listed twice by discovery, collecting it once\n", groupVersion, resourceName)
+	emitEvent(eventResourceSkipped, map[string]interface{}{
+		"resource":     resourceName,
+		"groupVersion": groupVersion,
+		"reason":       "duplicate",
+	})
+	return true
+}
+
+// isCollectable reports whether a discovered resource should be collected.
+// Subresources are only collected when requested with --include-subresources,
+// and resources blocked by the policy file or excluded by --exclude-resources
+// or --secure never are.
+func isCollectable(resource metav1.APIResource) bool {
+	if isBlockedResource(resource.Name) || isExcludedResource(resource.Name) {
+		return false
+	}
+	if strings.Contains(resource.Name, "/") {
+		return includeSubresource(resource.Name) && contains(resource.Verbs, "get")
+	}
+	return hasRequiredVerbs(resource)
+}
+
+// hasRequiredVerbs reports whether a resource supports every verb in --require-verbs
+func hasRequiredVerbs(resource metav1.APIResource) bool {
+	for _, verb := range requiredVerbs {
+		if !contains(resource.Verbs, verb) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseList splits a comma-separated flag value, trimming blanks and empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -711,52 +2720,194 @@ func runComparisonMode() error {
 		return fmt.Errorf("failed to create comparison directory: %w", err)
 	}
 
+	if compareSummaryOnly {
+		return runComparisonSummaryOnly(clusterName1, clusterName2, compareDir)
+	}
+
+	// With --diff-only the collections only live until the diff is written
+	collectionDir := compareDir
+	if diffOnly {
+		tempDir, err := os.MkdirTemp("", "k8s-resource-collector-compare-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tempDir)
+		if anonMapping == "" {
+			defer os.Remove(anonymizeMappingPath(tempDir))
+		}
+		collectionDir = tempDir
+	}
+
 	// Collect from cluster 1
 	fmt.Printf("\n[1/3] Collecting from cluster 1: %s\n", clusterName1)
-	outputFile1 := filepath.Join(compareDir, fmt.Sprintf("%s-resources.yaml", sanitizeClusterName(clusterName1)))
+	outputFile1 := filepath.Join(collectionDir, fmt.Sprintf("%s-resources.yaml", sanitizeClusterName(clusterName1)))
 	if err := collectFromCluster(kubeconfig1, outputFile1); err != nil {
 		return fmt.Errorf("failed to collect from cluster 1: %w", err)
 	}
-	fmt.Printf("✓ Saved to: %s\n", outputFile1)
+	if !diffOnly {
+		fmt.Printf("✓ Saved to: %s\n", outputFile1)
+	}
 
 	// Collect from cluster 2
 	fmt.Printf("\n[2/3] Collecting from cluster 2: %s\n", clusterName2)
-	outputFile2 := filepath.Join(compareDir, fmt.Sprintf("%s-resources.yaml", sanitizeClusterName(clusterName2)))
+	outputFile2 := filepath.Join(collectionDir, fmt.Sprintf("%s-resources.yaml", sanitizeClusterName(clusterName2)))
 	if err := collectFromCluster(kubeconfig2, outputFile2); err != nil {
 		return fmt.Errorf("failed to collect from cluster 2: %w", err)
 	}
-	fmt.Printf("✓ Saved to: %s\n", outputFile2)
+	if !diffOnly {
+		fmt.Printf("✓ Saved to: %s\n", outputFile2)
+	}
 
 	// Generate diff
 	fmt.Printf("\n[3/3] Generating difference report...\n")
-	diffFile := filepath.Join(compareDir, fmt.Sprintf("diff-%s-vs-%s.txt",
+	diffFile := filepath.Join(compareDir, fmt.Sprintf("diff-%s-vs-%s%s",
 		sanitizeClusterName(clusterName1),
-		sanitizeClusterName(clusterName2)))
+		sanitizeClusterName(clusterName2),
+		diffFileExtension()))
 
 	if err := generateDiff(outputFile1, outputFile2, diffFile, clusterName1, clusterName2); err != nil {
 		return fmt.Errorf("failed to generate diff: %w", err)
 	}
 	fmt.Printf("✓ Diff saved to: %s\n", diffFile)
 
+	if err := writeThreeWayReport(compareDir, outputFile1, outputFile2, clusterName1, clusterName2); err != nil {
+		return err
+	}
+
 	fmt.Println("\n=== Comparison Complete ===")
-	fmt.Printf("Cluster 1 (%s): %s\n", clusterName1, outputFile1)
-	fmt.Printf("Cluster 2 (%s): %s\n", clusterName2, outputFile2)
+	if !diffOnly {
+		fmt.Printf("Cluster 1 (%s): %s\n", clusterName1, outputFile1)
+		fmt.Printf("Cluster 2 (%s): %s\n", clusterName2, outputFile2)
+	}
 	fmt.Printf("Difference:     %s\n", diffFile)
 
 	return nil
 }
 
+// runComparisonSummaryOnly compares which resources two clusters serve without
+// writing the per-cluster collections. Each resource is probed with a single-item
+// List, so only the summary section of the report is produced.
+func runComparisonSummaryOnly(clusterName1, clusterName2, compareDir string) error {
+	fmt.Printf("\n[1/3] Inventorying cluster 1: %s\n", clusterName1)
+	resources1, err := inventoryCluster(kubeconfig1)
+	if err != nil {
+		return fmt.Errorf("failed to inventory cluster 1: %w", err)
+	}
+
+	fmt.Printf("\n[2/3] Inventorying cluster 2: %s\n", clusterName2)
+	resources2, err := inventoryCluster(kubeconfig2)
+	if err != nil {
+		return fmt.Errorf("failed to inventory cluster 2: %w", err)
+	}
+
+	fmt.Printf("\n[3/3] Generating summary report...\n")
+	summaryFile := filepath.Join(compareDir, fmt.Sprintf("summary-%s-vs-%s.txt",
+		sanitizeClusterName(clusterName1),
+		sanitizeClusterName(clusterName2)))
+
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("=== Cluster Comparison Summary ===\n"))
+	report.WriteString(fmt.Sprintf("Generated at: %s\n", time.Now().Format(time.RFC3339)))
+	report.WriteString(fmt.Sprintf("Cluster 1: %s\n", clusterName1))
+	report.WriteString(fmt.Sprintf("Cluster 2: %s\n", clusterName2))
+	report.WriteString(formatDiffSummary(resources1, resources2, clusterName1, clusterName2))
+
+	if err := writeOutputFile(summaryFile, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+
+	fmt.Print(formatDiffSummary(resources1, resources2, clusterName1, clusterName2))
+	fmt.Printf("\n✓ Summary saved to: %s\n", summaryFile)
+
+	return nil
+}
+
+// inventoryCluster returns the names of the resources that would be collected
+// from a cluster, in the same form parseResources extracts from a collection file.
+// A resource counts as present when a List with a limit of one succeeds.
+func inventoryCluster(kubeconfigPath string) ([]string, error) {
+	config, err := parseKubeConfig(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient := newBlocklistDynamicClient(client)
+
+	clusterVersion, err := detectClusterVersion(discoveryClient, dynamicClient)
+	if err != nil {
+		clusterVersion = nil
+	}
+
+	resourceLists, err := discoverResources(discoveryClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API resources: %w", err)
+	}
+
+	var resources []string
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			// Subresources are views of their parent, which is inventoried already
+			if strings.Contains(resource.Name, "/") || !isCollectable(resource) {
+				continue
+			}
+			if clusterVersion != nil {
+				if skip, _ := shouldSkipResource(resource, resourceList.GroupVersion, clusterVersion); skip {
+					continue
+				}
+			}
+
+			gvr := gv.WithResource(resource.Name)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1})
+			cancel()
+			if err != nil {
+				if verbose {
+					fmt.Printf("  %s: ERROR - %v\n", resource.Name, err)
+				}
+				continue
+			}
+
+			resources = append(resources, resource.Name)
+		}
+	}
+
+	return resources, nil
+}
+
 // getClusterName extracts the cluster name from kubeconfig
 func getClusterName(kubeconfigPath string) (string, error) {
-	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+	config, err := loadKubeconfig(kubeconfigPath)
 	if err != nil {
 		return "", err
 	}
 
-	// Get current context
+	// Get current context, falling back to the first context or the server host
 	currentContext := config.CurrentContext
 	if currentContext == "" {
-		return "", fmt.Errorf("no current context set in kubeconfig")
+		currentContext = fallbackContext(config)
+		if currentContext == "" {
+			if name := clusterNameFromServer(config); name != "" {
+				return name, nil
+			}
+			return "", fmt.Errorf("no current context set in kubeconfig and no contexts or clusters to fall back to")
+		}
+		if verbose {
+			fmt.Printf("No current context in %s, using context %s\n", kubeconfigPath, currentContext)
+		}
 	}
 
 	// Get context details
@@ -803,10 +2954,11 @@ func collectFromCluster(kubeconfigPath string, outputFile string) error {
 		return err
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(config)
+	client, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return err
 	}
+	dynamicClient := newBlocklistDynamicClient(client)
 
 	return collectAllResourcesToSingleFile(discoveryClient, dynamicClient, outputFile)
 }
@@ -828,6 +2980,14 @@ func generateDiff(file1, file2, outputFile, cluster1Name, cluster2Name string) e
 	resources1 := parseResources(string(content1))
 	resources2 := parseResources(string(content2))
 
+	if diffFormat == diffFormatJSON {
+		data, err := formatJSONDiff(resources1, resources2, cluster1Name, cluster2Name)
+		if err != nil {
+			return err
+		}
+		return writeOutputFile(outputFile, data, 0644)
+	}
+
 	// Generate diff report
 	var diff strings.Builder
 	diff.WriteString(fmt.Sprintf("=== Cluster Comparison Report ===\n"))
@@ -858,18 +3018,59 @@ func generateDiff(file1, file2, outputFile, cluster1Name, cluster2Name string) e
 	if len(commonResources) > 0 {
 		diff.WriteString(fmt.Sprintf("\n=== Common resources in both clusters ===\n"))
 		diff.WriteString(fmt.Sprintf("Total: %d resources\n", len(commonResources)))
+		if listCommon {
+			for _, resource := range commonResources {
+				diff.WriteString(fmt.Sprintf("- %s\n", resource))
+			}
+		}
+	}
+
+	// Field-level differences for objects present in both
+	if deepDiff {
+		deep, err := generateDeepDiff(file1, file2)
+		if err != nil {
+			return fmt.Errorf("failed to generate deep diff: %w", err)
+		}
+		diff.WriteString(deep)
+	}
+
+	// Object-level differences grouped by owner label
+	if compareLabel != "" {
+		grouped, err := generateLabelGroupedDiff(file1, file2, compareLabel, cluster1Name, cluster2Name)
+		if err != nil {
+			return fmt.Errorf("failed to group diff by label: %w", err)
+		}
+		diff.WriteString(grouped)
+	}
+
+	// Object-level differences per namespace, unchanged namespaces left out
+	if changedNamespaces {
+		namespaced, err := generateChangedNamespacesDiff(file1, file2, cluster1Name, cluster2Name)
+		if err != nil {
+			return fmt.Errorf("failed to aggregate changes per namespace: %w", err)
+		}
+		diff.WriteString(namespaced)
 	}
 
 	// Summary
-	diff.WriteString(fmt.Sprintf("\n=== Summary ===\n"))
-	diff.WriteString(fmt.Sprintf("Total resources in %s: %d\n", cluster1Name, len(resources1)))
-	diff.WriteString(fmt.Sprintf("Total resources in %s: %d\n", cluster2Name, len(resources2)))
-	diff.WriteString(fmt.Sprintf("Only in %s: %d\n", cluster1Name, len(onlyInCluster1)))
-	diff.WriteString(fmt.Sprintf("Only in %s: %d\n", cluster2Name, len(onlyInCluster2)))
-	diff.WriteString(fmt.Sprintf("Common to both: %d\n", len(commonResources)))
+	diff.WriteString(formatDiffSummary(resources1, resources2, cluster1Name, cluster2Name))
 
 	// Write diff to file
-	return os.WriteFile(outputFile, []byte(diff.String()), 0644)
+	return writeOutputFile(outputFile, []byte(diff.String()), 0644)
+}
+
+// formatDiffSummary renders the summary section of a comparison report
+func formatDiffSummary(resources1, resources2 []string, cluster1Name, cluster2Name string) string {
+	var summary strings.Builder
+
+	summary.WriteString(fmt.Sprintf("\n=== Summary ===\n"))
+	summary.WriteString(fmt.Sprintf("Total resources in %s: %d\n", cluster1Name, len(resources1)))
+	summary.WriteString(fmt.Sprintf("Total resources in %s: %d\n", cluster2Name, len(resources2)))
+	summary.WriteString(fmt.Sprintf("Only in %s: %d\n", cluster1Name, len(findUniqueResources(resources1, resources2))))
+	summary.WriteString(fmt.Sprintf("Only in %s: %d\n", cluster2Name, len(findUniqueResources(resources2, resources1))))
+	summary.WriteString(fmt.Sprintf("Common to both: %d\n", len(findCommonResources(resources1, resources2))))
+
+	return summary.String()
 }
 
 // parseResources extracts resource identifiers from YAML content
@@ -984,6 +3185,17 @@ func validateMustGatherPath(path string) error {
 // runMustGatherMode processes a must-gather directory and outputs resources
 func runMustGatherMode() error {
 	startTime := time.Now()
+	source := mustGather
+
+	// Download and extract a remote must-gather archive, removed again when done
+	if isRemoteMustGather(mustGather) {
+		dir, err := fetchRemoteMustGather(mustGather)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		mustGather = dir
+	}
 
 	// Validate must-gather path
 	if err := validateMustGatherPath(mustGather); err != nil {
@@ -1006,6 +3218,19 @@ func runMustGatherMode() error {
 		}
 	}
 
+	// Single file output
+	if isSingleFileMode() {
+		return runMustGatherToSingleFile(startTime, source)
+	}
+
+	// Record progress with --checkpoint so an interrupted run can be resumed
+	checkpoint, err := openMustGatherCheckpoint(outputDir, source, mustGather)
+	if err != nil {
+		return err
+	}
+	activeCheckpoint = checkpoint
+	defer checkpoint.close()
+
 	// Process must-gather directory
 	collectedCount, errorCount, err := processMustGatherDirectory(mustGather, outputDir)
 	if err != nil {
@@ -1024,11 +3249,49 @@ func runMustGatherMode() error {
 	return nil
 }
 
+// runMustGatherToSingleFile processes the must-gather directory into one file
+func runMustGatherToSingleFile(startTime time.Time, source string) error {
+	if outputFile == "" {
+		outputFile = filepath.Join(outputDir, "all-resources.yaml")
+		if outputFormat == "ndjson" {
+			outputFile = filepath.Join(outputDir, "all-resources.ndjson")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Record progress with --checkpoint so an interrupted run can be resumed
+	checkpoint, err := openMustGatherCheckpoint(filepath.Dir(outputFile), source, mustGather)
+	if err != nil {
+		return err
+	}
+	activeCheckpoint = checkpoint
+	defer checkpoint.close()
+
+	if err := processMustGatherToSingleFile(mustGather, outputFile); err != nil {
+		return err
+	}
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== Must-Gather Processing Summary ===\n")
+	fmt.Printf("Output file: %s\n", outputFile)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("====================================\n")
+
+	return nil
+}
+
 // runMustGatherComparisonMode processes two must-gather directories and generates a diff
 func runMustGatherComparisonMode() error {
 	fmt.Println("=== Must-Gather Comparison Mode ===")
 	fmt.Printf("Must-Gather 1: %s\n", mustGather1)
 	fmt.Printf("Must-Gather 2: %s\n", mustGather2)
+	if compareNamespace != "" {
+		fmt.Printf("Namespace: %s\n", compareNamespace)
+	}
 	fmt.Println()
 
 	// Validate both must-gather paths
@@ -1043,15 +3306,26 @@ func runMustGatherComparisonMode() error {
 	mgName1 := getMustGatherName(mustGather1)
 	mgName2 := getMustGatherName(mustGather2)
 
-	// Create comparison output directory
+	// Create comparison output directory, dropping a previous comparison's files if requested
 	compareDir := filepath.Join(outputDir, "comparison")
+	if clean {
+		if err := cleanDirectory(compareDir); err != nil {
+			return fmt.Errorf("failed to clean comparison directory: %w", err)
+		}
+	}
 	if err := os.MkdirAll(compareDir, 0755); err != nil {
 		return fmt.Errorf("failed to create comparison directory: %w", err)
 	}
 
+	// A namespace-scoped comparison gets its own file names, next to whole-bundle ones
+	scope := ""
+	if compareNamespace != "" {
+		scope = "-" + sanitizeClusterName(compareNamespace)
+	}
+
 	// Process from must-gather 1
 	fmt.Printf("\n[1/3] Processing must-gather 1: %s\n", mgName1)
-	outputFile1 := filepath.Join(compareDir, fmt.Sprintf("%s-resources.yaml", sanitizeClusterName(mgName1)))
+	outputFile1 := filepath.Join(compareDir, fmt.Sprintf("%s%s-resources.yaml", sanitizeClusterName(mgName1), scope))
 	if err := processMustGatherToSingleFile(mustGather1, outputFile1); err != nil {
 		return fmt.Errorf("failed to process must-gather 1: %w", err)
 	}
@@ -1059,7 +3333,7 @@ func runMustGatherComparisonMode() error {
 
 	// Process from must-gather 2
 	fmt.Printf("\n[2/3] Processing must-gather 2: %s\n", mgName2)
-	outputFile2 := filepath.Join(compareDir, fmt.Sprintf("%s-resources.yaml", sanitizeClusterName(mgName2)))
+	outputFile2 := filepath.Join(compareDir, fmt.Sprintf("%s%s-resources.yaml", sanitizeClusterName(mgName2), scope))
 	if err := processMustGatherToSingleFile(mustGather2, outputFile2); err != nil {
 		return fmt.Errorf("failed to process must-gather 2: %w", err)
 	}
@@ -1067,15 +3341,21 @@ func runMustGatherComparisonMode() error {
 
 	// Generate diff
 	fmt.Printf("\n[3/3] Generating difference report...\n")
-	diffFile := filepath.Join(compareDir, fmt.Sprintf("diff-%s-vs-%s.txt",
+	diffFile := filepath.Join(compareDir, fmt.Sprintf("diff-%s-vs-%s%s%s",
 		sanitizeClusterName(mgName1),
-		sanitizeClusterName(mgName2)))
+		sanitizeClusterName(mgName2),
+		scope,
+		diffFileExtension()))
 
 	if err := generateDiff(outputFile1, outputFile2, diffFile, mgName1, mgName2); err != nil {
 		return fmt.Errorf("failed to generate diff: %w", err)
 	}
 	fmt.Printf("✓ Diff saved to: %s\n", diffFile)
 
+	if err := writeThreeWayReport(compareDir, outputFile1, outputFile2, mgName1, mgName2); err != nil {
+		return err
+	}
+
 	fmt.Println("\n=== Comparison Complete ===")
 	fmt.Printf("Must-Gather 1 (%s): %s\n", mgName1, outputFile1)
 	fmt.Printf("Must-Gather 2 (%s): %s\n", mgName2, outputFile2)
@@ -1110,6 +3390,7 @@ func getMustGatherName(path string) string {
 // processMustGatherToSingleFile processes a must-gather directory into a single file
 func processMustGatherToSingleFile(mustGatherPath, outputFile string) error {
 	resourceMap := make(map[string][]interface{})
+	activeCheckpoint.seed(resourceMap)
 
 	// Walk through the must-gather directory
 	err := filepath.Walk(mustGatherPath, func(path string, info os.FileInfo, err error) error {
@@ -1117,8 +3398,11 @@ func processMustGatherToSingleFile(mustGatherPath, outputFile string) error {
 			return nil // Continue walking
 		}
 
-		// Skip directories
+		// Skip directories, and other namespaces' directories altogether
 		if info.IsDir() {
+			if isOtherNamespaceDir(path) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -1128,7 +3412,7 @@ func processMustGatherToSingleFile(mustGatherPath, outputFile string) error {
 		}
 
 		// Read and parse the YAML file
-		processMustGatherFile(path, resourceMap)
+		processCheckpointedFile(path, resourceMap)
 
 		return nil
 	})
@@ -1136,6 +3420,7 @@ func processMustGatherToSingleFile(mustGatherPath, outputFile string) error {
 	if err != nil {
 		return fmt.Errorf("failed to walk must-gather directory: %w", err)
 	}
+	dedupeCollapsedVersions(resourceMap)
 
 	// Build single file output
 	var allResourcesYaml strings.Builder
@@ -1152,8 +3437,16 @@ func processMustGatherToSingleFile(mustGatherPath, outputFile string) error {
 			continue
 		}
 
-		// Add resource comment
-		allResourcesYaml.WriteString(fmt.Sprintf("--- # Resource: %s\n", key))
+		// One flattened item per line, no separators
+		if outputFormat == "ndjson" {
+			if err := writeNDJSON(&allResourcesYaml, items); err != nil {
+				return fmt.Errorf("failed to write %s: %w", key, err)
+			}
+			continue
+		}
+
+		// Add resource separator
+		allResourcesYaml.WriteString(formatResourceSeparator(key))
 
 		// Create a list structure
 		list := map[string]interface{}{
@@ -1163,7 +3456,7 @@ func processMustGatherToSingleFile(mustGatherPath, outputFile string) error {
 		}
 
 		// Marshal to YAML
-		yamlData, err := yaml.Marshal(list)
+		yamlData, err := marshalListMap(list)
 		if err != nil {
 			continue
 		}
@@ -1173,7 +3466,10 @@ func processMustGatherToSingleFile(mustGatherPath, outputFile string) error {
 	}
 
 	// Write to file
-	return os.WriteFile(outputFile, []byte(allResourcesYaml.String()), 0644)
+	if err := writeOutputFile(outputFile, []byte(allResourcesYaml.String()), 0644); err != nil {
+		return err
+	}
+	return activeCheckpoint.finish()
 }
 
 // processMustGatherDirectory walks through the must-gather directory and processes YAML files
@@ -1181,6 +3477,7 @@ func processMustGatherDirectory(mustGatherPath, outputPath string) (int, int, er
 	resourceMap := make(map[string][]interface{}) // key: groupVersion-resource, value: list of items
 	collectedCount := 0
 	errorCount := 0
+	activeCheckpoint.seed(resourceMap)
 
 	// Walk through the must-gather directory
 	err := filepath.Walk(mustGatherPath, func(path string, info os.FileInfo, err error) error {
@@ -1206,7 +3503,7 @@ func processMustGatherDirectory(mustGatherPath, outputPath string) (int, int, er
 		}
 
 		// Read and parse the YAML file
-		if err := processMustGatherFile(path, resourceMap); err != nil {
+		if err := processCheckpointedFile(path, resourceMap); err != nil {
 			if verbose {
 				fmt.Printf("  Error processing %s: %v\n", path, err)
 			}
@@ -1219,6 +3516,7 @@ func processMustGatherDirectory(mustGatherPath, outputPath string) (int, int, er
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to walk must-gather directory: %w", err)
 	}
+	dedupeCollapsedVersions(resourceMap)
 
 	// Write organized resources to output directory
 	for key, items := range resourceMap {
@@ -1238,7 +3536,7 @@ func processMustGatherDirectory(mustGatherPath, outputPath string) (int, int, er
 		}
 
 		// Marshal to YAML
-		yamlData, err := yaml.Marshal(list)
+		yamlData, err := marshalListMap(list)
 		if err != nil {
 			if verbose {
 				fmt.Printf("Error marshaling %s: %v\n", key, err)
@@ -1248,18 +3546,12 @@ func processMustGatherDirectory(mustGatherPath, outputPath string) (int, int, er
 		}
 
 		// Create header
-		parts := strings.SplitN(key, "-", 2)
-		groupVersion := parts[0]
-		resourceName := ""
-		if len(parts) > 1 {
-			resourceName = parts[1]
-		}
-
+		groupVersion, resourceName := splitResourceKey(key)
 		header := formatHeader(resourceName, groupVersion)
 		finalYaml := header + string(yamlData)
 
 		// Write to file
-		if err := os.WriteFile(filePath, []byte(finalYaml), 0644); err != nil {
+		if err := writeOutputFile(filePath, []byte(finalYaml), 0644); err != nil {
 			if verbose {
 				fmt.Printf("Error writing %s: %v\n", filePath, err)
 			}
@@ -1273,6 +3565,9 @@ func processMustGatherDirectory(mustGatherPath, outputPath string) (int, int, er
 		collectedCount++
 	}
 
+	if err := activeCheckpoint.finish(); err != nil {
+		return 0, 0, err
+	}
 	return collectedCount, errorCount, nil
 }
 
@@ -1324,8 +3619,8 @@ func processMustGatherFile(filePath string, resourceMap map[string][]interface{}
 					// Extract item details
 					itemApiVersion, _ := itemMap["apiVersion"].(string)
 					itemKind, _ := itemMap["kind"].(string)
-					if itemApiVersion != "" && itemKind != "" {
-						key := makeResourceKey(itemApiVersion, itemKind)
+					if itemApiVersion != "" && itemKind != "" && inCompareNamespace(itemKind, itemMap) && !isBlockedKind(schema.FromAPIVersionAndKind(itemApiVersion, itemKind)) {
+						key := mustGatherResourceKey(itemApiVersion, itemKind, itemMap)
 						resourceMap[key] = append(resourceMap[key], itemMap)
 					}
 				}
@@ -1333,8 +3628,12 @@ func processMustGatherFile(filePath string, resourceMap map[string][]interface{}
 			continue
 		}
 
+		if !inCompareNamespace(kind, resource) || isBlockedKind(schema.FromAPIVersionAndKind(apiVersion, kind)) {
+			continue
+		}
+
 		// Create a key for this resource type
-		key := makeResourceKey(apiVersion, kind)
+		key := mustGatherResourceKey(apiVersion, kind, resource)
 
 		// Add to resource map
 		resourceMap[key] = append(resourceMap[key], resource)
@@ -1343,6 +3642,26 @@ func processMustGatherFile(filePath string, resourceMap map[string][]interface{}
 	return nil
 }
 
+// inCompareNamespace reports whether a must-gather object belongs to the
+// --compare-namespace namespace: it is namespaced there, or it is that
+// Namespace. Every object belongs when no namespace is set.
+func inCompareNamespace(kind string, object map[string]interface{}) bool {
+	if compareNamespace == "" {
+		return true
+	}
+	metadata, _ := object["metadata"].(map[string]interface{})
+	if kind == "Namespace" {
+		return metadata["name"] == compareNamespace
+	}
+	return metadata["namespace"] == compareNamespace
+}
+
+// isOtherNamespaceDir reports whether a must-gather directory holds another
+// namespace than --compare-namespace (namespaces/<name>), so it can be skipped
+func isOtherNamespaceDir(path string) bool {
+	return compareNamespace != "" && filepath.Base(filepath.Dir(path)) == "namespaces" && filepath.Base(path) != compareNamespace
+}
+
 // makeResourceKey creates a consistent key for resource types
 func makeResourceKey(apiVersion, kind string) string {
 	// Convert kind to lowercase plural (simple approach)
@@ -1354,3 +3673,14 @@ func makeResourceKey(apiVersion, kind string) string {
 	// Format: groupVersion-resource
 	return fmt.Sprintf("%s-%s", strings.ReplaceAll(apiVersion, "/", "-"), resource)
 }
+
+// splitResourceKey splits a must-gather resource key back into its
+// groupVersion and resource. The resource follows the last dash, as group
+// names may contain dashes; a collapsed core kind has no groupVersion at all.
+func splitResourceKey(key string) (string, string) {
+	i := strings.LastIndex(key, "-")
+	if i < 0 {
+		return "", key
+	}
+	return key[:i], key[i+1:]
+}
@@ -0,0 +1,718 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestFormatResourceSeparator(t *testing.T) {
+	defer func(saved string) { separatorStyle = saved }(separatorStyle)
+
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{style: "commented", want: "--- # Resource: deployments\n"},
+		{style: "plain", want: "---\n"},
+		{style: "none", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			separatorStyle = tt.style
+			if got := formatResourceSeparator("deployments"); got != tt.want {
+				t.Errorf("formatResourceSeparator() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDuplicateResource(t *testing.T) {
+	defer func(saved []schema.GroupVersionResource) { explicitGVRs = saved }(explicitGVRs)
+
+	type lookup struct {
+		resource     string
+		groupVersion string
+		want         bool
+	}
+	tests := []struct {
+		name     string
+		explicit []schema.GroupVersionResource
+		lookups  []lookup
+	}{
+		{
+			name: "discovered resources",
+			lookups: []lookup{
+				{resource: "deployments", groupVersion: "apps/v1"},
+				{resource: "deployments", groupVersion: "apps/v1", want: true},
+				{resource: "deployments", groupVersion: "extensions/v1beta1"},
+				{resource: "replicasets", groupVersion: "apps/v1"},
+				{resource: "pods", groupVersion: "v1"},
+				{resource: "pods", groupVersion: "v1", want: true},
+			},
+		},
+		{
+			name: "same group under two versions after an upgrade",
+			lookups: []lookup{
+				{resource: "widgets", groupVersion: "example.com/v1"},
+				{resource: "widgets", groupVersion: "example.com/v1beta1", want: true},
+				{resource: "widgets", groupVersion: "other.example.com/v1"},
+			},
+		},
+		{
+			name:     "versions requested with --gvr",
+			explicit: []schema.GroupVersionResource{{Group: "example.com", Version: "v1", Resource: "widgets"}},
+			lookups: []lookup{
+				{resource: "widgets", groupVersion: "example.com/v1"},
+				{resource: "widgets", groupVersion: "example.com/v1beta1"},
+				{resource: "widgets", groupVersion: "example.com/v1", want: true},
+			},
+		},
+		{
+			name:     "CRD and its resource resolved from --crd",
+			explicit: []schema.GroupVersionResource{crdGVR, {Group: "example.com", Version: "v1beta1", Resource: "widgets"}},
+			lookups: []lookup{
+				{resource: "customresourcedefinitions", groupVersion: "apiextensions.k8s.io/v1"},
+				{resource: "widgets", groupVersion: "example.com/v1beta1"},
+				{resource: "widgets", groupVersion: "example.com/v1beta1", want: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explicitGVRs = tt.explicit
+			seen := make(map[string]bool)
+			for _, l := range tt.lookups {
+				if got := isDuplicateResource(seen, l.resource, l.groupVersion); got != l.want {
+					t.Errorf("isDuplicateResource(%s, %s) = %v, want %v", l.resource, l.groupVersion, got, l.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateDiffListCommon(t *testing.T) {
+	defer func(list, deep, namespaces bool, label string) {
+		listCommon, deepDiff, changedNamespaces, compareLabel = list, deep, namespaces, label
+	}(listCommon, deepDiff, changedNamespaces, compareLabel)
+	deepDiff, changedNamespaces, compareLabel = false, false, ""
+
+	dir := t.TempDir()
+	file1 := filepath.Join(dir, "cluster-a.yaml")
+	file2 := filepath.Join(dir, "cluster-b.yaml")
+	if err := os.WriteFile(file1, []byte("--- # Resource: pods\n--- # Resource: services\n--- # Resource: widgets\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file2, []byte("--- # Resource: pods\n--- # Resource: services\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		listCommon bool
+		want       string
+	}{
+		{listCommon: false, want: "Total: 2 resources\n\n=== Summary ==="},
+		{listCommon: true, want: "Total: 2 resources\n- pods\n- services\n\n=== Summary ==="},
+	}
+
+	for _, tt := range tests {
+		listCommon = tt.listCommon
+		output := filepath.Join(dir, "diff.txt")
+		if err := generateDiff(file1, file2, output, "cluster-a", "cluster-b"); err != nil {
+			t.Fatalf("generateDiff() error = %v", err)
+		}
+		report, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(report), tt.want) {
+			t.Errorf("listCommon=%v report missing %q:\n%s", tt.listCommon, tt.want, report)
+		}
+	}
+}
+
+func TestInCompareNamespace(t *testing.T) {
+	defer func(saved string) { compareNamespace = saved }(compareNamespace)
+
+	object := func(name, namespace string) map[string]interface{} {
+		metadata := map[string]interface{}{"name": name}
+		if namespace != "" {
+			metadata["namespace"] = namespace
+		}
+		return map[string]interface{}{"metadata": metadata}
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		kind      string
+		object    map[string]interface{}
+		want      bool
+	}{
+		{"no namespace set", "", "ClusterRole", object("view", ""), true},
+		{"object in the namespace", "shop", "ConfigMap", object("settings", "shop"), true},
+		{"object in another namespace", "shop", "ConfigMap", object("settings", "billing"), false},
+		{"the Namespace itself", "shop", "Namespace", object("shop", ""), true},
+		{"another Namespace", "shop", "Namespace", object("billing", ""), false},
+		{"cluster-scoped object", "shop", "ClusterRole", object("view", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compareNamespace = tt.namespace
+			if got := inCompareNamespace(tt.kind, tt.object); got != tt.want {
+				t.Errorf("inCompareNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsOtherNamespaceDir(t *testing.T) {
+	defer func(saved string) { compareNamespace = saved }(compareNamespace)
+	compareNamespace = "shop"
+
+	tests := map[string]bool{
+		filepath.Join("must-gather", "namespaces", "shop"):                  false,
+		filepath.Join("must-gather", "namespaces", "billing"):               true,
+		filepath.Join("must-gather", "cluster-scoped-resources", "billing"): false,
+	}
+	for path, want := range tests {
+		if got := isOtherNamespaceDir(path); got != want {
+			t.Errorf("isOtherNamespaceDir(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	compareNamespace = ""
+	if isOtherNamespaceDir(filepath.Join("must-gather", "namespaces", "billing")) {
+		t.Error("isOtherNamespaceDir() without --compare-namespace should keep every namespace")
+	}
+}
+
+func TestRequireLiveMode(t *testing.T) {
+	defer func(mg, imp string) { mustGather, importFile = mg, imp }(mustGather, importFile)
+
+	tests := []struct {
+		name       string
+		mustGather string
+		importFile string
+		wantErr    bool
+	}{
+		{name: "live collection"},
+		{name: "must-gather", mustGather: "./must-gather", wantErr: true},
+		{name: "import", importFile: "all-resources.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mustGather, importFile = tt.mustGather, tt.importFile
+			err := requireLiveMode("quota-report")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("requireLiveMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "--quota-report applies to live collections") {
+				t.Errorf("requireLiveMode() error = %v, want it to name the flag", err)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "42", want: 42},
+		{input: "10B", want: 10},
+		{input: "500KB", want: 500 << 10},
+		{input: "100mb", want: 100 << 20},
+		{input: " 2 GiB ", want: 2 << 30},
+		{input: "1g", want: 1 << 30},
+		{input: "64Ki", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "0", wantErr: true},
+		{input: "-5MB", wantErr: true},
+		{input: "1.5GB", wantErr: true},
+		{input: "MB", wantErr: true},
+		{input: "10TB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseByteSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseByteSize(%q) = %d, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, %v; want %d", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartFilePath(t *testing.T) {
+	tests := []struct {
+		base string
+		part int
+		want string
+	}{
+		{"/out/all-resources.yaml", 0, "/out/all-resources.yaml"},
+		{"/out/all-resources.yaml", 1, "/out/all-resources.yaml"},
+		{"/out/all-resources.yaml", 2, "/out/all-resources.part2.yaml"},
+		{"/out/all-resources.yaml", 12, "/out/all-resources.part12.yaml"},
+		{"/out/all-resources.ndjson", 3, "/out/all-resources.part3.ndjson"},
+		{"/out.d/collection", 2, "/out.d/collection.part2"},
+	}
+
+	for _, tt := range tests {
+		if got := partFilePath(tt.base, tt.part); got != tt.want {
+			t.Errorf("partFilePath(%q, %d) = %q, want %q", tt.base, tt.part, got, tt.want)
+		}
+	}
+}
+
+func TestSingleFileWriter(t *testing.T) {
+	// Sizes are given past the part header, which every rotated part starts with
+	header := int64(len(formatPartHeader(1)))
+
+	tests := []struct {
+		name      string
+		maxSize   int64 // added to the header size; 0 turns rotation off
+		blocks    []string
+		wantParts []string
+	}{
+		{name: "no limit", blocks: []string{"aaaa", "bbbb"}, wantParts: []string{"aaaabbbb"}},
+		{name: "nothing written", maxSize: 8, wantParts: []string{""}},
+		{name: "exactly at the limit", maxSize: 8, blocks: []string{"aaaa", "bbbb"}, wantParts: []string{"aaaabbbb"}},
+		{name: "one byte over the limit", maxSize: 7, blocks: []string{"aaaa", "bbbb"}, wantParts: []string{"aaaa", "bbbb"}},
+		{name: "block larger than the limit", maxSize: 4, blocks: []string{"aa", "bbbbbbbbbb", "cc"}, wantParts: []string{"aa", "bbbbbbbbbb", "cc"}},
+		{name: "oversized first block", maxSize: 4, blocks: []string{"bbbbbbbbbb", "cc"}, wantParts: []string{"bbbbbbbbbb", "cc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := filepath.Join(t.TempDir(), "all-resources.yaml")
+			maxSize := int64(0)
+			if tt.maxSize > 0 {
+				maxSize = header + tt.maxSize
+			}
+
+			writer := newSingleFileWriter(base, maxSize)
+			for _, block := range tt.blocks {
+				if err := writer.WriteBlock(block); err != nil {
+					t.Fatalf("WriteBlock() error = %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			paths := writer.Paths()
+			if len(paths) != len(tt.wantParts) {
+				t.Fatalf("Paths() = %v, want %d parts", paths, len(tt.wantParts))
+			}
+			for i, path := range paths {
+				if want := partFilePath(base, i+1); path != want {
+					t.Errorf("part %d path = %s, want %s", i+1, path, want)
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content := string(data)
+				if maxSize > 0 {
+					if !strings.Contains(content, fmt.Sprintf("# Part: %d\n", i+1)) {
+						t.Errorf("part %d has no part header:\n%s", i+1, content)
+					}
+					content = content[strings.Index(content, "# ---\n\n")+len("# ---\n\n"):]
+				}
+				if content != tt.wantParts[i] {
+					t.Errorf("part %d = %q, want %q", i+1, content, tt.wantParts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseMajorMinor(t *testing.T) {
+	tests := []struct {
+		version   string
+		wantMajor int
+		wantMinor int
+		wantErr   bool
+	}{
+		{version: "4.15.3", wantMajor: 4, wantMinor: 15},
+		{version: "v1.28.4", wantMajor: 1, wantMinor: 28},
+		{version: "4.14", wantMajor: 4, wantMinor: 14},
+		{version: "4.16.0-rc.2", wantMajor: 4, wantMinor: 16},
+		{version: "4.17.0-ec.3", wantMajor: 4, wantMinor: 17},
+		{version: "4.15.0-0.nightly-2024-01-10-101042", wantMajor: 4, wantMinor: 15},
+		{version: "", wantErr: true},
+		{version: "4", wantErr: true},
+		{version: "latest", wantErr: true},
+		{version: "four.15.0", wantErr: true},
+		{version: "4.x.0", wantErr: true},
+		{version: "4.16-rc.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			major, minor, err := parseMajorMinor(tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseMajorMinor(%q) = %d.%d, want an error", tt.version, major, minor)
+				}
+				return
+			}
+			if err != nil || major != tt.wantMajor || minor != tt.wantMinor {
+				t.Errorf("parseMajorMinor(%q) = %d.%d, %v; want %d.%d", tt.version, major, minor, err, tt.wantMajor, tt.wantMinor)
+			}
+		})
+	}
+}
+
+func TestGetOpenShiftVersion(t *testing.T) {
+	clusterVersion := func(status map[string]interface{}) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+		object.SetAPIVersion("config.openshift.io/v1")
+		object.SetKind("ClusterVersion")
+		object.SetName("version")
+		return object
+	}
+	history := func(versions ...string) []interface{} {
+		var entries []interface{}
+		for _, version := range versions {
+			entries = append(entries, map[string]interface{}{"version": version})
+		}
+		return entries
+	}
+
+	tests := []struct {
+		name      string
+		object    *unstructured.Unstructured
+		wantMajor int
+		wantMinor int
+		wantErr   string
+	}{
+		{
+			name:      "desired version",
+			object:    clusterVersion(map[string]interface{}{"desired": map[string]interface{}{"version": "4.15.3"}, "history": history("4.14.9")}),
+			wantMajor: 4, wantMinor: 15,
+		},
+		{
+			name:      "pre-release desired version",
+			object:    clusterVersion(map[string]interface{}{"desired": map[string]interface{}{"version": "4.16.0-rc.2"}}),
+			wantMajor: 4, wantMinor: 16,
+		},
+		{
+			name:      "latest history entry without desired version",
+			object:    clusterVersion(map[string]interface{}{"history": history("4.14.9", "4.13.21")}),
+			wantMajor: 4, wantMinor: 14,
+		},
+		{
+			name:    "no version in status",
+			object:  clusterVersion(map[string]interface{}{}),
+			wantErr: "has no version",
+		},
+		{
+			name:    "malformed version",
+			object:  clusterVersion(map[string]interface{}{"desired": map[string]interface{}{"version": "nightly"}}),
+			wantErr: `invalid version "nightly"`,
+		},
+		{
+			name:    "not OpenShift",
+			wantErr: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.object != nil {
+				objects = append(objects, tt.object)
+			}
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{{Group: "config.openshift.io", Version: "v1", Resource: "clusterversions"}: "ClusterVersionList"},
+				objects...)
+
+			major, minor, err := getOpenShiftVersion(client)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("getOpenShiftVersion() = %d.%d, %v; want error %q", major, minor, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || major != tt.wantMajor || minor != tt.wantMinor {
+				t.Errorf("getOpenShiftVersion() = %d.%d, %v; want %d.%d", major, minor, err, tt.wantMajor, tt.wantMinor)
+			}
+		})
+	}
+
+	if _, _, err := getOpenShiftVersion(nil); err == nil {
+		t.Error("getOpenShiftVersion(nil) error = nil")
+	}
+}
+
+func TestParseServerVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		major      string
+		minor      string
+		gitVersion string
+		wantMajor  int
+		wantMinor  int
+		wantErr    string
+	}{
+		{name: "upstream", major: "1", minor: "28", gitVersion: "v1.28.4", wantMajor: 1, wantMinor: 28},
+		{name: "EKS minor with plus", major: "1", minor: "27+", gitVersion: "v1.27.3-eks-a5565ad", wantMajor: 1, wantMinor: 27},
+		{name: "k3s", major: "1", minor: "28", gitVersion: "v1.28.4+k3s1", wantMajor: 1, wantMinor: 28},
+		{name: "k3s without major and minor", gitVersion: "v1.28.4+k3s1", wantMajor: 1, wantMinor: 28},
+		{name: "GKE", major: "1", minor: "29+", gitVersion: "v1.29.1-gke.1589018", wantMajor: 1, wantMinor: 29},
+		{name: "GKE without major and minor", gitVersion: "v1.29.1-gke.1589018", wantMajor: 1, wantMinor: 29},
+		{name: "GKE non-numeric minor", major: "1", minor: "30.x", gitVersion: "v1.30.2-gke.1023000", wantMajor: 1, wantMinor: 30},
+		{name: "unparseable major", major: "one", minor: "28", gitVersion: "unknown", wantErr: `failed to parse major version "one"`},
+		{name: "unparseable minor", major: "1", minor: "", gitVersion: "", wantErr: `failed to parse minor version ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			major, minor, err := parseServerVersion(tt.major, tt.minor, tt.gitVersion)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseServerVersion() = %d.%d, %v; want error %q", major, minor, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || major != tt.wantMajor || minor != tt.wantMinor {
+				t.Errorf("parseServerVersion() = %d.%d, %v; want %d.%d", major, minor, err, tt.wantMajor, tt.wantMinor)
+			}
+		})
+	}
+}
+
+func TestResourceFilePath(t *testing.T) {
+	defer func(saved bool) { outputPerGroup = saved }(outputPerGroup)
+
+	tests := []struct {
+		name     string
+		perGroup bool
+		resource string
+		gv       schema.GroupVersion
+		want     string // relative to the output directory, slash separated
+		wantDir  string // created by resourceFilePath; "" when nothing is created
+	}{
+		{name: "flat core", resource: "pods", gv: schema.GroupVersion{Version: "v1"}, want: "v1-pods.yaml"},
+		{name: "flat grouped", resource: "deployments", gv: schema.GroupVersion{Group: "apps", Version: "v1"}, want: "apps-v1-deployments.yaml"},
+		{name: "per group core", perGroup: true, resource: "pods", gv: schema.GroupVersion{Version: "v1"}, want: "_core/v1/pods.yaml", wantDir: "_core/v1"},
+		{name: "per group grouped", perGroup: true, resource: "deployments", gv: schema.GroupVersion{Group: "apps", Version: "v1"}, want: "apps/v1/deployments.yaml", wantDir: "apps/v1"},
+		{name: "per group dotted group", perGroup: true, resource: "certificates", gv: schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}, want: "cert-manager.io/v1/certificates.yaml", wantDir: "cert-manager.io/v1"},
+		{name: "per group subresource", perGroup: true, resource: "deployments/status", gv: schema.GroupVersion{Group: "apps", Version: "v1"}, want: "apps/v1/deployments-status.yaml", wantDir: "apps/v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPerGroup = tt.perGroup
+			dir := t.TempDir()
+
+			got, err := resourceFilePath(dir, tt.resource, tt.gv)
+			if err != nil {
+				t.Fatalf("resourceFilePath() error = %v", err)
+			}
+			if want := filepath.Join(dir, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("resourceFilePath() = %s, want %s", got, want)
+			}
+			if tt.wantDir != "" {
+				if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(tt.wantDir))); err != nil || !info.IsDir() {
+					t.Errorf("directory %s not created: %v", tt.wantDir, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "", want: nil},
+		{value: " , ,", want: nil},
+		{value: "list", want: []string{"list"}},
+		{value: "list,get", want: []string{"list", "get"}},
+		{value: " list , get ,, watch ", want: []string{"list", "get", "watch"}},
+		{value: "list,\tget\n", want: []string{"list", "get"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseList(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseList(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestHasRequiredVerbs(t *testing.T) {
+	defer func(saved []string) { requiredVerbs = saved }(requiredVerbs)
+
+	tests := []struct {
+		name         string
+		requireVerbs string
+		verbs        []string
+		want         bool
+	}{
+		{name: "default list", requireVerbs: "list", verbs: []string{"get", "list", "watch"}, want: true},
+		{name: "all verbs present", requireVerbs: "list,get,watch", verbs: []string{"get", "list", "watch"}, want: true},
+		{name: "missing watch", requireVerbs: "list,watch", verbs: []string{"get", "list"}, want: false},
+		{name: "no verbs", requireVerbs: "list", verbs: nil, want: false},
+		{name: "blank entries ignored", requireVerbs: "list,,get, ", verbs: []string{"get", "list"}, want: true},
+		{name: "whitespace trimmed", requireVerbs: " list , get ", verbs: []string{"get", "list"}, want: true},
+		{name: "verbs are case sensitive", requireVerbs: "List", verbs: []string{"list"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requiredVerbs = parseList(tt.requireVerbs)
+			resource := metav1.APIResource{Name: "widgets", Verbs: tt.verbs}
+			if got := hasRequiredVerbs(resource); got != tt.want {
+				t.Errorf("hasRequiredVerbs(%v) with --require-verbs %q = %v, want %v", tt.verbs, tt.requireVerbs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryCluster(t *testing.T) {
+	defer func(verbs []string) { requiredVerbs = verbs }(requiredVerbs)
+	requiredVerbs = []string{"list"}
+
+	responses := map[string]string{
+		"/version": `{"major":"1","minor":"28","gitVersion":"v1.28.4"}`,
+		"/api":     `{"kind":"APIVersions","versions":["v1"],"serverAddressByClientCIDRs":[{"clientCIDR":"0.0.0.0/0","serverAddress":"127.0.0.1"}]}`,
+		"/apis":    `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}}]}`,
+		"/api/v1": `{"kind":"APIResourceList","groupVersion":"v1","resources":[
+			{"name":"pods","namespaced":true,"kind":"Pod","verbs":["get","list"]},
+			{"name":"pods/log","namespaced":true,"kind":"Pod","verbs":["get"]},
+			{"name":"bindings","namespaced":true,"kind":"Binding","verbs":["create"]},
+			{"name":"secrets","namespaced":true,"kind":"Secret","verbs":["get","list"]},
+			{"name":"configmaps","namespaced":true,"kind":"ConfigMap","verbs":["get","list"]}]}`,
+		"/apis/apps/v1": `{"kind":"APIResourceList","groupVersion":"apps/v1","resources":[
+			{"name":"deployments","namespaced":true,"kind":"Deployment","verbs":["get","list"]}]}`,
+		"/api/v1/pods":              `{"kind":"PodList","apiVersion":"v1","metadata":{},"items":[]}`,
+		"/api/v1/configmaps":        `{"kind":"ConfigMapList","apiVersion":"v1","metadata":{},"items":[]}`,
+		"/apis/apps/v1/deployments": `{"kind":"DeploymentList","apiVersion":"apps/v1","metadata":{},"items":[]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if body, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		// secrets are denied, anything else does not exist
+		status := http.StatusNotFound
+		if r.URL.Path == "/api/v1/secrets" {
+			status = http.StatusForbidden
+		}
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","code":%d}`, status)
+	}))
+	defer server.Close()
+
+	kubeconfigPath := writeKubeconfig(t, t.TempDir(), "config", `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: `+server.URL+`
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`)
+
+	resources, err := inventoryCluster(kubeconfigPath)
+	if err != nil {
+		t.Fatalf("inventoryCluster() error = %v", err)
+	}
+	// Subresources, resources without list and denied resources are left out
+	sort.Strings(resources)
+	if want := []string{"configmaps", "deployments", "pods"}; !reflect.DeepEqual(resources, want) {
+		t.Errorf("inventoryCluster() = %v, want %v", resources, want)
+	}
+
+	if _, err := inventoryCluster(filepath.Join(t.TempDir(), "missing")); err == nil || !strings.Contains(err.Error(), "kubeconfig file not found") {
+		t.Errorf("inventoryCluster(missing kubeconfig) error = %v", err)
+	}
+}
+
+func TestFormatDiffSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		resources1 []string
+		resources2 []string
+		want       string
+	}{
+		{
+			name:       "overlapping",
+			resources1: []string{"pods", "configmaps", "routes"},
+			resources2: []string{"pods", "configmaps", "deployments", "ingresses"},
+			want: `
+=== Summary ===
+Total resources in prod: 3
+Total resources in staging: 4
+Only in prod: 1
+Only in staging: 2
+Common to both: 2
+`,
+		},
+		{
+			name:       "identical with duplicates",
+			resources1: []string{"pods", "pods"},
+			resources2: []string{"pods"},
+			want: `
+=== Summary ===
+Total resources in prod: 2
+Total resources in staging: 1
+Only in prod: 0
+Only in staging: 0
+Common to both: 1
+`,
+		},
+		{
+			name:       "one side empty",
+			resources1: nil,
+			resources2: []string{"pods"},
+			want: `
+=== Summary ===
+Total resources in prod: 0
+Total resources in staging: 1
+Only in prod: 0
+Only in staging: 1
+Common to both: 0
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDiffSummary(tt.resources1, tt.resources2, "prod", "staging"); got != tt.want {
+				t.Errorf("formatDiffSummary() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// runMergeCommand implements "merge file1.yaml file2.yaml ... -o merged.yaml",
+// combining several single-file collections into one
+func runMergeCommand(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	mergeOutput := fs.String("o", "./output/merged-resources.yaml", "Output file for the merged collection")
+	fs.BoolVar(&verbose, "verbose", false, "Enable verbose output")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s merge [-o merged.yaml] file1.yaml file2.yaml ...\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+
+	// Allow flags after the file list (merge a.yaml b.yaml -o merged.yaml)
+	var files []string
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil
+			}
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(files) == 0 {
+		fs.Usage()
+		return fmt.Errorf("merge requires at least one input file")
+	}
+
+	startTime := time.Now()
+	merged, duplicates, err := mergeCollections(files)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*mergeOutput), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	writer := newSingleFileWriter(*mergeOutput, 0)
+	itemCount := 0
+	for _, resource := range merged.order {
+		items := merged.items[resource]
+		yamlData, err := yaml.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      items,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s to YAML: %w", resource, err)
+		}
+
+		block := fmt.Sprintf("--- # Resource: %s\n%s\n", resource, yamlData)
+		if err := writer.WriteBlock(block); err != nil {
+			return err
+		}
+		itemCount += len(items)
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	// Print summary
+	fmt.Printf("\n=== Merge Summary ===\n")
+	fmt.Printf("Input files: %d\n", len(files))
+	fmt.Printf("Resource types: %d\n", len(merged.order))
+	fmt.Printf("Objects written: %d\n", itemCount)
+	fmt.Printf("Duplicates dropped: %d\n", duplicates)
+	fmt.Printf("Output file: %s\n", *mergeOutput)
+	fmt.Printf("Duration: %v\n", time.Since(startTime))
+	fmt.Printf("=====================\n")
+
+	return nil
+}
+
+// mergedCollection holds the items of several collections grouped by resource,
+// in the order resources were first seen
+type mergedCollection struct {
+	order []string
+	items map[string][]interface{}
+}
+
+// mergeCollections reads single-file collections and combines their resource
+// blocks. Objects are deduplicated by group, kind, namespace and name; the first
+// occurrence (in file order) wins. It returns the number of duplicates dropped.
+func mergeCollections(files []string) (*mergedCollection, int, error) {
+	merged := &mergedCollection{items: make(map[string][]interface{})}
+	seen := make(map[string]bool)
+	duplicates := 0
+
+	for _, file := range files {
+		blocks, err := parseAllResourcesFile(file)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if verbose {
+			fmt.Printf("Merging %d resource blocks from %s\n", len(blocks), file)
+		}
+
+		for _, block := range blocks {
+			var list map[string]interface{}
+			if err := yaml.Unmarshal([]byte(block.Content), &list); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse %s in %s: %w", block.Name, file, err)
+			}
+
+			if _, ok := merged.items[block.Name]; !ok {
+				merged.order = append(merged.order, block.Name)
+				merged.items[block.Name] = []interface{}{}
+			}
+
+			items, _ := list["items"].([]interface{})
+			for _, item := range items {
+				key := objectKey(item)
+				if key != "" && seen[key] {
+					duplicates++
+					continue
+				}
+				if key != "" {
+					seen[key] = true
+				}
+				merged.items[block.Name] = append(merged.items[block.Name], item)
+			}
+		}
+	}
+
+	return merged, duplicates, nil
+}
+
+// objectKey identifies an object by kind, namespace and name, e.g.
+// "Pod/shop/web" or "Certificate.cert-manager.io/shop/web": kinds of API groups
+// are qualified with the group, so same-named kinds of two groups stay apart.
+// It returns an empty string for items that can't be identified, which are
+// never deduplicated.
+func objectKey(item interface{}) string {
+	itemMap, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	kind := qualifiedKind(itemMap)
+	metadata, _ := itemMap["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if kind == "" || name == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// qualifiedKind returns an object's kind followed by the group of its
+// apiVersion, or the kind alone for the core group
+func qualifiedKind(itemMap map[string]interface{}) string {
+	kind, _ := itemMap["kind"].(string)
+	apiVersion, _ := itemMap["apiVersion"].(string)
+	if group, _, found := strings.Cut(apiVersion, "/"); found && kind != "" {
+		return kind + "." + group
+	}
+	return kind
+}
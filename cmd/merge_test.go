@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestObjectKey(t *testing.T) {
+	tests := []struct {
+		name string
+		item interface{}
+		want string
+	}{
+		{
+			name: "core namespaced object",
+			item: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "web", "namespace": "shop"}},
+			want: "Pod/shop/web",
+		},
+		{
+			name: "grouped object",
+			item: map[string]interface{}{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": map[string]interface{}{"name": "web", "namespace": "shop"}},
+			want: "Certificate.cert-manager.io/shop/web",
+		},
+		{
+			name: "cluster-scoped object",
+			item: map[string]interface{}{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole", "metadata": map[string]interface{}{"name": "admin"}},
+			want: "ClusterRole.rbac.authorization.k8s.io//admin",
+		},
+		{
+			name: "no apiVersion",
+			item: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "web"}},
+			want: "Pod//web",
+		},
+		{name: "no kind", item: map[string]interface{}{"apiVersion": "v1", "metadata": map[string]interface{}{"name": "web"}}},
+		{name: "no name", item: map[string]interface{}{"apiVersion": "v1", "kind": "Pod"}},
+		{name: "not an object", item: "web"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := objectKey(tt.item); got != tt.want {
+				t.Errorf("objectKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeCollections(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	first := write("first.yaml", `--- # Resource: widgets
+apiVersion: v1
+kind: List
+items:
+- apiVersion: example.com/v1
+  kind: Widget
+  metadata:
+    name: w
+    namespace: shop
+  spec:
+    from: first
+`)
+	second := write("second.yaml", `--- # Resource: widgets
+apiVersion: v1
+kind: List
+items:
+- apiVersion: example.com/v1
+  kind: Widget
+  metadata:
+    name: w
+    namespace: shop
+  spec:
+    from: second
+- apiVersion: other.io/v1
+  kind: Widget
+  metadata:
+    name: w
+    namespace: shop
+- kind: Widget
+  metadata: {}
+- kind: Widget
+  metadata: {}
+--- # Resource: configmaps
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: app
+    namespace: shop
+`)
+
+	merged, duplicates, err := mergeCollections([]string{first, second})
+	if err != nil {
+		t.Fatalf("mergeCollections() error = %v", err)
+	}
+	if duplicates != 1 {
+		t.Errorf("duplicates = %d, want 1", duplicates)
+	}
+	if want := []string{"widgets", "configmaps"}; !reflect.DeepEqual(merged.order, want) {
+		t.Errorf("order = %v, want %v", merged.order, want)
+	}
+
+	// The first file wins, the same kind of another group is kept, and items
+	// without a name are never deduplicated
+	widgets := merged.items["widgets"]
+	if len(widgets) != 4 {
+		t.Fatalf("widgets = %v, want 4 items", widgets)
+	}
+	spec, _ := widgets[0].(map[string]interface{})["spec"].(map[string]interface{})
+	if spec["from"] != "first" {
+		t.Errorf("first widget from %v, want first", spec["from"])
+	}
+	if got := widgets[1].(map[string]interface{})["apiVersion"]; got != "other.io/v1" {
+		t.Errorf("second widget apiVersion = %v, want other.io/v1", got)
+	}
+	if len(merged.items["configmaps"]) != 1 {
+		t.Errorf("configmaps = %v, want 1 item", merged.items["configmaps"])
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// metricsDirName holds the NodeMetrics and PodMetrics lists with --collect-metrics
+	metricsDirName = "metrics"
+	// metricsSnapshotFile summarizes CPU and memory usage per node and pod
+	metricsSnapshotFile = "metrics-snapshot.txt"
+)
+
+var (
+	// collectMetrics is set by --collect-metrics
+	collectMetrics bool
+
+	nodeMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}
+	podMetricsGVR  = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+)
+
+// writeMetricsSnapshot collects NodeMetrics and PodMetrics from metrics.k8s.io
+// into dir/metrics and writes a CPU/memory usage summary next to them. A
+// cluster without the metrics API (no metrics-server) is skipped with a message.
+func writeMetricsSnapshot(dynamic dynamic.Interface, dir string) error {
+	if !collectMetrics {
+		return nil
+	}
+
+	nodes, err := listMetrics(dynamic, nodeMetricsGVR)
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+			fmt.Printf("Skipping --collect-metrics: metrics.k8s.io/v1beta1 is not available (is metrics-server installed?)\n")
+			return nil
+		}
+		return fmt.Errorf("failed to collect node metrics: %w", err)
+	}
+
+	pods, err := listMetrics(dynamic, podMetricsGVR)
+	if err != nil {
+		return fmt.Errorf("failed to collect pod metrics: %w", err)
+	}
+
+	metricsDir := filepath.Join(dir, metricsDirName)
+	if err := os.MkdirAll(metricsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	for _, metrics := range []struct {
+		gvr  schema.GroupVersionResource
+		list *unstructured.UnstructuredList
+	}{{nodeMetricsGVR, nodes}, {podMetricsGVR, pods}} {
+		yamlData, err := yaml.Marshal(metrics.list)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s metrics to YAML: %w", metrics.gvr.Resource, err)
+		}
+		groupVersion := metrics.gvr.GroupVersion().String()
+		filePath := filepath.Join(metricsDir, formatFilename(metrics.gvr.Resource, groupVersion))
+		if err := writeOutputFile(filePath, []byte(formatHeader(metrics.gvr.Resource, groupVersion)+string(yamlData)), 0644); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
+	}
+
+	var report strings.Builder
+	report.WriteString("=== Metrics Snapshot ===\n")
+	report.WriteString(fmt.Sprintf("Collected at: %s\n", time.Now().Format(time.RFC3339)))
+
+	report.WriteString(fmt.Sprintf("\nNodes (%d):\n", len(nodes.Items)))
+	for _, line := range nodeUsageLines(nodes) {
+		report.WriteString(line + "\n")
+	}
+
+	report.WriteString(fmt.Sprintf("\nPods (%d):\n", len(pods.Items)))
+	for _, line := range podUsageLines(pods) {
+		report.WriteString(line + "\n")
+	}
+
+	path := filepath.Join(dir, metricsSnapshotFile)
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics snapshot %s: %w", path, err)
+	}
+
+	fmt.Printf("Metrics snapshot: %s (%d nodes, %d pods)\n", path, len(nodes.Items), len(pods.Items))
+	return nil
+}
+
+// listMetrics lists one metrics.k8s.io resource across all namespaces
+func listMetrics(dynamic dynamic.Interface, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return dynamic.Resource(gvr).List(ctx, metav1.ListOptions{})
+}
+
+// nodeUsageLines renders "  node-1: cpu 350m, memory 2150Mi" per node, sorted by name
+func nodeUsageLines(nodes *unstructured.UnstructuredList) []string {
+	var lines []string
+	for _, node := range nodes.Items {
+		usage, _, _ := unstructured.NestedStringMap(node.Object, "usage")
+		lines = append(lines, fmt.Sprintf("  %s: %s", node.GetName(), formatUsage(usage["cpu"], usage["memory"])))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// podUsageLines renders the summed container usage of each pod, sorted by namespace/name
+func podUsageLines(pods *unstructured.UnstructuredList) []string {
+	var lines []string
+	for _, pod := range pods.Items {
+		cpu, memory := resource.Quantity{}, resource.Quantity{}
+		containers, _, _ := unstructured.NestedSlice(pod.Object, "containers")
+		for _, container := range containers {
+			c, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			usage, _, _ := unstructured.NestedStringMap(c, "usage")
+			if quantity, err := resource.ParseQuantity(usage["cpu"]); err == nil {
+				cpu.Add(quantity)
+			}
+			if quantity, err := resource.ParseQuantity(usage["memory"]); err == nil {
+				memory.Add(quantity)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("  %s/%s: %s", pod.GetNamespace(), pod.GetName(), formatUsage(cpu.String(), memory.String())))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// formatUsage renders CPU in millicores and memory in Mi, e.g. "cpu 350m, memory 512Mi"
+func formatUsage(cpu, memory string) string {
+	cpuText, memoryText := cpu, memory
+	if quantity, err := resource.ParseQuantity(cpu); err == nil {
+		cpuText = fmt.Sprintf("%dm", quantity.MilliValue())
+	}
+	if quantity, err := resource.ParseQuantity(memory); err == nil {
+		memoryText = fmt.Sprintf("%dMi", quantity.Value()/(1024*1024))
+	}
+	return fmt.Sprintf("cpu %s, memory %s", cpuText, memoryText)
+}
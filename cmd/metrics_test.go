@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFormatUsage(t *testing.T) {
+	tests := []struct {
+		cpu    string
+		memory string
+		want   string
+	}{
+		{"350m", "512Mi", "cpu 350m, memory 512Mi"},
+		{"2", "2Gi", "cpu 2000m, memory 2048Mi"},
+		{"123456789n", "1048576Ki", "cpu 124m, memory 1024Mi"},
+		{"", "", "cpu , memory "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cpu+"/"+tt.memory, func(t *testing.T) {
+			if got := formatUsage(tt.cpu, tt.memory); got != tt.want {
+				t.Errorf("formatUsage(%q, %q) = %q, want %q", tt.cpu, tt.memory, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodUsageLines(t *testing.T) {
+	pod := func(namespace, name string, usages ...map[string]interface{}) unstructured.Unstructured {
+		var containers []interface{}
+		for _, usage := range usages {
+			containers = append(containers, map[string]interface{}{"usage": usage})
+		}
+		item := unstructured.Unstructured{Object: map[string]interface{}{"containers": containers}}
+		item.SetNamespace(namespace)
+		item.SetName(name)
+		return item
+	}
+	pods := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		pod("shop", "web",
+			map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+			map[string]interface{}{"cpu": "50m", "memory": "64Mi"},
+		),
+		pod("kube-system", "dns", map[string]interface{}{"cpu": "5m", "memory": "20Mi"}),
+	}}
+
+	want := []string{
+		"  kube-system/dns: cpu 5m, memory 20Mi",
+		"  shop/web: cpu 150m, memory 192Mi",
+	}
+	if got := podUsageLines(pods); !reflect.DeepEqual(got, want) {
+		t.Errorf("podUsageLines() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteMetricsSnapshot(t *testing.T) {
+	defer func(saved bool) { collectMetrics = saved }(collectMetrics)
+	collectMetrics = true
+
+	node := unstructured.Unstructured{Object: map[string]interface{}{"usage": map[string]interface{}{"cpu": "350m", "memory": "2Gi"}}}
+	node.SetName("node-1")
+
+	tests := []struct {
+		name       string
+		client     *stubDynamic
+		wantReport bool
+		wantErr    bool
+	}{
+		{
+			name: "metrics available",
+			client: &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+				"nodes": {Items: []unstructured.Unstructured{node}},
+			}},
+			wantReport: true,
+		},
+		{
+			name: "no metrics-server",
+			client: &stubDynamic{errs: map[string]error{
+				"nodes": apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "nodes"}, ""),
+			}},
+		},
+		{
+			name: "pod metrics failure",
+			client: &stubDynamic{errs: map[string]error{
+				"pods": apierrors.NewForbidden(schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"}, "", nil),
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := writeMetricsSnapshot(tt.client, dir); (err != nil) != tt.wantErr {
+				t.Fatalf("writeMetricsSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			report, err := os.ReadFile(filepath.Join(dir, metricsSnapshotFile))
+			if !tt.wantReport {
+				if err == nil {
+					t.Errorf("metrics snapshot written:\n%s", report)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(report), "Nodes (1):\n  node-1: cpu 350m, memory 2048Mi\n") {
+				t.Errorf("metrics snapshot missing node usage:\n%s", report)
+			}
+			if _, err := os.Stat(filepath.Join(dir, metricsDirName, formatFilename("nodes", "metrics.k8s.io/v1beta1"))); err != nil {
+				t.Errorf("node metrics list not written: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// clusterScopedNamespace groups cluster-scoped objects in the per-namespace diff
+const clusterScopedNamespace = "<cluster-scoped>"
+
+// namespaceChanges lists the objects that moved in one namespace between two collections
+type namespaceChanges struct {
+	added   []string
+	removed []string
+	changed []string
+}
+
+// generateChangedNamespacesDiff aggregates object-level changes per namespace
+// and reports only the namespaces where something was added, removed or
+// modified; unchanged namespaces are counted but not listed
+func generateChangedNamespacesDiff(file1, file2, name1, name2 string) (string, error) {
+	objects1, err := loadCollectionObjects(file1)
+	if err != nil {
+		return "", err
+	}
+	objects2, err := loadCollectionObjects(file2)
+	if err != nil {
+		return "", err
+	}
+
+	changes := make(map[string]*namespaceChanges)
+	changesFor := func(item map[string]interface{}) *namespaceChanges {
+		metadata, _ := item["metadata"].(map[string]interface{})
+		namespace, _ := metadata["namespace"].(string)
+		if namespace == "" {
+			namespace = clusterScopedNamespace
+		}
+		if changes[namespace] == nil {
+			changes[namespace] = &namespaceChanges{}
+		}
+		return changes[namespace]
+	}
+
+	for key, item := range objects1 {
+		c := changesFor(item)
+		other, ok := objects2[key]
+		switch {
+		case !ok:
+			c.removed = append(c.removed, key)
+		case len(diffObjects(item, other)) > 0:
+			c.changed = append(c.changed, key)
+		}
+	}
+	for key, item := range objects2 {
+		c := changesFor(item)
+		if _, ok := objects1[key]; !ok {
+			c.added = append(c.added, key)
+		}
+	}
+
+	var namespaces []string
+	unchanged := 0
+	for namespace, c := range changes {
+		if len(c.added)+len(c.removed)+len(c.changed) == 0 {
+			unchanged++
+			continue
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var report strings.Builder
+	report.WriteString("\n=== Changed namespaces ===\n")
+	for _, namespace := range namespaces {
+		c := changes[namespace]
+		report.WriteString(fmt.Sprintf("\n%s: %d added, %d removed, %d changed\n",
+			namespace, len(c.added), len(c.removed), len(c.changed)))
+		writeNamespaceChangeKeys(&report, "+", fmt.Sprintf("(only in %s)", name2), c.added)
+		writeNamespaceChangeKeys(&report, "-", fmt.Sprintf("(only in %s)", name1), c.removed)
+		writeNamespaceChangeKeys(&report, "~", "", c.changed)
+	}
+	report.WriteString(fmt.Sprintf("\nChanged namespaces: %d, unchanged (not shown): %d\n", len(namespaces), unchanged))
+
+	return report.String(), nil
+}
+
+// writeNamespaceChangeKeys writes one sorted line per object key
+func writeNamespaceChangeKeys(report *strings.Builder, marker, note string, keys []string) {
+	sort.Strings(keys)
+	for _, key := range keys {
+		line := fmt.Sprintf("  %s %s", marker, key)
+		if note != "" {
+			line += " " + note
+		}
+		report.WriteString(line + "\n")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateChangedNamespacesDiff(t *testing.T) {
+	defer func(saved bool) { collapseGenerated = saved }(collapseGenerated)
+	collapseGenerated = false
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	file1 := write("before.yaml", `--- # Resource: configmaps
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: app
+    namespace: shop
+  data:
+    mode: blue
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: legacy
+    namespace: shop
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+    namespace: quiet
+--- # Resource: namespaces
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: shop
+`)
+	file2 := write("after.yaml", `--- # Resource: configmaps
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: app
+    namespace: shop
+  data:
+    mode: green
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cache
+    namespace: shop
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+    namespace: quiet
+--- # Resource: namespaces
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: shop
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: quiet
+`)
+
+	report, err := generateChangedNamespacesDiff(file1, file2, "before", "after")
+	if err != nil {
+		t.Fatalf("generateChangedNamespacesDiff() error = %v", err)
+	}
+
+	want := `
+=== Changed namespaces ===
+
+<cluster-scoped>: 1 added, 0 removed, 0 changed
+  + Namespace//quiet (only in after)
+
+shop: 1 added, 1 removed, 1 changed
+  + ConfigMap/shop/cache (only in after)
+  - ConfigMap/shop/legacy (only in before)
+  ~ ConfigMap/shop/app
+
+Changed namespaces: 2, unchanged (not shown): 1
+`
+	if report != want {
+		t.Errorf("report =\n%s\nwant\n%s", report, want)
+	}
+}
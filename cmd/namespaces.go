@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// Per-namespace collection (--all-namespaces-explicit)
+//
+// A service account with Role bindings in some namespaces but no cluster-wide
+// list permission fails the default cross-namespace List. In this mode the
+// namespaces are listed once up front, and every namespaced resource is listed
+// namespace by namespace. Namespaces that deny access are recorded in the
+// namespace manifest instead of failing the whole resource. With
+// --timeout-per-namespace, each namespace gets a time budget across all
+// resources; once it is used up, the namespace is recorded as timed out and
+// skipped for the remaining resources so one hung namespace can't stall the rest.
+
+// namespaceManifestFile is written next to the collection in this mode
+const namespaceManifestFile = "namespace-manifest.yaml"
+
+// namespaceResult records what was collected from one namespace
+type namespaceResult struct {
+	Collected map[string]int    `json:"collected,omitempty"`
+	Failed    map[string]string `json:"failed,omitempty"`
+	TimedOut  bool              `json:"timedOut,omitempty"`
+
+	// spent is the time used so far against --timeout-per-namespace
+	spent time.Duration
+}
+
+var (
+	// explicitNamespaces are the namespaces to iterate; empty when the mode is off
+	explicitNamespaces []string
+	// namespaceResults is the per-namespace manifest of the current run
+	namespaceResults map[string]*namespaceResult
+	// fallbackNamespaces caches the namespace list for resources that
+	// reject a cross-namespace List; nil until first needed
+	fallbackNamespaces []string
+)
+
+// prepareNamespaces lists the namespaces to iterate when --all-namespaces-explicit is set
+func prepareNamespaces(dynamic dynamic.Interface) error {
+	explicitNamespaces = nil
+	namespaceResults = make(map[string]*namespaceResult)
+	fallbackNamespaces = nil
+
+	if !allNamespacesExplicit {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	list, err := dynamic.Resource(namespaces).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces for --all-namespaces-explicit: %w", err)
+	}
+
+	for _, item := range list.Items {
+		explicitNamespaces = append(explicitNamespaces, item.GetName())
+		namespaceResults[item.GetName()] = &namespaceResult{}
+	}
+	sort.Strings(explicitNamespaces)
+
+	if verbose {
+		fmt.Printf("Collecting namespaced resources from %d namespaces one by one\n", len(explicitNamespaces))
+	}
+
+	return nil
+}
+
+// listResourceScoped lists a resource namespace by namespace in this mode, and
+// across all namespaces otherwise. Subresources are always listed cluster-wide.
+func listResourceScoped(dynamic dynamic.Interface, gvr schema.GroupVersionResource, namespaced bool) (*unstructured.UnstructuredList, error) {
+	if !namespaced || strings.Contains(gvr.Resource, "/") {
+		return listResource(dynamic, gvr)
+	}
+
+	if !allNamespacesExplicit {
+		list, err := listResource(dynamic, gvr)
+		if err != nil && isNamespaceRequiredError(err) {
+			fmt.Printf("%s: cross-namespace list is not supported, falling back to listing each namespace\n", gvr.Resource)
+			return listEachNamespace(dynamic, gvr)
+		}
+		return list, err
+	}
+
+	var merged *unstructured.UnstructuredList
+	var lastErr error
+	for _, namespace := range explicitNamespaces {
+		result := namespaceResults[namespace]
+
+		// A namespace that used up its budget is not listed again
+		if result.TimedOut {
+			continue
+		}
+
+		timeout := 30 * time.Second
+		if timeoutPerNamespace > 0 {
+			timeout = timeoutPerNamespace - result.spent
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		started := time.Now()
+		list, err := listPaged(ctx, dynamic.Resource(gvr).Namespace(namespace), metav1.ListOptions{})
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		cancel()
+		result.spent += time.Since(started)
+
+		if timedOut && timeoutPerNamespace > 0 {
+			result.TimedOut = true
+			fmt.Printf("Warning: namespace %s exceeded --timeout-per-namespace of %v, skipping it for the remaining resources\n", namespace, timeoutPerNamespace)
+		}
+
+		if err != nil {
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[gvr.Resource] = namespaceFailure(err)
+			lastErr = err
+			continue
+		}
+
+		if result.Collected == nil {
+			result.Collected = make(map[string]int)
+		}
+		result.Collected[gvr.Resource] += len(list.Items)
+
+		if merged == nil {
+			merged = list
+		} else {
+			merged.Items = append(merged.Items, list.Items...)
+		}
+	}
+
+	// Only fail the resource if no namespace could be listed
+	if merged == nil {
+		if lastErr == nil {
+			return &unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}}, nil
+		}
+		return nil, lastErr
+	}
+
+	return merged, nil
+}
+
+// isNamespaceRequiredError reports whether a List failed because the resource
+// can only be listed within a namespace
+func isNamespaceRequiredError(err error) bool {
+	if !apierrors.IsBadRequest(err) && !apierrors.IsMethodNotSupported(err) && !apierrors.IsNotFound(err) && !apierrors.IsForbidden(err) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "namespace") &&
+		(strings.Contains(message, "required") || strings.Contains(message, "must be specified") || strings.Contains(message, "must be provided"))
+}
+
+// listEachNamespace lists a namespaced resource one namespace at a time. It
+// only fails if no namespace could be listed.
+func listEachNamespace(dynamic dynamic.Interface, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+	if fallbackNamespaces == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		namespaces, err := dynamic.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).List(ctx, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces for per-namespace fallback: %w", err)
+		}
+
+		fallbackNamespaces = []string{}
+		for _, item := range namespaces.Items {
+			fallbackNamespaces = append(fallbackNamespaces, item.GetName())
+		}
+		sort.Strings(fallbackNamespaces)
+	}
+
+	merged := &unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}}
+	var lastErr error
+	listed := 0
+	for _, namespace := range fallbackNamespaces {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		list, err := listPaged(ctx, dynamic.Resource(gvr).Namespace(namespace), metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			if verbose {
+				fmt.Printf("  %s in %s: %v\n", gvr.Resource, namespace, err)
+			}
+			lastErr = err
+			continue
+		}
+
+		listed++
+		merged.Items = append(merged.Items, list.Items...)
+	}
+
+	if listed == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return merged, nil
+}
+
+// printNamespaceSummary reports the namespaces that ran out of their --timeout-per-namespace budget
+func printNamespaceSummary() {
+	var timedOut []string
+	for _, namespace := range explicitNamespaces {
+		if namespaceResults[namespace].TimedOut {
+			timedOut = append(timedOut, namespace)
+		}
+	}
+	if len(timedOut) > 0 {
+		fmt.Printf("Timed out namespaces: %d (%s)\n", len(timedOut), strings.Join(timedOut, ", "))
+	}
+}
+
+// namespaceFailure is how a failed List is recorded in the namespace
+// manifest. API errors name the user and the namespace, so with --anonymize
+// only their status reason (e.g. Forbidden) is kept.
+func namespaceFailure(err error) string {
+	if anonymizer == nil {
+		return err.Error()
+	}
+	if reason := apierrors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return "list failed"
+}
+
+// writeNamespaceManifest writes what was collected from and denied in each
+// namespace, keyed by pseudonym with --anonymize
+func writeNamespaceManifest(path string) error {
+	if !allNamespacesExplicit {
+		return nil
+	}
+
+	results := namespaceResults
+	if anonymizer != nil {
+		results = make(map[string]*namespaceResult, len(namespaceResults))
+		for name, result := range namespaceResults {
+			results[anonymizer.namespace(name)] = result
+		}
+	}
+
+	yamlData, err := yaml.Marshal(map[string]interface{}{"namespaces": results})
+	if err != nil {
+		return fmt.Errorf("failed to marshal namespace manifest: %w", err)
+	}
+
+	if err := writeOutputFile(path, yamlData, 0644); err != nil {
+		return fmt.Errorf("failed to write namespace manifest %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+func namespacedList(kind string, objects ...[2]string) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}}
+	for _, object := range objects {
+		item := unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": kind}}
+		item.SetNamespace(object[0])
+		item.SetName(object[1])
+		list.Items = append(list.Items, item)
+	}
+	return list
+}
+
+func TestListResourceScopedExplicitNamespaces(t *testing.T) {
+	defer func(explicit bool) { allNamespacesExplicit = explicit }(allNamespacesExplicit)
+	allNamespacesExplicit = true
+
+	namespaces := &unstructured.UnstructuredList{}
+	for _, name := range []string{"shop", "default"} {
+		item := unstructured.Unstructured{Object: map[string]interface{}{}}
+		item.SetName(name)
+		namespaces.Items = append(namespaces.Items, item)
+	}
+	client := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+		"namespaces": namespaces,
+		"pods":       namespacedList("Pod", [2]string{"shop", "web"}, [2]string{"shop", "db"}, [2]string{"default", "dns"}),
+	}}
+
+	if err := prepareNamespaces(client); err != nil {
+		t.Fatalf("prepareNamespaces() error = %v", err)
+	}
+	if got := strings.Join(explicitNamespaces, ","); got != "default,shop" {
+		t.Errorf("explicitNamespaces = %s, want default,shop", got)
+	}
+
+	list, err := listResourceScoped(client, schema.GroupVersionResource{Version: "v1", Resource: "pods"}, true)
+	if err != nil {
+		t.Fatalf("listResourceScoped() error = %v", err)
+	}
+	if len(list.Items) != 3 {
+		t.Errorf("listed %d pods, want 3", len(list.Items))
+	}
+	if got := namespaceResults["shop"].Collected["pods"]; got != 2 {
+		t.Errorf("shop collected %d pods, want 2", got)
+	}
+	if got := namespaceResults["default"].Collected["pods"]; got != 1 {
+		t.Errorf("default collected %d pods, want 1", got)
+	}
+
+	path := filepath.Join(t.TempDir(), namespaceManifestFile)
+	if err := writeNamespaceManifest(path); err != nil {
+		t.Fatalf("writeNamespaceManifest() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "shop:") || !strings.Contains(string(data), "pods: 2") {
+		t.Errorf("namespace manifest missing shop pods:\n%s", data)
+	}
+}
+
+func TestListResourceScopedAllNamespacesDenied(t *testing.T) {
+	defer func(explicit bool) { allNamespacesExplicit = explicit }(allNamespacesExplicit)
+	allNamespacesExplicit = true
+
+	namespaces := &unstructured.UnstructuredList{}
+	item := unstructured.Unstructured{Object: map[string]interface{}{}}
+	item.SetName("shop")
+	namespaces.Items = append(namespaces.Items, item)
+	denied := apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("denied"))
+	client := &stubDynamic{
+		lists: map[string]*unstructured.UnstructuredList{"namespaces": namespaces},
+		errs:  map[string]error{"secrets": denied},
+	}
+
+	if err := prepareNamespaces(client); err != nil {
+		t.Fatalf("prepareNamespaces() error = %v", err)
+	}
+	if _, err := listResourceScoped(client, schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, true); !apierrors.IsForbidden(err) {
+		t.Errorf("listResourceScoped() error = %v, want the Forbidden error", err)
+	}
+	if _, ok := namespaceResults["shop"].Failed["secrets"]; !ok {
+		t.Errorf("denied secrets not recorded for shop: %+v", namespaceResults["shop"])
+	}
+}
+
+func TestWriteNamespaceManifestAnonymized(t *testing.T) {
+	defer func(explicit bool, saved *pseudonymizer) {
+		allNamespacesExplicit, anonymizer = explicit, saved
+	}(allNamespacesExplicit, anonymizer)
+	allNamespacesExplicit = true
+	anonymizer = newPseudonymizer("")
+
+	namespaces := &unstructured.UnstructuredList{}
+	for _, name := range []string{"shop", "kube-system"} {
+		item := unstructured.Unstructured{Object: map[string]interface{}{}}
+		item.SetName(name)
+		namespaces.Items = append(namespaces.Items, item)
+	}
+	denied := apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New(`User "alice" cannot list resource "secrets" in the namespace "shop"`))
+	client := &stubDynamic{
+		lists: map[string]*unstructured.UnstructuredList{"namespaces": namespaces},
+		errs:  map[string]error{"secrets": denied},
+	}
+
+	if err := prepareNamespaces(client); err != nil {
+		t.Fatalf("prepareNamespaces() error = %v", err)
+	}
+	if _, err := listResourceScoped(client, schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, true); err == nil {
+		t.Fatal("listResourceScoped() error = nil, want the Forbidden error")
+	}
+
+	path := filepath.Join(t.TempDir(), namespaceManifestFile)
+	if err := writeNamespaceManifest(path); err != nil {
+		t.Fatalf("writeNamespaceManifest() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := string(data)
+	for _, leaked := range []string{"shop", "alice"} {
+		if strings.Contains(manifest, leaked) {
+			t.Errorf("anonymized namespace manifest contains %q:\n%s", leaked, manifest)
+		}
+	}
+	for _, want := range []string{anonymizer.namespace("shop") + ":", "kube-system:", "secrets: Forbidden"} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("anonymized namespace manifest missing %q:\n%s", want, manifest)
+		}
+	}
+}
+
+// hangingDynamic blocks every List in one namespace until its context expires
+type hangingDynamic struct {
+	*stubDynamic
+	namespace string
+	lists     int
+}
+
+func (d *hangingDynamic) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &hangingResource{stubNamespaceableResource: &stubNamespaceableResource{dynamic: d.stubDynamic, gvr: gvr}, hanging: d}
+}
+
+type hangingResource struct {
+	*stubNamespaceableResource
+	hanging *hangingDynamic
+}
+
+func (r *hangingResource) Namespace(namespace string) dynamic.ResourceInterface {
+	if namespace != r.hanging.namespace {
+		return r.stubNamespaceableResource.Namespace(namespace)
+	}
+	return r
+}
+
+func (r *hangingResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if r.gvr.Resource == "namespaces" {
+		return r.stubNamespaceableResource.List(ctx, opts)
+	}
+	r.hanging.lists++
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestListResourceScopedTimeoutPerNamespace(t *testing.T) {
+	defer func(explicit bool, chunk int64, timeout time.Duration) {
+		allNamespacesExplicit, chunkSize, timeoutPerNamespace = explicit, chunk, timeout
+	}(allNamespacesExplicit, chunkSize, timeoutPerNamespace)
+	allNamespacesExplicit, chunkSize, timeoutPerNamespace = true, 0, 50*time.Millisecond
+
+	namespaces := &unstructured.UnstructuredList{}
+	for _, name := range []string{"hung", "shop"} {
+		item := unstructured.Unstructured{Object: map[string]interface{}{}}
+		item.SetName(name)
+		namespaces.Items = append(namespaces.Items, item)
+	}
+	client := &hangingDynamic{namespace: "hung", stubDynamic: &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+		"namespaces": namespaces,
+		"pods":       namespacedList("Pod", [2]string{"shop", "web"}),
+		"services":   namespacedList("Service", [2]string{"shop", "web"}),
+	}}}
+
+	if err := prepareNamespaces(client); err != nil {
+		t.Fatalf("prepareNamespaces() error = %v", err)
+	}
+
+	for _, resource := range []string{"pods", "services"} {
+		list, err := listResourceScoped(client, schema.GroupVersionResource{Version: "v1", Resource: resource}, true)
+		if err != nil {
+			t.Fatalf("listResourceScoped(%s) error = %v", resource, err)
+		}
+		if len(list.Items) != 1 {
+			t.Errorf("listed %d %s, want the 1 from shop", len(list.Items), resource)
+		}
+	}
+
+	if !namespaceResults["hung"].TimedOut {
+		t.Error("hung namespace not recorded as timed out")
+	}
+	if namespaceResults["shop"].TimedOut {
+		t.Error("shop namespace recorded as timed out")
+	}
+	if client.lists != 1 {
+		t.Errorf("hung namespace listed %d times, want it skipped after its budget ran out", client.lists)
+	}
+}
+
+func TestWriteNamespaceManifestDisabled(t *testing.T) {
+	defer func(explicit bool) { allNamespacesExplicit = explicit }(allNamespacesExplicit)
+	allNamespacesExplicit = false
+
+	path := filepath.Join(t.TempDir(), namespaceManifestFile)
+	if err := writeNamespaceManifest(path); err != nil {
+		t.Fatalf("writeNamespaceManifest() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("manifest written without --all-namespaces-explicit: %v", err)
+	}
+}
+
+// clusterListRejectingDynamic fails every cross-namespace List other than
+// namespaces with err, and serves namespaced Lists from the stub
+type clusterListRejectingDynamic struct {
+	*stubDynamic
+	err error
+}
+
+func (d *clusterListRejectingDynamic) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &clusterListRejectingResource{stubNamespaceableResource: &stubNamespaceableResource{dynamic: d.stubDynamic, gvr: gvr}, err: d.err}
+}
+
+type clusterListRejectingResource struct {
+	*stubNamespaceableResource
+	err error
+}
+
+func (r *clusterListRejectingResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if r.gvr.Resource != "namespaces" {
+		return nil, r.err
+	}
+	return r.stubNamespaceableResource.List(ctx, opts)
+}
+
+func TestListResourceScopedNamespaceRequiredFallback(t *testing.T) {
+	defer func(explicit bool, chunk int64, attempts int) {
+		allNamespacesExplicit, chunkSize, retries = explicit, chunk, attempts
+	}(allNamespacesExplicit, chunkSize, retries)
+	allNamespacesExplicit, chunkSize, retries = false, 0, 0
+
+	namespaces := &unstructured.UnstructuredList{}
+	for _, name := range []string{"shop", "default"} {
+		item := unstructured.Unstructured{Object: map[string]interface{}{}}
+		item.SetName(name)
+		namespaces.Items = append(namespaces.Items, item)
+	}
+	stub := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+		"namespaces": namespaces,
+		"widgets":    namespacedList("Widget", [2]string{"shop", "a"}, [2]string{"default", "b"}),
+	}}
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantItems int
+		wantErr   bool
+	}{
+		{name: "namespace required", err: apierrors.NewBadRequest("a namespace must be specified to list widgets"), wantItems: 2},
+		{name: "other errors are returned", err: apierrors.NewBadRequest("invalid label selector"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := prepareNamespaces(stub); err != nil {
+				t.Fatal(err)
+			}
+
+			list, err := listResourceScoped(&clusterListRejectingDynamic{stubDynamic: stub, err: tt.err}, gvr, true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listResourceScoped() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(list.Items) != tt.wantItems {
+				t.Errorf("listed %d widgets, want %d", len(list.Items), tt.wantItems)
+			}
+			if got := strings.Join(fallbackNamespaces, ","); got != "default,shop" {
+				t.Errorf("fallbackNamespaces = %s, want default,shop", got)
+			}
+		})
+	}
+}
+
+func TestIsNamespaceRequiredError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"bad request asking for a namespace", apierrors.NewBadRequest("namespace is required"), true},
+		{"method not supported with namespace message", apierrors.NewMethodNotSupported(pods, "list: a namespace must be specified"), true},
+		{"forbidden without namespace hint", apierrors.NewForbidden(pods, "", errors.New("denied")), false},
+		{"bad request about something else", apierrors.NewBadRequest("invalid field selector"), false},
+		{"plain error mentioning namespace", errors.New("namespace is required"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNamespaceRequiredError(tt.err); got != tt.want {
+				t.Errorf("isNamespaceRequiredError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
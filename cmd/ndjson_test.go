@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []interface{}
+		want    string
+		wantErr bool
+	}{
+		{name: "no items", want: ""},
+		{
+			name: "one object per line",
+			items: []interface{}{
+				map[string]interface{}{"kind": "ConfigMap", "metadata": map[string]interface{}{"name": "a"}},
+				map[string]interface{}{"kind": "Secret"},
+			},
+			want: "{\"kind\":\"ConfigMap\",\"metadata\":{\"name\":\"a\"}}\n{\"kind\":\"Secret\"}\n",
+		},
+		{name: "multi-line strings stay on one line", items: []interface{}{map[string]interface{}{"data": "a\nb"}}, want: "{\"data\":\"a\\nb\"}\n"},
+		{name: "unmarshalable item", items: []interface{}{map[string]interface{}{"f": func() {}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeNDJSON(&buf, tt.items)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeNDJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && buf.String() != tt.want {
+				t.Errorf("writeNDJSON() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
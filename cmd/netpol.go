@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// netpolReportFile is written next to the collection when --netpol-report is set
+const netpolReportFile = "netpol-report.txt"
+
+// netpolInfo is the part of a NetworkPolicy the reachability summary needs
+type netpolInfo struct {
+	namespace   string
+	name        string
+	selector    labels.Selector
+	ingress     bool
+	egress      bool
+	denyIngress bool
+	denyEgress  bool
+}
+
+// podInfo is a collected pod with its labels
+type podInfo struct {
+	namespace string
+	name      string
+	labels    labels.Set
+}
+
+var (
+	// netpolReport is set by --netpol-report
+	netpolReport bool
+
+	// netpolPolicies and netpolPods are collected in the current run
+	netpolPolicies []netpolInfo
+	netpolPods     []podInfo
+	// netpolCollected notes whether networkpolicies were listed at all
+	netpolCollected bool
+)
+
+// recordNetworkPolicies keeps the collected NetworkPolicies and pods for the
+// reachability summary
+func recordNetworkPolicies(resourceName string, list *unstructured.UnstructuredList) {
+	if !netpolReport {
+		return
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		switch {
+		case resourceName == "networkpolicies" && item.GetKind() == "NetworkPolicy":
+			netpolPolicies = append(netpolPolicies, networkPolicyInfo(item))
+		case resourceName == "pods" && item.GetKind() == "Pod":
+			// Finished pods accept no traffic
+			if phase, _, _ := unstructured.NestedString(item.Object, "status", "phase"); phase == "Succeeded" || phase == "Failed" {
+				continue
+			}
+			netpolPods = append(netpolPods, podInfo{namespace: item.GetNamespace(), name: item.GetName(), labels: labels.Set(item.GetLabels())})
+		}
+	}
+	if resourceName == "networkpolicies" {
+		netpolCollected = true
+	}
+}
+
+// networkPolicyInfo reads a policy's pod selector and directions. As in
+// networking.k8s.io/v1, a policy without policyTypes always affects ingress and
+// affects egress when it has egress rules; a direction without rules denies
+// all traffic in that direction for the selected pods.
+func networkPolicyInfo(policy *unstructured.Unstructured) netpolInfo {
+	info := netpolInfo{namespace: policy.GetNamespace(), name: policy.GetName(), selector: labels.Nothing()}
+
+	if raw, found, _ := unstructured.NestedMap(policy.Object, "spec", "podSelector"); found {
+		var selector metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &selector); err == nil {
+			if converted, err := metav1.LabelSelectorAsSelector(&selector); err == nil {
+				info.selector = converted
+			}
+		}
+	} else {
+		// podSelector is required; a missing one is treated as empty like the API server does
+		info.selector = labels.Everything()
+	}
+
+	ingressRules, _, _ := unstructured.NestedSlice(policy.Object, "spec", "ingress")
+	egressRules, _, _ := unstructured.NestedSlice(policy.Object, "spec", "egress")
+	policyTypes, found, _ := unstructured.NestedStringSlice(policy.Object, "spec", "policyTypes")
+	if !found || len(policyTypes) == 0 {
+		policyTypes = []string{"Ingress"}
+		if len(egressRules) > 0 {
+			policyTypes = append(policyTypes, "Egress")
+		}
+	}
+	for _, policyType := range policyTypes {
+		switch policyType {
+		case "Ingress":
+			info.ingress = true
+			info.denyIngress = len(ingressRules) == 0
+		case "Egress":
+			info.egress = true
+			info.denyEgress = len(egressRules) == 0
+		}
+	}
+	return info
+}
+
+// writeNetpolReport summarizes per namespace whether a default-deny policy
+// exists and which pods no policy isolates, then lists the namespaces that
+// lack ingress restrictions
+func writeNetpolReport(path string) error {
+	if !netpolReport {
+		return nil
+	}
+
+	namespaces := make(map[string]bool)
+	for _, pod := range netpolPods {
+		namespaces[pod.namespace] = true
+	}
+	for _, policy := range netpolPolicies {
+		namespaces[policy.namespace] = true
+	}
+	var names []string
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	sort.Slice(netpolPods, func(i, j int) bool {
+		if netpolPods[i].namespace != netpolPods[j].namespace {
+			return netpolPods[i].namespace < netpolPods[j].namespace
+		}
+		return netpolPods[i].name < netpolPods[j].name
+	})
+
+	var summary, unrestricted, uncoveredPods []string
+	for _, namespace := range names {
+		var policies []netpolInfo
+		for _, policy := range netpolPolicies {
+			if policy.namespace == namespace {
+				policies = append(policies, policy)
+			}
+		}
+
+		denyIngress, denyEgress := false, false
+		for _, policy := range policies {
+			if policy.selector.Empty() {
+				denyIngress = denyIngress || policy.denyIngress
+				denyEgress = denyEgress || policy.denyEgress
+			}
+		}
+
+		pods, ingressCovered, egressCovered := 0, 0, 0
+		for _, pod := range netpolPods {
+			if pod.namespace != namespace {
+				continue
+			}
+			pods++
+			ingress, egress := false, false
+			for _, policy := range policies {
+				if policy.selector.Matches(pod.labels) {
+					ingress = ingress || policy.ingress
+					egress = egress || policy.egress
+				}
+			}
+			if ingress {
+				ingressCovered++
+			} else {
+				uncoveredPods = append(uncoveredPods, fmt.Sprintf("  %s/%s", pod.namespace, pod.name))
+			}
+			if egress {
+				egressCovered++
+			}
+		}
+
+		summary = append(summary, fmt.Sprintf("  %s: %d policies, default-deny ingress: %s, egress: %s, pods isolated for ingress: %d/%d, egress: %d/%d",
+			namespace, len(policies), yesNo(denyIngress), yesNo(denyEgress), ingressCovered, pods, egressCovered, pods))
+		if pods > 0 && ingressCovered < pods {
+			unrestricted = append(unrestricted, fmt.Sprintf("  %s (%d of %d pods accept ingress from anywhere)", namespace, pods-ingressCovered, pods))
+		}
+	}
+
+	var report strings.Builder
+	report.WriteString("=== NetworkPolicy Reachability ===\n")
+	if !netpolCollected {
+		report.WriteString("\nWarning: networkpolicies were not collected, so every pod is reported as unrestricted\n")
+	}
+	writeReportSection(&report, "Namespaces lacking ingress restrictions", unrestricted)
+	writeReportSection(&report, "Namespaces", summary)
+	writeReportSection(&report, "Pods no policy isolates for ingress", uncoveredPods)
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write NetworkPolicy report %s: %w", path, err)
+	}
+
+	fmt.Printf("NetworkPolicy report: %s (%d of %d namespaces lacking ingress restrictions)\n", path, len(unrestricted), len(names))
+	return nil
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// networkPolicy builds a NetworkPolicy with the given spec
+func networkPolicy(namespace, name string, spec map[string]interface{}) unstructured.Unstructured {
+	policy := unstructured.Unstructured{Object: map[string]interface{}{"kind": "NetworkPolicy", "spec": spec}}
+	policy.SetNamespace(namespace)
+	policy.SetName(name)
+	return policy
+}
+
+// labeledPod builds a Pod in the given phase
+func labeledPod(namespace, name, phase string, podLabels map[string]string) unstructured.Unstructured {
+	pod := unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":   "Pod",
+		"status": map[string]interface{}{"phase": phase},
+	}}
+	pod.SetNamespace(namespace)
+	pod.SetName(name)
+	pod.SetLabels(podLabels)
+	return pod
+}
+
+func TestNetworkPolicyInfo(t *testing.T) {
+	allowFrom := []interface{}{map[string]interface{}{"from": []interface{}{map[string]interface{}{"podSelector": map[string]interface{}{}}}}}
+	web := labels.Set{"app": "web"}
+
+	tests := []struct {
+		name        string
+		spec        map[string]interface{}
+		selects     bool
+		ingress     bool
+		egress      bool
+		denyIngress bool
+		denyEgress  bool
+	}{
+		{
+			name:    "default deny without policyTypes",
+			spec:    map[string]interface{}{"podSelector": map[string]interface{}{}},
+			selects: true, ingress: true, denyIngress: true,
+		},
+		{
+			name:    "egress rules imply egress",
+			spec:    map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}, "ingress": allowFrom, "egress": allowFrom},
+			selects: true, ingress: true, egress: true,
+		},
+		{
+			name:    "explicit egress deny",
+			spec:    map[string]interface{}{"podSelector": map[string]interface{}{}, "policyTypes": []interface{}{"Egress"}},
+			selects: true, egress: true, denyEgress: true,
+		},
+		{
+			name:    "selector not matching",
+			spec:    map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "db"}}, "ingress": allowFrom},
+			ingress: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := networkPolicy("shop", "policy", tt.spec)
+			info := networkPolicyInfo(&policy)
+			if got := info.selector.Matches(web); got != tt.selects {
+				t.Errorf("selector matches app=web = %v, want %v", got, tt.selects)
+			}
+			if info.ingress != tt.ingress || info.egress != tt.egress || info.denyIngress != tt.denyIngress || info.denyEgress != tt.denyEgress {
+				t.Errorf("networkPolicyInfo() = ingress %v egress %v denyIngress %v denyEgress %v, want %v %v %v %v",
+					info.ingress, info.egress, info.denyIngress, info.denyEgress, tt.ingress, tt.egress, tt.denyIngress, tt.denyEgress)
+			}
+		})
+	}
+}
+
+func TestWriteNetpolReport(t *testing.T) {
+	defer func(enabled bool, policies []netpolInfo, pods []podInfo, collected bool) {
+		netpolReport, netpolPolicies, netpolPods, netpolCollected = enabled, policies, pods, collected
+	}(netpolReport, netpolPolicies, netpolPods, netpolCollected)
+	netpolReport, netpolPolicies, netpolPods, netpolCollected = true, nil, nil, false
+
+	allowFrom := []interface{}{map[string]interface{}{"from": []interface{}{map[string]interface{}{"podSelector": map[string]interface{}{}}}}}
+	recordNetworkPolicies("networkpolicies", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		networkPolicy("shop", "default-deny", map[string]interface{}{"podSelector": map[string]interface{}{}, "policyTypes": []interface{}{"Ingress", "Egress"}}),
+		networkPolicy("web", "allow-web", map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}, "ingress": allowFrom}),
+	}})
+	recordNetworkPolicies("pods", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		labeledPod("shop", "cart", "Running", map[string]string{"app": "cart"}),
+		labeledPod("web", "web-1", "Running", map[string]string{"app": "web"}),
+		labeledPod("web", "db-1", "Running", map[string]string{"app": "db"}),
+		labeledPod("web", "migrate", "Succeeded", map[string]string{"app": "db"}),
+	}})
+
+	path := filepath.Join(t.TempDir(), netpolReportFile)
+	if err := writeNetpolReport(path); err != nil {
+		t.Fatalf("writeNetpolReport() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `=== NetworkPolicy Reachability ===
+
+Namespaces lacking ingress restrictions (1):
+  web (1 of 2 pods accept ingress from anywhere)
+
+Namespaces (2):
+  shop: 1 policies, default-deny ingress: yes, egress: yes, pods isolated for ingress: 1/1, egress: 1/1
+  web: 1 policies, default-deny ingress: no, egress: no, pods isolated for ingress: 1/2, egress: 0/2
+
+Pods no policy isolates for ingress (1):
+  web/db-1
+`
+	if string(data) != want {
+		t.Errorf("report =\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// apiVersionsNormalized counts objects whose apiVersion was rewritten in the current run
+var apiVersionsNormalized int
+
+// normalizedAPIVersion returns the replacement apiVersion for a deprecated one,
+// taken from the deprecation rules. Only rules that keep the resource name are
+// used: a rule that moves to a different resource (endpoints -> endpointslices)
+// changes the schema, and a rewritten apiVersion alone would not apply.
+func normalizedAPIVersion(groupVersion, resourceName string) string {
+	for _, rule := range getDeprecationRules() {
+		if rule.GroupVersion != groupVersion || rule.Resource != resourceName {
+			continue
+		}
+		if rule.ReplacementGV != "" && rule.ReplacementResource == rule.Resource {
+			return rule.ReplacementGV
+		}
+	}
+	return ""
+}
+
+// normalizeAPIVersions rewrites the apiVersion of objects listed from a
+// deprecated API to its replacement when --normalize-api-versions is set, so
+// the manifests apply on clusters where the old version has been removed
+func normalizeAPIVersions(list *unstructured.UnstructuredList, groupVersion, resourceName string) {
+	if !normalizeVersions {
+		return
+	}
+
+	replacement := normalizedAPIVersion(groupVersion, resourceName)
+	if replacement == "" {
+		return
+	}
+
+	for i := range list.Items {
+		if list.Items[i].GetAPIVersion() == groupVersion {
+			list.Items[i].SetAPIVersion(replacement)
+			apiVersionsNormalized++
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNormalizedAPIVersion(t *testing.T) {
+	tests := []struct {
+		groupVersion string
+		resource     string
+		want         string
+	}{
+		{"batch/v1beta1", "cronjobs", "batch/v1"},
+		{"policy/v1beta1", "poddisruptionbudgets", "policy/v1"},
+		{"v1", "endpoints", ""},
+		{"v1", "componentstatuses", ""},
+		{"apps/v1", "deployments", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupVersion+"/"+tt.resource, func(t *testing.T) {
+			if got := normalizedAPIVersion(tt.groupVersion, tt.resource); got != tt.want {
+				t.Errorf("normalizedAPIVersion(%s, %s) = %q, want %q", tt.groupVersion, tt.resource, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAPIVersions(t *testing.T) {
+	defer func(enabled bool, count int) {
+		normalizeVersions, apiVersionsNormalized = enabled, count
+	}(normalizeVersions, apiVersionsNormalized)
+
+	newList := func() *unstructured.UnstructuredList {
+		return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+			{Object: map[string]interface{}{"apiVersion": "batch/v1beta1", "kind": "CronJob"}},
+			{Object: map[string]interface{}{"apiVersion": "batch/v1", "kind": "CronJob"}},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		want      []string
+		wantCount int
+	}{
+		{name: "disabled", want: []string{"batch/v1beta1", "batch/v1"}},
+		{name: "enabled", enabled: true, want: []string{"batch/v1", "batch/v1"}, wantCount: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalizeVersions, apiVersionsNormalized = tt.enabled, 0
+			list := newList()
+			normalizeAPIVersions(list, "batch/v1beta1", "cronjobs")
+			for i, want := range tt.want {
+				if got := list.Items[i].GetAPIVersion(); got != want {
+					t.Errorf("item %d apiVersion = %s, want %s", i, got, want)
+				}
+			}
+			if apiVersionsNormalized != tt.wantCount {
+				t.Errorf("apiVersionsNormalized = %d, want %d", apiVersionsNormalized, tt.wantCount)
+			}
+		})
+	}
+}
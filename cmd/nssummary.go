@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// namespaceSummaryFile is written next to the collection when --namespace-summary is set
+const namespaceSummaryFile = "namespace-summary.txt"
+
+// namespaceRollup is the per-namespace part of the summary
+type namespaceRollup struct {
+	kinds     map[string]int
+	podPhases map[string]int
+	notReady  []string
+}
+
+var (
+	// nsSummary is set by --namespace-summary
+	nsSummary bool
+
+	// namespaceRollups are the namespaces seen in the current run, keyed by name
+	namespaceRollups map[string]*namespaceRollup
+)
+
+// recordNamespaceSummary counts the collected objects of each namespace by
+// kind, with pod phases and the workloads that are not fully ready
+func recordNamespaceSummary(resourceName string, list *unstructured.UnstructuredList) {
+	if !nsSummary || strings.Contains(resourceName, "/") {
+		return
+	}
+
+	if namespaceRollups == nil {
+		namespaceRollups = make(map[string]*namespaceRollup)
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		namespace := item.GetNamespace()
+		if namespace == "" {
+			continue
+		}
+		rollup, ok := namespaceRollups[namespace]
+		if !ok {
+			rollup = &namespaceRollup{kinds: make(map[string]int), podPhases: make(map[string]int)}
+			namespaceRollups[namespace] = rollup
+		}
+		rollup.kinds[item.GetKind()]++
+
+		if item.GetKind() == "Pod" {
+			phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+			if phase == "" {
+				phase = "Unknown"
+			}
+			rollup.podPhases[phase]++
+			continue
+		}
+		if ready, desired, ok := workloadReadiness(item); ok && ready < desired {
+			rollup.notReady = append(rollup.notReady, fmt.Sprintf("%s/%s (%d/%d ready)", item.GetKind(), item.GetName(), ready, desired))
+		}
+	}
+}
+
+// workloadReadiness returns the ready and desired replicas of a Deployment,
+// StatefulSet or DaemonSet
+func workloadReadiness(item *unstructured.Unstructured) (int64, int64, bool) {
+	switch item.GetKind() {
+	case "Deployment", "StatefulSet":
+		desired, found, _ := unstructured.NestedInt64(item.Object, "spec", "replicas")
+		if !found {
+			desired = 1
+		}
+		ready, _, _ := unstructured.NestedInt64(item.Object, "status", "readyReplicas")
+		return ready, desired, true
+	case "DaemonSet":
+		desired, _, _ := unstructured.NestedInt64(item.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(item.Object, "status", "numberReady")
+		return ready, desired, true
+	}
+	return 0, 0, false
+}
+
+// writeNamespaceSummary writes one block per namespace with its object counts
+// by kind, pod phases and not-ready workloads. Namespaces needing attention
+// (failed or pending pods, not-ready workloads) are listed first.
+func writeNamespaceSummary(path string) error {
+	if !nsSummary {
+		return nil
+	}
+
+	var names []string
+	for namespace := range namespaceRollups {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	var attention []string
+	var details strings.Builder
+	for _, namespace := range names {
+		rollup := namespaceRollups[namespace]
+		sort.Strings(rollup.notReady)
+
+		if rollup.podPhases["Failed"] > 0 || rollup.podPhases["Pending"] > 0 || rollup.podPhases["Unknown"] > 0 || len(rollup.notReady) > 0 {
+			attention = append(attention, fmt.Sprintf("  %s: %d failed, %d pending pods, %d not-ready workloads",
+				namespace, rollup.podPhases["Failed"], rollup.podPhases["Pending"], len(rollup.notReady)))
+		}
+
+		details.WriteString(fmt.Sprintf("\n%s\n", namespace))
+		details.WriteString(fmt.Sprintf("  Objects: %s\n", formatCounts(rollup.kinds)))
+		if len(rollup.podPhases) > 0 {
+			details.WriteString(fmt.Sprintf("  Pods: %s\n", formatCounts(rollup.podPhases)))
+		}
+		for _, workload := range rollup.notReady {
+			details.WriteString(fmt.Sprintf("  Not ready: %s\n", workload))
+		}
+	}
+
+	var report strings.Builder
+	report.WriteString("=== Namespace Summary ===\n")
+	writeReportSection(&report, "Namespaces needing attention", attention)
+	report.WriteString(fmt.Sprintf("\nNamespaces (%d):\n", len(names)))
+	report.WriteString(details.String())
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write namespace summary %s: %w", path, err)
+	}
+
+	fmt.Printf("Namespace summary: %s (%d of %d namespaces needing attention)\n", path, len(attention), len(names))
+	return nil
+}
+
+// formatCounts renders counts as "Deployment=2, Pod=5", sorted by name
+func formatCounts(counts map[string]int) string {
+	var names []string
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// workload builds an object of kind with the given spec and status
+func workload(kind, namespace, name string, spec, status map[string]interface{}) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{"kind": kind, "spec": spec, "status": status}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestWorkloadReadiness(t *testing.T) {
+	tests := []struct {
+		name        string
+		item        unstructured.Unstructured
+		wantReady   int64
+		wantDesired int64
+		wantOK      bool
+	}{
+		{
+			name:      "deployment",
+			item:      workload("Deployment", "shop", "web", map[string]interface{}{"replicas": int64(3)}, map[string]interface{}{"readyReplicas": int64(2)}),
+			wantReady: 2, wantDesired: 3, wantOK: true,
+		},
+		{
+			name:      "statefulset defaults to one replica",
+			item:      workload("StatefulSet", "shop", "db", map[string]interface{}{}, map[string]interface{}{}),
+			wantReady: 0, wantDesired: 1, wantOK: true,
+		},
+		{
+			name:      "daemonset",
+			item:      workload("DaemonSet", "shop", "agent", nil, map[string]interface{}{"desiredNumberScheduled": int64(4), "numberReady": int64(4)}),
+			wantReady: 4, wantDesired: 4, wantOK: true,
+		},
+		{
+			name: "not a workload",
+			item: workload("ConfigMap", "shop", "settings", nil, nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, desired, ok := workloadReadiness(&tt.item)
+			if ready != tt.wantReady || desired != tt.wantDesired || ok != tt.wantOK {
+				t.Errorf("workloadReadiness() = %d, %d, %v, want %d, %d, %v", ready, desired, ok, tt.wantReady, tt.wantDesired, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWriteNamespaceSummary(t *testing.T) {
+	defer func(enabled bool, rollups map[string]*namespaceRollup) {
+		nsSummary, namespaceRollups = enabled, rollups
+	}(nsSummary, namespaceRollups)
+	nsSummary, namespaceRollups = true, nil
+
+	recordNamespaceSummary("deployments", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		workload("Deployment", "shop", "web", map[string]interface{}{"replicas": int64(3)}, map[string]interface{}{"readyReplicas": int64(1)}),
+		workload("Deployment", "tools", "debug", map[string]interface{}{}, map[string]interface{}{"readyReplicas": int64(1)}),
+	}})
+	recordNamespaceSummary("pods", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		workload("Pod", "shop", "web-1", nil, map[string]interface{}{"phase": "Running"}),
+		workload("Pod", "shop", "web-2", nil, map[string]interface{}{"phase": "Pending"}),
+		workload("Pod", "tools", "debug-1", nil, map[string]interface{}{"phase": "Running"}),
+	}})
+	// Subresources and cluster-scoped objects are not counted
+	recordNamespaceSummary("pods/status", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		workload("Pod", "shop", "web-1", nil, map[string]interface{}{"phase": "Running"}),
+	}})
+	recordNamespaceSummary("nodes", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		workload("Node", "", "node-1", nil, nil),
+	}})
+
+	path := filepath.Join(t.TempDir(), namespaceSummaryFile)
+	if err := writeNamespaceSummary(path); err != nil {
+		t.Fatalf("writeNamespaceSummary() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `=== Namespace Summary ===
+
+Namespaces needing attention (1):
+  shop: 0 failed, 1 pending pods, 1 not-ready workloads
+
+Namespaces (2):
+
+shop
+  Objects: Deployment=1, Pod=2
+  Pods: Pending=1, Running=1
+  Not ready: Deployment/web (1/3 ready)
+
+tools
+  Objects: Deployment=1, Pod=1
+  Pods: Running=1
+`
+	if string(data) != want {
+		t.Errorf("summary =\n%s\nwant\n%s", data, want)
+	}
+}
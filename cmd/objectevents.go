@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// coreEventsGVR is the Event resource whose involvedObject links an event to an object
+var coreEventsGVR = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+
+// objectEvents are the event lines of the current run, keyed by kind/namespace/name
+var objectEvents map[string][]objectEvent
+
+// objectEvent is one Event rendered for embedding
+type objectEvent struct {
+	time string
+	line string
+}
+
+// prepareObjectEvents lists the Events once up front when --embed-events is
+// set and indexes them by their involvedObject
+func prepareObjectEvents(dynamic dynamic.Interface) error {
+	objectEvents = nil
+	if !embedEvents {
+		return nil
+	}
+
+	list, err := listResource(dynamic, coreEventsGVR)
+	if err != nil {
+		return fmt.Errorf("failed to list events for --embed-events: %w", err)
+	}
+
+	// Embedded messages are scrubbed like the collected Events; the count is
+	// left to the events resource itself
+	redacted := valuesRedacted
+	redactMatchingValues(list)
+	valuesRedacted = redacted
+
+	objectEvents = make(map[string][]objectEvent)
+	for i := range list.Items {
+		event := list.Items[i].Object
+		kind, _, _ := unstructured.NestedString(event, "involvedObject", "kind")
+		namespace, _, _ := unstructured.NestedString(event, "involvedObject", "namespace")
+		name, _, _ := unstructured.NestedString(event, "involvedObject", "name")
+		if kind == "" || name == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+		objectEvents[key] = append(objectEvents[key], formatObjectEvent(event))
+	}
+
+	for key := range objectEvents {
+		events := objectEvents[key]
+		sort.SliceStable(events, func(i, j int) bool { return events[i].time < events[j].time })
+	}
+
+	if verbose {
+		fmt.Printf("Indexed %d events for %d objects\n", len(list.Items), len(objectEvents))
+	}
+	return nil
+}
+
+// formatObjectEvent renders "2024-05-01T10:00:00Z Warning BackOff (x3): Back-off restarting failed container"
+func formatObjectEvent(event map[string]interface{}) objectEvent {
+	eventTime, _, _ := unstructured.NestedString(event, "lastTimestamp")
+	if eventTime == "" {
+		eventTime, _, _ = unstructured.NestedString(event, "eventTime")
+	}
+	eventType, _, _ := unstructured.NestedString(event, "type")
+	reason, _, _ := unstructured.NestedString(event, "reason")
+	message, _, _ := unstructured.NestedString(event, "message")
+	count, _, _ := unstructured.NestedInt64(event, "count")
+
+	line := fmt.Sprintf("%s %s %s", eventTime, eventType, reason)
+	if count > 1 {
+		line += fmt.Sprintf(" (x%d)", count)
+	}
+	// Messages can span lines; keep each event on one comment line
+	line += ": " + strings.Join(strings.Fields(message), " ")
+
+	return objectEvent{time: eventTime, line: line}
+}
+
+// formatEmbeddedEvents renders the Events of the objects in a list as a YAML
+// comment block to append to the resource file, or "" when there are none
+func formatEmbeddedEvents(list *unstructured.UnstructuredList) string {
+	if len(objectEvents) == 0 {
+		return ""
+	}
+
+	var block strings.Builder
+	for i := range list.Items {
+		item := &list.Items[i]
+		events := objectEvents[fmt.Sprintf("%s/%s/%s", item.GetKind(), item.GetNamespace(), item.GetName())]
+		if len(events) == 0 {
+			continue
+		}
+
+		name := item.GetName()
+		if item.GetNamespace() != "" {
+			name = item.GetNamespace() + "/" + name
+		}
+		block.WriteString(fmt.Sprintf("# Events for %s %s:\n", item.GetKind(), name))
+		for _, event := range events {
+			block.WriteString("#   " + event.line + "\n")
+		}
+	}
+
+	if block.Len() == 0 {
+		return ""
+	}
+	return "\n" + block.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFormatObjectEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event map[string]interface{}
+		want  objectEvent
+	}{
+		{
+			name: "repeated event",
+			event: map[string]interface{}{
+				"lastTimestamp": "2024-05-01T10:00:00Z", "type": "Warning", "reason": "BackOff",
+				"count": int64(3), "message": "Back-off restarting\n  failed container",
+			},
+			want: objectEvent{time: "2024-05-01T10:00:00Z", line: "2024-05-01T10:00:00Z Warning BackOff (x3): Back-off restarting failed container"},
+		},
+		{
+			name: "events.k8s.io style eventTime",
+			event: map[string]interface{}{
+				"eventTime": "2024-05-01T09:00:00.000000Z", "type": "Normal", "reason": "Scheduled", "count": int64(1), "message": "Assigned",
+			},
+			want: objectEvent{time: "2024-05-01T09:00:00.000000Z", line: "2024-05-01T09:00:00.000000Z Normal Scheduled: Assigned"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatObjectEvent(tt.event); got != tt.want {
+				t.Errorf("formatObjectEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedEvents(t *testing.T) {
+	defer func(embed bool, events map[string][]objectEvent, chunk int64, rv string) {
+		embedEvents, objectEvents, chunkSize, snapshotResourceVersion = embed, events, chunk, rv
+	}(embedEvents, objectEvents, chunkSize, snapshotResourceVersion)
+	embedEvents, chunkSize, snapshotResourceVersion = true, 0, ""
+
+	event := func(name, time, reason string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":           "Event",
+			"involvedObject": map[string]interface{}{"kind": "Pod", "namespace": "shop", "name": name},
+			"lastTimestamp":  time,
+			"type":           "Normal",
+			"reason":         reason,
+			"message":        reason + " message",
+		}}
+	}
+	client := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+		"events": {Items: []unstructured.Unstructured{
+			event("web", "2024-05-01T10:05:00Z", "Started"),
+			event("web", "2024-05-01T10:00:00Z", "Pulled"),
+			event("db", "2024-05-01T10:00:00Z", "Killing"),
+			{Object: map[string]interface{}{"kind": "Event", "reason": "NoObject"}},
+		}},
+	}}
+
+	if err := prepareObjectEvents(client); err != nil {
+		t.Fatalf("prepareObjectEvents() error = %v", err)
+	}
+
+	pods := namespacedList("Pod", [2]string{"shop", "web"}, [2]string{"shop", "quiet"})
+	want := `
+# Events for Pod shop/web:
+#   2024-05-01T10:00:00Z Normal Pulled: Pulled message
+#   2024-05-01T10:05:00Z Normal Started: Started message
+`
+	if got := formatEmbeddedEvents(pods); got != want {
+		t.Errorf("formatEmbeddedEvents() =\n%q\nwant\n%q", got, want)
+	}
+
+	if got := formatEmbeddedEvents(namespacedList("Pod", [2]string{"shop", "quiet"})); got != "" {
+		t.Errorf("formatEmbeddedEvents() without events = %q, want empty", got)
+	}
+}
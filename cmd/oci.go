@@ -0,0 +1,270 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+const (
+	// ociArtifactType identifies a pushed collection in its manifest
+	ociArtifactType = "application/vnd.k8s-resource-collector.collection.v1"
+	// ociLayerMediaType is the gzipped tarball holding the collection
+	ociLayerMediaType = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	// ociPushTimeout bounds the whole push, including the blob upload
+	ociPushTimeout = 30 * time.Minute
+)
+
+var (
+	// outputFiles holds the files this run wrote, for --push
+	outputFiles     = make(map[string]bool)
+	outputFilesLock sync.Mutex
+)
+
+// ociReference is a parsed --push oci://<registry>/<repository>:<tag>
+type ociReference struct {
+	registry   string
+	repository string
+	tag        string
+}
+
+// parseOCIReference parses oci://registry.example.com/team/snapshots:v1. The
+// tag defaults to "latest", like image references.
+func parseOCIReference(value string) (ociReference, error) {
+	rest, found := strings.CutPrefix(value, "oci://")
+	if !found {
+		return ociReference{}, fmt.Errorf("invalid --push %q: expected oci://<registry>/<repository>:<tag>", value)
+	}
+
+	registry, repository, found := strings.Cut(rest, "/")
+	if !found || registry == "" || repository == "" {
+		return ociReference{}, fmt.Errorf("invalid --push %q: expected oci://<registry>/<repository>:<tag>", value)
+	}
+
+	ref := ociReference{registry: registry, repository: repository, tag: "latest"}
+	if colon := strings.LastIndex(repository, ":"); colon >= 0 {
+		ref.repository, ref.tag = repository[:colon], repository[colon+1:]
+		if ref.repository == "" || ref.tag == "" {
+			return ociReference{}, fmt.Errorf("invalid --push %q: expected oci://<registry>/<repository>:<tag>", value)
+		}
+	}
+	if ref.repository != strings.ToLower(ref.repository) {
+		return ociReference{}, fmt.Errorf("invalid --push %q: repository names must be lowercase", value)
+	}
+	return ref, nil
+}
+
+func (r ociReference) String() string {
+	return r.registry + "/" + r.repository + ":" + r.tag
+}
+
+// openRepository opens the repository with the credentials of the Docker config
+// ($DOCKER_CONFIG/config.json or ~/.docker/config.json), including credential
+// helpers, as written by docker login or oras login. Registries on localhost
+// are reached over plain HTTP, like a local test registry.
+func (r ociReference) openRepository() (*remote.Repository, error) {
+	host := r.registry
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	repo, err := remote.NewRepository(host + "/" + r.repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --push reference %s: %w", r, err)
+	}
+	name := host
+	if h, _, found := strings.Cut(host, ":"); found {
+		name = h
+	}
+	repo.PlainHTTP = name == "localhost" || name == "127.0.0.1"
+
+	store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry credentials: %w", err)
+	}
+	repo.Client = &auth.Client{
+		Client:     retry.DefaultClient,
+		Cache:      auth.NewCache(),
+		Credential: credentials.Credential(store),
+	}
+	return repo, nil
+}
+
+// pushCollection packages files as a gzipped tarball, named relative to baseDir, and
+// pushes it as a single-layer OCI artifact to the --push reference
+func pushCollection(baseDir string, files []string) error {
+	if pushRef == "" {
+		return nil
+	}
+	ref, err := parseOCIReference(pushRef)
+	if err != nil {
+		return err
+	}
+	repo, err := ref.openRepository()
+	if err != nil {
+		return err
+	}
+
+	// Never publish the anonymization mapping
+	var publishable []string
+	for _, file := range files {
+		if filepath.Base(file) != anonymizeMappingFile {
+			publishable = append(publishable, file)
+		}
+	}
+	files = publishable
+
+	tarball, err := os.CreateTemp("", "collection-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary archive: %w", err)
+	}
+	defer os.Remove(tarball.Name())
+	defer tarball.Close()
+
+	digester := digest.Canonical.Digester()
+	if err := writeTarGz(io.MultiWriter(tarball, digester.Hash()), baseDir, files); err != nil {
+		return fmt.Errorf("failed to package collection: %w", err)
+	}
+	size, err := tarball.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tarball.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ociPushTimeout)
+	defer cancel()
+
+	layer := ocispec.Descriptor{
+		MediaType:   ociLayerMediaType,
+		Digest:      digester.Digest(),
+		Size:        size,
+		Annotations: map[string]string{ocispec.AnnotationTitle: strings.ReplaceAll(ref.repository, "/", "-") + "-" + ref.tag + ".tar.gz"},
+	}
+	exists, err := repo.Exists(ctx, layer)
+	if err != nil {
+		return fmt.Errorf("failed to push to oci://%s: %w", ref, err)
+	}
+	if !exists {
+		if err := repo.Push(ctx, layer, tarball); err != nil {
+			return fmt.Errorf("failed to push to oci://%s: %w", ref, err)
+		}
+	}
+
+	manifest, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, ociArtifactType, oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to push the manifest to oci://%s: %w", ref, err)
+	}
+	if err := repo.Tag(ctx, manifest, ref.tag); err != nil {
+		return fmt.Errorf("failed to tag oci://%s: %w", ref, err)
+	}
+
+	fmt.Printf("✓ Pushed %d files to oci://%s (%s, %d bytes)\n", len(files), ref, manifest.Digest, size)
+	return nil
+}
+
+// writeTarGz writes files as a gzipped tarball, with entries named relative to baseDir
+func writeTarGz(w io.Writer, baseDir string, files []string) error {
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+
+	for _, path := range files {
+		name, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(archive, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// collectionFiles lists every regular file under dir
+func collectionFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// writeOutputFile writes an output file and records it as written by this run
+func writeOutputFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	recordOutputFile(path)
+	return nil
+}
+
+// recordOutputFile records a file this run wrote, so --push publishes it
+func recordOutputFile(path string) {
+	outputFilesLock.Lock()
+	defer outputFilesLock.Unlock()
+	outputFiles[filepath.Clean(path)] = true
+}
+
+// writtenOutputFiles lists, sorted, the files under dir that this run wrote.
+// Files left by other runs are not published.
+func writtenOutputFiles(dir string) []string {
+	outputFilesLock.Lock()
+	defer outputFilesLock.Unlock()
+
+	var files []string
+	for path := range outputFiles {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
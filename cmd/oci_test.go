@@ -0,0 +1,239 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ociReference
+		wantErr bool
+	}{
+		{value: "oci://quay.io/team/snapshots:v1", want: ociReference{registry: "quay.io", repository: "team/snapshots", tag: "v1"}},
+		{value: "oci://localhost:5000/snapshots", want: ociReference{registry: "localhost:5000", repository: "snapshots", tag: "latest"}},
+		{value: "quay.io/team/snapshots:v1", wantErr: true},
+		{value: "oci://quay.io", wantErr: true},
+		{value: "oci://quay.io/team/snapshots:", wantErr: true},
+		{value: "oci://quay.io/Team/Snapshots:v1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseOCIReference(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOCIReference(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseOCIReference(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// fakeRegistry is an OCI registry that hands out a bearer token for the
+// credentials in the Docker config and keeps pushed content in memory
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.URL.Path == "/token" {
+		for _, scope := range req.URL.Query()["scope"] {
+			if !strings.HasPrefix(scope, "repository:team/snapshots:") {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+		}
+		if username, password, ok := req.BasicAuth(); !ok || username != "robot" || password != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"token": "push-token"}`))
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer push-token" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+req.Host+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const repository = "/v2/team/snapshots"
+	switch {
+	case req.Method == http.MethodHead && strings.HasPrefix(req.URL.Path, repository+"/blobs/"):
+		data, ok := r.blobs[strings.TrimPrefix(req.URL.Path, repository+"/blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	case req.Method == http.MethodPost && req.URL.Path == repository+"/blobs/uploads/":
+		w.Header().Set("Location", repository+"/blobs/uploads/1")
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPut && req.URL.Path == repository+"/blobs/uploads/1":
+		data, _ := io.ReadAll(req.Body)
+		digest := sha256Hex(data)
+		if req.URL.Query().Get("digest") != digest {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest] = data
+		w.WriteHeader(http.StatusCreated)
+	case req.Method == http.MethodPut && strings.HasPrefix(req.URL.Path, repository+"/manifests/"):
+		data, _ := io.ReadAll(req.Body)
+		r.manifests[strings.TrimPrefix(req.URL.Path, repository+"/manifests/")] = data
+		r.manifests[sha256Hex(data)] = data
+		w.Header().Set("Docker-Content-Digest", sha256Hex(data))
+		w.WriteHeader(http.StatusCreated)
+	case (req.Method == http.MethodGet || req.Method == http.MethodHead) && strings.HasPrefix(req.URL.Path, repository+"/manifests/"):
+		data, ok := r.manifests[strings.TrimPrefix(req.URL.Path, repository+"/manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", sha256Hex(data))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if req.Method == http.MethodGet {
+			w.Write(data)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestPushCollection(t *testing.T) {
+	defer func(saved string) { pushRef = saved }(pushRef)
+
+	registry := &fakeRegistry{blobs: make(map[string][]byte), manifests: make(map[string][]byte)}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	registryHost := strings.TrimPrefix(server.URL, "http://")
+	pushRef = "oci://" + registryHost + "/team/snapshots:v1"
+
+	// Credentials as docker login or oras login writes them
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", configDir)
+	config := `{"auths": {"` + registryHost + `": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("robot:s3cret")) + `"}}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		filepath.Join("shop", "configmaps.yaml"): "kind: ConfigMapList\n",
+		anonymizeMappingFile:                     "secret mapping\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := pushCollection(dir, paths); err != nil {
+		t.Fatalf("pushCollection() error = %v", err)
+	}
+
+	var manifest struct {
+		ArtifactType string               `json:"artifactType"`
+		Config       ocispec.Descriptor   `json:"config"`
+		Layers       []ocispec.Descriptor `json:"layers"`
+	}
+	if err := json.Unmarshal(registry.manifests["v1"], &manifest); err != nil {
+		t.Fatalf("manifest v1 not pushed: %v", err)
+	}
+	if manifest.ArtifactType != ociArtifactType || len(manifest.Layers) != 1 {
+		t.Fatalf("manifest = %s", registry.manifests["v1"])
+	}
+	if _, ok := registry.blobs[manifest.Config.Digest.String()]; !ok {
+		t.Errorf("config blob %s not pushed", manifest.Config.Digest)
+	}
+	if title := manifest.Layers[0].Annotations["org.opencontainers.image.title"]; title != "team-snapshots-v1.tar.gz" {
+		t.Errorf("layer title = %q", title)
+	}
+
+	layer, ok := registry.blobs[manifest.Layers[0].Digest.String()]
+	if !ok {
+		t.Fatalf("layer blob %s not pushed", manifest.Layers[0].Digest)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(layer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"shop/configmaps.yaml"}) {
+		t.Errorf("layer entries = %v, want only the collected file", names)
+	}
+}
+
+func TestWrittenOutputFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "v1-secrets.yaml")
+	if err := os.WriteFile(old, []byte("left by an earlier run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written := []string{filepath.Join(dir, "v1-configmaps.yaml"), filepath.Join(dir, "shop", "v1-pods.yaml")}
+	if err := os.MkdirAll(filepath.Join(dir, "shop"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutputFile(written[0], []byte("kind: ConfigMapList\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(written[1], []byte("kind: PodList\n")); err != nil {
+		t.Fatal(err)
+	}
+	// A file written elsewhere, like the --anonymize mapping
+	if err := writeOutputFile(filepath.Join(t.TempDir(), anonymizeMappingFile), []byte("mapping\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{written[1], written[0]}
+	if got := writtenOutputFiles(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("writtenOutputFiles() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	csvGVR          = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}
+	subscriptionGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}
+	deploymentGVR   = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+// operatorFootprint is what an operator's ClusterServiceVersion owns
+type operatorFootprint struct {
+	csvName     string
+	namespace   string
+	crds        map[string]bool
+	apiServices map[string]bool
+	deployments map[string]bool
+}
+
+// activeOperator is the footprint --operator collects, nil unless set
+var activeOperator *operatorFootprint
+
+// resolveOperator finds the ClusterServiceVersion of an operator, given as
+// the CSV name (cert-manager.v1.13.0) or its name without the version
+// (cert-manager), and returns the resources to collect: the owned CRDs and
+// their custom resources, owned APIServices and their aggregated resources,
+// plus the operator's Deployments, Subscription and CSV
+func resolveOperator(dynamicClient dynamic.Interface, name string) (*operatorFootprint, []schema.GroupVersionResource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := dynamicClient.Resource(csvGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list ClusterServiceVersions for --operator (is OLM installed?): %w", err)
+	}
+
+	var matches []*unstructured.Unstructured
+	for i := range list.Items {
+		csv := &list.Items[i]
+		// OLM copies the CSV of an AllNamespaces operator into every namespace
+		if _, copied := csv.GetLabels()["olm.copiedFrom"]; copied {
+			continue
+		}
+		if csv.GetName() == name || strings.HasPrefix(csv.GetName(), name+".") {
+			matches = append(matches, csv)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil, fmt.Errorf("--operator %q: no ClusterServiceVersion found", name)
+	case 1:
+	default:
+		var names []string
+		for _, csv := range matches {
+			names = append(names, csv.GetNamespace()+"/"+csv.GetName())
+		}
+		return nil, nil, fmt.Errorf("--operator %q matches several ClusterServiceVersions (%s); pass the full CSV name", name, strings.Join(names, ", "))
+	}
+
+	csv := matches[0]
+	footprint := &operatorFootprint{
+		csvName:     csv.GetName(),
+		namespace:   csv.GetNamespace(),
+		crds:        make(map[string]bool),
+		apiServices: make(map[string]bool),
+		deployments: make(map[string]bool),
+	}
+	gvrs := []schema.GroupVersionResource{csvGVR, subscriptionGVR, deploymentGVR}
+
+	owned, _, _ := unstructured.NestedSlice(csv.Object, "spec", "customresourcedefinitions", "owned")
+	if len(owned) > 0 {
+		gvrs = append(gvrs, crdGVR)
+	}
+	for _, entry := range owned {
+		crd, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		crdName, _ := crd["name"].(string)
+		version, _ := crd["version"].(string)
+		plural, group, found := strings.Cut(crdName, ".")
+		if !found || version == "" {
+			continue
+		}
+		footprint.crds[crdName] = true
+		gvrs = append(gvrs, schema.GroupVersionResource{Group: group, Version: version, Resource: plural})
+	}
+
+	ownedAPIs, _, _ := unstructured.NestedSlice(csv.Object, "spec", "apiservicedefinitions", "owned")
+	if len(ownedAPIs) > 0 {
+		gvrs = append(gvrs, apiServicesGVR)
+	}
+	for _, entry := range ownedAPIs {
+		api, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _ := api["group"].(string)
+		version, _ := api["version"].(string)
+		plural, _ := api["name"].(string)
+		if group == "" || version == "" || plural == "" {
+			continue
+		}
+		footprint.apiServices[version+"."+group] = true
+		gvrs = append(gvrs, schema.GroupVersionResource{Group: group, Version: version, Resource: plural})
+	}
+
+	deployments, _, _ := unstructured.NestedSlice(csv.Object, "spec", "install", "spec", "deployments")
+	for _, entry := range deployments {
+		if deployment, ok := entry.(map[string]interface{}); ok {
+			if deploymentName, _ := deployment["name"].(string); deploymentName != "" {
+				footprint.deployments[deploymentName] = true
+			}
+		}
+	}
+
+	return footprint, dedupeGVRs(gvrs), nil
+}
+
+func dedupeGVRs(gvrs []schema.GroupVersionResource) []schema.GroupVersionResource {
+	seen := make(map[schema.GroupVersionResource]bool)
+	var unique []schema.GroupVersionResource
+	for _, gvr := range gvrs {
+		if !seen[gvr] {
+			seen[gvr] = true
+			unique = append(unique, gvr)
+		}
+	}
+	return unique
+}
+
+// isOperatorFootprint keeps only the operator's own objects of the shared
+// kinds (CRDs, APIServices, Deployments, Subscriptions, CSVs); every object of
+// its owned custom resources is kept
+func isOperatorFootprint(obj *unstructured.Unstructured) bool {
+	operator := activeOperator
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:
+		return operator.crds[obj.GetName()]
+	case schema.GroupKind{Group: "apiregistration.k8s.io", Kind: "APIService"}:
+		return operator.apiServices[obj.GetName()]
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		return obj.GetNamespace() == operator.namespace && operator.deployments[obj.GetName()]
+	case schema.GroupKind{Group: "operators.coreos.com", Kind: "ClusterServiceVersion"}:
+		return obj.GetNamespace() == operator.namespace && obj.GetName() == operator.csvName
+	case schema.GroupKind{Group: "operators.coreos.com", Kind: "Subscription"}:
+		installed, _, _ := unstructured.NestedString(obj.Object, "status", "installedCSV")
+		current, _, _ := unstructured.NestedString(obj.Object, "status", "currentCSV")
+		return obj.GetNamespace() == operator.namespace && (installed == operator.csvName || current == operator.csvName)
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// clusterServiceVersion builds a CSV owning the given CRDs and deployments
+func clusterServiceVersion(namespace, name string, crds []interface{}, deployments []interface{}) unstructured.Unstructured {
+	csv := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "ClusterServiceVersion",
+		"spec": map[string]interface{}{
+			"customresourcedefinitions": map[string]interface{}{"owned": crds},
+			"install":                   map[string]interface{}{"spec": map[string]interface{}{"deployments": deployments}},
+		},
+	}}
+	csv.SetNamespace(namespace)
+	csv.SetName(name)
+	return csv
+}
+
+func TestResolveOperator(t *testing.T) {
+	certManager := clusterServiceVersion("cert-manager", "cert-manager.v1.13.0",
+		[]interface{}{
+			map[string]interface{}{"name": "certificates.cert-manager.io", "version": "v1", "kind": "Certificate"},
+			map[string]interface{}{"name": "issuers.cert-manager.io", "version": "v1", "kind": "Issuer"},
+		},
+		[]interface{}{map[string]interface{}{"name": "cert-manager"}, map[string]interface{}{"name": "cert-manager-webhook"}},
+	)
+	copied := clusterServiceVersion("shop", "cert-manager.v1.13.0", nil, nil)
+	copied.SetLabels(map[string]string{"olm.copiedFrom": "cert-manager"})
+	extras := clusterServiceVersion("cert-manager", "cert-manager-extras.v1.0.0", nil, nil)
+
+	client := &stubDynamic{lists: map[string]*unstructured.UnstructuredList{
+		"clusterserviceversions": {Items: []unstructured.Unstructured{certManager, copied, extras}},
+	}}
+
+	footprint, gvrs, err := resolveOperator(client, "cert-manager")
+	if err != nil {
+		t.Fatalf("resolveOperator() error = %v", err)
+	}
+	want := &operatorFootprint{
+		csvName:     "cert-manager.v1.13.0",
+		namespace:   "cert-manager",
+		crds:        map[string]bool{"certificates.cert-manager.io": true, "issuers.cert-manager.io": true},
+		apiServices: map[string]bool{},
+		deployments: map[string]bool{"cert-manager": true, "cert-manager-webhook": true},
+	}
+	if !reflect.DeepEqual(footprint, want) {
+		t.Errorf("resolveOperator() footprint = %+v, want %+v", footprint, want)
+	}
+	wantGVRs := []schema.GroupVersionResource{
+		csvGVR, subscriptionGVR, deploymentGVR, crdGVR,
+		{Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+		{Group: "cert-manager.io", Version: "v1", Resource: "issuers"},
+	}
+	if !reflect.DeepEqual(gvrs, wantGVRs) {
+		t.Errorf("resolveOperator() gvrs = %v, want %v", gvrs, wantGVRs)
+	}
+
+	if _, _, err := resolveOperator(client, "postgres-operator"); err == nil || !strings.Contains(err.Error(), "no ClusterServiceVersion found") {
+		t.Errorf("resolveOperator() of an unknown operator error = %v", err)
+	}
+
+	other := clusterServiceVersion("infra", "cert-manager.v1.12.0", nil, nil)
+	client.lists["clusterserviceversions"].Items = append(client.lists["clusterserviceversions"].Items, other)
+	if _, _, err := resolveOperator(client, "cert-manager"); err == nil || !strings.Contains(err.Error(), "matches several ClusterServiceVersions") {
+		t.Errorf("resolveOperator() of an ambiguous name error = %v", err)
+	}
+}
+
+func TestIsOperatorFootprint(t *testing.T) {
+	defer func(saved *operatorFootprint) { activeOperator = saved }(activeOperator)
+	activeOperator = &operatorFootprint{
+		csvName:     "cert-manager.v1.13.0",
+		namespace:   "cert-manager",
+		crds:        map[string]bool{"certificates.cert-manager.io": true},
+		apiServices: map[string]bool{},
+		deployments: map[string]bool{"cert-manager": true},
+	}
+
+	object := func(apiVersion, kind, namespace, name string, status map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": apiVersion, "kind": kind}}
+		if status != nil {
+			obj.Object["status"] = status
+		}
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return obj
+	}
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{"owned CRD", object("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "certificates.cert-manager.io", nil), true},
+		{"other CRD", object("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "widgets.example.com", nil), false},
+		{"operator deployment", object("apps/v1", "Deployment", "cert-manager", "cert-manager", nil), true},
+		{"same name elsewhere", object("apps/v1", "Deployment", "shop", "cert-manager", nil), false},
+		{"own CSV", object("operators.coreos.com/v1alpha1", "ClusterServiceVersion", "cert-manager", "cert-manager.v1.13.0", nil), true},
+		{"subscription installing the CSV", object("operators.coreos.com/v1alpha1", "Subscription", "cert-manager", "cert-manager", map[string]interface{}{"installedCSV": "cert-manager.v1.13.0"}), true},
+		{"other subscription", object("operators.coreos.com/v1alpha1", "Subscription", "cert-manager", "other", map[string]interface{}{"installedCSV": "other.v1"}), false},
+		{"custom resource", object("cert-manager.io/v1", "Certificate", "shop", "web-tls", nil), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOperatorFootprint(tt.obj); got != tt.want {
+				t.Errorf("isOperatorFootprint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sort"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// conventionalKeyOrder is the order kubectl users expect at the top of an
+// object with --preserve-order; other keys follow alphabetically
+var conventionalKeyOrder = []string{"apiVersion", "kind", "metadata", "spec", "status"}
+
+// marshalResourceList marshals a collected list to YAML. sigs.k8s.io/yaml sorts
+// keys alphabetically; with --preserve-order the list and its items use
+// conventionalKeyOrder instead, with the list's items last.
+func marshalResourceList(list *unstructured.UnstructuredList) ([]byte, error) {
+	if !preserveOrder {
+		return yaml.Marshal(list)
+	}
+	return marshalListMap(list.UnstructuredContent())
+}
+
+// marshalListMap is marshalResourceList for lists built as plain maps
+func marshalListMap(list map[string]interface{}) ([]byte, error) {
+	if !preserveOrder {
+		return yaml.Marshal(list)
+	}
+
+	ordered := orderedKeys(list, "items")
+	items, _ := list["items"].([]interface{})
+	orderedItems := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			orderedItems = append(orderedItems, orderedKeys(itemMap, ""))
+		} else {
+			orderedItems = append(orderedItems, item)
+		}
+	}
+	ordered = append(ordered, yamlv2.MapItem{Key: "items", Value: orderedItems})
+
+	return yamlv2.Marshal(ordered)
+}
+
+// orderedKeys returns the top-level keys of object in conventionalKeyOrder
+// followed by the rest alphabetically, leaving out skip. Nested maps keep
+// their alphabetical order.
+func orderedKeys(object map[string]interface{}, skip string) yamlv2.MapSlice {
+	var ordered yamlv2.MapSlice
+	placed := map[string]bool{skip: true}
+	for _, key := range conventionalKeyOrder {
+		if value, ok := object[key]; ok && !placed[key] {
+			ordered = append(ordered, yamlv2.MapItem{Key: key, Value: value})
+			placed[key] = true
+		}
+	}
+
+	var rest []string
+	for key := range object {
+		if !placed[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		ordered = append(ordered, yamlv2.MapItem{Key: key, Value: object[key]})
+	}
+
+	return ordered
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestMarshalResourceList(t *testing.T) {
+	defer func(saved bool) { preserveOrder = saved }(preserveOrder)
+
+	list := &unstructured.UnstructuredList{
+		Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"},
+		Items: []unstructured.Unstructured{{Object: map[string]interface{}{
+			"status":     map[string]interface{}{"phase": "Running"},
+			"spec":       map[string]interface{}{"nodeName": "node-1"},
+			"metadata":   map[string]interface{}{"namespace": "shop", "name": "web"},
+			"kind":       "Pod",
+			"apiVersion": "v1",
+			"data":       "extra",
+		}}},
+	}
+
+	tests := []struct {
+		name     string
+		preserve bool
+		want     string
+	}{
+		{
+			name: "alphabetical by default",
+			want: `apiVersion: v1
+items:
+- apiVersion: v1
+  data: extra
+  kind: Pod
+  metadata:
+    name: web
+    namespace: shop
+  spec:
+    nodeName: node-1
+  status:
+    phase: Running
+kind: List
+`,
+		},
+		{
+			name:     "conventional order, nested maps alphabetical",
+			preserve: true,
+			want: `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: web
+    namespace: shop
+  spec:
+    nodeName: node-1
+  status:
+    phase: Running
+  data: extra
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preserveOrder = tt.preserve
+			data, err := marshalResourceList(list)
+			if err != nil {
+				t.Fatalf("marshalResourceList() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("marshalResourceList() =\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// listPaged lists a resource in pages of --chunk-size items, following the
+// continue token until the server has returned everything. Without
+// --chunk-size it is a single listWithSnapshot call.
+func listPaged(ctx context.Context, client dynamic.ResourceInterface, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if chunkSize <= 0 {
+		return listWithSnapshot(ctx, client, opts)
+	}
+
+	pageOpts := opts
+	pageOpts.Limit = chunkSize
+
+	// The first page carries the snapshot baseline; later pages are pinned by the continue token
+	merged, err := listWithSnapshot(ctx, client, pageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	for page := 2; merged.GetContinue() != ""; page++ {
+		pageOpts.Continue = merged.GetContinue()
+		pageOpts.ResourceVersion = ""
+		pageOpts.ResourceVersionMatch = ""
+
+		next, err := client.List(ctx, pageOpts)
+		if err != nil {
+			// The continue token outlived etcd compaction: start over without paging
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				if verbose {
+					fmt.Printf("  continue token expired after %d items, relisting without --chunk-size\n", len(merged.Items))
+				}
+				return listWithSnapshot(ctx, client, opts)
+			}
+			return nil, err
+		}
+
+		merged.Items = append(merged.Items, next.Items...)
+		merged.SetContinue(next.GetContinue())
+
+		if verbose {
+			fmt.Printf("  page %d, %d items so far\n", page, len(merged.Items))
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// pagedResource serves items in pages of opts.Limit, failing the continue
+// request with expireAt when set, and records the options of every call
+type pagedResource struct {
+	dynamic.ResourceInterface
+	items    int
+	expireAt string
+	failWith error
+	calls    []metav1.ListOptions
+}
+
+func (r *pagedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.calls = append(r.calls, opts)
+	if opts.Continue != "" && opts.Continue == r.expireAt {
+		return nil, r.failWith
+	}
+
+	start := 0
+	if opts.Continue != "" {
+		fmt.Sscanf(opts.Continue, "%d", &start)
+	}
+	end := r.items
+	if opts.Limit > 0 && start+int(opts.Limit) < r.items {
+		end = start + int(opts.Limit)
+	}
+
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}}
+	for i := start; i < end; i++ {
+		item := unstructured.Unstructured{Object: map[string]interface{}{}}
+		item.SetName(fmt.Sprintf("item-%d", i))
+		list.Items = append(list.Items, item)
+	}
+	if end < r.items {
+		list.SetContinue(fmt.Sprintf("%d", end))
+	}
+	return list, nil
+}
+
+func TestListPaged(t *testing.T) {
+	defer func(chunk int64, rv string) {
+		chunkSize, snapshotResourceVersion = chunk, rv
+	}(chunkSize, snapshotResourceVersion)
+	snapshotResourceVersion = ""
+
+	tests := []struct {
+		name      string
+		chunkSize int64
+		resource  *pagedResource
+		wantItems int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "single list without --chunk-size", resource: &pagedResource{items: 5}, wantItems: 5, wantCalls: 1},
+		{name: "follows continue tokens", chunkSize: 2, resource: &pagedResource{items: 5}, wantItems: 5, wantCalls: 3},
+		{name: "exact multiple of the chunk size", chunkSize: 5, resource: &pagedResource{items: 5}, wantItems: 5, wantCalls: 1},
+		{
+			name:      "expired continue token relists without paging",
+			chunkSize: 2,
+			resource:  &pagedResource{items: 5, expireAt: "4", failWith: apierrors.NewResourceExpired("continue expired")},
+			wantItems: 5,
+			wantCalls: 4,
+		},
+		{
+			name:      "other page errors fail the list",
+			chunkSize: 2,
+			resource:  &pagedResource{items: 5, expireAt: "2", failWith: errors.New("connection reset")},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunkSize = tt.chunkSize
+			list, err := listPaged(context.Background(), tt.resource, metav1.ListOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listPaged() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tt.resource.calls) != tt.wantCalls {
+				t.Errorf("List calls = %d, want %d", len(tt.resource.calls), tt.wantCalls)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(list.Items) != tt.wantItems {
+				t.Errorf("items = %d, want %d", len(list.Items), tt.wantItems)
+			}
+			if last := tt.resource.calls[len(tt.resource.calls)-1]; tt.resource.expireAt != "" && last.Limit != 0 {
+				t.Errorf("relist after an expired token used limit %d, want no paging", last.Limit)
+			}
+			if list.GetContinue() != "" {
+				t.Errorf("merged list keeps continue token %q", list.GetContinue())
+			}
+		})
+	}
+}
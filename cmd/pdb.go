@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// pdbReportFile is written next to the collection when --pdb-report is set
+const pdbReportFile = "pdb-report.txt"
+
+// pdbInfo is the part of a PodDisruptionBudget the coverage report needs
+type pdbInfo struct {
+	namespace string
+	name      string
+	selector  labels.Selector
+}
+
+// workloadInfo is a Deployment or StatefulSet with its pod template labels
+type workloadInfo struct {
+	kind      string
+	namespace string
+	name      string
+	replicas  int64
+	podLabels labels.Set
+}
+
+var (
+	// pdbReport is set by --pdb-report
+	pdbReport bool
+
+	// pdbBudgets and pdbWorkloads are collected in the current run
+	pdbBudgets   []pdbInfo
+	pdbWorkloads []workloadInfo
+	// pdbCollected notes whether poddisruptionbudgets were listed at all
+	pdbCollected bool
+)
+
+// recordPDBCoverage keeps the collected PodDisruptionBudgets, Deployments and
+// StatefulSets for the PDB coverage report
+func recordPDBCoverage(resourceName string, list *unstructured.UnstructuredList) {
+	if !pdbReport {
+		return
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		switch {
+		case resourceName == "poddisruptionbudgets" && item.GetKind() == "PodDisruptionBudget":
+			pdbBudgets = append(pdbBudgets, pdbInfo{namespace: item.GetNamespace(), name: item.GetName(), selector: pdbSelector(item)})
+		case (resourceName == "deployments" && item.GetKind() == "Deployment") ||
+			(resourceName == "statefulsets" && item.GetKind() == "StatefulSet"):
+			replicas, found, _ := unstructured.NestedInt64(item.Object, "spec", "replicas")
+			if !found {
+				replicas = 1
+			}
+			podLabels, _, _ := unstructured.NestedStringMap(item.Object, "spec", "template", "metadata", "labels")
+			pdbWorkloads = append(pdbWorkloads, workloadInfo{
+				kind:      item.GetKind(),
+				namespace: item.GetNamespace(),
+				name:      item.GetName(),
+				replicas:  replicas,
+				podLabels: labels.Set(podLabels),
+			})
+		}
+	}
+	if resourceName == "poddisruptionbudgets" {
+		pdbCollected = true
+	}
+}
+
+// pdbSelector converts .spec.selector to a label selector. As in policy/v1,
+// an empty selector matches every pod in the namespace and a missing one none.
+func pdbSelector(pdb *unstructured.Unstructured) labels.Selector {
+	raw, found, _ := unstructured.NestedMap(pdb.Object, "spec", "selector")
+	if !found {
+		return labels.Nothing()
+	}
+
+	var selector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &selector); err != nil {
+		return labels.Nothing()
+	}
+	converted, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return converted
+}
+
+// writePDBReport lists the Deployments and StatefulSets no PodDisruptionBudget
+// selects, plus the budgets that select no collected workload
+func writePDBReport(path string) error {
+	if !pdbReport {
+		return nil
+	}
+
+	sort.Slice(pdbWorkloads, func(i, j int) bool {
+		a, b := pdbWorkloads[i], pdbWorkloads[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.name < b.name
+	})
+
+	usedBudgets := make(map[string]bool)
+	var covered, uncovered []string
+	for _, workload := range pdbWorkloads {
+		var matching []string
+		for _, budget := range pdbBudgets {
+			if budget.namespace == workload.namespace && budget.selector.Matches(workload.podLabels) {
+				matching = append(matching, budget.name)
+				usedBudgets[budget.namespace+"/"+budget.name] = true
+			}
+		}
+
+		key := fmt.Sprintf("  %s %s/%s (%d replicas)", workload.kind, workload.namespace, workload.name, workload.replicas)
+		if len(matching) == 0 {
+			uncovered = append(uncovered, key)
+		} else {
+			covered = append(covered, fmt.Sprintf("%s <- %s", key, strings.Join(matching, ", ")))
+		}
+	}
+
+	var unused []string
+	for _, budget := range pdbBudgets {
+		if !usedBudgets[budget.namespace+"/"+budget.name] {
+			unused = append(unused, fmt.Sprintf("  %s/%s", budget.namespace, budget.name))
+		}
+	}
+	sort.Strings(unused)
+
+	var report strings.Builder
+	report.WriteString("=== PodDisruptionBudget Coverage ===\n")
+	if !pdbCollected {
+		report.WriteString("\nWarning: poddisruptionbudgets were not collected, so every workload is reported without a PDB\n")
+	}
+	writeReportSection(&report, "Workloads without a PDB", uncovered)
+	writeReportSection(&report, "Workloads covered by a PDB", covered)
+	writeReportSection(&report, "PDBs selecting no Deployment or StatefulSet", unused)
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write PDB report %s: %w", path, err)
+	}
+
+	fmt.Printf("PDB report: %s (%d of %d workloads without a PDB)\n", path, len(uncovered), len(pdbWorkloads))
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestPDBSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     map[string]interface{}
+		podLabel labels.Set
+		want     bool
+	}{
+		{"matchLabels", map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}}, labels.Set{"app": "web"}, true},
+		{"matchLabels mismatch", map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}}, labels.Set{"app": "db"}, false},
+		{"matchExpressions", map[string]interface{}{"selector": map[string]interface{}{"matchExpressions": []interface{}{
+			map[string]interface{}{"key": "tier", "operator": "In", "values": []interface{}{"frontend", "edge"}},
+		}}}, labels.Set{"tier": "edge"}, true},
+		{"empty selector matches every pod", map[string]interface{}{"selector": map[string]interface{}{}}, labels.Set{"app": "db"}, true},
+		{"missing selector matches none", map[string]interface{}{}, labels.Set{"app": "web"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdb := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "PodDisruptionBudget", "spec": tt.spec}}
+			if got := pdbSelector(pdb).Matches(tt.podLabel); got != tt.want {
+				t.Errorf("pdbSelector().Matches(%v) = %v, want %v", tt.podLabel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePDBReport(t *testing.T) {
+	defer func(enabled bool, budgets []pdbInfo, workloads []workloadInfo, collected bool) {
+		pdbReport, pdbBudgets, pdbWorkloads, pdbCollected = enabled, budgets, workloads, collected
+	}(pdbReport, pdbBudgets, pdbWorkloads, pdbCollected)
+	pdbReport, pdbBudgets, pdbWorkloads, pdbCollected = true, nil, nil, false
+
+	workload := func(kind, namespace, name string, replicas interface{}, app string) unstructured.Unstructured {
+		spec := map[string]interface{}{"template": map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": app}}}}
+		if replicas != nil {
+			spec["replicas"] = replicas
+		}
+		item := unstructured.Unstructured{Object: map[string]interface{}{"kind": kind, "spec": spec}}
+		item.SetNamespace(namespace)
+		item.SetName(name)
+		return item
+	}
+	budget := func(namespace, name, app string) unstructured.Unstructured {
+		item := unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": "PodDisruptionBudget",
+			"spec": map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": app}}},
+		}}
+		item.SetNamespace(namespace)
+		item.SetName(name)
+		return item
+	}
+
+	recordPDBCoverage("deployments", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		workload("Deployment", "shop", "web", int64(3), "web"),
+		workload("Deployment", "shop", "worker", nil, "worker"),
+	}})
+	recordPDBCoverage("statefulsets", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		workload("StatefulSet", "shop", "db", int64(2), "db"),
+	}})
+	recordPDBCoverage("poddisruptionbudgets", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		budget("shop", "web-pdb", "web"),
+		budget("shop", "db-pdb", "db"),
+		budget("other", "web-pdb", "web"),
+	}})
+
+	path := filepath.Join(t.TempDir(), pdbReportFile)
+	if err := writePDBReport(path); err != nil {
+		t.Fatalf("writePDBReport() error = %v", err)
+	}
+	report, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `=== PodDisruptionBudget Coverage ===
+
+Workloads without a PDB (1):
+  Deployment shop/worker (1 replicas)
+
+Workloads covered by a PDB (2):
+  Deployment shop/web (3 replicas) <- web-pdb
+  StatefulSet shop/db (2 replicas) <- db-pdb
+
+PDBs selecting no Deployment or StatefulSet (1):
+  other/web-pdb
+`
+	if string(report) != want {
+		t.Errorf("report =\n%s\nwant\n%s", report, want)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	presetName  string
+	listPresets bool
+)
+
+// presetFlag is one flag value a preset sets
+type presetFlag struct {
+	name  string
+	value string
+}
+
+// collectionPreset is a named set of flag values for a common kind of collection
+type collectionPreset struct {
+	name        string
+	description string
+	flags       []presetFlag
+}
+
+// collectionPresets are the presets --preset accepts, in the order --list-presets prints them
+var collectionPresets = []collectionPreset{
+	{
+		name:        "backup",
+		description: "Configuration that can be re-applied: no controller-owned objects, runtime-only resources or server-set metadata",
+		flags: []presetFlag{
+			{"exclude-owned", "true"},
+			{"strip-metadata", "true"},
+			{"exclude-resources", "events,endpoints,endpointslices,leases,componentstatuses"},
+		},
+	},
+	{
+		name:        "lean",
+		description: "Skip high-churn resources that are rarely needed when troubleshooting",
+		flags: []presetFlag{
+			{"exclude-resources", "events,endpoints,endpointslices,leases"},
+		},
+	},
+	{
+		name:        "share",
+		description: "Safe to hand to someone outside the team: the --secure profile with anonymized names",
+		flags: []presetFlag{
+			{"secure", "true"},
+			{"anonymize", "true"},
+		},
+	},
+}
+
+// findPreset returns the preset with the given name
+func findPreset(name string) (collectionPreset, bool) {
+	for _, preset := range collectionPresets {
+		if preset.name == name {
+			return preset, true
+		}
+	}
+	return collectionPreset{}, false
+}
+
+// applyPreset sets the flags of a preset that were not given on the command
+// line or through a KRC_* environment variable, so both win over the preset.
+// Must be called after applyEnvOverrides.
+func applyPreset(fs *flag.FlagSet, name string) error {
+	if name == "" {
+		return nil
+	}
+	preset, ok := findPreset(name)
+	if !ok {
+		return fmt.Errorf("unknown --preset %q; see --list-presets", name)
+	}
+
+	alreadySet := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		alreadySet[f.Name] = true
+	})
+
+	for _, pf := range preset.flags {
+		if alreadySet[pf.name] {
+			continue
+		}
+		if err := fs.Set(pf.name, pf.value); err != nil {
+			return fmt.Errorf("preset %s: invalid value %q for --%s: %w", preset.name, pf.value, pf.name, err)
+		}
+	}
+	return nil
+}
+
+// formatPresets renders the --list-presets table
+func formatPresets() string {
+	var report strings.Builder
+	table := tabwriter.NewWriter(&report, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PRESET\tFLAGS\tDESCRIPTION")
+	for _, preset := range collectionPresets {
+		var flags []string
+		for _, pf := range preset.flags {
+			if pf.value == "true" {
+				flags = append(flags, "--"+pf.name)
+			} else {
+				flags = append(flags, "--"+pf.name+" "+pf.value)
+			}
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", preset.name, strings.Join(flags, " "), preset.description)
+	}
+	table.Flush()
+	return report.String()
+}
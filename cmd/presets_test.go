@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestApplyPreset(t *testing.T) {
+	tests := []struct {
+		name         string
+		preset       string
+		args         []string
+		env          map[string]string
+		wantExclude  string
+		wantOwned    bool
+		wantStripped bool
+		wantErr      bool
+	}{
+		{name: "no preset"},
+		{
+			name:        "preset sets its flags",
+			preset:      "backup",
+			wantExclude: "events,endpoints,endpointslices,leases,componentstatuses", wantOwned: true, wantStripped: true,
+		},
+		{
+			name:        "command line and environment win over the preset",
+			preset:      "backup",
+			args:        []string{"--exclude-resources", "events"},
+			env:         map[string]string{"KRC_STRIP_METADATA": "false"},
+			wantExclude: "events", wantOwned: true,
+		},
+		{name: "unknown preset", preset: "everything", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			exclude := fs.String("exclude-resources", "", "")
+			owned := fs.Bool("exclude-owned", false, "")
+			stripped := fs.Bool("strip-metadata", false, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := applyEnvOverrides(fs); err != nil {
+				t.Fatal(err)
+			}
+
+			err := applyPreset(fs, tt.preset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyPreset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *exclude != tt.wantExclude || *owned != tt.wantOwned || *stripped != tt.wantStripped {
+				t.Errorf("exclude-resources=%q exclude-owned=%v strip-metadata=%v, want %q %v %v",
+					*exclude, *owned, *stripped, tt.wantExclude, tt.wantOwned, tt.wantStripped)
+			}
+		})
+	}
+}
+
+func TestFormatPresets(t *testing.T) {
+	report := formatPresets()
+	for _, want := range []string{"PRESET", "backup", "--exclude-owned --strip-metadata", "share", "--secure --anonymize"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("formatPresets() is missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+// protobufExtension replaces .yaml for resources written with --format protobuf
+const protobufExtension = ".pb"
+
+// protobufAccept asks for protobuf; API servers answer JSON for types without it
+const protobufAccept = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+
+// protobufDynamicClient lists built-in resources as protobuf with --format
+// protobuf and hands them on as unstructured lists, so paging, filters and
+// transforms work unchanged. Custom resources, which have no protobuf
+// encoding, and every other call go through the wrapped dynamic client.
+type protobufDynamicClient struct {
+	dynamic.Interface
+	config    *rest.Config
+	discovery discovery.DiscoveryInterface
+
+	mu      sync.Mutex
+	clients map[schema.GroupVersion]*rest.RESTClient
+	kinds   map[schema.GroupVersion]map[string]string // resource -> kind, built-in types only
+}
+
+func newProtobufDynamicClient(config *rest.Config, dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface) *protobufDynamicClient {
+	return &protobufDynamicClient{
+		Interface: dynamicClient,
+		config:    config,
+		discovery: discoveryClient,
+		clients:   make(map[schema.GroupVersion]*rest.RESTClient),
+		kinds:     make(map[schema.GroupVersion]map[string]string),
+	}
+}
+
+// Resource returns a protobuf-listing client for built-in resources
+func (c *protobufDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	parent := c.Interface.Resource(gvr)
+	kind, client := c.protobufClientFor(gvr)
+	if client == nil {
+		return parent
+	}
+	return &protobufResource{ResourceInterface: parent, parent: parent, client: client, gvr: gvr, kind: kind}
+}
+
+// protobufClientFor resolves a resource's kind from discovery and returns a
+// protobuf REST client for its group version, or nil when the client-go
+// scheme does not know the type
+func (c *protobufDynamicClient) protobufClientFor(gvr schema.GroupVersionResource) (string, *rest.RESTClient) {
+	if strings.Contains(gvr.Resource, "/") {
+		return "", nil
+	}
+	gv := gvr.GroupVersion()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kinds, ok := c.kinds[gv]
+	if !ok {
+		kinds = make(map[string]string)
+		if resources, err := c.discovery.ServerResourcesForGroupVersion(gv.String()); err == nil {
+			for _, resource := range resources.APIResources {
+				if scheme.Scheme.Recognizes(gv.WithKind(resource.Kind + "List")) {
+					kinds[resource.Name] = resource.Kind
+				}
+			}
+		}
+		c.kinds[gv] = kinds
+	}
+	kind, ok := kinds[gvr.Resource]
+	if !ok {
+		return "", nil
+	}
+
+	client, ok := c.clients[gv]
+	if !ok {
+		config := rest.CopyConfig(c.config)
+		config.GroupVersion = &gv
+		config.APIPath = "/apis"
+		if gv.Group == "" {
+			config.APIPath = "/api"
+		}
+		config.ContentType = runtime.ContentTypeProtobuf
+		config.AcceptContentTypes = protobufAccept
+		config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+
+		var err error
+		if client, err = rest.RESTClientFor(config); err != nil {
+			if verbose {
+				fmt.Printf("  %s: protobuf client unavailable, using JSON: %v\n", gv, err)
+			}
+			return "", nil
+		}
+		c.clients[gv] = client
+	}
+	return kind, client
+}
+
+// protobufResource overrides List; every other call uses the dynamic client
+type protobufResource struct {
+	dynamic.ResourceInterface
+	parent    dynamic.NamespaceableResourceInterface
+	client    *rest.RESTClient
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+}
+
+func (r *protobufResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &protobufResource{
+		ResourceInterface: r.parent.Namespace(namespace),
+		parent:            r.parent,
+		client:            r.client,
+		gvr:               r.gvr,
+		kind:              r.kind,
+		namespace:         namespace,
+	}
+}
+
+func (r *protobufResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	gvk := r.gvr.GroupVersion().WithKind(r.kind + "List")
+	typed, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	request := r.client.Get().Resource(r.gvr.Resource).VersionedParams(&opts, scheme.ParameterCodec)
+	if r.namespace != "" {
+		request = request.Namespace(r.namespace)
+	}
+	if err := request.Do(ctx).Into(typed); err != nil {
+		return nil, err
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", gvk.Kind, err)
+	}
+
+	// Items decoded from a typed list carry no apiVersion and kind
+	items, _ := content["items"].([]interface{})
+	delete(content, "items")
+	list := &unstructured.UnstructuredList{Object: content}
+	list.SetAPIVersion(gvk.GroupVersion().String())
+	list.SetKind(gvk.Kind)
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		object["apiVersion"] = gvk.GroupVersion().String()
+		object["kind"] = r.kind
+		list.Items = append(list.Items, unstructured.Unstructured{Object: object})
+	}
+	return list, nil
+}
+
+// encodeProtobufList encodes a list of a built-in type in the Kubernetes
+// protobuf envelope. ok is false for types without a protobuf encoding, and
+// for lists that no longer fit the typed schema after transforms (e.g.
+// --redact-secrets replacing base64 data); those are written as YAML.
+func encodeProtobufList(list *unstructured.UnstructuredList, groupVersion string) ([]byte, bool) {
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return nil, false
+	}
+	kind := list.GetKind()
+	if kind == "" && len(list.Items) > 0 {
+		kind = list.Items[0].GetKind() + "List"
+	}
+	gvk := gv.WithKind(kind)
+
+	typed, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, false
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.UnstructuredContent(), typed); err != nil {
+		if verbose {
+			fmt.Printf("  %s: does not convert to %s, writing YAML: %v\n", groupVersion, kind, err)
+		}
+		return nil, false
+	}
+	typed.GetObjectKind().SetGroupVersionKind(gvk)
+
+	var buf bytes.Buffer
+	if err := protobuf.NewSerializer(scheme.Scheme, scheme.Scheme).Encode(typed, &buf); err != nil {
+		if verbose {
+			fmt.Printf("  %s: protobuf encoding failed, writing YAML: %v\n", kind, err)
+		}
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// runDecodeMode converts the .pb files of a --format protobuf collection (a
+// directory, searched recursively, or one file) to YAML files next to them
+func runDecodeMode() error {
+	startTime := time.Now()
+
+	info, err := os.Stat(decodePath)
+	if err != nil {
+		return fmt.Errorf("decode path not found: %s", decodePath)
+	}
+
+	var files []string
+	if info.IsDir() {
+		files, err = collectionFiles(decodePath)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", decodePath, err)
+		}
+	} else {
+		files = []string{decodePath}
+	}
+
+	decoded := 0
+	for _, file := range files {
+		if !strings.HasSuffix(file, protobufExtension) {
+			continue
+		}
+		target, err := decodeProtobufFile(file)
+		if err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Printf("  %s -> %s\n", file, target)
+		}
+		decoded++
+	}
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== Decode Summary ===\n")
+	fmt.Printf("Decoded: %d files\n", decoded)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("======================\n")
+
+	return nil
+}
+
+// decodeProtobufFile writes the YAML form of a .pb resource file, with the
+// header a YAML collection would have had, and returns its path
+func decodeProtobufFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	typed, gvk, err := protobuf.NewSerializer(scheme.Scheme, scheme.Scheme).Decode(data, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert %s: %w", path, err)
+	}
+
+	content["apiVersion"] = gvk.GroupVersion().String()
+	content["kind"] = gvk.Kind
+	items, _ := content["items"].([]interface{})
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			object["apiVersion"] = gvk.GroupVersion().String()
+			object["kind"] = strings.TrimSuffix(gvk.Kind, "List")
+		}
+	}
+
+	yamlData, err := yaml.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s to YAML: %w", path, err)
+	}
+
+	// Files are named <group>-<version>-<resource>.pb, or <resource>.pb with --output-per-group
+	groupVersion := gvk.GroupVersion().String()
+	resourceName := strings.TrimSuffix(filepath.Base(path), protobufExtension)
+	resourceName = strings.TrimPrefix(resourceName, strings.ReplaceAll(groupVersion, "/", "-")+"-")
+
+	target := strings.TrimSuffix(path, protobufExtension) + ".yaml"
+	if err := writeFileAtomic(target, []byte(formatHeader(resourceName, groupVersion)+string(yamlData))); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return target, nil
+}
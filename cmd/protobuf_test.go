@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestProtobufRoundTrip(t *testing.T) {
+	configMap := func(data interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "app", "namespace": "shop"},
+			"data":       data,
+		}}
+	}
+
+	tests := []struct {
+		name         string
+		groupVersion string
+		items        []unstructured.Unstructured
+		wantEncoded  bool
+		wantYAML     []string
+	}{
+		{
+			name:         "built-in type",
+			groupVersion: "v1",
+			items:        []unstructured.Unstructured{configMap(map[string]interface{}{"key": "value"})},
+			wantEncoded:  true,
+			wantYAML:     []string{"# Resource: configmaps\n", "# Group Version: v1\n", "kind: ConfigMap\n", "name: app", "key: value"},
+		},
+		{
+			name:         "custom resource",
+			groupVersion: "example.com/v1",
+			items: []unstructured.Unstructured{{Object: map[string]interface{}{
+				"apiVersion": "example.com/v1", "kind": "Widget", "metadata": map[string]interface{}{"name": "w"},
+			}}},
+		},
+		{
+			name:         "items that no longer fit the typed schema",
+			groupVersion: "v1",
+			items:        []unstructured.Unstructured{configMap("not a map")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &unstructured.UnstructuredList{Items: tt.items}
+			data, ok := encodeProtobufList(list, tt.groupVersion)
+			if ok != tt.wantEncoded {
+				t.Fatalf("encodeProtobufList() ok = %v, want %v", ok, tt.wantEncoded)
+			}
+			if !ok {
+				return
+			}
+
+			path := filepath.Join(t.TempDir(), "v1-configmaps.pb")
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			target, err := decodeProtobufFile(path)
+			if err != nil {
+				t.Fatalf("decodeProtobufFile() error = %v", err)
+			}
+			if want := strings.TrimSuffix(path, ".pb") + ".yaml"; target != want {
+				t.Errorf("decodeProtobufFile() = %s, want %s", target, want)
+			}
+			decoded, _ := os.ReadFile(target)
+			for _, want := range tt.wantYAML {
+				if !strings.Contains(string(decoded), want) {
+					t.Errorf("decoded YAML missing %q:\n%s", want, decoded)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeProtobufFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v1-configmaps.pb")
+	if err := os.WriteFile(path, []byte("apiVersion: v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeProtobufFile(path); err == nil {
+		t.Error("decodeProtobufFile() of a YAML file succeeded")
+	}
+}
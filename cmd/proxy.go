@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+	"k8s.io/client-go/rest"
+)
+
+// validateProxyURL checks that --proxy-url is an absolute http(s) or socks5 URL
+func validateProxyURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid --proxy-url %q: %w", raw, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid --proxy-url %q: scheme must be http, https or socks5", raw)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("invalid --proxy-url %q: missing host", raw)
+	}
+
+	return nil
+}
+
+// applyProxy routes API server traffic through --proxy-url. Hosts matched by
+// NO_PROXY still connect directly, the same as they would with HTTPS_PROXY.
+// Without --proxy-url the kubeconfig proxy-url, or else the environment, is used.
+func applyProxy(config *rest.Config) error {
+	if proxyURL == "" {
+		if config.Proxy == nil {
+			config.Proxy = http.ProxyFromEnvironment
+		}
+		return nil
+	}
+
+	if err := validateProxyURL(proxyURL); err != nil {
+		return err
+	}
+
+	proxyConfig := &httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    getEnvAny("NO_PROXY", "no_proxy"),
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+	config.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	return nil
+}
+
+// getEnvAny returns the value of the first non-empty environment variable
+func getEnvAny(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
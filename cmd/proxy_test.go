@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestValidateProxyURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{raw: ""},
+		{raw: "http://proxy.example.com:3128"},
+		{raw: "https://proxy.example.com"},
+		{raw: "socks5://127.0.0.1:1080"},
+		{raw: "ftp://proxy.example.com", wantErr: true},
+		{raw: "proxy.example.com:3128", wantErr: true},
+		{raw: "http://", wantErr: true},
+		{raw: "http://%zz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if err := validateProxyURL(tt.raw); (err != nil) != tt.wantErr {
+				t.Errorf("validateProxyURL(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyProxy(t *testing.T) {
+	defer func(saved string) { proxyURL = saved }(proxyURL)
+	t.Setenv("NO_PROXY", "internal.example.com")
+	t.Setenv("no_proxy", "")
+
+	tests := []struct {
+		name      string
+		proxy     string
+		target    string
+		wantProxy string
+		wantErr   bool
+	}{
+		{name: "API server through the proxy", proxy: "http://proxy:3128", target: "https://api.example.com:6443", wantProxy: "http://proxy:3128"},
+		{name: "NO_PROXY hosts connect directly", proxy: "http://proxy:3128", target: "https://internal.example.com:6443"},
+		{name: "invalid proxy", proxy: "ftp://proxy", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxyURL = tt.proxy
+			config := &rest.Config{}
+			err := applyProxy(config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, tt.target, nil)
+			got, err := config.Proxy(req)
+			if err != nil {
+				t.Fatalf("Proxy() error = %v", err)
+			}
+			gotProxy := ""
+			if got != nil {
+				gotProxy = got.String()
+			}
+			if gotProxy != tt.wantProxy {
+				t.Errorf("Proxy(%s) = %q, want %q", tt.target, gotProxy, tt.wantProxy)
+			}
+		})
+	}
+}
+
+func TestApplyProxyKeepsKubeconfigProxy(t *testing.T) {
+	defer func(saved string) { proxyURL = saved }(proxyURL)
+	proxyURL = ""
+
+	kubeconfigProxy := func(*http.Request) (*url.URL, error) { return nil, nil }
+	config := &rest.Config{Proxy: kubeconfigProxy}
+	if err := applyProxy(config); err != nil {
+		t.Fatal(err)
+	}
+	if reflect.ValueOf(config.Proxy).Pointer() != reflect.ValueOf(kubeconfigProxy).Pointer() {
+		t.Error("applyProxy() replaced the kubeconfig proxy")
+	}
+
+	config = &rest.Config{}
+	if err := applyProxy(config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Proxy == nil {
+		t.Error("applyProxy() left no proxy function without --proxy-url")
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultRule removes a field from objects of a kind when it holds the value
+// the API server defaults it to. The kind PodSpec applies to the pod spec of
+// Pods and of every workload's pod template, and Container to each container
+// and init container in it.
+type defaultRule struct {
+	Kind  string      `json:"kind"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// builtinDefaultRules are the defaults --prune-defaults removes unless
+// --prune-defaults-file replaces them. Defaults that depend on other fields
+// (e.g. imagePullPolicy, which depends on the image tag) are left alone.
+var builtinDefaultRules = []defaultRule{
+	{Kind: "PodSpec", Path: "dnsPolicy", Value: "ClusterFirst"},
+	{Kind: "PodSpec", Path: "restartPolicy", Value: "Always"},
+	{Kind: "PodSpec", Path: "schedulerName", Value: "default-scheduler"},
+	{Kind: "PodSpec", Path: "securityContext", Value: map[string]interface{}{}},
+	{Kind: "PodSpec", Path: "terminationGracePeriodSeconds", Value: 30},
+	{Kind: "PodSpec", Path: "serviceAccount", Value: "default"},
+	{Kind: "PodSpec", Path: "serviceAccountName", Value: "default"},
+	{Kind: "PodSpec", Path: "enableServiceLinks", Value: true},
+	{Kind: "PodSpec", Path: "preemptionPolicy", Value: "PreemptLowerPriority"},
+	{Kind: "PodSpec", Path: "priority", Value: 0},
+	{Kind: "Container", Path: "terminationMessagePath", Value: "/dev/termination-log"},
+	{Kind: "Container", Path: "terminationMessagePolicy", Value: "File"},
+	{Kind: "Container", Path: "resources", Value: map[string]interface{}{}},
+	{Kind: "Deployment", Path: "spec.progressDeadlineSeconds", Value: 600},
+	{Kind: "Deployment", Path: "spec.revisionHistoryLimit", Value: 10},
+	{Kind: "Deployment", Path: "spec.strategy", Value: map[string]interface{}{
+		"type": "RollingUpdate", "rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"}}},
+	{Kind: "StatefulSet", Path: "spec.podManagementPolicy", Value: "OrderedReady"},
+	{Kind: "StatefulSet", Path: "spec.revisionHistoryLimit", Value: 10},
+	{Kind: "StatefulSet", Path: "spec.updateStrategy", Value: map[string]interface{}{
+		"type": "RollingUpdate", "rollingUpdate": map[string]interface{}{"partition": 0}}},
+	{Kind: "DaemonSet", Path: "spec.revisionHistoryLimit", Value: 10},
+	{Kind: "DaemonSet", Path: "spec.updateStrategy", Value: map[string]interface{}{
+		"type": "RollingUpdate", "rollingUpdate": map[string]interface{}{"maxSurge": 0, "maxUnavailable": 1}}},
+	{Kind: "Job", Path: "spec.backoffLimit", Value: 6},
+	{Kind: "Job", Path: "spec.completionMode", Value: "NonIndexed"},
+	{Kind: "Job", Path: "spec.suspend", Value: false},
+	{Kind: "CronJob", Path: "spec.concurrencyPolicy", Value: "Allow"},
+	{Kind: "CronJob", Path: "spec.failedJobsHistoryLimit", Value: 1},
+	{Kind: "CronJob", Path: "spec.successfulJobsHistoryLimit", Value: 3},
+	{Kind: "CronJob", Path: "spec.suspend", Value: false},
+	{Kind: "Service", Path: "spec.internalTrafficPolicy", Value: "Cluster"},
+	{Kind: "Service", Path: "spec.sessionAffinity", Value: "None"},
+	{Kind: "Service", Path: "spec.type", Value: "ClusterIP"},
+}
+
+// podSpecPaths are where each workload kind keeps its pod spec
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+var (
+	// defaultRules are the rules --prune-defaults applies in the current run
+	defaultRules []defaultRule
+	// defaultsPruned counts the fields removed by --prune-defaults in the current run
+	defaultsPruned int
+)
+
+// loadDefaultRules reads a YAML list of rules (kind, path, value) that
+// replaces the built-in set
+func loadDefaultRules(path string) ([]defaultRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --prune-defaults-file: %w", err)
+	}
+
+	var rules []defaultRule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse --prune-defaults-file %s: %w", path, err)
+	}
+	for i, rule := range rules {
+		if rule.Kind == "" || rule.Path == "" {
+			return nil, fmt.Errorf("rule %d of %s: kind and path are required", i+1, path)
+		}
+	}
+	return rules, nil
+}
+
+// pruneDefaultValues removes the fields of each object that hold their
+// server-defaulted value, per defaultRules
+func pruneDefaultValues(list *unstructured.UnstructuredList) {
+	if len(defaultRules) == 0 {
+		return
+	}
+
+	for i := range list.Items {
+		object := list.Items[i].Object
+		kind := list.Items[i].GetKind()
+
+		var podSpec map[string]interface{}
+		if path, ok := podSpecPaths[kind]; ok {
+			podSpec, _, _ = unstructured.NestedMap(object, path...)
+		}
+
+		for _, rule := range defaultRules {
+			switch {
+			case rule.Kind == kind:
+				pruneDefaultField(object, rule)
+			case rule.Kind == "PodSpec" && podSpec != nil:
+				pruneDefaultField(nestedObject(object, podSpecPaths[kind]), rule)
+			case rule.Kind == "Container" && podSpec != nil:
+				spec := nestedObject(object, podSpecPaths[kind])
+				for _, field := range []string{"initContainers", "containers"} {
+					containers, _ := spec[field].([]interface{})
+					for _, container := range containers {
+						if containerMap, ok := container.(map[string]interface{}); ok {
+							pruneDefaultField(containerMap, rule)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// nestedObject returns the map at path without copying it, so it can be edited in place
+func nestedObject(object map[string]interface{}, path []string) map[string]interface{} {
+	current := object
+	for _, field := range path {
+		next, ok := current[field].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// pruneDefaultField removes the rule's field from object when it equals the
+// rule's value. Values are compared as JSON, so 30 matches 30 whether it was
+// decoded as an integer or a float.
+func pruneDefaultField(object map[string]interface{}, rule defaultRule) {
+	if object == nil {
+		return
+	}
+	fields := strings.Split(rule.Path, ".")
+	parent := nestedObject(object, fields[:len(fields)-1])
+	if parent == nil {
+		return
+	}
+	value, ok := parent[fields[len(fields)-1]]
+	if !ok {
+		return
+	}
+
+	actual, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	expected, err := json.Marshal(rule.Value)
+	if err != nil || string(actual) != string(expected) {
+		return
+	}
+	delete(parent, fields[len(fields)-1])
+	defaultsPruned++
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPruneDefaultValues(t *testing.T) {
+	defer func(rules []defaultRule, pruned int) {
+		defaultRules, defaultsPruned = rules, pruned
+	}(defaultRules, defaultsPruned)
+	defaultRules, defaultsPruned = builtinDefaultRules, 0
+
+	deployment := unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{
+			"replicas":                int64(2),
+			"revisionHistoryLimit":    int64(10),
+			"progressDeadlineSeconds": int64(300),
+			"strategy": map[string]interface{}{
+				"type":          "RollingUpdate",
+				"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+			},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"dnsPolicy":                     "ClusterFirst",
+					"restartPolicy":                 "Always",
+					"schedulerName":                 "default-scheduler",
+					"securityContext":               map[string]interface{}{},
+					"terminationGracePeriodSeconds": float64(30),
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":                     "app",
+							"image":                    "quay.io/shop/app:v1",
+							"terminationMessagePath":   "/dev/termination-log",
+							"terminationMessagePolicy": "FallbackToLogsOnError",
+							"resources":                map[string]interface{}{},
+						},
+					},
+				},
+			},
+		},
+	}}
+	list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{deployment}}
+
+	pruneDefaultValues(list)
+
+	want := map[string]interface{}{
+		"replicas":                int64(2),
+		"progressDeadlineSeconds": int64(300),
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{
+						"name":                     "app",
+						"image":                    "quay.io/shop/app:v1",
+						"terminationMessagePolicy": "FallbackToLogsOnError",
+					},
+				},
+			},
+		},
+	}
+	if got := list.Items[0].Object["spec"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("pruned spec = %v, want %v", got, want)
+	}
+	if defaultsPruned != 9 {
+		t.Errorf("defaultsPruned = %d, want 9", defaultsPruned)
+	}
+}
+
+func TestLoadDefaultRules(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "rules.yaml")
+	if err := os.WriteFile(valid, []byte("- kind: Service\n  path: spec.type\n  value: ClusterIP\n- kind: PodSpec\n  path: priority\n  value: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadDefaultRules(valid)
+	if err != nil {
+		t.Fatalf("loadDefaultRules() error = %v", err)
+	}
+	want := []defaultRule{{Kind: "Service", Path: "spec.type", Value: "ClusterIP"}, {Kind: "PodSpec", Path: "priority", Value: float64(0)}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("loadDefaultRules() = %+v, want %+v", rules, want)
+	}
+
+	missingPath := filepath.Join(dir, "missing-path.yaml")
+	if err := os.WriteFile(missingPath, []byte("- kind: Service\n  value: ClusterIP\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadDefaultRules(missingPath); err == nil {
+		t.Error("loadDefaultRules() of a rule without a path should fail")
+	}
+
+	if _, err := loadDefaultRules(filepath.Join(dir, "absent.yaml")); err == nil {
+		t.Error("loadDefaultRules() of a missing file should fail")
+	}
+}
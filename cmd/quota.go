@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// quotaReportFile is written next to the collection when --quota-report is set
+const quotaReportFile = "quota-report.txt"
+
+var (
+	// quotaReport is set by --quota-report
+	quotaReport bool
+
+	// quotaLines holds the report lines of each namespace, gathered from the
+	// resourcequotas and limitranges collected in the current run
+	quotaLines map[string][]string
+)
+
+// recordQuotaUsage summarizes collected ResourceQuotas (used vs hard) and
+// LimitRanges (per-type limits) for the quota report
+func recordQuotaUsage(resourceName string, list *unstructured.UnstructuredList) {
+	if !quotaReport || (resourceName != "resourcequotas" && resourceName != "limitranges") {
+		return
+	}
+	if quotaLines == nil {
+		quotaLines = make(map[string][]string)
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		namespace := item.GetNamespace()
+
+		var lines []string
+		switch item.GetKind() {
+		case "ResourceQuota":
+			lines = formatQuotaUsage(item)
+		case "LimitRange":
+			lines = formatLimitRange(item)
+		default:
+			continue
+		}
+		quotaLines[namespace] = append(quotaLines[namespace], lines...)
+	}
+}
+
+// formatQuotaUsage renders "<resource>: <used> / <hard> (<percent>)" per quota entry
+func formatQuotaUsage(quota *unstructured.Unstructured) []string {
+	hard, _, _ := unstructured.NestedStringMap(quota.Object, "status", "hard")
+	used, _, _ := unstructured.NestedStringMap(quota.Object, "status", "used")
+
+	lines := []string{fmt.Sprintf("  ResourceQuota %s", quota.GetName())}
+	if len(hard) == 0 {
+		return append(lines, "    (no status yet)")
+	}
+
+	var names []string
+	for name := range hard {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		usedValue := used[name]
+		if usedValue == "" {
+			usedValue = "0"
+		}
+		lines = append(lines, fmt.Sprintf("    %s: %s / %s%s", name, usedValue, hard[name], formatQuotaPercent(usedValue, hard[name])))
+	}
+
+	return lines
+}
+
+// formatQuotaPercent returns " (NN%)" when both quantities parse and hard is non-zero
+func formatQuotaPercent(used, hard string) string {
+	usedQuantity, err := resource.ParseQuantity(used)
+	if err != nil {
+		return ""
+	}
+	hardQuantity, err := resource.ParseQuantity(hard)
+	if err != nil || hardQuantity.IsZero() {
+		return ""
+	}
+
+	percent := float64(usedQuantity.MilliValue()) / float64(hardQuantity.MilliValue()) * 100
+	return fmt.Sprintf(" (%.0f%%)", percent)
+}
+
+// formatLimitRange renders each limit type with its max/min/default values
+func formatLimitRange(limitRange *unstructured.Unstructured) []string {
+	lines := []string{fmt.Sprintf("  LimitRange %s", limitRange.GetName())}
+
+	limits, _, _ := unstructured.NestedSlice(limitRange.Object, "spec", "limits")
+	for _, limit := range limits {
+		limitMap, ok := limit.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		limitType, _ := limitMap["type"].(string)
+
+		for _, field := range []string{"max", "min", "default", "defaultRequest", "maxLimitRequestRatio"} {
+			values, _, _ := unstructured.NestedStringMap(limitMap, field)
+			if len(values) == 0 {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("    %s %s: %s", limitType, field, formatResourceValues(values)))
+		}
+	}
+
+	return lines
+}
+
+// formatResourceValues renders a resource map as sorted "cpu=1, memory=1Gi"
+func formatResourceValues(values map[string]string) string {
+	var pairs []string
+	for name, value := range values {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// writeQuotaReport writes the per-namespace quota report to path
+func writeQuotaReport(path string) error {
+	if !quotaReport {
+		return nil
+	}
+
+	var namespaces []string
+	for namespace := range quotaLines {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var report strings.Builder
+	report.WriteString("=== Quota Report ===\n")
+	if len(namespaces) == 0 {
+		report.WriteString("\nNo ResourceQuotas or LimitRanges found\n")
+	}
+	for _, namespace := range namespaces {
+		report.WriteString(fmt.Sprintf("\nNamespace: %s\n", namespace))
+		for _, line := range quotaLines[namespace] {
+			report.WriteString(line + "\n")
+		}
+	}
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write quota report %s: %w", path, err)
+	}
+
+	fmt.Printf("Quota report: %s (%d namespaces)\n", path, len(namespaces))
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFormatQuotaPercent(t *testing.T) {
+	tests := []struct {
+		used string
+		hard string
+		want string
+	}{
+		{used: "5", hard: "10", want: " (50%)"},
+		{used: "500m", hard: "2", want: " (25%)"},
+		{used: "1Gi", hard: "4Gi", want: " (25%)"},
+		{used: "0", hard: "0", want: ""},
+		{used: "lots", hard: "10", want: ""},
+		{used: "1", hard: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.used+"/"+tt.hard, func(t *testing.T) {
+			if got := formatQuotaPercent(tt.used, tt.hard); got != tt.want {
+				t.Errorf("formatQuotaPercent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordQuotaUsage(t *testing.T) {
+	defer func(enabled bool, lines map[string][]string) { quotaReport, quotaLines = enabled, lines }(quotaReport, quotaLines)
+	quotaReport, quotaLines = true, nil
+
+	recordQuotaUsage("resourcequotas", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"kind":     "ResourceQuota",
+			"metadata": map[string]interface{}{"name": "compute", "namespace": "shop"},
+			"status": map[string]interface{}{
+				"hard": map[string]interface{}{"pods": "10", "requests.cpu": "2"},
+				"used": map[string]interface{}{"requests.cpu": "500m"},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"kind":     "ResourceQuota",
+			"metadata": map[string]interface{}{"name": "new", "namespace": "data"},
+		}},
+	}})
+	recordQuotaUsage("limitranges", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"kind":     "LimitRange",
+			"metadata": map[string]interface{}{"name": "defaults", "namespace": "shop"},
+			"spec": map[string]interface{}{"limits": []interface{}{
+				map[string]interface{}{"type": "Container", "max": map[string]interface{}{"memory": "1Gi", "cpu": "1"}, "default": map[string]interface{}{"cpu": "100m"}},
+			}},
+		}},
+	}})
+	// Other resources are ignored
+	recordQuotaUsage("pods", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		{Object: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "p", "namespace": "other"}}},
+	}})
+
+	want := map[string][]string{
+		"shop": {
+			"  ResourceQuota compute",
+			"    pods: 0 / 10 (0%)",
+			"    requests.cpu: 500m / 2 (25%)",
+			"  LimitRange defaults",
+			"    Container max: cpu=1, memory=1Gi",
+			"    Container default: cpu=100m",
+		},
+		"data": {"  ResourceQuota new", "    (no status yet)"},
+	}
+	if !reflect.DeepEqual(quotaLines, want) {
+		t.Errorf("quotaLines = %q, want %q", quotaLines, want)
+	}
+
+	path := filepath.Join(t.TempDir(), quotaReportFile)
+	if err := writeQuotaReport(path); err != nil {
+		t.Fatalf("writeQuotaReport() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	wantReport := "=== Quota Report ===\n\nNamespace: data\n  ResourceQuota new\n    (no status yet)\n\nNamespace: shop\n" +
+		"  ResourceQuota compute\n    pods: 0 / 10 (0%)\n    requests.cpu: 500m / 2 (25%)\n" +
+		"  LimitRange defaults\n    Container max: cpu=1, memory=1Gi\n    Container default: cpu=100m\n"
+	if string(data) != wantReport {
+		t.Errorf("report = %q, want %q", data, wantReport)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	rolesGVR               = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}
+	clusterRolesGVR        = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}
+	roleBindingsGVR        = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}
+	clusterRoleBindingsGVR = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}
+)
+
+// rbacGrant is one binding of a role to a subject, with the role's rules resolved
+type rbacGrant struct {
+	Scope   string // "cluster-wide" or "namespace <name>"
+	Binding string // "ClusterRoleBinding admins"
+	Role    string // "ClusterRole cluster-admin"
+	Rules   []string
+	Full    bool // the role allows every verb on every resource
+	Missing bool // the bound role does not exist
+}
+
+// rbacRole is a Role or ClusterRole with its rules rendered
+type rbacRole struct {
+	rules []string
+	full  bool
+}
+
+// runRBACGraphMode collects Roles, ClusterRoles and their bindings and
+// resolves, per subject, which verbs it has on which resources and where
+func runRBACGraphMode(dynamicClient dynamic.Interface) error {
+	startTime := time.Now()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	roles, err := collectFocusedList(dynamicClient, rolesGVR)
+	if err != nil {
+		return err
+	}
+	clusterRoles, err := collectFocusedList(dynamicClient, clusterRolesGVR)
+	if err != nil {
+		return err
+	}
+	roleBindings, err := collectFocusedList(dynamicClient, roleBindingsGVR)
+	if err != nil {
+		return err
+	}
+	clusterRoleBindings, err := collectFocusedList(dynamicClient, clusterRoleBindingsGVR)
+	if err != nil {
+		return err
+	}
+
+	// Roles are keyed by namespace/name, ClusterRoles by name. Aggregated
+	// ClusterRoles already carry the aggregated rules, filled in by the API server.
+	roleRules := make(map[string]rbacRole)
+	for i := range roles.Items {
+		roleRules[roles.Items[i].GetNamespace()+"/"+roles.Items[i].GetName()] = rbacRoleOf(&roles.Items[i])
+	}
+	clusterRoleRules := make(map[string]rbacRole)
+	for i := range clusterRoles.Items {
+		clusterRoleRules[clusterRoles.Items[i].GetName()] = rbacRoleOf(&clusterRoles.Items[i])
+	}
+
+	grants := make(map[string][]rbacGrant)
+	bindings := append(append([]unstructured.Unstructured{}, clusterRoleBindings.Items...), roleBindings.Items...)
+	for i := range bindings {
+		binding := &bindings[i]
+		namespace := binding.GetNamespace()
+		roleKind, _, _ := unstructured.NestedString(binding.Object, "roleRef", "kind")
+		roleName, _, _ := unstructured.NestedString(binding.Object, "roleRef", "name")
+
+		grant := rbacGrant{
+			Scope:   "cluster-wide",
+			Binding: binding.GetKind() + " " + binding.GetName(),
+			Role:    roleKind + " " + roleName,
+		}
+		if namespace != "" {
+			grant.Scope = "namespace " + namespace
+		}
+		var role rbacRole
+		var found bool
+		if roleKind == "Role" {
+			role, found = roleRules[namespace+"/"+roleName]
+		} else {
+			role, found = clusterRoleRules[roleName]
+		}
+		grant.Rules, grant.Full, grant.Missing = role.rules, role.full, !found
+
+		subjects, _, _ := unstructured.NestedSlice(binding.Object, "subjects")
+		for _, entry := range subjects {
+			if subject := rbacSubjectKey(entry, namespace); subject != "" {
+				grants[subject] = append(grants[subject], grant)
+			}
+		}
+	}
+
+	report, admins := formatRBACGraph(grants)
+	reportPath := filepath.Join(outputDir, "rbac-graph.txt")
+	if err := writeOutputFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reportPath, err)
+	}
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== RBAC Graph Summary ===\n")
+	fmt.Printf("Roles: %d, ClusterRoles: %d\n", len(roles.Items), len(clusterRoles.Items))
+	fmt.Printf("RoleBindings: %d, ClusterRoleBindings: %d\n", len(roleBindings.Items), len(clusterRoleBindings.Items))
+	fmt.Printf("Subjects: %d (%d with full cluster-wide access)\n", len(grants), admins)
+	fmt.Printf("Output directory: %s\n", outputDir)
+	fmt.Printf("Graph: %s\n", reportPath)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("==========================\n")
+
+	return nil
+}
+
+// rbacSubjectKey renders a binding subject as "ServiceAccount ns/name",
+// "User name" or "Group name". A ServiceAccount without a namespace is in the
+// binding's namespace.
+func rbacSubjectKey(entry interface{}, bindingNamespace string) string {
+	subject, ok := entry.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	kind, _ := subject["kind"].(string)
+	name, _ := subject["name"].(string)
+	if kind == "" || name == "" {
+		return ""
+	}
+	if kind == "ServiceAccount" {
+		namespace, _ := subject["namespace"].(string)
+		if namespace == "" {
+			namespace = bindingNamespace
+		}
+		return kind + " " + namespace + "/" + name
+	}
+	return kind + " " + name
+}
+
+// rbacRoleOf renders each rule of a role as "verbs  resources", e.g.
+// "get,list,watch  pods, apps/deployments" or "get  /healthz"
+func rbacRoleOf(role *unstructured.Unstructured) rbacRole {
+	rules, _, _ := unstructured.NestedSlice(role.Object, "rules")
+	var result rbacRole
+	for _, entry := range rules {
+		rule, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		verbs := rbacStrings(rule["verbs"])
+		groups := rbacStrings(rule["apiGroups"])
+		resourceNames := rbacStrings(rule["resourceNames"])
+		if contains(verbs, "*") && contains(groups, "*") && contains(rbacStrings(rule["resources"]), "*") && len(resourceNames) == 0 {
+			result.full = true
+		}
+
+		var targets []string
+		for _, resource := range rbacStrings(rule["resources"]) {
+			for _, group := range groups {
+				target := resource
+				if group != "" {
+					target = group + "/" + resource
+				}
+				if len(resourceNames) > 0 {
+					target += " [" + strings.Join(resourceNames, ",") + "]"
+				}
+				targets = append(targets, target)
+			}
+		}
+		targets = append(targets, rbacStrings(rule["nonResourceURLs"])...)
+		if len(verbs) == 0 || len(targets) == 0 {
+			continue
+		}
+		result.rules = append(result.rules, fmt.Sprintf("%-24s %s", strings.Join(verbs, ","), strings.Join(targets, ", ")))
+	}
+	return result
+}
+
+func rbacStrings(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var values []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// formatRBACGraph lists every subject with its grants, the subjects with full
+// cluster-wide access first. It returns the report and that subject count.
+func formatRBACGraph(grants map[string][]rbacGrant) (string, int) {
+	var subjects, admins []string
+	for subject, subjectGrants := range grants {
+		subjects = append(subjects, subject)
+		for _, grant := range subjectGrants {
+			if grant.Scope == "cluster-wide" && grant.Full {
+				admins = append(admins, fmt.Sprintf("  %s (via %s -> %s)", subject, grant.Binding, grant.Role))
+				break
+			}
+		}
+	}
+	sort.Strings(subjects)
+	sort.Strings(admins)
+
+	var report strings.Builder
+	report.WriteString("=== RBAC Graph ===\n")
+	writeReportSection(&report, "Subjects with full cluster-wide access", admins)
+	report.WriteString(fmt.Sprintf("\nSubjects (%d):\n", len(subjects)))
+	for _, subject := range subjects {
+		subjectGrants := grants[subject]
+		sort.SliceStable(subjectGrants, func(i, j int) bool {
+			if subjectGrants[i].Scope != subjectGrants[j].Scope {
+				// "cluster-wide" sorts before "namespace <name>"
+				return subjectGrants[i].Scope < subjectGrants[j].Scope
+			}
+			return subjectGrants[i].Binding < subjectGrants[j].Binding
+		})
+
+		report.WriteString(fmt.Sprintf("\n%s\n", subject))
+		for _, grant := range subjectGrants {
+			report.WriteString(fmt.Sprintf("  %s via %s -> %s\n", grant.Scope, grant.Binding, grant.Role))
+			if grant.Missing {
+				report.WriteString("    (role not found, grants nothing)\n")
+				continue
+			}
+			for _, rule := range grant.Rules {
+				report.WriteString("    " + rule + "\n")
+			}
+		}
+	}
+	return report.String(), len(admins)
+}
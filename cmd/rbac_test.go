@@ -0,0 +1,113 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRbacSubjectKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry interface{}
+		want  string
+	}{
+		{"service account", map[string]interface{}{"kind": "ServiceAccount", "name": "builder", "namespace": "ci"}, "ServiceAccount ci/builder"},
+		{"service account in the binding's namespace", map[string]interface{}{"kind": "ServiceAccount", "name": "default"}, "ServiceAccount shop/default"},
+		{"user", map[string]interface{}{"kind": "User", "name": "alice@example.com"}, "User alice@example.com"},
+		{"group", map[string]interface{}{"kind": "Group", "name": "system:masters"}, "Group system:masters"},
+		{"no name", map[string]interface{}{"kind": "User"}, ""},
+		{"not an object", "User alice", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rbacSubjectKey(tt.entry, "shop"); got != tt.want {
+				t.Errorf("rbacSubjectKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRbacRoleOf(t *testing.T) {
+	role := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "ClusterRole",
+		"rules": []interface{}{
+			map[string]interface{}{
+				"apiGroups": []interface{}{"", "apps"},
+				"resources": []interface{}{"pods"},
+				"verbs":     []interface{}{"get", "list", "watch"},
+			},
+			map[string]interface{}{
+				"apiGroups":     []interface{}{""},
+				"resources":     []interface{}{"configmaps"},
+				"resourceNames": []interface{}{"settings"},
+				"verbs":         []interface{}{"update"},
+			},
+			map[string]interface{}{
+				"nonResourceURLs": []interface{}{"/healthz"},
+				"verbs":           []interface{}{"get"},
+			},
+			map[string]interface{}{
+				"apiGroups": []interface{}{"apps"},
+				"resources": []interface{}{"deployments"},
+			},
+		},
+	}}
+
+	want := rbacRole{rules: []string{
+		"get,list,watch           pods, apps/pods",
+		"update                   configmaps [settings]",
+		"get                      /healthz",
+	}}
+	if got := rbacRoleOf(role); !reflect.DeepEqual(got, want) {
+		t.Errorf("rbacRoleOf() = %q, want %q", got.rules, want.rules)
+	}
+
+	admin := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "ClusterRole",
+		"rules": []interface{}{
+			map[string]interface{}{"apiGroups": []interface{}{"*"}, "resources": []interface{}{"*"}, "verbs": []interface{}{"*"}},
+		},
+	}}
+	if got := rbacRoleOf(admin); !got.full {
+		t.Error("rbacRoleOf() of a wildcard role is not full access")
+	}
+}
+
+func TestFormatRBACGraph(t *testing.T) {
+	report, admins := formatRBACGraph(map[string][]rbacGrant{
+		"Group system:masters": {
+			{Scope: "cluster-wide", Binding: "ClusterRoleBinding cluster-admin", Role: "ClusterRole cluster-admin", Rules: []string{"*                        */*"}, Full: true},
+		},
+		"ServiceAccount shop/builder": {
+			{Scope: "namespace shop", Binding: "RoleBinding deployer", Role: "Role deployer", Rules: []string{"update                   apps/deployments"}},
+			{Scope: "cluster-wide", Binding: "ClusterRoleBinding view", Role: "ClusterRole missing", Missing: true},
+		},
+	})
+
+	want := `=== RBAC Graph ===
+
+Subjects with full cluster-wide access (1):
+  Group system:masters (via ClusterRoleBinding cluster-admin -> ClusterRole cluster-admin)
+
+Subjects (2):
+
+Group system:masters
+  cluster-wide via ClusterRoleBinding cluster-admin -> ClusterRole cluster-admin
+    *                        */*
+
+ServiceAccount shop/builder
+  cluster-wide via ClusterRoleBinding view -> ClusterRole missing
+    (role not found, grants nothing)
+  namespace shop via RoleBinding deployer -> Role deployer
+    update                   apps/deployments
+`
+	if report != want {
+		t.Errorf("report =\n%s\nwant\n%s", report, want)
+	}
+	if admins != 1 {
+		t.Errorf("formatRBACGraph() admins = %d, want 1", admins)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// regexList is a repeatable flag that keeps each occurrence whole, since a
+// pattern may itself contain commas (e.g. "[A-Z0-9]{16,20}")
+type regexList []string
+
+func (r *regexList) String() string {
+	return strings.Join(*r, " ")
+}
+
+func (r *regexList) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+var (
+	// redactPatterns are the compiled --redact-regex patterns
+	redactPatterns []*regexp.Regexp
+	// valuesRedacted counts string values replaced by --redact-regex in the current run
+	valuesRedacted int
+)
+
+// compileRedactPatterns compiles every --redact-regex pattern
+func compileRedactPatterns(values []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, value := range values {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --redact-regex %q: %w", value, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// redactMatchingValues replaces every string value in the list that matches a
+// --redact-regex pattern, wherever it sits in the object (annotations, env
+// vars, ConfigMap data, custom resource fields)
+func redactMatchingValues(list *unstructured.UnstructuredList) {
+	if len(redactPatterns) == 0 {
+		return
+	}
+
+	for i := range list.Items {
+		redactValues(list.Items[i].Object)
+	}
+}
+
+func redactValues(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if s, ok := child.(string); ok {
+				if matchesRedactPattern(s) {
+					v[key] = redactedValue
+					valuesRedacted++
+				}
+				continue
+			}
+			redactValues(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			if s, ok := child.(string); ok {
+				if matchesRedactPattern(s) {
+					v[i] = redactedValue
+					valuesRedacted++
+				}
+				continue
+			}
+			redactValues(child)
+		}
+	}
+}
+
+func matchesRedactPattern(value string) bool {
+	if value == redactedValue {
+		return false
+	}
+	for _, pattern := range redactPatterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// collectWithRediscovery runs collect for a resource and, if the resource type
+// itself is not found (the discovery data was stale, e.g. mid-upgrade), refreshes
+// discovery once and retries with the group's current preferred version.
+// A List of an existing type never returns NotFound, so this doesn't fire for
+// resources that are merely empty.
+func collectWithRediscovery(discoveryClient discovery.DiscoveryInterface, resource metav1.APIResource, groupVersion string, collect func(groupVersion string) (int, error)) (int, error) {
+	items, err := collect(groupVersion)
+	if err == nil || !isStaleResourceError(err) {
+		return items, err
+	}
+
+	// Resources given with --gvr were never discovered, so there is nothing to refresh
+	if len(explicitGVRs) > 0 {
+		return items, fmt.Errorf("%s/%s is not served by this cluster (no match): %w", groupVersion, resource.Name, err)
+	}
+
+	currentGV, refreshErr := refreshPreferredVersion(discoveryClient, resource.Name, groupVersion)
+	if refreshErr != nil {
+		if verbose {
+			fmt.Printf("  %s: rediscovery failed: %v\n", resource.Name, refreshErr)
+		}
+		return items, err
+	}
+
+	if verbose {
+		fmt.Printf("  %s: not found at %s, retrying with %s after refreshing discovery\n",
+			resource.Name, groupVersion, currentGV)
+	}
+
+	return collect(currentGV)
+}
+
+// isStaleResourceError reports whether err means the resource type does not exist
+func isStaleResourceError(err error) bool {
+	return apierrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+// refreshPreferredVersion invalidates any cached discovery data and returns the
+// preferred group version that currently serves the resource
+func refreshPreferredVersion(discoveryClient discovery.DiscoveryInterface, resourceName string, groupVersion string) (string, error) {
+	if cached, ok := discoveryClient.(discovery.CachedDiscoveryInterface); ok {
+		cached.Invalidate()
+	}
+
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return "", err
+	}
+
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return "", err
+	}
+
+	for _, group := range groups.Groups {
+		if group.Name != gv.Group {
+			continue
+		}
+
+		// Try the preferred version first, then any other served version
+		candidates := []string{group.PreferredVersion.GroupVersion}
+		for _, version := range group.Versions {
+			if version.GroupVersion != group.PreferredVersion.GroupVersion {
+				candidates = append(candidates, version.GroupVersion)
+			}
+		}
+
+		for _, candidate := range candidates {
+			resources, err := discoveryClient.ServerResourcesForGroupVersion(candidate)
+			if err != nil {
+				continue
+			}
+			for _, r := range resources.APIResources {
+				if r.Name == resourceName {
+					return candidate, nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%s is no longer served by group %q", resourceName, gv.Group)
+}
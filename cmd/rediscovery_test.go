@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCollectWithRediscovery(t *testing.T) {
+	defer func(saved []schema.GroupVersionResource) { explicitGVRs = saved }(explicitGVRs)
+
+	// The group moved its widgets from v1beta1 to v1 since discovery ran
+	discoveryClient := &stubDiscovery{resources: []*metav1.APIResourceList{
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{{Name: "widgets"}}},
+		{GroupVersion: "example.com/v1beta2", APIResources: []metav1.APIResource{{Name: "gadgets"}}},
+	}}
+	notFound := apierrors.NewNotFound(schema.GroupResource{Group: "example.com", Resource: "widgets"}, "")
+
+	tests := []struct {
+		name      string
+		resource  string
+		explicit  bool
+		errs      map[string]error // by group version; missing means success
+		wantCalls []string
+		wantErr   bool
+	}{
+		{name: "collected at the discovered version", resource: "widgets", wantCalls: []string{"example.com/v1beta1"}},
+		{
+			name: "other errors are not retried", resource: "widgets",
+			errs:      map[string]error{"example.com/v1beta1": errors.New("connection refused")},
+			wantCalls: []string{"example.com/v1beta1"}, wantErr: true,
+		},
+		{
+			name: "stale version retried at the current one", resource: "widgets",
+			errs:      map[string]error{"example.com/v1beta1": notFound},
+			wantCalls: []string{"example.com/v1beta1", "example.com/v1"},
+		},
+		{
+			name: "any served version is tried", resource: "gadgets",
+			errs:      map[string]error{"example.com/v1beta1": notFound},
+			wantCalls: []string{"example.com/v1beta1", "example.com/v1beta2"},
+		},
+		{
+			name: "resource no longer served", resource: "gizmos",
+			errs:      map[string]error{"example.com/v1beta1": notFound},
+			wantCalls: []string{"example.com/v1beta1"}, wantErr: true,
+		},
+		{
+			name: "--gvr resources are not rediscovered", resource: "widgets", explicit: true,
+			errs:      map[string]error{"example.com/v1beta1": notFound},
+			wantCalls: []string{"example.com/v1beta1"}, wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explicitGVRs = nil
+			if tt.explicit {
+				explicitGVRs = []schema.GroupVersionResource{{Group: "example.com", Version: "v1beta1", Resource: tt.resource}}
+			}
+
+			var calls []string
+			_, err := collectWithRediscovery(discoveryClient, metav1.APIResource{Name: tt.resource}, "example.com/v1beta1", func(groupVersion string) (int, error) {
+				calls = append(calls, groupVersion)
+				return 1, tt.errs[groupVersion]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("collectWithRediscovery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("collected at %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"golang.org/x/time/rate"
+)
+
+// Remote output options
+var (
+	outputURL         string
+	uploadConcurrency int
+	uploadRate        float64
+	uploadRetries     int
+	uploadCompression string
+)
+
+const (
+	defaultUploadConcurrency = 4
+	defaultUploadRate        = 10
+	defaultUploadRetries     = 5
+
+	// uploadCompression values; auto compresses single file output only
+	compressionAuto = "auto"
+	compressionGzip = "gzip"
+	compressionNone = "none"
+
+	// maxUploadBackoff caps the wait between retries of a throttled upload
+	maxUploadBackoff = 30 * time.Second
+)
+
+// uploadBackoff is the wait after the first throttled attempt of an upload;
+// it doubles with each further attempt
+var uploadBackoff = time.Second
+
+// s3ThrottleCodes are the error codes S3 and S3-compatible stores use to ask
+// clients to slow down
+var s3ThrottleCodes = []string{"SlowDown", "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequests", "ServiceUnavailable"}
+
+// outputObject is one file of the collection to store remotely
+type outputObject struct {
+	// key is the path relative to the collection root, with forward slashes
+	key string
+	// open returns the content; it is called again for every attempt
+	open func() (io.ReadCloser, error)
+	// size is the content length, or -1 if it is not known up front
+	size int64
+	// contentEncoding is stored as the object's Content-Encoding, if set
+	contentEncoding string
+}
+
+// OutputWriter stores the objects of a collection in a remote backend
+type OutputWriter interface {
+	WriteObject(ctx context.Context, object outputObject) error
+}
+
+// throttledError is a write the backend rejected because of the request
+// rate; it succeeds if retried after a pause
+type throttledError struct {
+	err error
+	// retryAfter is the pause the backend asked for, if any
+	retryAfter time.Duration
+}
+
+func (e *throttledError) Error() string {
+	return e.err.Error()
+}
+
+func (e *throttledError) Unwrap() error {
+	return e.err
+}
+
+// parseOutputURL validates an --output-url: s3://bucket[/prefix] or an
+// http(s):// URL objects are PUT under
+func parseOutputURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --output-url %q: %w", rawURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "s3":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("invalid --output-url %q: s3:// URLs need a bucket, e.g. s3://bucket/prefix", rawURL)
+		}
+	case "http", "https":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("invalid --output-url %q: missing host", rawURL)
+		}
+	default:
+		return nil, fmt.Errorf("invalid --output-url %q: must be s3://bucket/prefix or an http(s):// URL", rawURL)
+	}
+	return parsed, nil
+}
+
+// displayURL drops credentials and query parameters, such as the signature of
+// a pre-signed URL, from a URL before it is printed
+func displayURL(target *url.URL) string {
+	shown := *target
+	shown.User = nil
+	shown.RawQuery = ""
+	return shown.String()
+}
+
+// newOutputWriter returns the backend an --output-url points at
+func newOutputWriter(ctx context.Context, target *url.URL) (OutputWriter, error) {
+	if target.Scheme == "s3" {
+		return newS3OutputWriter(ctx, target.Host, target.Path)
+	}
+	return &httpOutputWriter{base: target, client: http.DefaultClient}, nil
+}
+
+// httpOutputWriter PUTs each object to <base URL>/<key>, as WebDAV servers
+// and most artifact stores accept
+type httpOutputWriter struct {
+	base   *url.URL
+	client *http.Client
+}
+
+func (w *httpOutputWriter) WriteObject(ctx context.Context, object outputObject) error {
+	body, err := object.open()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	target := *w.base
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + object.key
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), body)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = object.size
+	if object.contentEncoding != "" {
+		request.Header.Set("Content-Encoding", object.contentEncoding)
+	}
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable:
+		return &throttledError{
+			err:        fmt.Errorf("PUT %s: %s", displayURL(&target), response.Status),
+			retryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		}
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		return fmt.Errorf("PUT %s: %s", displayURL(&target), response.Status)
+	}
+	return nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds; HTTP dates
+// and missing values give 0
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// s3OutputWriter uploads each object to s3://<bucket>/<prefix>/<key>,
+// switching to multipart uploads for large or unsized objects
+type s3OutputWriter struct {
+	uploader *manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+func newS3OutputWriter(ctx context.Context, bucket, prefix string) (*s3OutputWriter, error) {
+	// Credentials and region come from the usual AWS environment variables
+	// and shared config files. Throttled uploads are retried by
+	// rateLimitedWriter, so the SDK does not retry on top.
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRetryer(func() aws.Retryer {
+		return aws.NopRetryer{}
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return &s3OutputWriter{
+		uploader: manager.NewUploader(s3.NewFromConfig(cfg)),
+		bucket:   bucket,
+		prefix:   strings.Trim(prefix, "/"),
+	}, nil
+}
+
+func (w *s3OutputWriter) WriteObject(ctx context.Context, object outputObject) error {
+	body, err := object.open()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(w.bucket),
+		Key:    aws.String(path.Join(w.prefix, object.key)),
+		Body:   body,
+	}
+	if object.contentEncoding != "" {
+		input.ContentEncoding = aws.String(object.contentEncoding)
+	}
+
+	if _, err := w.uploader.Upload(ctx, input); err != nil {
+		if isS3Throttle(err) {
+			return &throttledError{err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// isS3Throttle reports whether S3 rejected a request because of its rate
+func isS3Throttle(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && contains(s3ThrottleCodes, apiErr.ErrorCode()) {
+		return true
+	}
+	var responseErr *smithyhttp.ResponseError
+	if errors.As(err, &responseErr) {
+		status := responseErr.HTTPStatusCode()
+		return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// rateLimitedWriter is a buffered OutputWriter for remote backends. Objects
+// are queued and written by a fixed number of workers, starting no more
+// writes per second than the limit, and writes the backend throttles are
+// retried with exponential backoff. WriteObject only queues; Close waits for
+// the queue to drain and returns the writes that failed.
+type rateLimitedWriter struct {
+	writer  OutputWriter
+	limiter *rate.Limiter
+	retries int
+	queue   chan outputObject
+	workers sync.WaitGroup
+
+	mu      sync.Mutex
+	written int
+	errs    []error
+}
+
+// newRateLimitedWriter starts concurrency workers writing to writer. A
+// perSecond of 0 does not limit the rate.
+func newRateLimitedWriter(ctx context.Context, writer OutputWriter, concurrency int, perSecond float64, retries int) *rateLimitedWriter {
+	limit := rate.Limit(perSecond)
+	if perSecond <= 0 {
+		limit = rate.Inf
+	}
+
+	w := &rateLimitedWriter{
+		writer:  writer,
+		limiter: rate.NewLimiter(limit, concurrency),
+		retries: retries,
+		queue:   make(chan outputObject, concurrency*4),
+	}
+	for i := 0; i < concurrency; i++ {
+		w.workers.Add(1)
+		go func() {
+			defer w.workers.Done()
+			for object := range w.queue {
+				w.record(object, w.write(ctx, object))
+			}
+		}()
+	}
+	return w
+}
+
+// WriteObject queues an object, blocking only while the queue is full
+func (w *rateLimitedWriter) WriteObject(ctx context.Context, object outputObject) error {
+	select {
+	case w.queue <- object:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close waits for every queued write and returns those that failed
+func (w *rateLimitedWriter) Close() error {
+	close(w.queue)
+	w.workers.Wait()
+	return errors.Join(w.errs...)
+}
+
+// write stores one object, retrying while the backend throttles
+func (w *rateLimitedWriter) write(ctx context.Context, object outputObject) error {
+	backoff := uploadBackoff
+	for attempt := 0; ; attempt++ {
+		if err := w.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		err := w.writer.WriteObject(ctx, object)
+		var throttled *throttledError
+		if err == nil || !errors.As(err, &throttled) || attempt >= w.retries {
+			return err
+		}
+
+		wait := backoff
+		if throttled.retryAfter > wait {
+			wait = throttled.retryAfter
+		}
+		if verbose {
+			fmt.Printf("  upload %s: throttled, retrying in %v\n", object.key, wait)
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > maxUploadBackoff {
+			backoff = maxUploadBackoff
+		}
+	}
+}
+
+func (w *rateLimitedWriter) record(object outputObject, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.errs = append(w.errs, fmt.Errorf("failed to upload %s: %w", object.key, err))
+		return
+	}
+	w.written++
+}
+
+// uploadOutputDir copies the files this run wrote under the output
+// directory to --output-url, if set; the same files --push publishes
+func uploadOutputDir(dir string) error {
+	if outputURL == "" {
+		return nil
+	}
+	return uploadFiles(dir, writtenOutputFiles(dir), uploadCompression == compressionGzip)
+}
+
+// uploadOutputFile copies the files this run wrote next to a single file
+// output, its --max-file-size parts and reports included, to --output-url, if
+// set. They are gzipped on the way unless --upload-compression is none.
+func uploadOutputFile(file string) error {
+	if outputURL == "" {
+		return nil
+	}
+	return uploadFiles(filepath.Dir(file), writtenOutputFiles(filepath.Dir(file)), uploadCompression != compressionNone)
+}
+
+// uploadFiles writes files to --output-url through a rateLimitedWriter, keyed
+// by their path relative to root and optionally gzipped
+func uploadFiles(root string, files []string, compress bool) error {
+	target, err := parseOutputURL(outputURL)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	backend, err := newOutputWriter(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		fmt.Printf("Uploading %d files to %s\n", len(files), displayURL(target))
+	}
+
+	writer := newRateLimitedWriter(ctx, backend, uploadConcurrency, uploadRate, uploadRetries)
+	for _, file := range files {
+		object, err := fileObject(root, file)
+		if err != nil {
+			writer.Close()
+			return err
+		}
+		if compress {
+			object = gzipObject(object)
+		}
+		if err := writer.WriteObject(ctx, object); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	encoding := ""
+	if compress {
+		encoding = " (gzip)"
+	}
+	fmt.Printf("Uploaded %d files to %s%s\n", writer.written, displayURL(target), encoding)
+	return nil
+}
+
+// fileObject describes a local output file as an outputObject keyed by its
+// path relative to root
+func fileObject(root, file string) (outputObject, error) {
+	relative, err := filepath.Rel(root, file)
+	if err != nil {
+		return outputObject{}, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return outputObject{}, err
+	}
+
+	return outputObject{
+		key:  filepath.ToSlash(relative),
+		open: func() (io.ReadCloser, error) { return os.Open(file) },
+		size: info.Size(),
+	}, nil
+}
+
+// gzipObject compresses an object while it is uploaded. The content is
+// gzipped through a pipe as the backend reads it, so neither the compressed
+// nor the original file is held in memory; the size is then unknown up front
+// (a chunked HTTP PUT, an S3 multipart upload for large files). The key is
+// kept and the object stored with Content-Encoding gzip, so HTTP clients
+// decompress it transparently.
+func gzipObject(object outputObject) outputObject {
+	open := object.open
+	object.open = func() (io.ReadCloser, error) {
+		source, err := open()
+		if err != nil {
+			return nil, err
+		}
+
+		reader, writer := io.Pipe()
+		go func() {
+			defer source.Close()
+			gz := gzip.NewWriter(writer)
+			_, err := io.Copy(gz, source)
+			if closeErr := gz.Close(); err == nil {
+				err = closeErr
+			}
+			// A reader that stopped early (a failed upload) ends the copy
+			writer.CloseWithError(err)
+		}()
+		return reader, nil
+	}
+	object.size = -1
+	object.contentEncoding = compressionGzip
+	return object
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestParseOutputURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{url: "s3://backups/clusters/prod"},
+		{url: "s3://backups"},
+		{url: "https://artifacts.example.com/collections/"},
+		{url: "http://localhost:8080"},
+		{url: "s3:///prefix", wantErr: "need a bucket"},
+		{url: "https:///path", wantErr: "missing host"},
+		{url: "ftp://files.example.com/", wantErr: "must be s3://bucket/prefix or an http(s):// URL"},
+		{url: "backups/prod", wantErr: "must be s3://bucket/prefix or an http(s):// URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			_, err := parseOutputURL(tt.url)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseOutputURL() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseOutputURL() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPOutputWriter(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]string{}
+	var encoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/collections/prod/v1-pods.yaml":
+			received[r.URL.Path] = string(body)
+			encoding = r.Header.Get("Content-Encoding")
+			w.WriteHeader(http.StatusCreated)
+		case "/collections/prod/busy.yaml":
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	target, err := parseOutputURL(server.URL + "/collections/prod/?sig=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := &httpOutputWriter{base: target, client: server.Client()}
+	object := func(key, content string) outputObject {
+		return outputObject{
+			key:             key,
+			open:            func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(content)), nil },
+			size:            int64(len(content)),
+			contentEncoding: "gzip",
+		}
+	}
+
+	if err := writer.WriteObject(context.Background(), object("v1-pods.yaml", "items: []\n")); err != nil {
+		t.Fatalf("WriteObject() error = %v", err)
+	}
+	if received["/collections/prod/v1-pods.yaml"] != "items: []\n" || encoding != "gzip" {
+		t.Errorf("received = %v with Content-Encoding %q", received, encoding)
+	}
+
+	err = writer.WriteObject(context.Background(), object("busy.yaml", "items: []\n"))
+	var throttled *throttledError
+	if !errors.As(err, &throttled) || throttled.retryAfter != 3*time.Second {
+		t.Errorf("WriteObject() on 429 error = %v, want a throttledError with Retry-After 3s", err)
+	}
+
+	err = writer.WriteObject(context.Background(), object("denied.yaml", "items: []\n"))
+	if err == nil || errors.As(err, &throttled) {
+		t.Errorf("WriteObject() on 403 error = %v, want a non-throttle error", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "secret") {
+		t.Errorf("error %q leaks the URL query", err)
+	}
+}
+
+func TestIsS3Throttle(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&smithy.GenericAPIError{Code: "SlowDown", Message: "Please reduce your request rate."}, true},
+		{fmt.Errorf("upload failed: %w", &smithy.GenericAPIError{Code: "RequestLimitExceeded"}), true},
+		{&smithy.GenericAPIError{Code: "AccessDenied"}, false},
+		{errors.New("connection reset by peer"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isS3Throttle(tt.err); got != tt.want {
+			t.Errorf("isS3Throttle(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// fakeOutputWriter throttles the first throttles writes of each key, fails
+// keys listed in failing and tracks the highest number of concurrent writes
+type fakeOutputWriter struct {
+	mu        sync.Mutex
+	throttles int
+	failing   []string
+	attempts  map[string]int
+	written   []string
+	active    int
+	maxActive int
+}
+
+func (w *fakeOutputWriter) WriteObject(ctx context.Context, object outputObject) error {
+	w.mu.Lock()
+	w.attempts[object.key]++
+	attempt := w.attempts[object.key]
+	w.active++
+	if w.active > w.maxActive {
+		w.maxActive = w.active
+	}
+	w.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.active--
+	if contains(w.failing, object.key) {
+		return errors.New("access denied")
+	}
+	if attempt <= w.throttles {
+		return &throttledError{err: errors.New("slow down")}
+	}
+	w.written = append(w.written, object.key)
+	return nil
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	defer func(backoff time.Duration) { uploadBackoff = backoff }(uploadBackoff)
+	uploadBackoff = time.Millisecond
+
+	object := func(key string) outputObject {
+		return outputObject{key: key, open: func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("")), nil }}
+	}
+	keys := []string{"a.yaml", "b.yaml", "c.yaml", "d.yaml", "e.yaml", "f.yaml"}
+
+	t.Run("retries throttled writes within the concurrency limit", func(t *testing.T) {
+		backend := &fakeOutputWriter{throttles: 2, attempts: map[string]int{}}
+		writer := newRateLimitedWriter(context.Background(), backend, 2, 0, 3)
+		for _, key := range keys {
+			if err := writer.WriteObject(context.Background(), object(key)); err != nil {
+				t.Fatalf("WriteObject() error = %v", err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		sort.Strings(backend.written)
+		if strings.Join(backend.written, ",") != strings.Join(keys, ",") || writer.written != len(keys) {
+			t.Errorf("written = %v (%d), want %v", backend.written, writer.written, keys)
+		}
+		if backend.attempts["a.yaml"] != 3 {
+			t.Errorf("attempts of a.yaml = %d, want 3", backend.attempts["a.yaml"])
+		}
+		if backend.maxActive > 2 {
+			t.Errorf("%d concurrent writes, want at most 2", backend.maxActive)
+		}
+	})
+
+	t.Run("gives up after the retries and does not retry other errors", func(t *testing.T) {
+		backend := &fakeOutputWriter{throttles: 5, failing: []string{"b.yaml"}, attempts: map[string]int{}}
+		writer := newRateLimitedWriter(context.Background(), backend, 1, 0, 2)
+		writer.WriteObject(context.Background(), object("a.yaml"))
+		writer.WriteObject(context.Background(), object("b.yaml"))
+
+		err := writer.Close()
+		if err == nil || !strings.Contains(err.Error(), "failed to upload a.yaml: slow down") || !strings.Contains(err.Error(), "failed to upload b.yaml: access denied") {
+			t.Errorf("Close() error = %v, want both failures", err)
+		}
+		if backend.attempts["a.yaml"] != 3 || backend.attempts["b.yaml"] != 1 {
+			t.Errorf("attempts = %v, want a.yaml 3 times and b.yaml once", backend.attempts)
+		}
+	})
+
+	t.Run("limits the rate", func(t *testing.T) {
+		backend := &fakeOutputWriter{attempts: map[string]int{}}
+		writer := newRateLimitedWriter(context.Background(), backend, 1, 50, 0)
+		start := time.Now()
+		for _, key := range keys {
+			writer.WriteObject(context.Background(), object(key))
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// The burst equals the concurrency, so the remaining five writes wait 20ms each
+		if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+			t.Errorf("6 writes at 50/s took %v, want at least 80ms", elapsed)
+		}
+	})
+}
+
+func TestGzipObject(t *testing.T) {
+	opened := 0
+	content := strings.Repeat("kind: ConfigMap\n", 1000)
+	object := gzipObject(outputObject{
+		key: "all-resources.yaml",
+		open: func() (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+		size: int64(len(content)),
+	})
+	if object.key != "all-resources.yaml" || object.size != -1 || object.contentEncoding != "gzip" {
+		t.Errorf("gzipObject() = key %q, size %d, encoding %q", object.key, object.size, object.contentEncoding)
+	}
+
+	// Every attempt of a retried upload reads the content again
+	for attempt := 1; attempt <= 2; attempt++ {
+		body, err := object.open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decompressed, err := io.ReadAll(gz)
+		body.Close()
+		if err != nil || string(decompressed) != content {
+			t.Errorf("attempt %d: decompressed %d bytes (error %v), want %d", attempt, len(decompressed), err, len(content))
+		}
+	}
+	if opened != 2 {
+		t.Errorf("source opened %d times, want 2", opened)
+	}
+
+	// An upload that gives up early closes the pipe without leaking the copy
+	body, err := object.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+}
+
+func TestUploadOutputFile(t *testing.T) {
+	defer func(url string, concurrency int, rate float64, compression string) {
+		outputURL, uploadConcurrency, uploadRate, uploadCompression = url, concurrency, rate, compression
+	}(outputURL, uploadConcurrency, uploadRate, uploadCompression)
+
+	var mu sync.Mutex
+	received := map[string]string{}
+	encodings := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		encodings[r.Header.Get("Content-Encoding")] = true
+		mu.Unlock()
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" && r.ContentLength == -1 {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = gz
+		}
+		data, _ := io.ReadAll(body)
+		mu.Lock()
+		received[r.URL.Path] = string(data)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	outputURL, uploadConcurrency, uploadRate = server.URL+"/prod", 2, 0
+
+	dir := t.TempDir()
+	for _, name := range []string{"all-resources.yaml", "all-resources.part2.yaml", "quota-report.txt"} {
+		if err := writeOutputFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A leftover of an earlier run is not uploaded
+	if err := os.WriteFile(filepath.Join(dir, "all-resources.part3.yaml"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		compression string
+		encoding    string
+	}{
+		{compressionAuto, "gzip"},
+		{compressionNone, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			received, encodings = map[string]string{}, map[string]bool{}
+			uploadCompression = tt.compression
+			if err := uploadOutputFile(filepath.Join(dir, "all-resources.yaml")); err != nil {
+				t.Fatalf("uploadOutputFile() error = %v", err)
+			}
+			if len(encodings) != 1 || !encodings[tt.encoding] {
+				t.Errorf("Content-Encoding of the uploads = %v, want %q", encodings, tt.encoding)
+			}
+
+			var paths []string
+			for path, data := range received {
+				paths = append(paths, path)
+				if data != "data" {
+					t.Errorf("%s = %q, want the file content", path, data)
+				}
+			}
+			sort.Strings(paths)
+			if want := "/prod/all-resources.part2.yaml,/prod/all-resources.yaml,/prod/quota-report.txt"; strings.Join(paths, ",") != want {
+				t.Errorf("uploaded %v, want %s", paths, want)
+			}
+		})
+	}
+
+	// Directory mode uploads the same recorded files, not everything under the directory
+	t.Run("directory", func(t *testing.T) {
+		received = map[string]string{}
+		uploadCompression = compressionNone
+		outputDir := t.TempDir()
+		if err := writeOutputFile(filepath.Join(outputDir, "pods.yaml"), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, "stale.yaml"), []byte("stale"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := uploadOutputDir(outputDir); err != nil {
+			t.Fatalf("uploadOutputDir() error = %v", err)
+		}
+		if _, ok := received["/prod/pods.yaml"]; !ok || len(received) != 1 {
+			t.Errorf("uploaded %v, want only /prod/pods.yaml", received)
+		}
+	})
+}
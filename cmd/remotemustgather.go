@@ -0,0 +1,174 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// remoteDownloadTimeout bounds the download of a remote must-gather archive
+const remoteDownloadTimeout = 30 * time.Minute
+
+var (
+	// --max-archive-size and --max-archive-file-size bound what a remote
+	// must-gather archive may extract to, so a corrupt or hostile archive
+	// cannot fill the disk
+	maxArchiveSize                = "20GB"
+	maxArchiveFileSize            = "2GB"
+	maxArchiveSizeBytes     int64 = 20 << 30
+	maxArchiveFileSizeBytes int64 = 2 << 30
+)
+
+// parseArchiveLimits validates --max-archive-size and --max-archive-file-size
+func parseArchiveLimits() error {
+	total, err := parseByteSize(maxArchiveSize)
+	if err != nil {
+		return fmt.Errorf("invalid --max-archive-size: %w", err)
+	}
+	perFile, err := parseByteSize(maxArchiveFileSize)
+	if err != nil {
+		return fmt.Errorf("invalid --max-archive-file-size: %w", err)
+	}
+	maxArchiveSizeBytes, maxArchiveFileSizeBytes = total, perFile
+	return nil
+}
+
+// isRemoteMustGather reports whether a --must-gather value is a URL rather than a directory
+func isRemoteMustGather(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "s3://")
+}
+
+// fetchRemoteMustGather downloads a .tar.gz must-gather and extracts it into a
+// temporary directory. The caller removes the returned directory when done.
+func fetchRemoteMustGather(source string) (string, error) {
+	url, err := mustGatherDownloadURL(source)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "must-gather-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	if verbose {
+		fmt.Printf("Downloading must-gather from %s\n", url)
+	}
+
+	client := &http.Client{Timeout: remoteDownloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to download must-gather %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to download must-gather %s: %s", source, resp.Status)
+	}
+
+	files, err := extractTarGz(resp.Body, dir, maxArchiveSizeBytes, maxArchiveFileSizeBytes)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to extract must-gather %s: %w", source, err)
+	}
+
+	if verbose {
+		fmt.Printf("Extracted %d files to %s\n", files, dir)
+	}
+
+	return dir, nil
+}
+
+// mustGatherDownloadURL maps s3://bucket/key to the bucket's virtual-hosted
+// HTTPS endpoint; private objects need a presigned https:// URL instead
+func mustGatherDownloadURL(source string) (string, error) {
+	if !strings.HasPrefix(source, "s3://") {
+		return source, nil
+	}
+
+	bucket, key, found := strings.Cut(strings.TrimPrefix(source, "s3://"), "/")
+	if !found || bucket == "" || key == "" {
+		return "", fmt.Errorf("invalid must-gather URL %q: expected s3://<bucket>/<key>", source)
+	}
+	return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+}
+
+// extractTarGz unpacks the regular files and directories of a gzipped tar
+// stream under dir, rejecting entries that would land outside it. It stops
+// once a file exceeds maxFile bytes or all files together exceed maxTotal.
+func extractTarGz(r io.Reader, dir string, maxTotal, maxFile int64) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	files := 0
+	var total int64
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return files, err
+		}
+
+		target := filepath.Join(dir, header.Name)
+		if target != dir && !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return files, fmt.Errorf("archive entry %q points outside the extraction directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return files, err
+			}
+		case tar.TypeReg:
+			if header.Size > maxFile {
+				return files, fmt.Errorf("archive entry %q is %d bytes, more than --max-archive-file-size %d", header.Name, header.Size, maxFile)
+			}
+			if total+header.Size > maxTotal {
+				return files, fmt.Errorf("archive extracts to more than --max-archive-size %d bytes", maxTotal)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return files, err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return files, err
+			}
+			// Never trust the header alone: copy at most the remaining budget
+			written, err := io.Copy(file, io.LimitReader(archive, min(maxFile, maxTotal-total)+1))
+			if err != nil {
+				file.Close()
+				return files, err
+			}
+			if err := file.Close(); err != nil {
+				return files, err
+			}
+			if written > maxFile {
+				return files, fmt.Errorf("archive entry %q is more than --max-archive-file-size %d bytes", header.Name, maxFile)
+			}
+			total += written
+			if total > maxTotal {
+				return files, fmt.Errorf("archive extracts to more than --max-archive-size %d bytes", maxTotal)
+			}
+			files++
+		default:
+			// Links and special files are not needed to read resources
+			if verbose {
+				fmt.Printf("  Skipping archive entry %s\n", header.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarGz builds a gzipped tar archive of regular files
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsRemoteMustGather(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com/must-gather.tar.gz": true,
+		"http://example.com/must-gather.tar.gz":  true,
+		"s3://bucket/must-gather.tar.gz":         true,
+		"./must-gather.local.123":                false,
+		"/tmp/https-must-gather":                 false,
+	}
+
+	for path, want := range tests {
+		if got := isRemoteMustGather(path); got != want {
+			t.Errorf("isRemoteMustGather(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestMustGatherDownloadURL(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"https://example.com/mg.tar.gz", "https://example.com/mg.tar.gz", false},
+		{"s3://support-cases/case-42/mg.tar.gz", "https://support-cases.s3.amazonaws.com/case-42/mg.tar.gz", false},
+		{"s3://support-cases", "", true},
+		{"s3:///mg.tar.gz", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := mustGatherDownloadURL(tt.source)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("mustGatherDownloadURL(%q) error = %v, wantErr %v", tt.source, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("mustGatherDownloadURL(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestExtractTarGz(t *testing.T) {
+	dir := t.TempDir()
+	archive := tarGz(t, map[string]string{
+		"must-gather/cluster-scoped-resources/nodes.yaml": "kind: NodeList\n",
+		"must-gather/namespaces/shop/pods.yaml":           "kind: PodList\n",
+	})
+
+	files, err := extractTarGz(bytes.NewReader(archive), dir, 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("extractTarGz() error = %v", err)
+	}
+	if files != 2 {
+		t.Errorf("extractTarGz() extracted %d files, want 2", files)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "must-gather", "namespaces", "shop", "pods.yaml"))
+	if err != nil || string(data) != "kind: PodList\n" {
+		t.Errorf("extracted pods.yaml = %q, %v", data, err)
+	}
+
+	escaping := tarGz(t, map[string]string{"../outside.yaml": "kind: List\n"})
+	if _, err := extractTarGz(bytes.NewReader(escaping), dir, 1<<20, 1<<20); err == nil || !strings.Contains(err.Error(), "outside the extraction directory") {
+		t.Errorf("extractTarGz() of an escaping entry error = %v, want outside the extraction directory", err)
+	}
+
+	if _, err := extractTarGz(strings.NewReader("not gzip"), dir, 1<<20, 1<<20); err == nil {
+		t.Error("extractTarGz() of a plain stream should fail")
+	}
+}
+
+func TestExtractTarGzLimits(t *testing.T) {
+	archive := tarGz(t, map[string]string{
+		"must-gather/a.yaml": strings.Repeat("a", 600),
+		"must-gather/b.yaml": strings.Repeat("b", 600),
+	})
+
+	tests := []struct {
+		name          string
+		maxTotal      int64
+		maxFile       int64
+		wantErr       string
+		wantExtracted int
+	}{
+		{name: "within the limits", maxTotal: 1200, maxFile: 600, wantExtracted: 2},
+		{name: "a file above the per-file limit", maxTotal: 1200, maxFile: 599, wantErr: "--max-archive-file-size"},
+		{name: "files above the total limit", maxTotal: 1000, maxFile: 600, wantErr: "--max-archive-size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := extractTarGz(bytes.NewReader(archive), t.TempDir(), tt.maxTotal, tt.maxFile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("extractTarGz() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || files != tt.wantExtracted {
+				t.Errorf("extractTarGz() = %d, %v; want %d files", files, err, tt.wantExtracted)
+			}
+		})
+	}
+}
+
+func TestFetchRemoteMustGather(t *testing.T) {
+	archive := tarGz(t, map[string]string{"must-gather/version": "4.14\n"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mg.tar.gz" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	dir, err := fetchRemoteMustGather(server.URL + "/mg.tar.gz")
+	if err != nil {
+		t.Fatalf("fetchRemoteMustGather() error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if data, err := os.ReadFile(filepath.Join(dir, "must-gather", "version")); err != nil || string(data) != "4.14\n" {
+		t.Errorf("downloaded version file = %q, %v", data, err)
+	}
+
+	if _, err := fetchRemoteMustGather(server.URL + "/missing.tar.gz"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("fetchRemoteMustGather() of a missing archive error = %v, want 404", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podGVR is the only resource collected with --collect-pods-with-restarts
+var podGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// hasRestarts reports whether any container (or init container) of a pod has
+// restarted at least --min-restarts times. Objects other than pods are kept.
+func hasRestarts(obj *unstructured.Unstructured) bool {
+	if obj.GetKind() != "Pod" || obj.GroupVersionKind().Group != "" {
+		return true
+	}
+	return podMaxRestarts(obj) >= int64(minRestarts)
+}
+
+// podMaxRestarts returns the highest restartCount across the pod's container statuses
+func podMaxRestarts(pod *unstructured.Unstructured) int64 {
+	var max int64
+	for _, field := range []string{"containerStatuses", "initContainerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+		for _, entry := range statuses {
+			status, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if count, ok, _ := unstructured.NestedInt64(status, "restartCount"); ok && count > max {
+				max = count
+			}
+		}
+	}
+	return max
+}
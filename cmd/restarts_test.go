@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestHasRestarts(t *testing.T) {
+	defer func(saved int) { minRestarts = saved }(minRestarts)
+	minRestarts = 3
+
+	pod := func(containers, initContainers []int64) *unstructured.Unstructured {
+		statuses := func(counts []int64) []interface{} {
+			var list []interface{}
+			for _, count := range counts {
+				list = append(list, map[string]interface{}{"restartCount": count})
+			}
+			return list
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"status": map[string]interface{}{
+				"containerStatuses":     statuses(containers),
+				"initContainerStatuses": statuses(initContainers),
+			},
+		}}
+	}
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{"below the threshold", pod([]int64{0, 2}, nil), false},
+		{"one container at the threshold", pod([]int64{0, 3}, nil), true},
+		{"init container restarts count", pod([]int64{0}, []int64{5}), true},
+		{"no statuses yet", &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod"}}, false},
+		{"other kinds are kept", &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Event"}}, true},
+		{"a Pod kind of another group is kept", &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "metrics.k8s.io/v1beta1", "kind": "Pod"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasRestarts(tt.obj); got != tt.want {
+				t.Errorf("hasRestarts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
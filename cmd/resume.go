@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// isAlreadyCollected reports whether an earlier run already wrote the output
+// file of a resource, so --resume can skip it
+func isAlreadyCollected(outputDir, resourceName, groupVersion string) bool {
+	return collectedFilePath(outputDir, resourceName, groupVersion) != ""
+}
+
+// collectedFilePath returns the output file an earlier run wrote for a
+// resource, or "" if there is none
+func collectedFilePath(outputDir, resourceName, groupVersion string) string {
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return ""
+	}
+
+	filePath, err := resourceFilePath(outputDir, resourceName, gv)
+	if err != nil {
+		return ""
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		return ""
+	}
+	return filePath
+}
+
+// countFileItems returns the number of objects in a resource file written by
+// an earlier run
+func countFileItems(filePath string) (int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	var list struct {
+		Items []interface{} `json:"items"`
+	}
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	return len(list.Items), nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place, so
+// an interrupted run never leaves a truncated file that --resume would trust
+func writeFileAtomic(filePath string, data []byte) error {
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return err
+	}
+	recordOutputFile(filePath)
+	return nil
+}
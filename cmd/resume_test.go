@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectedFilePath(t *testing.T) {
+	defer func(saved bool) { outputPerGroup = saved }(outputPerGroup)
+
+	tests := []struct {
+		name         string
+		perGroup     bool
+		existing     string
+		resource     string
+		groupVersion string
+		want         string
+	}{
+		{name: "core resource written", existing: "v1-pods.yaml", resource: "pods", groupVersion: "v1", want: "v1-pods.yaml"},
+		{name: "grouped resource written", existing: "apps-v1-deployments.yaml", resource: "deployments", groupVersion: "apps/v1", want: "apps-v1-deployments.yaml"},
+		{name: "not written yet", existing: "v1-pods.yaml", resource: "services", groupVersion: "v1"},
+		{name: "only a temporary file", existing: "v1-pods.yaml.tmp", resource: "pods", groupVersion: "v1"},
+		{name: "per-group layout", perGroup: true, existing: "_core/v1/pods.yaml", resource: "pods", groupVersion: "v1", want: "_core/v1/pods.yaml"},
+		{name: "invalid group version", existing: "v1-pods.yaml", resource: "pods", groupVersion: "a/b/c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPerGroup = tt.perGroup
+			dir := t.TempDir()
+			existing := filepath.Join(dir, tt.existing)
+			os.MkdirAll(filepath.Dir(existing), 0755)
+			if err := os.WriteFile(existing, []byte("items: []\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(dir, tt.want)
+			}
+			if got := collectedFilePath(dir, tt.resource, tt.groupVersion); got != want {
+				t.Errorf("collectedFilePath() = %q, want %q", got, want)
+			}
+			if got := isAlreadyCollected(dir, tt.resource, tt.groupVersion); got != (want != "") {
+				t.Errorf("isAlreadyCollected() = %v, want %v", got, want != "")
+			}
+		})
+	}
+}
+
+func TestCountFileItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{name: "with header", content: "# Resource: pods\n# ---\n\napiVersion: v1\nkind: List\nitems:\n- kind: Pod\n- kind: Pod\n", want: 2},
+		{name: "empty list", content: "apiVersion: v1\nkind: List\nitems: []\n", want: 0},
+		{name: "not YAML", content: "items: [\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "v1-pods.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := countFileItems(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("countFileItems() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("countFileItems() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "v1-pods.yaml")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new")); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("file = %q, want new", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind")
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "v1-pods.yaml"), []byte("new")); err == nil {
+		t.Error("writeFileAtomic() into a missing directory succeeded")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+)
+
+// transientErrorMessages match transport failures client-go reports only as
+// text, without a typed error to check
+var transientErrorMessages = []string{
+	"connection reset by peer",
+	"connection refused",
+	"TLS handshake timeout",
+	"http2: client connection lost",
+	"i/o timeout",
+}
+
+// isRetryableError reports whether an API call failed transiently: timeouts,
+// throttling (429), server errors (5xx) and dropped or refused connections.
+// Anything else, such as missing credentials or permissions, an unknown
+// resource, a certificate or DNS failure or a broken kubeconfig or exec
+// plugin, is not retried since a retry cannot fix it.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var groupErr *discovery.ErrGroupDiscoveryFailed
+	if errors.As(err, &groupErr) {
+		for _, groupVersionErr := range groupErr.Groups {
+			if isRetryableError(groupVersionErr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if isConversionWebhookError(err) {
+		// Reported (and optionally retried) per resource by listWithConversionFallback
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return false
+	}
+
+	switch {
+	case apierrors.IsTooManyRequests(err), apierrors.IsServerTimeout(err), apierrors.IsTimeout(err),
+		apierrors.IsInternalError(err), apierrors.IsServiceUnavailable(err), apierrors.IsUnexpectedServerError(err):
+		return true
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return status.Status().Code >= 500
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	message := err.Error()
+	for _, transient := range transientErrorMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry runs an API call, retrying transient failures up to --retries
+// times with exponential backoff starting at --retry-backoff
+func withRetry(description string, call func() error) error {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= retries || !isRetryableError(err) {
+			return err
+		}
+
+		if verbose {
+			fmt.Printf("  %s failed (attempt %d of %d), retrying in %s: %v\n", description, attempt+1, retries+1, backoff, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"throttled", apierrors.NewTooManyRequests("slow down", 1), true},
+		{"server timeout", apierrors.NewServerTimeout(pods, "list", 1), true},
+		{"internal error", apierrors.NewInternalError(errors.New("etcd leader changed")), true},
+		{"service unavailable", apierrors.NewServiceUnavailable("apiserver shutting down"), true},
+		{"forbidden", apierrors.NewForbidden(pods, "", errors.New("no RBAC")), false},
+		{"unauthorized", apierrors.NewUnauthorized("token expired"), false},
+		{"not found", apierrors.NewNotFound(pods, "web"), false},
+		{"conversion webhook", apierrors.NewInternalError(errors.New("conversion webhook for example.com/v1, Kind=Widget failed: EOF")), false},
+		{"deadline exceeded", fmt.Errorf("list pods: %w", context.DeadlineExceeded), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"connection reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"dns failure", &net.DNSError{Err: "no such host", Name: "api.example.com"}, false},
+		{"reset reported as text", errors.New("read tcp 10.0.0.1:443: connection reset by peer"), true},
+		{"broken kubeconfig", errors.New("invalid configuration: no server found"), false},
+		{
+			"partial discovery with a transient group",
+			&discovery.ErrGroupDiscoveryFailed{Groups: map[schema.GroupVersion]error{{Group: "metrics.k8s.io", Version: "v1beta1"}: apierrors.NewServiceUnavailable("unavailable")}},
+			true,
+		},
+		{
+			"partial discovery with a permanent group",
+			&discovery.ErrGroupDiscoveryFailed{Groups: map[schema.GroupVersion]error{{Group: "example.com", Version: "v1"}: apierrors.NewForbidden(pods, "", errors.New("no RBAC"))}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	defer func(count int, backoff time.Duration) {
+		retries, retryBackoff = count, backoff
+	}(retries, retryBackoff)
+	retries, retryBackoff = 2, time.Millisecond
+
+	transient := apierrors.NewServiceUnavailable("unavailable")
+	tests := []struct {
+		name      string
+		failures  []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds at once", nil, 1, false},
+		{"recovers from a transient failure", []error{transient}, 2, false},
+		{"gives up after --retries", []error{transient, transient, transient, transient}, 3, true},
+		{"does not retry a permanent failure", []error{apierrors.NewUnauthorized("token expired")}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry("list pods", func() error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("withRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("withRetry() made %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
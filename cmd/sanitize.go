@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// redactedValue replaces every Secret value when --redact-secrets is set
+const redactedValue = "REDACTED"
+
+// lastAppliedAnnotation is set by kubectl apply and holds a full copy of the
+// applied manifest, including Secret values
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// decodedSecretAnnotation lists the keys --decode-secrets moved from data to stringData
+const decodedSecretAnnotation = "k8s-resource-collector/decoded-secret-keys"
+
+// secretsDecoded counts the Secrets with decoded values in the current run
+var secretsDecoded int
+
+// secureExcludedResources are left out by --secure unless given in --include-resources
+var secureExcludedResources = []string{
+	"secrets",
+	"certificatesigningrequests",
+	"oauthaccesstokens",
+	"oauthauthorizetokens",
+	"useroauthaccesstokens",
+}
+
+// strippedMetadataFields are removed by --strip-metadata; they identify a
+// specific cluster's copy of an object rather than describe the object
+var strippedMetadataFields = []string{"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields", "selfLink"}
+
+// excludedResources are the resource names not collected in the current run
+var excludedResources []string
+
+// applySecureProfile turns on every sanitizing option for --secure and builds
+// the exclusion list; names in --include-resources are always collected
+func applySecureProfile() {
+	excludedResources = parseList(excludeResources)
+	if secure {
+		redactSecrets = true
+		stripMetadata = true
+		excludedResources = append(excludedResources, secureExcludedResources...)
+	}
+
+	included := parseList(includeResources)
+	kept := excludedResources[:0]
+	for _, name := range excludedResources {
+		if !contains(included, name) {
+			kept = append(kept, name)
+		}
+	}
+	excludedResources = kept
+}
+
+// isExcludedResource reports whether a resource (or the parent of a
+// subresource) is excluded from collection
+func isExcludedResource(name string) bool {
+	base, _, _ := strings.Cut(name, "/")
+	return contains(excludedResources, base)
+}
+
+// redactSecretValues replaces the values of Secret data and stringData, and
+// drops the last-applied annotation, whose manifest copy holds them in plain text
+func redactSecretValues(list *unstructured.UnstructuredList) {
+	if !redactSecrets {
+		return
+	}
+
+	for i := range list.Items {
+		if list.Items[i].GetKind() != "Secret" {
+			continue
+		}
+		if annotations := list.Items[i].GetAnnotations(); annotations != nil {
+			if _, ok := annotations[lastAppliedAnnotation]; ok {
+				delete(annotations, lastAppliedAnnotation)
+				if len(annotations) == 0 {
+					annotations = nil
+				}
+				list.Items[i].SetAnnotations(annotations)
+			}
+		}
+		for _, field := range []string{"data", "stringData"} {
+			values, ok := list.Items[i].Object[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key := range values {
+				values[key] = redactedValue
+			}
+		}
+	}
+}
+
+// decodeSecretValues moves each Secret data value that decodes to readable
+// text into stringData, and records the moved keys in an annotation so the
+// decoded copy is never mistaken for the stored object. Binary values stay
+// base64-encoded in data.
+func decodeSecretValues(list *unstructured.UnstructuredList) {
+	if !decodeSecrets {
+		return
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.GetKind() != "Secret" || item.GroupVersionKind().Group != "" {
+			continue
+		}
+		data, ok := item.Object["data"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stringData, ok := item.Object["stringData"].(map[string]interface{})
+		if !ok {
+			stringData = make(map[string]interface{})
+		}
+
+		var decodedKeys []string
+		for key, value := range data {
+			encoded, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if _, exists := stringData[key]; exists {
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || !isReadableText(decoded) {
+				continue
+			}
+			stringData[key] = string(decoded)
+			delete(data, key)
+			decodedKeys = append(decodedKeys, key)
+		}
+		if len(decodedKeys) == 0 {
+			continue
+		}
+
+		sort.Strings(decodedKeys)
+		item.Object["stringData"] = stringData
+		if len(data) == 0 {
+			delete(item.Object, "data")
+		}
+		annotations := item.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[decodedSecretAnnotation] = strings.Join(decodedKeys, ",")
+		item.SetAnnotations(annotations)
+		secretsDecoded++
+	}
+}
+
+// isReadableText reports whether a decoded value is UTF-8 text without
+// control characters other than line breaks and tabs
+func isReadableText(value []byte) bool {
+	if !utf8.Valid(value) {
+		return false
+	}
+	for _, r := range string(value) {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// stripObjectMetadata removes server-populated metadata and the
+// last-applied-configuration annotation, which embeds a full copy of the object
+func stripObjectMetadata(list *unstructured.UnstructuredList) {
+	if !stripMetadata {
+		return
+	}
+
+	for i := range list.Items {
+		metadata, ok := list.Items[i].Object["metadata"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range strippedMetadataFields {
+			delete(metadata, field)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, lastAppliedAnnotation)
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRedactSecretValues(t *testing.T) {
+	defer func(saved bool) { redactSecrets = saved }(redactSecrets)
+	redactSecrets = true
+
+	tests := []struct {
+		name string
+		in   map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "data and stringData values are redacted",
+			in: map[string]interface{}{
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "s"},
+				"data":       map[string]interface{}{"password": "c2VjcmV0"},
+				"stringData": map[string]interface{}{"token": "abc"},
+			},
+			want: map[string]interface{}{
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "s"},
+				"data":       map[string]interface{}{"password": redactedValue},
+				"stringData": map[string]interface{}{"token": redactedValue},
+			},
+		},
+		{
+			name: "last-applied annotation is dropped from Secrets",
+			in: map[string]interface{}{
+				"kind": "Secret",
+				"metadata": map[string]interface{}{"name": "s", "annotations": map[string]interface{}{
+					lastAppliedAnnotation: `{"data":{"password":"c2VjcmV0"}}`,
+					"team":                "shop",
+				}},
+				"data": map[string]interface{}{"password": "c2VjcmV0"},
+			},
+			want: map[string]interface{}{
+				"kind":     "Secret",
+				"metadata": map[string]interface{}{"name": "s", "annotations": map[string]interface{}{"team": "shop"}},
+				"data":     map[string]interface{}{"password": redactedValue},
+			},
+		},
+		{
+			name: "annotations removed when only last-applied was set",
+			in: map[string]interface{}{
+				"kind": "Secret",
+				"metadata": map[string]interface{}{"name": "s", "annotations": map[string]interface{}{
+					lastAppliedAnnotation: "{}",
+				}},
+			},
+			want: map[string]interface{}{
+				"kind":     "Secret",
+				"metadata": map[string]interface{}{"name": "s"},
+			},
+		},
+		{
+			name: "other kinds are untouched",
+			in: map[string]interface{}{
+				"kind": "ConfigMap",
+				"metadata": map[string]interface{}{"name": "c", "annotations": map[string]interface{}{
+					lastAppliedAnnotation: "{}",
+				}},
+				"data": map[string]interface{}{"key": "value"},
+			},
+			want: map[string]interface{}{
+				"kind": "ConfigMap",
+				"metadata": map[string]interface{}{"name": "c", "annotations": map[string]interface{}{
+					lastAppliedAnnotation: "{}",
+				}},
+				"data": map[string]interface{}{"key": "value"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: tt.in}}}
+			redactSecretValues(list)
+			if got := list.Items[0].Object; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redactSecretValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSecretValues(t *testing.T) {
+	defer func(enabled bool, decoded int) { decodeSecrets, secretsDecoded = enabled, decoded }(decodeSecrets, secretsDecoded)
+	decodeSecrets = true
+
+	tests := []struct {
+		name string
+		in   map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "text values move to stringData, binary values stay in data",
+			in: map[string]interface{}{
+				"kind":     "Secret",
+				"metadata": map[string]interface{}{"name": "s"},
+				"data": map[string]interface{}{
+					"password": "c2VjcmV0",         // "secret"
+					"config":   "YTogMQpiOiAyCg==", // "a: 1\nb: 2\n"
+					"keystore": "AAEC/w==",         // 0x00 0x01 0x02 0xff
+				},
+			},
+			want: map[string]interface{}{
+				"kind": "Secret",
+				"metadata": map[string]interface{}{"name": "s", "annotations": map[string]interface{}{
+					decodedSecretAnnotation: "config,password",
+				}},
+				"data":       map[string]interface{}{"keystore": "AAEC/w=="},
+				"stringData": map[string]interface{}{"password": "secret", "config": "a: 1\nb: 2\n"},
+			},
+		},
+		{
+			name: "existing stringData keys are not overwritten",
+			in: map[string]interface{}{
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "s"},
+				"data":       map[string]interface{}{"token": "b2xk", "user": "YWRtaW4="},
+				"stringData": map[string]interface{}{"token": "new"},
+			},
+			want: map[string]interface{}{
+				"kind": "Secret",
+				"metadata": map[string]interface{}{"name": "s", "annotations": map[string]interface{}{
+					decodedSecretAnnotation: "user",
+				}},
+				"data":       map[string]interface{}{"token": "b2xk"},
+				"stringData": map[string]interface{}{"token": "new", "user": "admin"},
+			},
+		},
+		{
+			name: "data is dropped once every value is decoded",
+			in: map[string]interface{}{
+				"kind":     "Secret",
+				"metadata": map[string]interface{}{"name": "s"},
+				"data":     map[string]interface{}{"user": "YWRtaW4="},
+			},
+			want: map[string]interface{}{
+				"kind": "Secret",
+				"metadata": map[string]interface{}{"name": "s", "annotations": map[string]interface{}{
+					decodedSecretAnnotation: "user",
+				}},
+				"stringData": map[string]interface{}{"user": "admin"},
+			},
+		},
+		{
+			name: "nothing readable leaves the Secret untouched",
+			in: map[string]interface{}{
+				"kind":     "Secret",
+				"metadata": map[string]interface{}{"name": "s"},
+				"data":     map[string]interface{}{"keystore": "AAEC/w==", "broken": "not base64!"},
+			},
+			want: map[string]interface{}{
+				"kind":     "Secret",
+				"metadata": map[string]interface{}{"name": "s"},
+				"data":     map[string]interface{}{"keystore": "AAEC/w==", "broken": "not base64!"},
+			},
+		},
+		{
+			name: "other kinds are untouched",
+			in: map[string]interface{}{
+				"kind":     "ConfigMap",
+				"metadata": map[string]interface{}{"name": "c"},
+				"data":     map[string]interface{}{"password": "c2VjcmV0"},
+			},
+			want: map[string]interface{}{
+				"kind":     "ConfigMap",
+				"metadata": map[string]interface{}{"name": "c"},
+				"data":     map[string]interface{}{"password": "c2VjcmV0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: tt.in}}}
+			decodeSecretValues(list)
+			if got := list.Items[0].Object; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeSecretValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySecureProfile(t *testing.T) {
+	defer func(s, r, m bool, e, i string) {
+		secure, redactSecrets, stripMetadata, excludeResources, includeResources = s, r, m, e, i
+		excludedResources = nil
+	}(secure, redactSecrets, stripMetadata, excludeResources, includeResources)
+
+	tests := []struct {
+		name     string
+		secure   bool
+		exclude  string
+		include  string
+		excluded []string
+		kept     []string
+	}{
+		{name: "exclusions only", exclude: "events", excluded: []string{"events"}, kept: []string{"secrets"}},
+		{name: "secure profile", secure: true, excluded: []string{"secrets", "certificatesigningrequests", "oauthaccesstokens"}, kept: []string{"configmaps"}},
+		{name: "include wins", secure: true, exclude: "events", include: "secrets,events", excluded: []string{"oauthaccesstokens"}, kept: []string{"secrets", "events"}},
+		{name: "subresources follow their parent", exclude: "pods", excluded: []string{"pods/status"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secure, redactSecrets, stripMetadata = tt.secure, false, false
+			excludeResources, includeResources = tt.exclude, tt.include
+			applySecureProfile()
+
+			if redactSecrets != tt.secure || stripMetadata != tt.secure {
+				t.Errorf("redactSecrets=%v stripMetadata=%v, want both %v", redactSecrets, stripMetadata, tt.secure)
+			}
+			for _, name := range tt.excluded {
+				if !isExcludedResource(name) {
+					t.Errorf("%s not excluded", name)
+				}
+			}
+			for _, name := range tt.kept {
+				if isExcludedResource(name) {
+					t.Errorf("%s excluded", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// servedObjects are the collected objects kept for --serve, keyed by
+	// servedKindKey: lowercase kind qualified by the API group
+	servedObjects map[string][]*unstructured.Unstructured
+	// servedAliases map the other names of a kind (bare kind, resource name
+	// and resource.group) to the keys they may stand for
+	servedAliases map[string]map[string]bool
+	// serveListener is opened once the flags are validated, just before
+	// collecting, so a busy or invalid address fails the run early
+	serveListener net.Listener
+)
+
+// listenForServe opens the --serve listener. An address without a host (e.g.
+// :8080) listens on the loopback interface only, since the collection may hold
+// Secrets and is served without authentication; other addresses get a warning.
+func listenForServe() error {
+	host, port, err := net.SplitHostPort(serveAddr)
+	if err != nil {
+		return fmt.Errorf("invalid --serve address %q: %w", serveAddr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	address := net.JoinHostPort(host, port)
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		fmt.Fprintf(os.Stderr, "WARNING: --serve %s is reachable from other machines and has no authentication; the collection may contain Secrets.\n", address)
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on --serve address %s: %w", address, err)
+	}
+	serveListener = listener
+	return nil
+}
+
+// recordServedObjects keeps a copy of the written objects for --serve
+func recordServedObjects(resourceName string, list *unstructured.UnstructuredList) {
+	if serveAddr == "" {
+		return
+	}
+
+	if servedObjects == nil {
+		servedObjects = make(map[string][]*unstructured.Unstructured)
+		servedAliases = make(map[string]map[string]bool)
+	}
+	for i := range list.Items {
+		gvk := list.Items[i].GroupVersionKind()
+		key := servedKindKey(gvk.Kind, gvk.Group)
+		servedObjects[key] = append(servedObjects[key], list.Items[i].DeepCopy())
+
+		aliases := []string{strings.ToLower(gvk.Kind), resourceName}
+		if gvk.Group != "" {
+			aliases = append(aliases, resourceName+"."+gvk.Group)
+		}
+		for _, alias := range aliases {
+			if servedAliases[alias] == nil {
+				servedAliases[alias] = make(map[string]bool)
+			}
+			servedAliases[alias][key] = true
+		}
+	}
+}
+
+// servedKindKey names a served kind: "pod" for the core group,
+// "deployment.apps" otherwise, so that kinds of the same name in different
+// groups (Event in "" and events.k8s.io) are kept apart
+func servedKindKey(kind, group string) string {
+	key := strings.ToLower(kind)
+	if group != "" {
+		key += "." + group
+	}
+	return key
+}
+
+// serveCollection exposes the collected objects over a read-only HTTP API
+// until the process is stopped:
+//
+//	/resources                           kinds with their object counts
+//	/resources/<kind>                    every object of a kind
+//	/resources/<kind>/<namespace>/<name> one namespaced object
+//	/resources/<kind>/<name>             one cluster-scoped object
+//
+// <kind> is a kind or resource name in any case, qualified by its API group
+// (deployment.apps, deployments.apps) or bare when only one group has it.
+// Responses are JSON, or YAML with ?format=yaml or an Accept header asking
+// for YAML.
+func serveCollection() error {
+	if serveAddr == "" {
+		return nil
+	}
+
+	// Sort once up front; handlers only read
+	for _, objects := range servedObjects {
+		sort.SliceStable(objects, func(i, j int) bool {
+			if objects[i].GetNamespace() != objects[j].GetNamespace() {
+				return objects[i].GetNamespace() < objects[j].GetNamespace()
+			}
+			return objects[i].GetName() < objects[j].GetName()
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/resources", handleServedKinds)
+	mux.HandleFunc("/resources/", handleServedResources)
+
+	address := serveListener.Addr().String()
+	fmt.Printf("Serving %d kinds on http://%s/resources (Ctrl+C to stop)\n", len(servedObjects), address)
+	if err := http.Serve(serveListener, mux); err != nil {
+		return fmt.Errorf("failed to serve collection on %s: %w", address, err)
+	}
+	return nil
+}
+
+func handleServedKinds(w http.ResponseWriter, r *http.Request) {
+	if !allowReadOnly(w, r) {
+		return
+	}
+
+	counts := make(map[string]int)
+	for kind, objects := range servedObjects {
+		counts[kind] = len(objects)
+	}
+	writeServed(w, r, counts)
+}
+
+func handleServedResources(w http.ResponseWriter, r *http.Request) {
+	if !allowReadOnly(w, r) {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/resources/"), "/"), "/")
+	key, candidates := resolveServedKind(parts[0])
+	if key == "" && len(candidates) > 1 {
+		http.Error(w, fmt.Sprintf("%q is ambiguous, use one of: %s", parts[0], strings.Join(candidates, ", ")), http.StatusBadRequest)
+		return
+	}
+	if key == "" {
+		http.Error(w, fmt.Sprintf("no collected objects of kind %q", parts[0]), http.StatusNotFound)
+		return
+	}
+	objects := servedObjects[key]
+
+	var namespace, name string
+	switch len(parts) {
+	case 1:
+		items := make([]interface{}, 0, len(objects))
+		for _, object := range objects {
+			items = append(items, object.Object)
+		}
+		writeServed(w, r, map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+		return
+	case 2:
+		name = parts[1]
+	case 3:
+		namespace, name = parts[1], parts[2]
+	default:
+		http.Error(w, "expected /resources/<kind>[/<namespace>]/<name>", http.StatusNotFound)
+		return
+	}
+
+	for _, object := range objects {
+		if object.GetNamespace() == namespace && object.GetName() == name {
+			writeServed(w, r, object.Object)
+			return
+		}
+	}
+	http.Error(w, fmt.Sprintf("%s %s not found in the collection", parts[0], strings.Join(parts[1:], "/")), http.StatusNotFound)
+}
+
+// resolveServedKind returns the key of the served kind a name stands for. A
+// name that more than one kind answers to resolves to nothing and returns the
+// keys it could mean, sorted.
+func resolveServedKind(name string) (string, []string) {
+	name = strings.ToLower(name)
+	if _, ok := servedObjects[name]; ok {
+		return name, nil
+	}
+
+	var candidates []string
+	for key := range servedAliases[name] {
+		candidates = append(candidates, key)
+	}
+	sort.Strings(candidates)
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return "", candidates
+}
+
+// allowReadOnly rejects anything but GET and HEAD
+func allowReadOnly(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, "the collection is read-only", http.StatusMethodNotAllowed)
+	return false
+}
+
+// writeServed encodes a response as JSON, or as YAML when asked for
+func writeServed(w http.ResponseWriter, r *http.Request, value interface{}) {
+	if r.URL.Query().Get("format") == "yaml" || strings.Contains(r.Header.Get("Accept"), "yaml") {
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestListenForServe(t *testing.T) {
+	defer func(saved string) { serveAddr = saved }(serveAddr)
+
+	tests := []struct {
+		name     string
+		addr     string
+		wantHost string
+		wantErr  bool
+	}{
+		{name: "no host listens on loopback", addr: ":0", wantHost: "127.0.0.1"},
+		{name: "explicit loopback", addr: "127.0.0.1:0", wantHost: "127.0.0.1"},
+		{name: "missing port", addr: "localhost", wantErr: true},
+		{name: "invalid port", addr: ":notaport", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveAddr = tt.addr
+			serveListener = nil
+			err := listenForServe()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listenForServe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer serveListener.Close()
+			host, _, _ := net.SplitHostPort(serveListener.Addr().String())
+			if host != tt.wantHost {
+				t.Errorf("listening on %s, want host %s", serveListener.Addr(), tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestServedResources(t *testing.T) {
+	defer func(addr string, objects map[string][]*unstructured.Unstructured, aliases map[string]map[string]bool) {
+		serveAddr, servedObjects, servedAliases = addr, objects, aliases
+	}(serveAddr, servedObjects, servedAliases)
+	serveAddr = ":0"
+	servedObjects, servedAliases = nil, nil
+
+	pod := func(namespace, name string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": name, "namespace": namespace},
+		}}
+	}
+	node := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1", "kind": "Node", "metadata": map[string]interface{}{"name": "worker"},
+	}}
+	event := func(apiVersion, name string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion, "kind": "Event", "metadata": map[string]interface{}{"name": name, "namespace": "shop"},
+		}}
+	}
+	deployment := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "web", "namespace": "shop"},
+	}}
+	recordServedObjects("pods", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{pod("shop", "web"), pod("other", "db")}})
+	recordServedObjects("nodes", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{node}})
+	recordServedObjects("deployments", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{deployment}})
+	// The same Events are served by the core group and events.k8s.io
+	recordServedObjects("events", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{event("v1", "web.1")}})
+	recordServedObjects("events", &unstructured.UnstructuredList{Items: []unstructured.Unstructured{event("events.k8s.io/v1", "web.1")}})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		accept     string
+		wantStatus int
+		wantBody   []string
+	}{
+		{name: "kinds with counts", path: "/resources", wantStatus: http.StatusOK, wantBody: []string{`"pod": 2`, `"node": 1`, `"deployment.apps": 1`, `"event": 1`, `"event.events.k8s.io": 1`}},
+		{name: "all objects of a resource", path: "/resources/pods", wantStatus: http.StatusOK, wantBody: []string{`"web"`, `"db"`}},
+		{name: "kind in any case", path: "/resources/Pod/shop/web", wantStatus: http.StatusOK, wantBody: []string{`"namespace": "shop"`}},
+		{name: "cluster-scoped object", path: "/resources/node/worker", wantStatus: http.StatusOK, wantBody: []string{`"worker"`}},
+		{name: "YAML on request", path: "/resources/nodes/worker?format=yaml", wantStatus: http.StatusOK, wantBody: []string{"name: worker"}},
+		{name: "YAML by Accept header", path: "/resources/nodes/worker", accept: "application/yaml", wantStatus: http.StatusOK, wantBody: []string{"kind: Node"}},
+		{name: "kind qualified by its group", path: "/resources/Deployment.apps/shop/web", wantStatus: http.StatusOK, wantBody: []string{`"apps/v1"`}},
+		{name: "resource qualified by its group", path: "/resources/deployments.apps", wantStatus: http.StatusOK, wantBody: []string{`"web"`}},
+		{name: "unambiguous short name", path: "/resources/deployments/shop/web", wantStatus: http.StatusOK, wantBody: []string{`"Deployment"`}},
+		{name: "core kind by its key", path: "/resources/event", wantStatus: http.StatusOK, wantBody: []string{`"apiVersion": "v1"`}},
+		{name: "group kind by its key", path: "/resources/event.events.k8s.io", wantStatus: http.StatusOK, wantBody: []string{`"events.k8s.io/v1"`}},
+		{name: "ambiguous short name", path: "/resources/events", wantStatus: http.StatusBadRequest, wantBody: []string{"event, event.events.k8s.io"}},
+		{name: "unknown kind", path: "/resources/secrets", wantStatus: http.StatusNotFound},
+		{name: "unknown object", path: "/resources/pods/shop/missing", wantStatus: http.StatusNotFound},
+		{name: "too many segments", path: "/resources/pods/a/b/c", wantStatus: http.StatusNotFound},
+		{name: "read-only", method: http.MethodDelete, path: "/resources/pods/shop/web", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/resources", handleServedKinds)
+	mux.HandleFunc("/resources/", handleServedResources)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body missing %q:\n%s", want, rec.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// checksumsFile lists the SHA-256 of every output file, sha256sum style
+	checksumsFile = "checksums.sha256"
+	// signatureFile is the raw ed25519 signature over checksumsFile
+	signatureFile = "checksums.sha256.sig"
+)
+
+// loadSigningKey reads an ed25519 private key in PKCS#8 PEM form, as written
+// by "openssl genpkey -algorithm ed25519"
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("signing key %s is not a PEM encoded PRIVATE KEY", path)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an ed25519 key", path)
+	}
+
+	return privateKey, nil
+}
+
+// writeSignedChecksums writes checksums.sha256 for files (paths relative to
+// dir) and a detached ed25519 signature over it to checksums.sha256.sig. The
+// checksums and signature of an earlier signing are not checksummed.
+func writeSignedChecksums(dir string, files []string) error {
+	if signingKey == nil {
+		return nil
+	}
+
+	var signed []string
+	for _, file := range files {
+		if file != filepath.Join(dir, checksumsFile) && file != filepath.Join(dir, signatureFile) {
+			signed = append(signed, file)
+		}
+	}
+	sort.Strings(signed)
+
+	var checksums strings.Builder
+	for _, file := range signed {
+		sum, err := sha256File(file)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		checksums.WriteString(fmt.Sprintf("%s  %s\n", sum, filepath.ToSlash(rel)))
+	}
+
+	checksumsPath := filepath.Join(dir, checksumsFile)
+	if err := writeOutputFile(checksumsPath, []byte(checksums.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", checksumsPath, err)
+	}
+
+	signature := ed25519.Sign(signingKey, []byte(checksums.String()))
+	signaturePath := filepath.Join(dir, signatureFile)
+	if err := writeOutputFile(signaturePath, signature, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", signaturePath, err)
+	}
+
+	fmt.Printf("Signed checksums of %d files: %s\n", len(signed), signaturePath)
+	return nil
+}
+
+// sha256File returns the hex SHA-256 of a file
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
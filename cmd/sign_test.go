@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePrivateKey writes a key as PKCS#8 PEM, like "openssl genpkey"
+func writePrivateKey(t *testing.T, path string, key interface{}) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePrivateKey(t, filepath.Join(dir, "ed25519.pem"), edKey)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePrivateKey(t, filepath.Join(dir, "ecdsa.pem"), ecKey)
+
+	if err := os.WriteFile(filepath.Join(dir, "garbage.pem"), []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file    string
+		wantErr string
+	}{
+		{file: "ed25519.pem"},
+		{file: "ecdsa.pem", wantErr: "is not an ed25519 key"},
+		{file: "garbage.pem", wantErr: "is not a PEM encoded PRIVATE KEY"},
+		{file: "missing.pem", wantErr: "failed to read signing key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			key, err := loadSigningKey(filepath.Join(dir, tt.file))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("loadSigningKey() error = %v", err)
+				}
+				if !key.Equal(edKey) {
+					t.Error("loadSigningKey() returned a different key")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadSigningKey() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteSignedChecksums(t *testing.T) {
+	defer func(saved ed25519.PrivateKey) { signingKey = saved }(signingKey)
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey = privateKey
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"pods.yaml": "kind: List\n", "logs/web.log": "started\n"}
+	for name, content := range files {
+		if err := writeOutputFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A leftover of an earlier run is neither signed nor pushed
+	if err := os.WriteFile(filepath.Join(dir, "stale.yaml"), []byte("kind: List\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing twice must not checksum the previous checksums and signature
+	for i := 0; i < 2; i++ {
+		if err := writeSignedChecksums(dir, writtenOutputFiles(dir)); err != nil {
+			t.Fatalf("writeSignedChecksums() error = %v", err)
+		}
+	}
+
+	checksums, err := os.ReadFile(filepath.Join(dir, checksumsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := func(content string) string {
+		hash := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(hash[:])
+	}
+	want := sum("started\n") + "  logs/web.log\n" + sum("kind: List\n") + "  pods.yaml\n"
+	if string(checksums) != want {
+		t.Errorf("checksums =\n%s\nwant\n%s", checksums, want)
+	}
+
+	signature, err := os.ReadFile(filepath.Join(dir, signatureFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey, checksums, signature) {
+		t.Error("signature does not verify against the checksums")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Consistent snapshots (--consistent)
+//
+// Every List normally returns the latest state, so resources collected a few
+// minutes apart can disagree (a Pod whose ReplicaSet is already gone). With
+// --consistent, a cluster-wide resourceVersion is read once up front and every
+// List asks for exactly that version.
+//
+// This is best effort:
+//   - etcd compacts old revisions (every 5 minutes by default), so long
+//     collections eventually get "resource version too old" errors. Those
+//     resources fall back to resourceVersionMatch=NotOlderThan.
+//   - Aggregated APIs (e.g. metrics.k8s.io) keep their own storage and may
+//     reject the baseline version; they fall back to a plain List.
+// The summary reports how many resources could not be pinned.
+
+var (
+	// snapshotResourceVersion is the pinned baseline; empty when --consistent is off
+	snapshotResourceVersion string
+	// snapshotFallbacks counts resources that could not be listed at the baseline
+	snapshotFallbacks int
+)
+
+// prepareSnapshot reads the resourceVersion baseline when --consistent is set
+func prepareSnapshot(dynamic dynamic.Interface) error {
+	snapshotResourceVersion = ""
+	snapshotFallbacks = 0
+
+	if !consistent {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// A quorum read of any list returns the current etcd revision
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	list, err := dynamic.Resource(namespaces).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("failed to read resourceVersion baseline for --consistent: %w", err)
+	}
+
+	snapshotResourceVersion = list.GetResourceVersion()
+	if snapshotResourceVersion == "" {
+		return fmt.Errorf("failed to read resourceVersion baseline for --consistent: server returned an empty resourceVersion")
+	}
+
+	if verbose {
+		fmt.Printf("Pinned snapshot at resourceVersion %s\n", snapshotResourceVersion)
+	}
+
+	return nil
+}
+
+// listWithSnapshot lists a resource, pinning it to the snapshot baseline when one is set
+func listWithSnapshot(ctx context.Context, client dynamic.ResourceInterface, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if snapshotResourceVersion == "" {
+		return client.List(ctx, opts)
+	}
+
+	pinned := opts
+	pinned.ResourceVersion = snapshotResourceVersion
+	pinned.ResourceVersionMatch = metav1.ResourceVersionMatchExact
+	list, err := client.List(ctx, pinned)
+	if err == nil {
+		return list, nil
+	}
+
+	// The baseline was compacted away: take anything at least as new
+	if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+		snapshotFallbacks++
+		if verbose {
+			fmt.Printf("  resourceVersion %s expired, falling back to NotOlderThan\n", snapshotResourceVersion)
+		}
+		pinned.ResourceVersionMatch = metav1.ResourceVersionMatchNotOlderThan
+		return client.List(ctx, pinned)
+	}
+
+	// Aggregated APIs may not understand the baseline at all
+	if apierrors.IsBadRequest(err) || apierrors.IsInvalid(err) {
+		snapshotFallbacks++
+		if verbose {
+			fmt.Printf("  resourceVersion %s rejected, falling back to a plain list\n", snapshotResourceVersion)
+		}
+		return client.List(ctx, opts)
+	}
+
+	return nil, err
+}
+
+// printSnapshotSummary adds the snapshot details to the collection summary
+func printSnapshotSummary() {
+	if snapshotResourceVersion == "" {
+		return
+	}
+	fmt.Printf("Snapshot resourceVersion: %s\n", snapshotResourceVersion)
+	if snapshotFallbacks > 0 {
+		fmt.Printf("Not pinned to snapshot: %d resources\n", snapshotFallbacks)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// stubResource answers List calls in turn with the given errors, and an empty
+// list once they run out, recording the options of every call
+type stubResource struct {
+	dynamic.ResourceInterface
+	errs  []error
+	calls []metav1.ListOptions
+}
+
+func (r *stubResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.calls = append(r.calls, opts)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &unstructured.UnstructuredList{}, nil
+}
+
+func TestListWithSnapshot(t *testing.T) {
+	defer func(rv string, fallbacks int) {
+		snapshotResourceVersion, snapshotFallbacks = rv, fallbacks
+	}(snapshotResourceVersion, snapshotFallbacks)
+
+	gr := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name          string
+		baseline      string
+		errs          []error
+		wantErr       bool
+		wantMatches   []metav1.ResourceVersionMatch
+		wantFallbacks int
+	}{
+		{name: "not pinned without --consistent", wantMatches: []metav1.ResourceVersionMatch{""}},
+		{name: "pinned to the baseline", baseline: "42", wantMatches: []metav1.ResourceVersionMatch{metav1.ResourceVersionMatchExact}},
+		{
+			name:          "expired baseline falls back to NotOlderThan",
+			baseline:      "42",
+			errs:          []error{apierrors.NewResourceExpired("too old")},
+			wantMatches:   []metav1.ResourceVersionMatch{metav1.ResourceVersionMatchExact, metav1.ResourceVersionMatchNotOlderThan},
+			wantFallbacks: 1,
+		},
+		{
+			name:          "rejected baseline falls back to a plain list",
+			baseline:      "42",
+			errs:          []error{apierrors.NewBadRequest("unknown resourceVersion")},
+			wantMatches:   []metav1.ResourceVersionMatch{metav1.ResourceVersionMatchExact, ""},
+			wantFallbacks: 1,
+		},
+		{
+			name:        "other errors are returned",
+			baseline:    "42",
+			errs:        []error{apierrors.NewForbidden(gr, "", nil)},
+			wantErr:     true,
+			wantMatches: []metav1.ResourceVersionMatch{metav1.ResourceVersionMatchExact},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshotResourceVersion, snapshotFallbacks = tt.baseline, 0
+			client := &stubResource{errs: tt.errs}
+
+			_, err := listWithSnapshot(context.Background(), client, metav1.ListOptions{Limit: 10})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listWithSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(client.calls) != len(tt.wantMatches) {
+				t.Fatalf("got %d List calls, want %d", len(client.calls), len(tt.wantMatches))
+			}
+			for i, call := range client.calls {
+				if call.ResourceVersionMatch != tt.wantMatches[i] {
+					t.Errorf("call %d resourceVersionMatch = %q, want %q", i, call.ResourceVersionMatch, tt.wantMatches[i])
+				}
+				if wantRV := tt.baseline; tt.wantMatches[i] == "" && call.ResourceVersion != "" || tt.wantMatches[i] != "" && call.ResourceVersion != wantRV {
+					t.Errorf("call %d resourceVersion = %q", i, call.ResourceVersion)
+				}
+				if call.Limit != 10 {
+					t.Errorf("call %d dropped the list options: %+v", i, call)
+				}
+			}
+			if snapshotFallbacks != tt.wantFallbacks {
+				t.Errorf("snapshotFallbacks = %d, want %d", snapshotFallbacks, tt.wantFallbacks)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// defaultLargeThreshold is the item count from which --split-large-resources splits a resource
+const defaultLargeThreshold = 5000
+
+// isLargeResource reports whether a namespaced resource's list is split by namespace
+func isLargeResource(list *unstructured.UnstructuredList, namespaced bool) bool {
+	return splitLarge && namespaced && len(list.Items) >= largeThreshold
+}
+
+// writeSplitByNamespace writes a large resource as one file per namespace,
+// <namespace>/<file> next to where its single file would go, with the same
+// layout as --split-by-namespace in import mode. It returns the number of
+// namespaces written.
+func writeSplitByNamespace(list *unstructured.UnstructuredList, resourceName, groupVersion, filePath string) (int, error) {
+	byNamespace := make(map[string][]unstructured.Unstructured)
+	for _, item := range list.Items {
+		byNamespace[item.GetNamespace()] = append(byNamespace[item.GetNamespace()], item)
+	}
+
+	var namespaces []string
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	baseDir, fileName := filepath.Split(filePath)
+	for _, namespace := range namespaces {
+		dir := filepath.Join(baseDir, namespace)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+
+		namespaceList := &unstructured.UnstructuredList{Object: list.Object, Items: byNamespace[namespace]}
+		yamlData, err := marshalResourceList(namespaceList)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal %s to YAML: %w", resourceName, err)
+		}
+
+		namespacePath := filepath.Join(dir, fileName)
+		content := formatHeader(resourceName, groupVersion) + string(yamlData) + formatEmbeddedEvents(namespaceList)
+		if err := writeFileAtomic(namespacePath, []byte(content)); err != nil {
+			return 0, fmt.Errorf("failed to write file %s: %w", namespacePath, err)
+		}
+	}
+
+	// Drop the single file of an earlier, unsplit collection
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to remove %s: %w", filePath, err)
+	}
+
+	return len(namespaces), nil
+}
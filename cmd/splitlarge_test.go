@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsLargeResource(t *testing.T) {
+	defer func(split bool, threshold int) {
+		splitLarge, largeThreshold = split, threshold
+	}(splitLarge, largeThreshold)
+	largeThreshold = 2
+
+	list := namespacedList("ConfigMap", [2]string{"shop", "a"}, [2]string{"billing", "b"})
+	small := namespacedList("ConfigMap", [2]string{"shop", "a"})
+
+	splitLarge = false
+	if isLargeResource(list, true) {
+		t.Error("isLargeResource() without --split-large-resources = true, want false")
+	}
+
+	splitLarge = true
+	if !isLargeResource(list, true) {
+		t.Error("isLargeResource() at the threshold = false, want true")
+	}
+	if isLargeResource(small, true) {
+		t.Error("isLargeResource() below the threshold = true, want false")
+	}
+	if isLargeResource(list, false) {
+		t.Error("isLargeResource() of a cluster-scoped resource = true, want false")
+	}
+}
+
+func TestWriteSplitByNamespace(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "configmaps_v1.yaml")
+	if err := os.WriteFile(filePath, []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list := namespacedList("ConfigMap", [2]string{"shop", "a"}, [2]string{"billing", "b"}, [2]string{"shop", "c"})
+	namespaces, err := writeSplitByNamespace(list, "configmaps", "v1", filePath)
+	if err != nil {
+		t.Fatalf("writeSplitByNamespace() error = %v", err)
+	}
+	if namespaces != 2 {
+		t.Errorf("writeSplitByNamespace() wrote %d namespaces, want 2", namespaces)
+	}
+
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("unsplit file still present: %v", err)
+	}
+
+	shop, err := os.ReadFile(filepath.Join(dir, "shop", "configmaps_v1.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(shop), "name: a") || !strings.Contains(string(shop), "name: c") || strings.Contains(string(shop), "name: b") {
+		t.Errorf("shop file holds the wrong objects:\n%s", shop)
+	}
+
+	billing, err := os.ReadFile(filepath.Join(dir, "billing", "configmaps_v1.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(billing), "# Generated by k8s-resource-collector") || !strings.Contains(string(billing), "name: b") {
+		t.Errorf("billing file =\n%s", billing)
+	}
+}
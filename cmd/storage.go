@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// storageReportFile is written next to the collection when --storage-report is set
+const storageReportFile = "storage-report.txt"
+
+// volumeInfo is the part of a PersistentVolume the storage report needs
+type volumeInfo struct {
+	name         string
+	capacity     string
+	storageClass string
+	phase        string
+	claimRef     string // namespace/name from .spec.claimRef
+}
+
+// claimInfo is the part of a PersistentVolumeClaim the storage report needs
+type claimInfo struct {
+	key          string // namespace/name
+	storageClass string
+	phase        string
+	volumeName   string
+}
+
+var (
+	// storageReport is set by --storage-report
+	storageReport bool
+
+	// storageVolumes and storageClaims are collected in the current run
+	storageVolumes []volumeInfo
+	storageClaims  []claimInfo
+)
+
+// recordStorage keeps the collected PersistentVolumes and PersistentVolumeClaims for the storage report
+func recordStorage(resourceName string, list *unstructured.UnstructuredList) {
+	if !storageReport {
+		return
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		switch {
+		case resourceName == "persistentvolumes" && item.GetKind() == "PersistentVolume":
+			capacity, _, _ := unstructured.NestedString(item.Object, "spec", "capacity", "storage")
+			storageClass, _, _ := unstructured.NestedString(item.Object, "spec", "storageClassName")
+			phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+			volume := volumeInfo{name: item.GetName(), capacity: capacity, storageClass: storageClass, phase: phase}
+			if claimName, ok, _ := unstructured.NestedString(item.Object, "spec", "claimRef", "name"); ok {
+				claimNamespace, _, _ := unstructured.NestedString(item.Object, "spec", "claimRef", "namespace")
+				volume.claimRef = claimNamespace + "/" + claimName
+			}
+			storageVolumes = append(storageVolumes, volume)
+		case resourceName == "persistentvolumeclaims" && item.GetKind() == "PersistentVolumeClaim":
+			storageClass, _, _ := unstructured.NestedString(item.Object, "spec", "storageClassName")
+			phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+			volumeName, _, _ := unstructured.NestedString(item.Object, "spec", "volumeName")
+			storageClaims = append(storageClaims, claimInfo{
+				key:          item.GetNamespace() + "/" + item.GetName(),
+				storageClass: storageClass,
+				phase:        phase,
+				volumeName:   volumeName,
+			})
+		}
+	}
+}
+
+// writeStorageReport links volumes to their claims and sums capacity per StorageClass
+func writeStorageReport(path string) error {
+	if !storageReport {
+		return nil
+	}
+
+	claimsByKey := make(map[string]claimInfo)
+	for _, claim := range storageClaims {
+		claimsByKey[claim.key] = claim
+	}
+	volumesByName := make(map[string]bool)
+
+	sort.Slice(storageVolumes, func(i, j int) bool { return storageVolumes[i].name < storageVolumes[j].name })
+	sort.Slice(storageClaims, func(i, j int) bool { return storageClaims[i].key < storageClaims[j].key })
+
+	var bound, unbound []string
+	capacityByClass := make(map[string]*resource.Quantity)
+	volumesByClass := make(map[string]int)
+	for _, volume := range storageVolumes {
+		volumesByName[volume.name] = true
+
+		class := volume.storageClass
+		if class == "" {
+			class = "<none>"
+		}
+		volumesByClass[class]++
+		if quantity, err := resource.ParseQuantity(volume.capacity); err == nil {
+			if capacityByClass[class] == nil {
+				capacityByClass[class] = &resource.Quantity{}
+			}
+			capacityByClass[class].Add(quantity)
+		}
+
+		// A claimRef is only a binding if the claim points back at the volume
+		if claim, ok := claimsByKey[volume.claimRef]; ok && claim.volumeName == volume.name {
+			bound = append(bound, fmt.Sprintf("  %s (%s, %s) <-> %s (%s)", volume.name, volume.capacity, class, claim.key, claim.phase))
+			continue
+		}
+		line := fmt.Sprintf("  %s (%s, %s, %s)", volume.name, volume.capacity, class, volume.phase)
+		if volume.claimRef != "" {
+			line += fmt.Sprintf(" claimRef %s", volume.claimRef)
+		}
+		unbound = append(unbound, line)
+	}
+
+	var pending []string
+	for _, claim := range storageClaims {
+		if claim.volumeName == "" || !volumesByName[claim.volumeName] {
+			pending = append(pending, fmt.Sprintf("  %s (%s, storageClass %s)", claim.key, claim.phase, claim.storageClass))
+		}
+	}
+
+	var classes []string
+	for class := range volumesByClass {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	var report strings.Builder
+	report.WriteString("=== Storage Report ===\n")
+	writeReportSection(&report, "Bound volumes", bound)
+	writeReportSection(&report, "Volumes without a bound claim", unbound)
+	writeReportSection(&report, "Claims without a collected volume", pending)
+	report.WriteString("\nCapacity by StorageClass:\n")
+	for _, class := range classes {
+		capacity := "unknown"
+		if quantity := capacityByClass[class]; quantity != nil {
+			capacity = quantity.String()
+		}
+		report.WriteString(fmt.Sprintf("  %s: %d volumes, %s\n", class, volumesByClass[class], capacity))
+	}
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write storage report %s: %w", path, err)
+	}
+
+	fmt.Printf("Storage report: %s (%d volumes, %d claims)\n", path, len(storageVolumes), len(storageClaims))
+	return nil
+}
+
+// writeReportSection writes a titled, counted list of report lines
+func writeReportSection(report *strings.Builder, title string, lines []string) {
+	report.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(lines)))
+	for _, line := range lines {
+		report.WriteString(line + "\n")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestWriteStorageReport(t *testing.T) {
+	defer func(enabled bool, volumes []volumeInfo, claims []claimInfo) {
+		storageReport, storageVolumes, storageClaims = enabled, volumes, claims
+	}(storageReport, storageVolumes, storageClaims)
+	storageReport, storageVolumes, storageClaims = true, nil, nil
+
+	volumes := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"kind":     "PersistentVolume",
+			"metadata": map[string]interface{}{"name": "pv-data"},
+			"spec": map[string]interface{}{
+				"capacity":         map[string]interface{}{"storage": "10Gi"},
+				"storageClassName": "fast",
+				"claimRef":         map[string]interface{}{"namespace": "shop", "name": "data"},
+			},
+			"status": map[string]interface{}{"phase": "Bound"},
+		}},
+		{Object: map[string]interface{}{
+			"kind":     "PersistentVolume",
+			"metadata": map[string]interface{}{"name": "pv-stale"},
+			"spec": map[string]interface{}{
+				"capacity":         map[string]interface{}{"storage": "5Gi"},
+				"storageClassName": "fast",
+				"claimRef":         map[string]interface{}{"namespace": "shop", "name": "old"},
+			},
+			"status": map[string]interface{}{"phase": "Released"},
+		}},
+	}}
+	claims := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"kind":     "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{"name": "data", "namespace": "shop"},
+			"spec":     map[string]interface{}{"storageClassName": "fast", "volumeName": "pv-data"},
+			"status":   map[string]interface{}{"phase": "Bound"},
+		}},
+		{Object: map[string]interface{}{
+			"kind":     "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{"name": "cache", "namespace": "shop"},
+			"spec":     map[string]interface{}{"storageClassName": "slow"},
+			"status":   map[string]interface{}{"phase": "Pending"},
+		}},
+	}}
+	recordStorage("persistentvolumes", volumes)
+	recordStorage("persistentvolumeclaims", claims)
+
+	path := filepath.Join(t.TempDir(), storageReportFile)
+	if err := writeStorageReport(path); err != nil {
+		t.Fatalf("writeStorageReport() error = %v", err)
+	}
+	report, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `=== Storage Report ===
+
+Bound volumes (1):
+  pv-data (10Gi, fast) <-> shop/data (Bound)
+
+Volumes without a bound claim (1):
+  pv-stale (5Gi, fast, Released) claimRef shop/old
+
+Claims without a collected volume (1):
+  shop/cache (Pending, storageClass slow)
+
+Capacity by StorageClass:
+  fast: 2 volumes, 15Gi
+`
+	if string(report) != want {
+		t.Errorf("report =\n%s\nwant\n%s", report, want)
+	}
+}
+
+func TestWriteStorageReportDisabled(t *testing.T) {
+	defer func(enabled bool) { storageReport = enabled }(storageReport)
+	storageReport = false
+
+	path := filepath.Join(t.TempDir(), storageReportFile)
+	if err := writeStorageReport(path); err != nil {
+		t.Fatalf("writeStorageReport() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("storage report written without --storage-report: %v", err)
+	}
+}
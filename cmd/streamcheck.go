@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// streamDocument is one document of a single file, with the resource marker
+// that opened it when the separator style has one. A document opened by a bare
+// "---" is attributed to the last marked resource, since in the commented style
+// only a stray separator inside a resource starts one.
+type streamDocument struct {
+	number   int
+	line     int
+	resource string
+	fragment bool
+	content  string
+}
+
+// validateSingleFileStream re-reads the written single file parts as
+// multi-document YAML streams and checks that every document parses and is an
+// object with a kind. A marshaled value that contains a bare "---" line splits
+// its resource into fragments, which fails here and names the resource.
+func validateSingleFileStream(paths []string) error {
+	var problems []string
+	documents := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s for validation: %w", path, err)
+		}
+
+		for _, document := range splitStreamDocuments(string(data)) {
+			var object map[string]interface{}
+			if err := yaml.Unmarshal([]byte(document.content), &object); err != nil {
+				problems = append(problems, fmt.Sprintf("  %s: %s: %v", path, document.describe(), err))
+				continue
+			}
+			if object == nil {
+				// Only comments, such as the file header
+				continue
+			}
+			documents++
+			if kind, _ := object["kind"].(string); kind == "" {
+				problems = append(problems, fmt.Sprintf("  %s: %s: not an object with a kind, the stream was split inside a resource", path, document.describe()))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("single file output is not a valid multi-document YAML stream:\n%s", strings.Join(problems, "\n"))
+	}
+	if verbose {
+		fmt.Printf("Validated single file output: %d documents\n", documents)
+	}
+	return nil
+}
+
+// splitStreamDocuments splits a YAML stream on document separator lines
+// ("---", optionally followed by a comment), keeping the "# Resource: <name>"
+// marker of the commented separator style
+func splitStreamDocuments(data string) []streamDocument {
+	var documents []streamDocument
+	current := streamDocument{number: 1, line: 1}
+	var content strings.Builder
+	for i, line := range strings.Split(data, "\n") {
+		if isDocumentSeparator(line) {
+			current.content = content.String()
+			documents = append(documents, current)
+			content.Reset()
+			previous := current.resource
+			current = streamDocument{number: current.number + 1, line: i + 1}
+			if comment := strings.TrimSpace(strings.TrimPrefix(line, "---")); strings.HasPrefix(comment, "# Resource:") {
+				current.resource = strings.TrimSpace(strings.TrimPrefix(comment, "# Resource:"))
+			} else if previous != "" {
+				current.resource, current.fragment = previous, true
+			}
+			continue
+		}
+		content.WriteString(line + "\n")
+	}
+	current.content = content.String()
+	return append(documents, current)
+}
+
+// isDocumentSeparator matches the lines a YAML parser ends a document on
+func isDocumentSeparator(line string) bool {
+	if !strings.HasPrefix(line, "---") {
+		return false
+	}
+	rest := strings.TrimSpace(line[3:])
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
+
+func (d streamDocument) describe() string {
+	if d.fragment {
+		return fmt.Sprintf("document %d (line %d, inside resource %s)", d.number, d.line, d.resource)
+	}
+	if d.resource != "" {
+		return fmt.Sprintf("document %d (line %d, resource %s)", d.number, d.line, d.resource)
+	}
+	return fmt.Sprintf("document %d (line %d)", d.number, d.line)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsDocumentSeparator(t *testing.T) {
+	tests := map[string]bool{
+		"---":                        true,
+		"---   ":                     true,
+		"--- # Resource: configmaps": true,
+		"---foo":                     false,
+		"# ---":                      false,
+		"  ---":                      false,
+		"--- value":                  false,
+	}
+
+	for line, want := range tests {
+		if got := isDocumentSeparator(line); got != want {
+			t.Errorf("isDocumentSeparator(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestSplitStreamDocuments(t *testing.T) {
+	stream := "# header\n--- # Resource: configmaps\nkind: ConfigMapList\n---\nstray: true\n--- # Resource: secrets\nkind: SecretList\n"
+
+	documents := splitStreamDocuments(stream)
+	want := []streamDocument{
+		{number: 1, line: 1, content: "# header\n"},
+		{number: 2, line: 2, resource: "configmaps", content: "kind: ConfigMapList\n"},
+		{number: 3, line: 4, resource: "configmaps", fragment: true, content: "stray: true\n"},
+		{number: 4, line: 6, resource: "secrets", content: "kind: SecretList\n\n"},
+	}
+	if len(documents) != len(want) {
+		t.Fatalf("splitStreamDocuments() = %d documents, want %d: %+v", len(documents), len(want), documents)
+	}
+	for i := range want {
+		if documents[i] != want[i] {
+			t.Errorf("document %d = %+v, want %+v", i+1, documents[i], want[i])
+		}
+	}
+
+	if got := documents[2].describe(); got != "document 3 (line 4, inside resource configmaps)" {
+		t.Errorf("describe() = %q", got)
+	}
+}
+
+func TestValidateSingleFileStream(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	if err := os.WriteFile(valid, []byte("# Generated by k8s-resource-collector\n--- # Resource: configmaps\napiVersion: v1\nkind: ConfigMapList\nitems: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateSingleFileStream([]string{valid}); err != nil {
+		t.Errorf("validateSingleFileStream() of a valid stream error = %v", err)
+	}
+
+	// A value holding a bare "---" line splits its resource in two
+	split := filepath.Join(dir, "split.yaml")
+	if err := os.WriteFile(split, []byte("--- # Resource: configmaps\napiVersion: v1\nkind: ConfigMapList\n---\ndata: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := validateSingleFileStream([]string{valid, split})
+	if err == nil || !strings.Contains(err.Error(), "inside resource configmaps") || strings.Contains(err.Error(), "valid.yaml") {
+		t.Errorf("validateSingleFileStream() of a split stream error = %v, want only the split resource named", err)
+	}
+
+	if err := validateSingleFileStream([]string{filepath.Join(dir, "absent.yaml")}); err == nil {
+		t.Error("validateSingleFileStream() of a missing file should fail")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// stuckReportFile is written next to the collection when --stuck-report is set
+const stuckReportFile = "stuck-report.txt"
+
+// stuckObject is an object being deleted that still has finalizers
+type stuckObject struct {
+	key        string
+	deleting   time.Time
+	finalizers []string
+}
+
+var (
+	// stuckReport is set by --stuck-report
+	stuckReport bool
+
+	// stuckObjects are the objects stuck in Terminating seen in the current run
+	stuckObjects []stuckObject
+)
+
+// recordStuckObjects keeps the objects with a deletionTimestamp whose
+// finalizers have not been removed yet
+func recordStuckObjects(list *unstructured.UnstructuredList) {
+	if !stuckReport {
+		return
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		deleting := item.GetDeletionTimestamp()
+		finalizers := item.GetFinalizers()
+		if deleting == nil || len(finalizers) == 0 {
+			continue
+		}
+
+		key := item.GetKind() + " " + item.GetName()
+		if item.GetNamespace() != "" {
+			key = item.GetKind() + " " + item.GetNamespace() + "/" + item.GetName()
+		}
+		stuckObjects = append(stuckObjects, stuckObject{key: key, deleting: deleting.Time, finalizers: finalizers})
+	}
+}
+
+// writeStuckReport lists the objects stuck in Terminating, longest stuck
+// first, with the finalizers holding them and, per finalizer, how many objects it holds
+func writeStuckReport(path string) error {
+	if !stuckReport {
+		return nil
+	}
+
+	sort.Slice(stuckObjects, func(i, j int) bool {
+		if !stuckObjects[i].deleting.Equal(stuckObjects[j].deleting) {
+			return stuckObjects[i].deleting.Before(stuckObjects[j].deleting)
+		}
+		return stuckObjects[i].key < stuckObjects[j].key
+	})
+
+	now := time.Now()
+	byFinalizer := make(map[string]int)
+	var stuck []string
+	for _, object := range stuckObjects {
+		for _, finalizer := range object.finalizers {
+			byFinalizer[finalizer]++
+		}
+		stuck = append(stuck, fmt.Sprintf("  %s: deleting for %s (since %s), finalizers: %s",
+			object.key, now.Sub(object.deleting).Round(time.Second), object.deleting.UTC().Format(time.RFC3339), strings.Join(object.finalizers, ", ")))
+	}
+
+	var finalizers []string
+	for finalizer, count := range byFinalizer {
+		finalizers = append(finalizers, fmt.Sprintf("  %s: %d objects", finalizer, count))
+	}
+	sort.Strings(finalizers)
+
+	var report strings.Builder
+	report.WriteString("=== Objects Stuck in Terminating ===\n")
+	writeReportSection(&report, "Stuck objects, longest first", stuck)
+	writeReportSection(&report, "Finalizers holding objects", finalizers)
+
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write stuck report %s: %w", path, err)
+	}
+
+	fmt.Printf("Stuck report: %s (%d objects stuck in Terminating)\n", path, len(stuckObjects))
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// terminatingObject builds an object deleted at deleted with the given finalizers
+func terminatingObject(kind, namespace, name string, deleted time.Time, finalizers ...string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{"kind": kind}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	if !deleted.IsZero() {
+		timestamp := metav1.NewTime(deleted)
+		obj.SetDeletionTimestamp(&timestamp)
+	}
+	obj.SetFinalizers(finalizers)
+	return obj
+}
+
+func TestRecordStuckObjects(t *testing.T) {
+	defer func(enabled bool, stuck []stuckObject) {
+		stuckReport, stuckObjects = enabled, stuck
+	}(stuckReport, stuckObjects)
+	stuckReport, stuckObjects = true, nil
+
+	deleted := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	recordStuckObjects(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		terminatingObject("PersistentVolumeClaim", "shop", "data", deleted, "kubernetes.io/pvc-protection"),
+		terminatingObject("Namespace", "", "old", deleted, "kubernetes"),
+		// Deleted without finalizers: gone as soon as the API server processes it
+		terminatingObject("Pod", "shop", "web-1", deleted),
+		// Finalizers without a deletion: a live object
+		terminatingObject("Service", "shop", "web", time.Time{}, "service.kubernetes.io/load-balancer-cleanup"),
+	}})
+
+	if len(stuckObjects) != 2 {
+		t.Fatalf("stuckObjects = %+v, want the PVC and the Namespace", stuckObjects)
+	}
+	if stuckObjects[0].key != "PersistentVolumeClaim shop/data" || stuckObjects[1].key != "Namespace old" {
+		t.Errorf("stuck keys = %q, %q", stuckObjects[0].key, stuckObjects[1].key)
+	}
+	if !stuckObjects[0].deleting.Equal(deleted) {
+		t.Errorf("deleting = %v, want %v", stuckObjects[0].deleting, deleted)
+	}
+}
+
+func TestWriteStuckReport(t *testing.T) {
+	defer func(enabled bool, stuck []stuckObject) {
+		stuckReport, stuckObjects = enabled, stuck
+	}(stuckReport, stuckObjects)
+	stuckReport = true
+	stuckObjects = []stuckObject{
+		{key: "PersistentVolumeClaim shop/data", deleting: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), finalizers: []string{"kubernetes.io/pvc-protection"}},
+		{key: "Namespace old", deleting: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), finalizers: []string{"kubernetes"}},
+		{key: "PersistentVolumeClaim shop/logs", deleting: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), finalizers: []string{"kubernetes.io/pvc-protection"}},
+	}
+
+	path := filepath.Join(t.TempDir(), stuckReportFile)
+	if err := writeStuckReport(path); err != nil {
+		t.Fatalf("writeStuckReport() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+
+	// Longest stuck first, ties by key
+	namespace := strings.Index(report, "  Namespace old: deleting for ")
+	pvcData := strings.Index(report, "  PersistentVolumeClaim shop/data: deleting for ")
+	pvcLogs := strings.Index(report, "  PersistentVolumeClaim shop/logs: deleting for ")
+	if namespace < 0 || pvcData < namespace || pvcLogs < pvcData {
+		t.Errorf("stuck objects out of order:\n%s", report)
+	}
+	for _, want := range []string{
+		"Stuck objects, longest first (3):",
+		"(since 2026-09-01T00:00:00Z), finalizers: kubernetes\n",
+		"Finalizers holding objects (2):\n  kubernetes.io/pvc-protection: 2 objects\n  kubernetes: 1 objects\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// supportedSubresources lists the subresources --include-subresources can collect.
+// Others (pods/log, deployments/scale, ...) are not objects with a status to keep.
+var supportedSubresources = []string{"status"}
+
+// validateSubresources checks --include-subresources entries, which are either a
+// bare subresource ("status") or a specific one ("deployments/status")
+func validateSubresources(entries []string) error {
+	for _, entry := range entries {
+		sub := entry
+		if i := strings.Index(entry, "/"); i >= 0 {
+			if i == 0 {
+				return fmt.Errorf("invalid --include-subresources entry %q: expected <resource>/<subresource>", entry)
+			}
+			sub = entry[i+1:]
+		}
+		if !contains(supportedSubresources, sub) {
+			return fmt.Errorf("unsupported subresource %q in --include-subresources (supported: %s)",
+				entry, strings.Join(supportedSubresources, ", "))
+		}
+	}
+	return nil
+}
+
+// includeSubresource reports whether a discovered subresource (e.g. "deployments/status")
+// was requested with --include-subresources
+func includeSubresource(name string) bool {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	for _, entry := range parseList(subresources) {
+		if entry == parts[1] || entry == name {
+			return true
+		}
+	}
+	return false
+}
+
+// listSubresource lists the parent resource and keeps the subresource of each
+// object. The only supported subresource, status, serves the same object as
+// its parent, so it is read from the parent List rather than with one GET per
+// object. Only identifying metadata and the status are kept, so the result
+// reads as a list of statuses.
+func listSubresource(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+	parts := strings.SplitN(gvr.Resource, "/", 2)
+	if parts[1] != "status" {
+		return nil, fmt.Errorf("unsupported subresource %s", gvr.Resource)
+	}
+	parentGVR := gvr
+	parentGVR.Resource = parts[0]
+
+	parents, err := listResource(dynamicClient, parentGVR)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &unstructured.UnstructuredList{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+	}}
+
+	for _, parent := range parents.Items {
+		trimmed := unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": parent.GetAPIVersion(),
+			"kind":       parent.GetKind(),
+		}}
+		trimmed.SetName(parent.GetName())
+		trimmed.SetNamespace(parent.GetNamespace())
+		if status, ok := parent.Object["status"]; ok {
+			trimmed.Object["status"] = status
+		}
+
+		result.Items = append(result.Items, trimmed)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestValidateSubresources(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "bare subresource", entries: []string{"status"}},
+		{name: "specific subresource", entries: []string{"deployments/status"}},
+		{name: "unsupported subresource", entries: []string{"scale"}, wantErr: true},
+		{name: "unsupported specific subresource", entries: []string{"pods/log"}, wantErr: true},
+		{name: "missing resource", entries: []string{"/status"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSubresources(tt.entries); (err != nil) != tt.wantErr {
+				t.Errorf("validateSubresources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIncludeSubresource(t *testing.T) {
+	defer func(saved string) { subresources = saved }(subresources)
+
+	tests := []struct {
+		flag string
+		name string
+		want bool
+	}{
+		{flag: "status", name: "deployments/status", want: true},
+		{flag: "status", name: "pods/status", want: true},
+		{flag: "deployments/status", name: "deployments/status", want: true},
+		{flag: "deployments/status", name: "pods/status", want: false},
+		{flag: "status", name: "deployments/scale", want: false},
+		{flag: "status", name: "deployments", want: false},
+		{flag: "", name: "deployments/status", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag+" "+tt.name, func(t *testing.T) {
+			subresources = tt.flag
+			if got := includeSubresource(tt.name); got != tt.want {
+				t.Errorf("includeSubresource(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSubresource(t *testing.T) {
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	deployment := informerObject("apps/v1", "Deployment", "shop", "web")
+	deployment.SetLabels(map[string]string{"app": "web"})
+	deployment.Object["spec"] = map[string]interface{}{"replicas": int64(3)}
+	deployment.Object["status"] = map[string]interface{}{"readyReplicas": int64(2)}
+	pending := informerObject("apps/v1", "Deployment", "shop", "pending")
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{deploymentsGVR: "DeploymentList"}, deployment, pending)
+
+	statusGVR := deploymentsGVR
+	statusGVR.Resource = "deployments/status"
+	list, err := listSubresource(client, statusGVR)
+	if err != nil {
+		t.Fatalf("listSubresource() error = %v", err)
+	}
+
+	want := map[string]map[string]interface{}{
+		"web":     {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "web", "namespace": "shop"}, "status": map[string]interface{}{"readyReplicas": int64(2)}},
+		"pending": {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "pending", "namespace": "shop"}},
+	}
+	if len(list.Items) != len(want) {
+		t.Fatalf("listSubresource() returned %d items, want %d", len(list.Items), len(want))
+	}
+	for _, item := range list.Items {
+		if !reflect.DeepEqual(item.Object, want[item.GetName()]) {
+			t.Errorf("item %s = %v, want %v", item.GetName(), item.Object, want[item.GetName()])
+		}
+	}
+
+	// The status comes from the List, without a request per object
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "list" {
+			t.Errorf("unexpected %s request for %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+
+	scaleGVR := deploymentsGVR
+	scaleGVR.Resource = "deployments/scale"
+	if _, err := listSubresource(client, scaleGVR); err == nil {
+		t.Error("listSubresource(deployments/scale) error = nil, want unsupported")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/yaml"
+)
+
+// tableAcceptHeader asks the API server for the server-side printed form
+// (metav1.Table) that kubectl get uses, with plain JSON as a fallback
+const tableAcceptHeader = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+// tablesDirName holds one table document per resource when --table is set
+const tablesDirName = "tables"
+
+var (
+	// tableOutput is set by --table
+	tableOutput bool
+
+	// tablesWritten counts the table documents written in the current run
+	tablesWritten int
+)
+
+// writeResourceTable requests a resource as a metav1.Table and writes it to
+// dir, so the collection keeps the columns kubectl get shows (pod READY and
+// RESTARTS, deployment UP-TO-DATE, ...). Rows carry only the cells, not the
+// objects, which are already in the regular output.
+func writeResourceTable(discoveryClient discovery.DiscoveryInterface, resource metav1.APIResource, groupVersion, dir string) error {
+	if !tableOutput || strings.Contains(resource.Name, "/") {
+		return nil
+	}
+
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse group version: %w", err)
+	}
+
+	path := "/apis/" + gv.Group + "/" + gv.Version + "/" + resource.Name
+	if gv.Group == "" {
+		path = "/api/" + gv.Version + "/" + resource.Name
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, err := discoveryClient.RESTClient().Get().
+		AbsPath(path).
+		Param("includeObject", "None").
+		SetHeader("Accept", tableAcceptHeader).
+		DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get %s as a table: %w", resource.Name, err)
+	}
+
+	yamlData, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s table to YAML: %w", resource.Name, err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create tables directory: %w", err)
+	}
+
+	filePath := filepath.Join(dir, formatFilename(resource.Name, groupVersion))
+	if err := writeOutputFile(filePath, []byte(formatHeader(resource.Name, groupVersion)+string(yamlData)), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+
+	tablesWritten++
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+func TestWriteResourceTable(t *testing.T) {
+	defer func(enabled bool, written int) {
+		tableOutput, tablesWritten = enabled, written
+	}(tableOutput, tablesWritten)
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		switch r.URL.Path {
+		case "/api/v1/pods", "/apis/apps/v1/deployments":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"kind":"Table","apiVersion":"meta.k8s.io/v1","columnDefinitions":[{"name":"Name","type":"string"}],"rows":[{"cells":["web"]}]}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		enabled      bool
+		resource     string
+		groupVersion string
+		wantPath     string
+		wantErr      bool
+	}{
+		{name: "disabled", resource: "pods", groupVersion: "v1"},
+		{name: "core resource", enabled: true, resource: "pods", groupVersion: "v1", wantPath: "/api/v1/pods"},
+		{name: "grouped resource", enabled: true, resource: "deployments", groupVersion: "apps/v1", wantPath: "/apis/apps/v1/deployments"},
+		{name: "subresource skipped", enabled: true, resource: "pods/status", groupVersion: "v1"},
+		{name: "request failure", enabled: true, resource: "widgets", groupVersion: "example.com/v1", wantPath: "/apis/example.com/v1/widgets", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tableOutput, tablesWritten, requests = tt.enabled, 0, nil
+			dir := filepath.Join(t.TempDir(), tablesDirName)
+
+			err := writeResourceTable(discoveryClient, metav1.APIResource{Name: tt.resource}, tt.groupVersion, dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeResourceTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantPath == "" {
+				if len(requests) != 0 {
+					t.Errorf("requested %s, want no request", requests[0].URL.Path)
+				}
+				return
+			}
+			if len(requests) != 1 || requests[0].URL.Path != tt.wantPath {
+				t.Fatalf("requests = %v, want one to %s", requests, tt.wantPath)
+			}
+			if accept := requests[0].Header.Get("Accept"); !strings.Contains(accept, "as=Table") {
+				t.Errorf("Accept = %q, want a Table request", accept)
+			}
+			if got := requests[0].URL.Query().Get("includeObject"); got != "None" {
+				t.Errorf("includeObject = %q, want None", got)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, formatFilename(tt.resource, tt.groupVersion)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), "kind: Table") || !strings.Contains(string(data), "- web") {
+				t.Errorf("table file =\n%s\nwant the Table as YAML", data)
+			}
+			if tablesWritten != 1 {
+				t.Errorf("tablesWritten = %d, want 1", tablesWritten)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// groupByPresence sorts the items of N sets by which sets contain them: the
+// result maps a bitmask (bit i set when sets[i] has the item) to its items
+func groupByPresence(sets [][]string) map[int][]string {
+	presence := make(map[string]int)
+	for i, set := range sets {
+		for _, item := range set {
+			presence[item] |= 1 << i
+		}
+	}
+
+	groups := make(map[int][]string)
+	for item, mask := range presence {
+		groups[mask] = append(groups[mask], item)
+	}
+	for mask := range groups {
+		sort.Strings(groups[mask])
+	}
+	return groups
+}
+
+// threeWaySections names each presence pattern of (baseline, A, B), in report order
+var threeWaySections = []struct {
+	mask  int
+	title string
+}{
+	{0b110, "Added in both clusters (shared drift)"},
+	{0b001, "Removed from both clusters (shared drift)"},
+	{0b010, "Added only in %[1]s"},
+	{0b100, "Added only in %[2]s"},
+	{0b101, "Removed only from %[1]s"},
+	{0b011, "Removed only from %[2]s"},
+}
+
+// writeThreeWayReport compares two collections against the --compare-three-way
+// baseline and tells shared drift (both clusters moved away from the baseline
+// the same way) from drift unique to one cluster. Resource types and objects
+// are grouped by which of the three collections have them; objects in all
+// three are compared field by field.
+func writeThreeWayReport(compareDir, file1, file2, name1, name2 string) error {
+	if threeWayBaseline == "" {
+		return nil
+	}
+
+	files := []string{threeWayBaseline, file1, file2}
+	var resourceSets, objectSets [][]string
+	var objects []map[string]map[string]interface{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		resourceSets = append(resourceSets, parseResources(string(content)))
+
+		collection, err := loadCollectionObjects(file)
+		if err != nil {
+			return err
+		}
+		var keys []string
+		for key := range collection {
+			keys = append(keys, key)
+		}
+		objects = append(objects, collection)
+		objectSets = append(objectSets, keys)
+	}
+
+	baseName := fmt.Sprintf("baseline (%s)", filepath.Base(threeWayBaseline))
+	var report strings.Builder
+	report.WriteString("=== Three-Way Comparison Report ===\n")
+	report.WriteString(fmt.Sprintf("Generated at: %s\n", time.Now().Format(time.RFC3339)))
+	report.WriteString(fmt.Sprintf("Baseline: %s\nCluster A: %s\nCluster B: %s\n", baseName, name1, name2))
+
+	resourceGroups := groupByPresence(resourceSets)
+	objectGroups := groupByPresence(objectSets)
+	writeThreeWaySections(&report, "Resource types", resourceGroups, name1, name2)
+	writeThreeWaySections(&report, "Objects", objectGroups, name1, name2)
+
+	shared, divergent, onlyA, onlyB := writeThreeWayFieldDrift(&report, objectGroups[0b111], objects, name1, name2)
+
+	report.WriteString("\n=== Summary ===\n")
+	report.WriteString(fmt.Sprintf("Objects in all three: %d\n", len(objectGroups[0b111])))
+	report.WriteString(fmt.Sprintf("Objects added or removed the same way in both clusters: %d\n", len(objectGroups[0b110])+len(objectGroups[0b001])))
+	report.WriteString(fmt.Sprintf("Objects added or removed in one cluster only: %d\n",
+		len(objectGroups[0b010])+len(objectGroups[0b100])+len(objectGroups[0b101])+len(objectGroups[0b011])))
+	report.WriteString(fmt.Sprintf("Field changes shared by both clusters: %d\n", shared))
+	report.WriteString(fmt.Sprintf("Field changes diverging between the clusters: %d\n", divergent))
+	report.WriteString(fmt.Sprintf("Field changes only in %s: %d, only in %s: %d\n", name1, onlyA, name2, onlyB))
+
+	path := filepath.Join(compareDir, fmt.Sprintf("three-way-%s-%s-vs-%s.txt",
+		sanitizeClusterName(strings.TrimSuffix(filepath.Base(threeWayBaseline), filepath.Ext(threeWayBaseline))),
+		sanitizeClusterName(name1), sanitizeClusterName(name2)))
+	if err := writeOutputFile(path, []byte(report.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write three-way report %s: %w", path, err)
+	}
+	fmt.Printf("✓ Three-way report saved to: %s\n", path)
+	return nil
+}
+
+func writeThreeWaySections(report *strings.Builder, title string, groups map[int][]string, name1, name2 string) {
+	report.WriteString(fmt.Sprintf("\n=== %s ===\n", title))
+	report.WriteString(fmt.Sprintf("Unchanged in both clusters: %d\n", len(groups[0b111])))
+	for _, section := range threeWaySections {
+		lines := make([]string, 0, len(groups[section.mask]))
+		for _, item := range groups[section.mask] {
+			lines = append(lines, "  "+item)
+		}
+		writeReportSection(report, fmt.Sprintf(section.title, name1, name2), lines)
+	}
+}
+
+// writeThreeWayFieldDrift diffs each object present in all three collections
+// against the baseline in both clusters. A field both clusters changed to the
+// same value is shared drift; changed to different values, it diverges.
+func writeThreeWayFieldDrift(report *strings.Builder, keys []string, objects []map[string]map[string]interface{}, name1, name2 string) (int, int, int, int) {
+	shared, divergent, onlyA, onlyB := 0, 0, 0, 0
+	report.WriteString("\n=== Field-level drift from the baseline ===\n")
+	for _, key := range keys {
+		changesA := make(map[string]fieldChange)
+		for _, change := range diffObjects(objects[0][key], objects[1][key]) {
+			changesA[change.Path] = change
+		}
+		changesB := make(map[string]fieldChange)
+		for _, change := range diffObjects(objects[0][key], objects[2][key]) {
+			changesB[change.Path] = change
+		}
+		if len(changesA) == 0 && len(changesB) == 0 {
+			continue
+		}
+
+		var paths []string
+		for path := range changesA {
+			paths = append(paths, path)
+		}
+		for path := range changesB {
+			if _, ok := changesA[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+
+		report.WriteString(fmt.Sprintf("\n%s\n", key))
+		for _, path := range paths {
+			a, inA := changesA[path]
+			b, inB := changesB[path]
+			switch {
+			case inA && inB && formatFieldValue(a.After, a.InAfter) == formatFieldValue(b.After, b.InAfter):
+				shared++
+				report.WriteString(fmt.Sprintf("  shared     %s: %s -> %s\n", path, formatFieldValue(a.Before, a.InBefore), formatFieldValue(a.After, a.InAfter)))
+			case inA && inB:
+				divergent++
+				report.WriteString(fmt.Sprintf("  divergent  %s: %s -> %s (%s), %s (%s)\n", path, formatFieldValue(a.Before, a.InBefore),
+					formatFieldValue(a.After, a.InAfter), name1, formatFieldValue(b.After, b.InAfter), name2))
+			case inA:
+				onlyA++
+				report.WriteString(fmt.Sprintf("  only %s  %s: %s -> %s\n", name1, path, formatFieldValue(a.Before, a.InBefore), formatFieldValue(a.After, a.InAfter)))
+			default:
+				onlyB++
+				report.WriteString(fmt.Sprintf("  only %s  %s: %s -> %s\n", name2, path, formatFieldValue(b.Before, b.InBefore), formatFieldValue(b.After, b.InAfter)))
+			}
+		}
+	}
+	return shared, divergent, onlyA, onlyB
+}
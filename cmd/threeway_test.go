@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGroupByPresence(t *testing.T) {
+	groups := groupByPresence([][]string{
+		{"configmaps", "secrets", "services"},
+		{"configmaps", "secrets", "routes"},
+		{"configmaps", "routes", "jobs"},
+	})
+
+	want := map[int][]string{
+		0b111: {"configmaps"},
+		0b011: {"secrets"},
+		0b001: {"services"},
+		0b110: {"routes"},
+		0b100: {"jobs"},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("groupByPresence() = %v, want %v", groups, want)
+	}
+}
+
+func TestWriteThreeWayFieldDrift(t *testing.T) {
+	key := "Deployment shop/web"
+	unchanged := "ConfigMap shop/settings"
+	objects := []map[string]map[string]interface{}{
+		{
+			key:       {"spec": map[string]interface{}{"replicas": 2, "paused": false, "image": "web:1"}},
+			unchanged: {"data": map[string]interface{}{"mode": "fast"}},
+		},
+		{
+			key:       {"spec": map[string]interface{}{"replicas": 3, "paused": true, "image": "web:2"}},
+			unchanged: {"data": map[string]interface{}{"mode": "fast"}},
+		},
+		{
+			key:       {"spec": map[string]interface{}{"replicas": 3, "paused": false, "image": "web:3", "minReadySeconds": 5}},
+			unchanged: {"data": map[string]interface{}{"mode": "fast"}},
+		},
+	}
+
+	var report strings.Builder
+	shared, divergent, onlyA, onlyB := writeThreeWayFieldDrift(&report, []string{unchanged, key}, objects, "prod", "staging")
+
+	want := `
+=== Field-level drift from the baseline ===
+
+Deployment shop/web
+  divergent  spec.image: web:1 -> web:2 (prod), web:3 (staging)
+  only staging  spec.minReadySeconds: <absent> -> 5
+  only prod  spec.paused: false -> true
+  shared     spec.replicas: 2 -> 3
+`
+	if report.String() != want {
+		t.Errorf("report =\n%s\nwant\n%s", report.String(), want)
+	}
+	if shared != 1 || divergent != 1 || onlyA != 1 || onlyB != 1 {
+		t.Errorf("writeThreeWayFieldDrift() = %d, %d, %d, %d, want 1, 1, 1, 1", shared, divergent, onlyA, onlyB)
+	}
+}
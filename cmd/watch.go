@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// Watch options
+var (
+	watchInterval time.Duration
+	watchCount    int
+)
+
+// snapshotDirFormat names each snapshot directory after its UTC start time
+const snapshotDirFormat = "20060102T150405Z"
+
+// minWatchInterval keeps snapshot directory names unique
+const minWatchInterval = time.Second
+
+// runWatchMode collects a snapshot every --watch-interval into
+// <output>/<timestamp>, until --watch-count snapshots were taken or the run is
+// interrupted; an interrupt lets the current snapshot finish. Lists are served
+// from informer caches, so after the first snapshot the API server only
+// streams changes.
+func runWatchMode(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) error {
+	root := outputDir
+	if singleFile {
+		if outputFile == "" {
+			outputFile = "./output/all-resources.yaml"
+		}
+		root = filepath.Dir(outputFile)
+
+		if maxFileSize != "" {
+			size, err := parseByteSize(maxFileSize)
+			if err != nil {
+				return fmt.Errorf("invalid --max-file-size: %w", err)
+			}
+			maxFileSizeBytes = size
+		}
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if clean {
+		if err := cleanDirectory(root); err != nil {
+			return fmt.Errorf("failed to clean output directory: %w", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cached := newInformerDynamicClient(dynamicClient)
+	defer cached.Stop()
+
+	fmt.Printf("Watching the cluster: a snapshot every %v into %s (interrupt to stop)\n", watchInterval, root)
+
+	taken, failed := 0, 0
+	for {
+		started := time.Now()
+		dir := filepath.Join(root, started.UTC().Format(snapshotDirFormat))
+
+		err := os.MkdirAll(dir, 0755)
+		if err == nil && singleFile {
+			err = collectAllResourcesToSingleFile(discoveryClient, cached, filepath.Join(dir, filepath.Base(outputFile)))
+		} else if err == nil {
+			err = collectResources(discoveryClient, cached, dir)
+		}
+		taken++
+
+		// One failed snapshot does not end the watch; the next one may succeed
+		if err != nil {
+			failed++
+			fmt.Printf("\nSnapshot %d failed: %v\n", taken, err)
+		} else {
+			fmt.Printf("\nSnapshot %d written to %s in %v (%d resources cached)\n",
+				taken, dir, time.Since(started).Round(time.Millisecond), cached.CachedResources())
+		}
+
+		if watchCount > 0 && taken >= watchCount {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Until(started.Add(watchInterval))):
+			continue
+		}
+		break
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d snapshots failed", failed, taken)
+	}
+	return nil
+}
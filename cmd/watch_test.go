@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRunWatchMode(t *testing.T) {
+	defer func(dir string, single bool, interval time.Duration, count int, gvrs []schema.GroupVersionResource) {
+		outputDir, singleFile, watchInterval, watchCount, explicitGVRs = dir, single, interval, count, gvrs
+	}(outputDir, singleFile, watchInterval, watchCount, explicitGVRs)
+
+	configMapsGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	outputDir, singleFile, explicitGVRs = t.TempDir(), false, []schema.GroupVersionResource{configMapsGVR}
+	watchInterval, watchCount = time.Second, 2
+
+	discoveryClient := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"get", "list", "watch"}}},
+	}}}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{configMapsGVR: "ConfigMapList"},
+		informerObject("v1", "ConfigMap", "prod", "settings"),
+	)
+
+	if err := runWatchMode(discoveryClient, dynamicClient); err != nil {
+		t.Fatalf("runWatchMode() error = %v", err)
+	}
+
+	snapshots, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("%d snapshots, want 2", len(snapshots))
+	}
+	for _, snapshot := range snapshots {
+		data, err := os.ReadFile(filepath.Join(outputDir, snapshot.Name(), "v1-configmaps.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "name: settings") {
+			t.Errorf("snapshot %s is missing the ConfigMap:\n%s", snapshot.Name(), data)
+		}
+	}
+
+	lists := 0
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "list" && action.GetResource().Resource == "configmaps" {
+			lists++
+		}
+	}
+	if lists != 1 {
+		t.Errorf("configmaps were listed %d times for 2 snapshots, want once", lists)
+	}
+}
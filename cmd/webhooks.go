@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// webhookConfigurationGVRs are the admission webhook registration resources
+var webhookConfigurationGVRs = []schema.GroupVersionResource{
+	{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"},
+}
+
+// webhookTrust is the triage view of one webhook in a configuration
+type webhookTrust struct {
+	Configuration string
+	Kind          string
+	Name          string
+	Target        string
+	FailurePolicy string
+	HasCABundle   bool
+}
+
+// runWebhooksMode collects the admission webhook configurations and summarizes
+// where each webhook calls out to, what happens when it fails and whether it
+// carries a caBundle
+func runWebhooksMode(dynamicClient dynamic.Interface) error {
+	startTime := time.Now()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var webhooks []webhookTrust
+	for _, gvr := range webhookConfigurationGVRs {
+		list, err := collectFocusedList(dynamicClient, gvr)
+		if err != nil {
+			return err
+		}
+
+		for i := range list.Items {
+			webhooks = append(webhooks, webhookTrustOf(&list.Items[i])...)
+		}
+	}
+	sort.SliceStable(webhooks, func(i, j int) bool {
+		if webhooks[i].Kind != webhooks[j].Kind {
+			return webhooks[i].Kind > webhooks[j].Kind
+		}
+		return webhooks[i].Configuration < webhooks[j].Configuration
+	})
+
+	report := formatWebhooksReport(webhooks)
+	reportPath := filepath.Join(outputDir, "webhooks-summary.txt")
+	if err := writeOutputFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reportPath, err)
+	}
+	fmt.Print(report)
+
+	// Print summary
+	duration := time.Since(startTime)
+	fmt.Printf("\n=== Webhook Summary ===\n")
+	fmt.Printf("Webhooks: %d\n", len(webhooks))
+	fmt.Printf("Output directory: %s\n", outputDir)
+	fmt.Printf("Summary: %s\n", reportPath)
+	fmt.Printf("Duration: %v\n", duration)
+	fmt.Printf("=======================\n")
+
+	return nil
+}
+
+// webhookTrustOf reads the webhooks of one validating or mutating configuration
+func webhookTrustOf(configuration *unstructured.Unstructured) []webhookTrust {
+	kind := strings.TrimSuffix(configuration.GetKind(), "WebhookConfiguration")
+
+	var webhooks []webhookTrust
+	entries, _, _ := unstructured.NestedSlice(configuration.Object, "webhooks")
+	for _, entry := range entries {
+		w, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		trust := webhookTrust{Configuration: configuration.GetName(), Kind: kind, FailurePolicy: "Fail"}
+		trust.Name, _ = w["name"].(string)
+		if policy, ok := w["failurePolicy"].(string); ok && policy != "" {
+			trust.FailurePolicy = policy
+		}
+
+		if url, ok, _ := unstructured.NestedString(w, "clientConfig", "url"); ok {
+			trust.Target = url
+		} else {
+			namespace, _, _ := unstructured.NestedString(w, "clientConfig", "service", "namespace")
+			name, _, _ := unstructured.NestedString(w, "clientConfig", "service", "name")
+			trust.Target = "service " + namespace + "/" + name
+			if port, ok, _ := unstructured.NestedInt64(w, "clientConfig", "service", "port"); ok {
+				trust.Target += fmt.Sprintf(":%d", port)
+			}
+			if path, ok, _ := unstructured.NestedString(w, "clientConfig", "service", "path"); ok {
+				trust.Target += path
+			}
+		}
+
+		caBundle, _, _ := unstructured.NestedString(w, "clientConfig", "caBundle")
+		trust.HasCABundle = caBundle != ""
+
+		webhooks = append(webhooks, trust)
+	}
+
+	return webhooks
+}
+
+// formatWebhooksReport lists every webhook, flagging those that block
+// admission on failure (failurePolicy Fail) and those without a caBundle
+func formatWebhooksReport(webhooks []webhookTrust) string {
+	blocking := 0
+	var report strings.Builder
+	report.WriteString("=== Admission Webhooks ===\n\n")
+	for _, w := range webhooks {
+		caBundle := "caBundle present"
+		if !w.HasCABundle {
+			caBundle = "NO caBundle"
+		}
+		if w.FailurePolicy == "Fail" {
+			blocking++
+		}
+		report.WriteString(fmt.Sprintf("%s %s/%s\n  target: %s\n  failurePolicy: %s, %s\n",
+			w.Kind, w.Configuration, w.Name, w.Target, w.FailurePolicy, caBundle))
+	}
+	if len(webhooks) == 0 {
+		report.WriteString("No admission webhooks configured\n")
+	}
+	report.WriteString(fmt.Sprintf("\n%d webhooks, %d with failurePolicy Fail (requests are rejected while they are unreachable)\n", len(webhooks), blocking))
+
+	return report.String()
+}
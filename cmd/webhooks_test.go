@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestWebhookTrustOf(t *testing.T) {
+	configuration := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingWebhookConfiguration",
+		"metadata":   map[string]interface{}{"name": "policy"},
+		"webhooks": []interface{}{
+			map[string]interface{}{
+				"name": "pods.policy.example.com",
+				"clientConfig": map[string]interface{}{
+					"service":  map[string]interface{}{"namespace": "policy", "name": "webhook", "port": int64(8443), "path": "/validate"},
+					"caBundle": "LS0tLS1CRUdJTg==",
+				},
+			},
+			map[string]interface{}{
+				"name":          "external.policy.example.com",
+				"failurePolicy": "Ignore",
+				"clientConfig":  map[string]interface{}{"url": "https://hooks.example.com/validate"},
+			},
+		},
+	}}
+
+	want := []webhookTrust{
+		{Configuration: "policy", Kind: "Validating", Name: "pods.policy.example.com", Target: "service policy/webhook:8443/validate", FailurePolicy: "Fail", HasCABundle: true},
+		{Configuration: "policy", Kind: "Validating", Name: "external.policy.example.com", Target: "https://hooks.example.com/validate", FailurePolicy: "Ignore"},
+	}
+	if got := webhookTrustOf(configuration); !reflect.DeepEqual(got, want) {
+		t.Errorf("webhookTrustOf() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFormatWebhooksReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		webhooks []webhookTrust
+		want     string
+	}{
+		{
+			name: "no webhooks",
+			want: "=== Admission Webhooks ===\n\nNo admission webhooks configured\n\n0 webhooks, 0 with failurePolicy Fail (requests are rejected while they are unreachable)\n",
+		},
+		{
+			name: "blocking webhook without caBundle",
+			webhooks: []webhookTrust{
+				{Configuration: "injector", Kind: "Mutating", Name: "sidecar.example.com", Target: "service mesh/injector", FailurePolicy: "Fail"},
+				{Configuration: "policy", Kind: "Validating", Name: "pods.example.com", Target: "https://hooks.example.com", FailurePolicy: "Ignore", HasCABundle: true},
+			},
+			want: `=== Admission Webhooks ===
+
+Mutating injector/sidecar.example.com
+  target: service mesh/injector
+  failurePolicy: Fail, NO caBundle
+Validating policy/pods.example.com
+  target: https://hooks.example.com
+  failurePolicy: Ignore, caBundle present
+
+2 webhooks, 1 with failurePolicy Fail (requests are rejected while they are unreachable)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatWebhooksReport(tt.webhooks); got != tt.want {
+				t.Errorf("formatWebhooksReport() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// defaultContextLines is the number of unchanged lines shown around each change
+const defaultContextLines = 3
+
+// diffOp is one line of a line diff: ' ' unchanged, '-' removed, '+' added
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// formatObjectDiff renders the YAML of two versions of an object as unified
+// diff hunks, like diff -U, with contextLines unchanged lines around each
+// change. noisyFields are removed first so they never show up as changes.
+func formatObjectDiff(before, after map[string]interface{}, contextLines int) (string, error) {
+	beforeYAML, err := yaml.Marshal(withoutNoisyFields("", before))
+	if err != nil {
+		return "", err
+	}
+	afterYAML, err := yaml.Marshal(withoutNoisyFields("", after))
+	if err != nil {
+		return "", err
+	}
+
+	ops := diffLines(strings.Split(strings.TrimSuffix(string(beforeYAML), "\n"), "\n"),
+		strings.Split(strings.TrimSuffix(string(afterYAML), "\n"), "\n"))
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		// Extend the hunk while the gap to the next change fits in the context
+		last := first
+		for next := first + 1; next < len(ops); next++ {
+			if ops[next].kind == ' ' {
+				continue
+			}
+			if next-last-1 > 2*contextLines {
+				break
+			}
+			last = next
+		}
+
+		hunkStart := first - contextLines
+		if hunkStart < start {
+			hunkStart = start
+		}
+		hunkEnd := last + contextLines + 1
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		writeHunk(&out, ops, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	return out.String(), nil
+}
+
+// writeHunk writes ops[from:to] with a "@@ -a,b +c,d @@" header
+func writeHunk(out *strings.Builder, ops []diffOp, from, to int) {
+	beforeLine, afterLine := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			beforeLine++
+		}
+		if op.kind != '-' {
+			afterLine++
+		}
+	}
+
+	beforeCount, afterCount := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			beforeCount++
+		}
+		if op.kind != '-' {
+			afterCount++
+		}
+	}
+
+	out.WriteString(fmt.Sprintf("  @@ -%d,%d +%d,%d @@\n", beforeLine, beforeCount, afterLine, afterCount))
+	for _, op := range ops[from:to] {
+		out.WriteString(fmt.Sprintf("  %c%s\n", op.kind, op.line))
+	}
+}
+
+// diffLines computes a line diff from the longest common subsequence. The
+// common prefix and suffix are trimmed first, so a one-field change in a large
+// object only runs the quadratic part on the lines around it.
+func diffLines(before, after []string) []diffOp {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range before[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	b := before[prefix : len(before)-suffix]
+	a := after[prefix : len(after)-suffix]
+
+	// lcs[i][j] is the LCS length of b[i:] and a[j:]
+	lcs := make([][]int, len(b)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(a)+1)
+	}
+	for i := len(b) - 1; i >= 0; i-- {
+		for j := len(a) - 1; j >= 0; j-- {
+			if b[i] == a[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(b) && j < len(a) {
+		switch {
+		case b[i] == a[j]:
+			ops = append(ops, diffOp{' ', b[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', b[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', a[j]})
+			j++
+		}
+	}
+	for ; i < len(b); i++ {
+		ops = append(ops, diffOp{'-', b[i]})
+	}
+	for ; j < len(a); j++ {
+		ops = append(ops, diffOp{'+', a[j]})
+	}
+
+	for _, line := range before[len(before)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops
+}
+
+// withoutNoisyFields returns a copy of value without the noisyFields paths
+func withoutNoisyFields(path string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			childPath := joinFieldPath(path, key)
+			if isNoisyField(childPath) {
+				continue
+			}
+			copied[key] = withoutNoisyFields(childPath, child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = withoutNoisyFields(fmt.Sprintf("%s[%d]", path, i), child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
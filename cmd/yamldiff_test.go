@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+		want   []diffOp
+	}{
+		{
+			name:   "identical",
+			before: []string{"a", "b"},
+			after:  []string{"a", "b"},
+			want:   []diffOp{{' ', "a"}, {' ', "b"}},
+		},
+		{
+			name:   "changed line between common prefix and suffix",
+			before: []string{"a", "b", "c"},
+			after:  []string{"a", "x", "c"},
+			want:   []diffOp{{' ', "a"}, {'-', "b"}, {'+', "x"}, {' ', "c"}},
+		},
+		{
+			name:   "added and removed lines",
+			before: []string{"a", "b", "c", "d"},
+			after:  []string{"b", "c", "e", "d"},
+			want:   []diffOp{{'-', "a"}, {' ', "b"}, {' ', "c"}, {'+', "e"}, {' ', "d"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatObjectDiff(t *testing.T) {
+	before := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "resourceVersion": "1"},
+		"spec":     map[string]interface{}{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7, "h": 8, "i": 9, "replicas": 1},
+	}
+	after := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "resourceVersion": "2"},
+		"spec":     map[string]interface{}{"a": 0, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7, "h": 8, "i": 9, "replicas": 3},
+	}
+
+	tests := []struct {
+		name         string
+		contextLines int
+		want         string
+	}{
+		{
+			name:         "one line of context splits the hunks",
+			contextLines: 1,
+			want: `  @@ -3,3 +3,3 @@
+   spec:
+  -  a: 1
+  +  a: 0
+     b: 2
+  @@ -12,2 +12,2 @@
+     i: 9
+  -  replicas: 1
+  +  replicas: 3
+`,
+		},
+		{
+			name:         "wide context merges the hunks",
+			contextLines: 4,
+			want: `  @@ -1,13 +1,13 @@
+   metadata:
+     name: web
+   spec:
+  -  a: 1
+  +  a: 0
+     b: 2
+     c: 3
+     d: 4
+     e: 5
+     f: 6
+     g: 7
+     h: 8
+     i: 9
+  -  replicas: 1
+  +  replicas: 3
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatObjectDiff(before, after, tt.contextLines)
+			if err != nil {
+				t.Fatalf("formatObjectDiff() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatObjectDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if got, _ := formatObjectDiff(before, before, 3); got != "" {
+		t.Errorf("formatObjectDiff() of identical objects =\n%s\nwant no hunks", got)
+	}
+}
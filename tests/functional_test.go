@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,6 +10,11 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 // Test configuration
@@ -109,6 +116,22 @@ func TestHelpCommand(t *testing.T) {
 	suite.PrintSummary()
 }
 
+// TestListPresets tests that --list-presets prints the presets and exits
+func TestListPresets(t *testing.T) {
+	suite := NewTestSuite()
+
+	output, err := RunCommand("--list-presets")
+	if err != nil {
+		suite.AddResult("List Presets", false, "--list-presets failed", err)
+	} else if strings.Contains(output, "PRESET") && strings.Contains(output, "backup") {
+		suite.AddResult("List Presets", true, "Presets listed correctly", nil)
+	} else {
+		suite.AddResult("List Presets", false, "Preset list format incorrect", nil)
+	}
+
+	suite.PrintSummary()
+}
+
 // TestInvalidArguments tests invalid command line arguments
 func TestInvalidArguments(t *testing.T) {
 	suite := NewTestSuite()
@@ -193,6 +216,395 @@ func TestCleanMode(t *testing.T) {
 	suite.PrintSummary()
 }
 
+// writeFixture writes a must-gather fixture file, creating its directory
+func writeFixture(path, content string) {
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(content), 0644)
+}
+
+// configMapFixture is a ConfigMap document for must-gather fixtures
+func configMapFixture(name, namespace, value string) string {
+	return fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\n  namespace: %s\ndata:\n  key: %s\n", name, namespace, value)
+}
+
+// TestNDJSONFormat tests that --format ndjson writes one JSON object per line
+func TestNDJSONFormat(t *testing.T) {
+	suite := NewTestSuite()
+
+	testDir := filepath.Join(testDir, "ndjson-test")
+
+	mustGather := filepath.Join(testDir, "must-gather")
+	writeFixture(filepath.Join(mustGather, "namespaces", "shop", "core", "configmaps.yaml"), configMapFixture("app", "shop", "value"))
+	writeFixture(filepath.Join(mustGather, "namespaces", "other", "core", "configmaps.yaml"), configMapFixture("unrelated", "other", "value"))
+
+	outputDir := filepath.Join(testDir, "output")
+	output, err := RunCommand("--must-gather", mustGather, "--single-file", "--format", "ndjson", "--output", outputDir)
+	data, readErr := os.ReadFile(filepath.Join(outputDir, "all-resources.ndjson"))
+	if err != nil {
+		suite.AddResult("NDJSON Format", false, "Must-gather processing failed: "+output, err)
+	} else if readErr != nil {
+		suite.AddResult("NDJSON Format", false, "NDJSON file not written", readErr)
+	} else {
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		valid := len(lines) == 2
+		for _, line := range lines {
+			var object map[string]interface{}
+			if json.Unmarshal([]byte(line), &object) != nil || object["kind"] != "ConfigMap" {
+				valid = false
+			}
+		}
+		if valid {
+			suite.AddResult("NDJSON Format", true, "One JSON object per line", nil)
+		} else {
+			suite.AddResult("NDJSON Format", false, "Expected one ConfigMap JSON object per line, got: "+string(data), nil)
+		}
+	}
+
+	// NDJSON is only written as a single file
+	output, err = RunCommand("--must-gather", mustGather, "--format", "ndjson", "--output", outputDir)
+	if err != nil && strings.Contains(output, "--format ndjson is only supported for must-gather single file processing") {
+		suite.AddResult("NDJSON Format Validation", true, "Correctly rejects directory output", nil)
+	} else {
+		suite.AddResult("NDJSON Format Validation", false, "Should reject --format ndjson in directory mode: "+output, err)
+	}
+
+	// Clean up
+	os.RemoveAll(testDir)
+
+	suite.PrintSummary()
+}
+
+// TestFlagValidation tests that invalid flag combinations are rejected before
+// anything is collected
+func TestFlagValidation(t *testing.T) {
+	suite := NewTestSuite()
+
+	testCases := []struct {
+		name    string
+		args    []string
+		message string
+	}{
+		{"Diff Only Without Comparison", []string{"--diff-only"}, "--diff-only requires comparison mode"},
+		{"Timeout Per Namespace Without Explicit Namespaces", []string{"--timeout-per-namespace", "1s"}, "--timeout-per-namespace must be positive and requires --all-namespaces-explicit"},
+		{"Negative Context Lines", []string{"--context-lines", "-1"}, "--context-lines must not be negative"},
+		{"Baseline Without Single File Mode", []string{"--baseline", "last-week.yaml"}, "--baseline needs single file mode"},
+		{"Negative Max Total Items", []string{"--max-total-items", "-1"}, "--max-total-items must not be negative"},
+		{"JSON Schema Check In Must-Gather Mode", []string{"--output-json-schema", "--must-gather", "must-gather.local"}, "--output-json-schema applies to live collections"},
+		{"JSON Schema Check With Anonymize", []string{"--output-json-schema", "--anonymize"}, "--anonymize cannot be used with --output-json-schema"},
+		{"JSON Schema Check With Secure", []string{"--output-json-schema", "--secure"}, "--secure cannot be used with --output-json-schema"},
+		{"JSON Schema Check With Redact Regex", []string{"--output-json-schema", "--redact-regex", "secret"}, "--redact-regex cannot be used with --output-json-schema"},
+		{"Split Large Resources With Resume", []string{"--split-large-resources", "--resume"}, "--split-large-resources cannot be combined with --resume"},
+		{"Non-Positive Large Threshold", []string{"--split-large-resources", "--large-threshold", "0"}, "--large-threshold must be positive"},
+		{"CRD Target With GVR", []string{"--crd", "cert-manager.io/Certificate", "--gvr", "v1/pods"}, "--crd and --gvr are mutually exclusive"},
+		{"Negative Retries", []string{"--retries", "-1"}, "--retries must not be negative"},
+		{"Kustomize With Resume", []string{"--kustomize", "--resume"}, "--kustomize writes its own layout"},
+		{"Prune Defaults File Without Prune Defaults", []string{"--prune-defaults-file", "rules.yaml"}, "--prune-defaults-file requires --prune-defaults"},
+		{"Operator With CRD Target", []string{"--operator", "cert-manager", "--crd", "cert-manager.io/Certificate"}, "--operator cannot be combined with --gvr or --crd"},
+		{"Seed Without Anonymize", []string{"--seed", "case-42"}, "--seed requires --anonymize"},
+		{"Three-Way Without Comparison", []string{"--compare-three-way", "baseline.yaml"}, "--compare-three-way requires comparison mode"},
+		{"Invalid Helm Chart Name", []string{"--helm-chart", "My_Chart"}, "invalid --helm-chart name"},
+		{"Helm Values Without Helm Chart", []string{"--helm-values"}, "--helm-values requires --helm-chart"},
+		{"Invalid Condition", []string{"--condition", "Ready"}, "invalid --condition entry"},
+		{"Storage CSI Mode In Must-Gather Mode", []string{"--collect-storage-classes-and-csi", "--must-gather", "must-gather.local"}, "--collect-storage-classes-and-csi needs a single live cluster"},
+		{"Push Without OCI Scheme", []string{"--push", "quay.io/team/snapshots:v1"}, "invalid --push"},
+		{"Non-Positive Min Restarts", []string{"--collect-pods-with-restarts", "--min-restarts", "0"}, "--min-restarts must be at least 1"},
+		{"Decode Secrets With Redaction", []string{"--decode-secrets", "--redact-secrets"}, "--decode-secrets cannot be combined with --secure, --redact-secrets or --redact-regex"},
+		{"Output URL With Must-Gather", []string{"--must-gather", "./must-gather", "--output-url", "s3://backups/prod"}, "--output-url applies to live collections"},
+		{"Invalid Output URL", []string{"--output-url", "ftp://files.example.com/"}, "invalid --output-url"},
+		{"Invalid Upload Compression", []string{"--output-url", "s3://backups/prod", "--upload-compression", "zstd"}, "invalid --upload-compression"},
+		{"Checkpoint Without Must-Gather", []string{"--checkpoint"}, "--checkpoint applies to must-gather processing"},
+		{"JSON Diff Without Comparison", []string{"--diff-format", "json"}, "--diff-format requires comparison mode"},
+		{"Unknown Preset", []string{"--preset", "everything"}, "unknown --preset"},
+		{"Invalid Max Archive Size", []string{"--must-gather", "https://example.com/mg.tar.gz", "--max-archive-size", "lots"}, "invalid --max-archive-size"},
+		{"Serve With All Contexts", []string{"--all-contexts", "--serve", ":8080"}, "--serve serves a single collection"},
+		{"Push With Watch Interval", []string{"--push", "oci://quay.io/team/snapshots:v1", "--watch-interval", "1m"}, "--push publishes a single collection"},
+		{"All Contexts With Must-Gather", []string{"--must-gather", "./must-gather", "--all-contexts"}, "--all-contexts applies to live collections"},
+		{"All Contexts With Context", []string{"--all-contexts", "--context", "prod"}, "--all-contexts and --context cannot be used together"},
+		{"Parallel Clusters Without All Contexts", []string{"--parallel-clusters", "4"}, "--parallel-clusters requires --all-contexts"},
+		{"Watch Interval Too Short", []string{"--watch-interval", "100ms"}, "--watch-interval must be at least 1s"},
+		{"Watch Count Without Watch Interval", []string{"--watch-count", "3"}, "--watch-count must not be negative and requires --watch-interval"},
+		{"Anonymize With CRDs Only Mode", []string{"--anonymize", "--collect-crds-only"}, "--anonymize cannot be used with --collect-crds-only"},
+		{"Anonymize With Autoscalers Mode", []string{"--anonymize", "--collect-autoscalers"}, "--anonymize cannot be used with --collect-autoscalers"},
+		{"Anonymize With Storage CSI Mode", []string{"--anonymize", "--collect-storage-classes-and-csi"}, "--anonymize cannot be used with --collect-storage-classes-and-csi"},
+		{"Anonymize With RBAC Graph Mode", []string{"--anonymize", "--collect-rbac-graph"}, "--anonymize cannot be used with --collect-rbac-graph"},
+		{"Anonymize With Leases Mode", []string{"--anonymize", "--collect-leases"}, "--anonymize cannot be used with --collect-leases"},
+		{"Anonymize With Webhooks Mode", []string{"--anonymize", "--webhooks"}, "--anonymize cannot be used with --webhooks"},
+		{"Anonymize With APIServices Mode", []string{"--anonymize", "--apiservices"}, "--anonymize cannot be used with --apiservices"},
+		{"Share Preset With RBAC Graph Mode", []string{"--preset", "share", "--collect-rbac-graph"}, "--anonymize cannot be used with --collect-rbac-graph"},
+	}
+
+	for _, tc := range testCases {
+		output, err := RunCommand(tc.args...)
+		if err != nil && strings.Contains(output, tc.message) {
+			suite.AddResult(tc.name, true, "Correctly rejected", nil)
+		} else {
+			suite.AddResult(tc.name, false, "Expected \""+tc.message+"\": "+output, err)
+		}
+	}
+
+	suite.PrintSummary()
+}
+
+// TestCollapseVersions tests that --collapse-versions files all versions of a kind together
+func TestCollapseVersions(t *testing.T) {
+	suite := NewTestSuite()
+
+	testDir := filepath.Join(testDir, "collapse-versions-test")
+
+	// One custom resource kind stored under two served versions
+	widget := "apiVersion: example.com/%s\nkind: Widget\nmetadata:\n  name: %s\n  namespace: shop\n"
+	mustGather := filepath.Join(testDir, "must-gather")
+	writeFixture(filepath.Join(mustGather, "namespaces", "shop", "example.com", "widgets-v1beta1.yaml"), fmt.Sprintf(widget, "v1beta1", "old"))
+	writeFixture(filepath.Join(mustGather, "namespaces", "shop", "example.com", "widgets-v1.yaml"), fmt.Sprintf(widget, "v1", "new"))
+	// A core kind, which has no group to keep
+	writeFixture(filepath.Join(mustGather, "namespaces", "shop", "core", "configmaps.yaml"), configMapFixture("app", "shop", "value"))
+
+	outputDir := filepath.Join(testDir, "output")
+	output, err := RunCommand("--must-gather", mustGather, "--collapse-versions", "--output", outputDir)
+	collapsed, readErr := os.ReadFile(filepath.Join(outputDir, "example.com-widgets.yaml"))
+	if err != nil {
+		suite.AddResult("Collapse Versions", false, "Must-gather processing failed: "+output, err)
+	} else if readErr != nil {
+		suite.AddResult("Collapse Versions", false, "Collapsed file not written", readErr)
+	} else if _, statErr := os.Stat(filepath.Join(outputDir, "example.com-v1-widgets.yaml")); statErr == nil {
+		suite.AddResult("Collapse Versions", false, "Per-version file written despite --collapse-versions", nil)
+	} else if !strings.Contains(string(collapsed), "k8s-resource-collector/api-version: example.com/v1beta1") ||
+		!strings.Contains(string(collapsed), "k8s-resource-collector/api-version: example.com/v1\n") {
+		suite.AddResult("Collapse Versions", false, "Items not annotated with the version they were read with", nil)
+	} else {
+		suite.AddResult("Collapse Versions", true, "Both versions filed together and annotated", nil)
+	}
+
+	// Core kinds are filed by resource alone, with the resource in the header
+	core, readErr := os.ReadFile(filepath.Join(outputDir, "configmaps.yaml"))
+	if err != nil {
+		suite.AddResult("Collapse Versions Core Kind", false, "Must-gather processing failed: "+output, err)
+	} else if readErr != nil {
+		suite.AddResult("Collapse Versions Core Kind", false, "Core kind file not written", readErr)
+	} else if !strings.Contains(string(core), "# Resource: configmaps\n") || strings.Contains(string(core), "# Group Version:") {
+		suite.AddResult("Collapse Versions Core Kind", false, "Header does not name the resource: "+string(core), nil)
+	} else if !strings.Contains(string(core), "name: app") {
+		suite.AddResult("Collapse Versions Core Kind", false, "Core kind items missing", nil)
+	} else {
+		suite.AddResult("Collapse Versions Core Kind", true, "Core kind filed by resource with a correct header", nil)
+	}
+
+	// It only applies to must-gather processing
+	output, err = RunCommand("--collapse-versions", "--output", outputDir)
+	if err != nil && strings.Contains(output, "--collapse-versions only applies to must-gather processing") {
+		suite.AddResult("Collapse Versions Validation", true, "Correctly rejects live collections", nil)
+	} else {
+		suite.AddResult("Collapse Versions Validation", false, "Should reject --collapse-versions without --must-gather: "+output, err)
+	}
+
+	// Clean up
+	os.RemoveAll(testDir)
+
+	suite.PrintSummary()
+}
+
+// TestMustGatherComparisonCleanMode tests that --clean removes a previous must-gather comparison
+func TestMustGatherComparisonCleanMode(t *testing.T) {
+	suite := NewTestSuite()
+
+	testDir := filepath.Join(testDir, "must-gather-compare-clean-test")
+
+	// Create two minimal must-gathers and a stale file from an earlier comparison
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: example\n  namespace: default\ndata:\n  key: %s\n"
+	mustGather1 := filepath.Join(testDir, "must-gather-1")
+	mustGather2 := filepath.Join(testDir, "must-gather-2")
+	os.MkdirAll(mustGather1, 0755)
+	os.MkdirAll(mustGather2, 0755)
+	os.WriteFile(filepath.Join(mustGather1, "configmaps.yaml"), []byte(fmt.Sprintf(configMap, "before")), 0644)
+	os.WriteFile(filepath.Join(mustGather2, "configmaps.yaml"), []byte(fmt.Sprintf(configMap, "after")), 0644)
+
+	outputDir := filepath.Join(testDir, "output")
+	staleFile := filepath.Join(outputDir, "comparison", "diff-old-vs-older.txt")
+	os.MkdirAll(filepath.Dir(staleFile), 0755)
+	os.WriteFile(staleFile, []byte("stale comparison"), 0644)
+
+	output, err := RunCommand("--must-gather1", mustGather1, "--must-gather2", mustGather2, "--output", outputDir, "--clean")
+	if err != nil {
+		suite.AddResult("Must-Gather Comparison Clean Mode", false, "Comparison failed: "+output, err)
+	} else if _, statErr := os.Stat(staleFile); !os.IsNotExist(statErr) {
+		suite.AddResult("Must-Gather Comparison Clean Mode", false, "Stale comparison file not removed", statErr)
+	} else if _, statErr := os.Stat(filepath.Join(outputDir, "comparison", "diff-must-gather-1-vs-must-gather-2.txt")); statErr != nil {
+		suite.AddResult("Must-Gather Comparison Clean Mode", false, "Diff not written", statErr)
+	} else {
+		suite.AddResult("Must-Gather Comparison Clean Mode", true, "Comparison directory cleaned successfully", nil)
+	}
+
+	// Clean up
+	os.RemoveAll(testDir)
+
+	suite.PrintSummary()
+}
+
+// TestMustGatherCheckpointResume tests the must-gather checkpoint and --resume
+func TestMustGatherCheckpointResume(t *testing.T) {
+	suite := NewTestSuite()
+
+	testDir := filepath.Join(testDir, "checkpoint-resume-test")
+
+	mustGather := filepath.Join(testDir, "must-gather")
+	writeFixture(filepath.Join(mustGather, "namespaces", "shop", "core", "configmaps.yaml"), configMapFixture("from-file", "shop", "value"))
+	outputDir := filepath.Join(testDir, "output")
+	checkpoint := filepath.Join(outputDir, ".must-gather-checkpoint.jsonl")
+
+	// A completed run leaves no checkpoint behind
+	output, err := RunCommand("--must-gather", mustGather, "--output", outputDir, "--checkpoint")
+	if err != nil {
+		suite.AddResult("Checkpoint Removed", false, "Must-gather processing failed: "+output, err)
+	} else if _, statErr := os.Stat(checkpoint); !os.IsNotExist(statErr) {
+		suite.AddResult("Checkpoint Removed", false, "Checkpoint left after a completed run", statErr)
+	} else {
+		suite.AddResult("Checkpoint Removed", true, "Checkpoint removed once the output was written", nil)
+	}
+
+	// A checkpoint of an interrupted run that already processed the file; the
+	// object recorded for it differs from the file, so a skipped file shows
+	entry := map[string]interface{}{
+		"file": "namespaces/shop/core/configmaps.yaml",
+		"resources": map[string]interface{}{
+			"v1-configmaps": []interface{}{map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "from-checkpoint", "namespace": "shop"},
+			}},
+		},
+	}
+	header, _ := json.Marshal(map[string]string{"source": mustGather})
+	line, _ := json.Marshal(entry)
+	// The last line was cut short by the interruption and is ignored
+	writeFixture(checkpoint, string(header)+"\n"+string(line)+"\n"+`{"file":"namespaces/shop/co`)
+
+	output, err = RunCommand("--must-gather", mustGather, "--output", outputDir, "--resume")
+	resumed, _ := os.ReadFile(filepath.Join(outputDir, "v1-configmaps.yaml"))
+	if err != nil {
+		suite.AddResult("Checkpoint Resume", false, "Resumed processing failed: "+output, err)
+	} else if !strings.Contains(output, "Resuming from checkpoint: 1 files already processed") {
+		suite.AddResult("Checkpoint Resume", false, "Checkpoint not loaded: "+output, nil)
+	} else if !strings.Contains(string(resumed), "from-checkpoint") || strings.Contains(string(resumed), "from-file") {
+		suite.AddResult("Checkpoint Resume", false, "Processed file was read again instead of taken from the checkpoint", nil)
+	} else if _, statErr := os.Stat(checkpoint); !os.IsNotExist(statErr) {
+		suite.AddResult("Checkpoint Resume", false, "Checkpoint left after the resumed run", statErr)
+	} else {
+		suite.AddResult("Checkpoint Resume", true, "Processed files skipped and their objects kept", nil)
+	}
+
+	// --resume keeps the earlier output, so it cannot clean it
+	output, err = RunCommand("--must-gather", mustGather, "--output", outputDir, "--resume", "--clean")
+	if err != nil && strings.Contains(output, "--resume and --clean are mutually exclusive") {
+		suite.AddResult("Checkpoint Resume Validation", true, "Correctly rejects --resume with --clean", nil)
+	} else {
+		suite.AddResult("Checkpoint Resume Validation", false, "Should reject --resume with --clean: "+output, err)
+	}
+
+	// Clean up
+	os.RemoveAll(testDir)
+
+	suite.PrintSummary()
+}
+
+// TestCompareNamespace tests that --compare-namespace limits a must-gather comparison to one namespace
+func TestCompareNamespace(t *testing.T) {
+	suite := NewTestSuite()
+
+	testDir := filepath.Join(testDir, "compare-namespace-test")
+
+	// Two must-gathers with the same objects in the compared namespace and another one
+	namespace := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: shop\n"
+	for _, mg := range []string{"mg1", "mg2"} {
+		writeFixture(filepath.Join(testDir, mg, "namespaces", "shop", "core", "configmaps.yaml"), configMapFixture("app", "shop", mg))
+		writeFixture(filepath.Join(testDir, mg, "namespaces", "other", "core", "configmaps.yaml"), configMapFixture("unrelated", "other", mg))
+		writeFixture(filepath.Join(testDir, mg, "cluster-scoped-resources", "core", "namespaces", "shop.yaml"), namespace)
+	}
+
+	mustGather1 := filepath.Join(testDir, "mg1")
+	mustGather2 := filepath.Join(testDir, "mg2")
+	outputDir := filepath.Join(testDir, "output")
+	output, err := RunCommand("--must-gather1", mustGather1, "--must-gather2", mustGather2, "--compare-namespace", "shop", "--output", outputDir)
+	collection, readErr := os.ReadFile(filepath.Join(outputDir, "comparison", "mg1-shop-resources.yaml"))
+	if err != nil {
+		suite.AddResult("Compare Namespace", false, "Comparison failed: "+output, err)
+	} else if readErr != nil {
+		suite.AddResult("Compare Namespace", false, "Namespace-scoped collection not written", readErr)
+	} else if _, statErr := os.Stat(filepath.Join(outputDir, "comparison", "diff-mg1-vs-mg2-shop.txt")); statErr != nil {
+		suite.AddResult("Compare Namespace", false, "Namespace-scoped diff not written", statErr)
+	} else if !strings.Contains(string(collection), "name: app") || !strings.Contains(string(collection), "kind: Namespace") {
+		suite.AddResult("Compare Namespace", false, "Objects of the namespace missing from the collection", nil)
+	} else if strings.Contains(string(collection), "unrelated") {
+		suite.AddResult("Compare Namespace", false, "Objects of another namespace were compared", nil)
+	} else {
+		suite.AddResult("Compare Namespace", true, "Only the namespace's objects were compared", nil)
+	}
+
+	// It only applies to must-gather comparison mode
+	output, err = RunCommand("--must-gather", mustGather1, "--compare-namespace", "shop", "--output", outputDir)
+	if err != nil && strings.Contains(output, "--compare-namespace requires must-gather comparison mode") {
+		suite.AddResult("Compare Namespace Validation", true, "Correctly rejects single must-gather mode", nil)
+	} else {
+		suite.AddResult("Compare Namespace Validation", false, "Should reject --compare-namespace without two must-gathers: "+output, err)
+	}
+
+	// Clean up
+	os.RemoveAll(testDir)
+
+	suite.PrintSummary()
+}
+
+// TestDecodeRoundTrip tests that --decode turns a protobuf collection back into YAML
+func TestDecodeRoundTrip(t *testing.T) {
+	suite := NewTestSuite()
+
+	testDir := filepath.Join(testDir, "decode-test")
+
+	// A --format protobuf resource file, as a live collection writes it
+	list := &corev1.ConfigMapList{Items: []corev1.ConfigMap{{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "shop"},
+		Data:       map[string]string{"key": "value"},
+	}}}
+	list.GetObjectKind().SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMapList"))
+	var encoded bytes.Buffer
+	if err := protobuf.NewSerializer(scheme.Scheme, scheme.Scheme).Encode(list, &encoded); err != nil {
+		suite.AddResult("Decode Round Trip", false, "Failed to encode the protobuf fixture", err)
+	}
+	writeFixture(filepath.Join(testDir, "collection", "v1-configmaps.pb"), encoded.String())
+
+	output, err := RunCommand("--decode", filepath.Join(testDir, "collection"))
+	decoded, readErr := os.ReadFile(filepath.Join(testDir, "collection", "v1-configmaps.yaml"))
+	if err != nil {
+		suite.AddResult("Decode Round Trip", false, "Decode failed: "+output, err)
+	} else if readErr != nil {
+		suite.AddResult("Decode Round Trip", false, "YAML file not written next to the .pb file", readErr)
+	} else if !strings.Contains(string(decoded), "# Resource: configmaps") || !strings.Contains(string(decoded), "kind: ConfigMap\n") ||
+		!strings.Contains(string(decoded), "name: app") || !strings.Contains(string(decoded), "key: value") {
+		suite.AddResult("Decode Round Trip", false, "Decoded YAML does not match the encoded objects: "+string(decoded), nil)
+	} else {
+		suite.AddResult("Decode Round Trip", true, "Protobuf collection decoded to YAML", nil)
+	}
+
+	// Decoding works offline
+	output, err = RunCommand("--decode", filepath.Join(testDir, "collection"), "--kubeconfig", "/nonexistent/path")
+	if err != nil && strings.Contains(output, "--decode works offline") {
+		suite.AddResult("Decode Kubeconfig Validation", true, "Correctly rejects --kubeconfig", nil)
+	} else {
+		suite.AddResult("Decode Kubeconfig Validation", false, "Should reject --decode with --kubeconfig: "+output, err)
+	}
+
+	output, err = RunCommand("--decode", filepath.Join(testDir, "missing"))
+	if err != nil && strings.Contains(output, "decode path not found") {
+		suite.AddResult("Decode Missing Path", true, "Correctly reports a missing path", nil)
+	} else {
+		suite.AddResult("Decode Missing Path", false, "Should fail for a missing path: "+output, err)
+	}
+
+	// Clean up
+	os.RemoveAll(testDir)
+
+	suite.PrintSummary()
+}
+
 // TestSingleFileMode tests single file mode
 func TestSingleFileMode(t *testing.T) {
 	suite := NewTestSuite()